@@ -0,0 +1,58 @@
+// Command migrate-media memindahkan gambar menu yang sudah ada ke storage lain dan
+// memperbarui URL-nya di database.
+//
+//	STORAGE_BACKEND=s3 go run ./cmd/migrate-media -from local -dry-run
+//	STORAGE_BACKEND=s3 go run ./cmd/migrate-media -from local -delete-source
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/yeremiapane/restaurant-app/config"
+	"github.com/yeremiapane/restaurant-app/services"
+)
+
+func main() {
+	from := flag.String("from", services.StorageBackendLocal, "storage asal (local atau s3)")
+	to := flag.String("to", os.Getenv("STORAGE_BACKEND"), "storage tujuan (default STORAGE_BACKEND)")
+	dryRun := flag.Bool("dry-run", false, "hanya hitung gambar yang akan dipindahkan")
+	deleteSource := flag.Bool("delete-source", false, "hapus file di storage asal setelah dipindahkan")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Warning: .env file not found")
+	}
+
+	source := services.NewStorage(*from)
+	target := services.NewStorage(*to)
+	if source.Name() == target.Name() {
+		log.Fatalf("Source and target storage are both %s", source.Name())
+	}
+
+	db, err := config.InitDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	// URL lokal memakai base URL dari profil restoran
+	services.NewRestaurantProfileService(db)
+
+	log.Printf("Migrating menu images from %s to %s (dry run: %v)", source.Name(), target.Name(), *dryRun)
+	result, err := services.MigrateMenuImages(db, source, target, services.MediaMigrationOptions{
+		DryRun:       *dryRun,
+		DeleteSource: *deleteSource,
+	})
+	if result != nil {
+		summary, _ := json.MarshalIndent(result, "", "  ")
+		log.Printf("Result:\n%s", summary)
+	}
+	if err != nil {
+		log.Fatalf("Migration stopped: %v", err)
+	}
+	if result.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/go-pdf/fpdf"
 	"github.com/wcharczuk/go-chart/v2"
 	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/services"
 	"github.com/yeremiapane/restaurant-app/utils"
 	"gorm.io/gorm"
 )
@@ -59,11 +60,11 @@ func (ac *AdminController) GetDashboardStats(c *gin.Context) {
 	today := time.Now().Format("2006-01-02")
 
 	var stats struct {
-		TotalOrders    int64   `json:"total_orders"`
-		TodayOrders    int64   `json:"today_orders"`
-		TotalRevenue   float64 `json:"total_revenue"`
-		TodayRevenue   float64 `json:"today_revenue"`
-		AvgCookingTime float64 `json:"avg_cooking_time"`
+		TotalOrders    int64       `json:"total_orders"`
+		TodayOrders    int64       `json:"today_orders"`
+		TotalRevenue   utils.Money `json:"total_revenue"`
+		TodayRevenue   utils.Money `json:"today_revenue"`
+		AvgCookingTime float64     `json:"avg_cooking_time"`
 		OrderStats     struct {
 			PendingPayment int64 `json:"pending_payment"`
 			Paid           int64 `json:"paid"`
@@ -72,10 +73,10 @@ func (ac *AdminController) GetDashboardStats(c *gin.Context) {
 			Completed      int64 `json:"completed"`
 		} `json:"order_stats"`
 		PaymentStats struct {
-			Pending int64   `json:"pending"`
-			Success int64   `json:"success"`
-			Total   float64 `json:"total"`
-			Today   float64 `json:"today"`
+			Pending int64       `json:"pending"`
+			Success int64       `json:"success"`
+			Total   utils.Money `json:"total"`
+			Today   utils.Money `json:"today"`
 		} `json:"payment_stats"`
 		TableStats struct {
 			Available int64 `json:"available"`
@@ -83,8 +84,8 @@ func (ac *AdminController) GetDashboardStats(c *gin.Context) {
 			Dirty     int64 `json:"dirty"`
 		} `json:"table_stats"`
 		RevenueTrend []struct {
-			Date   string  `json:"date"`
-			Amount float64 `json:"amount"`
+			Date   string      `json:"date"`
+			Amount utils.Money `json:"amount"`
 		} `json:"revenue_trend"`
 	}
 
@@ -142,14 +143,14 @@ func (ac *AdminController) GetDashboardStats(c *gin.Context) {
 
 	// Calculate revenue trend for the last 7 days
 	var revenueTrend []struct {
-		Date   string  `json:"date"`
-		Amount float64 `json:"amount"`
+		Date   string      `json:"date"`
+		Amount utils.Money `json:"amount"`
 	}
 
 	// Get last 7 days including today
 	for i := 6; i >= 0; i-- {
 		date := time.Now().AddDate(0, 0, -i).Format("2006-01-02")
-		var amount float64
+		var amount utils.Money
 
 		ac.DB.Model(&models.Order{}).
 			Where("status = ? AND DATE(created_at) = ?", "completed", date).
@@ -157,8 +158,8 @@ func (ac *AdminController) GetDashboardStats(c *gin.Context) {
 			Row().Scan(&amount)
 
 		revenueTrend = append(revenueTrend, struct {
-			Date   string  `json:"date"`
-			Amount float64 `json:"amount"`
+			Date   string      `json:"date"`
+			Amount utils.Money `json:"amount"`
 		}{
 			Date:   date,
 			Amount: amount,
@@ -240,14 +241,14 @@ func (ac *AdminController) MonitorOrderFlow(c *gin.Context) {
 // GetSalesReport mengambil laporan penjualan
 func (ac *AdminController) GetSalesReport(c *gin.Context) {
 	var sales struct {
-		TotalSales     float64 `json:"total_sales"`
-		TotalOrders    int64   `json:"total_orders"`
-		AverageOrder   float64 `json:"average_order"`
+		TotalSales     utils.Money `json:"total_sales"`
+		TotalOrders    int64       `json:"total_orders"`
+		AverageOrder   utils.Money `json:"average_order"`
 		TopSellingMenu []struct {
-			MenuID   uint    `json:"menu_id"`
-			Name     string  `json:"name"`
-			Quantity int     `json:"quantity"`
-			Revenue  float64 `json:"revenue"`
+			MenuID   uint        `json:"menu_id"`
+			Name     string      `json:"name"`
+			Quantity int         `json:"quantity"`
+			Revenue  utils.Money `json:"revenue"`
 		} `json:"top_selling_menu"`
 	}
 
@@ -256,7 +257,7 @@ func (ac *AdminController) GetSalesReport(c *gin.Context) {
 	ac.DB.Model(&models.Order{}).Where("status = ?", "completed").Count(&sales.TotalOrders)
 
 	if sales.TotalOrders > 0 {
-		sales.AverageOrder = sales.TotalSales / float64(sales.TotalOrders)
+		sales.AverageOrder = sales.TotalSales.Div(sales.TotalOrders)
 	}
 
 	utils.RespondJSON(c, http.StatusOK, "Sales report", gin.H{
@@ -289,7 +290,7 @@ func (ac *AdminController) GetOrderFlow(c *gin.Context) {
 		OrderID     uint        `json:"order_id"`
 		TableID     uint        `json:"table_id"`
 		TableNumber string      `json:"table_number"`
-		TotalAmount float64     `json:"total"`
+		TotalAmount utils.Money `json:"total"`
 		Status      string      `json:"status"`
 		CreatedAt   time.Time   `json:"created_at"`
 		Items       []OrderItem `json:"items"`
@@ -302,7 +303,7 @@ func (ac *AdminController) GetOrderFlow(c *gin.Context) {
 		var items []OrderItem
 		for _, item := range order.OrderItems {
 			items = append(items, OrderItem{
-				Name:     item.Menu.Name,
+				Name:     item.DisplayName(),
 				Quantity: item.Quantity,
 			})
 		}
@@ -385,37 +386,43 @@ func (ac *AdminController) GetAnalytics(c *gin.Context) {
 	}
 
 	var analytics struct {
-		TotalSales      float64 `json:"total_sales"`
-		TotalOrders     int64   `json:"total_orders"`
-		AverageOrder    float64 `json:"average_order"`
+		TotalSales      utils.Money `json:"total_sales"`
+		TotalOrders     int64       `json:"total_orders"`
+		AverageOrder    utils.Money `json:"average_order"`
 		PopularCategory struct {
 			Name  string `json:"name"`
 			Count int64  `json:"count"`
 		} `json:"popular_category"`
 		SalesTrend []struct {
-			Date   string  `json:"date"`
-			Amount float64 `json:"amount"`
+			Date   string      `json:"date"`
+			Amount utils.Money `json:"amount"`
 		} `json:"sales_trend"`
 		CategoryPerformance []struct {
-			Name  string  `json:"name"`
-			Total float64 `json:"total"`
+			Name  string      `json:"name"`
+			Total utils.Money `json:"total"`
 		} `json:"category_performance"`
 		PeakHours []struct {
 			Hour  int   `json:"hour"`
 			Count int64 `json:"count"`
 		} `json:"peak_hours"`
 		PopularItems []struct {
-			MenuName string  `json:"menu_name"`
-			Count    int     `json:"count"`
-			Revenue  float64 `json:"revenue"`
-			Trend    float64 `json:"trend"`
+			MenuName string      `json:"menu_name"`
+			Count    int         `json:"count"`
+			Revenue  utils.Money `json:"revenue"`
+			Trend    float64     `json:"trend"`
 		} `json:"popular_items"`
 		MenuPerformance []struct {
-			Name    string  `json:"name"`
-			Sold    int     `json:"sold"`
-			Revenue float64 `json:"revenue"`
-			Trend   float64 `json:"trend"`
+			Name    string      `json:"name"`
+			Sold    int         `json:"sold"`
+			Revenue utils.Money `json:"revenue"`
+			Trend   float64     `json:"trend"`
 		} `json:"menu_performance"`
+		VariantPerformance []struct {
+			MenuName    string      `json:"menu_name"`
+			VariantName string      `json:"variant_name"`
+			Sold        int         `json:"sold"`
+			Revenue     utils.Money `json:"revenue"`
+		} `json:"variant_performance"`
 	}
 
 	// Query total sales dan orders with date range
@@ -429,7 +436,7 @@ func (ac *AdminController) GetAnalytics(c *gin.Context) {
 		Row().Scan(&analytics.TotalSales)
 
 	if analytics.TotalOrders > 0 {
-		analytics.AverageOrder = analytics.TotalSales / float64(analytics.TotalOrders)
+		analytics.AverageOrder = analytics.TotalSales.Div(analytics.TotalOrders)
 	}
 
 	// Query popular category with date range
@@ -454,8 +461,8 @@ func (ac *AdminController) GetAnalytics(c *gin.Context) {
 		// Generate all hours for today
 		for i := 0; i < 24; i++ {
 			analytics.SalesTrend = append(analytics.SalesTrend, struct {
-				Date   string  `json:"date"`
-				Amount float64 `json:"amount"`
+				Date   string      `json:"date"`
+				Amount utils.Money `json:"amount"`
 			}{
 				Date:   fmt.Sprintf("%02d:00", i),
 				Amount: 0,
@@ -467,8 +474,8 @@ func (ac *AdminController) GetAnalytics(c *gin.Context) {
 		for i := 6; i >= 0; i-- {
 			date := time.Now().AddDate(0, 0, -i).Format("2006-01-02")
 			analytics.SalesTrend = append(analytics.SalesTrend, struct {
-				Date   string  `json:"date"`
-				Amount float64 `json:"amount"`
+				Date   string      `json:"date"`
+				Amount utils.Money `json:"amount"`
 			}{
 				Date:   date,
 				Amount: 0,
@@ -480,8 +487,8 @@ func (ac *AdminController) GetAnalytics(c *gin.Context) {
 		for i := 29; i >= 0; i-- {
 			date := time.Now().AddDate(0, 0, -i).Format("2006-01-02")
 			analytics.SalesTrend = append(analytics.SalesTrend, struct {
-				Date   string  `json:"date"`
-				Amount float64 `json:"amount"`
+				Date   string      `json:"date"`
+				Amount utils.Money `json:"amount"`
 			}{
 				Date:   date,
 				Amount: 0,
@@ -493,8 +500,8 @@ func (ac *AdminController) GetAnalytics(c *gin.Context) {
 		for i := 11; i >= 0; i-- {
 			date := time.Now().AddDate(0, -i, 0).Format("2006-01")
 			analytics.SalesTrend = append(analytics.SalesTrend, struct {
-				Date   string  `json:"date"`
-				Amount float64 `json:"amount"`
+				Date   string      `json:"date"`
+				Amount utils.Money `json:"amount"`
 			}{
 				Date:   date,
 				Amount: 0,
@@ -506,8 +513,8 @@ func (ac *AdminController) GetAnalytics(c *gin.Context) {
 		for i := 6; i >= 0; i-- {
 			date := time.Now().AddDate(0, 0, -i).Format("2006-01-02")
 			analytics.SalesTrend = append(analytics.SalesTrend, struct {
-				Date   string  `json:"date"`
-				Amount float64 `json:"amount"`
+				Date   string      `json:"date"`
+				Amount utils.Money `json:"amount"`
 			}{
 				Date:   date,
 				Amount: 0,
@@ -517,8 +524,8 @@ func (ac *AdminController) GetAnalytics(c *gin.Context) {
 
 	// Query actual sales data
 	var salesData []struct {
-		Date   string  `json:"date"`
-		Amount float64 `json:"amount"`
+		Date   string      `json:"date"`
+		Amount utils.Money `json:"amount"`
 	}
 
 	ac.DB.Raw(`
@@ -546,7 +553,7 @@ func (ac *AdminController) GetAnalytics(c *gin.Context) {
 		for i := range analytics.SalesTrend {
 			if analytics.SalesTrend[i].Date == sale.Date {
 				analytics.SalesTrend[i].Amount = sale.Amount
-				log.Printf("Updated sales trend for date %s with amount %f", sale.Date, sale.Amount)
+				log.Printf("Updated sales trend for date %s with amount %s", sale.Date, sale.Amount)
 				break
 			}
 		}
@@ -651,11 +658,28 @@ func (ac *AdminController) GetAnalytics(c *gin.Context) {
 		ORDER BY ro.recent_revenue DESC
 	`, startDate, endDate, startDate.Add(-time.Hour*24*7), startDate).Scan(&analytics.MenuPerformance)
 
+	// Query variant performance: nama variant diambil dari snapshot di order item
+	ac.DB.Raw(`
+		SELECT
+			m.name as menu_name,
+			oi.variant_name,
+			COALESCE(SUM(oi.quantity), 0) as sold,
+			COALESCE(SUM(oi.price * oi.quantity), 0) as revenue
+		FROM order_items oi
+		JOIN menus m ON oi.menu_id = m.id
+		JOIN orders o ON oi.order_id = o.id
+		WHERE o.status = 'completed'
+		AND o.created_at BETWEEN ? AND ?
+		AND oi.variant_name <> ''
+		GROUP BY m.id, m.name, oi.variant_name
+		ORDER BY revenue DESC
+	`, startDate, endDate).Scan(&analytics.VariantPerformance)
+
 	// Initialize empty arrays if no data
 	if analytics.CategoryPerformance == nil {
 		analytics.CategoryPerformance = []struct {
-			Name  string  `json:"name"`
-			Total float64 `json:"total"`
+			Name  string      `json:"name"`
+			Total utils.Money `json:"total"`
 		}{}
 	}
 	if analytics.PeakHours == nil {
@@ -666,18 +690,27 @@ func (ac *AdminController) GetAnalytics(c *gin.Context) {
 	}
 	if analytics.PopularItems == nil {
 		analytics.PopularItems = []struct {
-			MenuName string  `json:"menu_name"`
-			Count    int     `json:"count"`
-			Revenue  float64 `json:"revenue"`
-			Trend    float64 `json:"trend"`
+			MenuName string      `json:"menu_name"`
+			Count    int         `json:"count"`
+			Revenue  utils.Money `json:"revenue"`
+			Trend    float64     `json:"trend"`
 		}{}
 	}
 	if analytics.MenuPerformance == nil {
 		analytics.MenuPerformance = []struct {
-			Name    string  `json:"name"`
-			Sold    int     `json:"sold"`
-			Revenue float64 `json:"revenue"`
-			Trend   float64 `json:"trend"`
+			Name    string      `json:"name"`
+			Sold    int         `json:"sold"`
+			Revenue utils.Money `json:"revenue"`
+			Trend   float64     `json:"trend"`
+		}{}
+	}
+
+	if analytics.VariantPerformance == nil {
+		analytics.VariantPerformance = []struct {
+			MenuName    string      `json:"menu_name"`
+			VariantName string      `json:"variant_name"`
+			Sold        int         `json:"sold"`
+			Revenue     utils.Money `json:"revenue"`
 		}{}
 	}
 
@@ -706,13 +739,16 @@ func (ac *AdminController) ExportData(c *gin.Context) {
 	}
 
 	// Parse dates
-	start, err := time.Parse("2006-01-02", startDate)
+	// Tanggal laporan mengikuti zona waktu restoran
+	profile := services.CurrentRestaurantProfile()
+	loc := profile.Location()
+	start, err := time.ParseInLocation("2006-01-02", startDate, loc)
 	if err != nil {
 		utils.RespondError(c, http.StatusBadRequest, errors.New("format start_date tidak valid"))
 		return
 	}
 
-	end, err := time.Parse("2006-01-02", endDate)
+	end, err := time.ParseInLocation("2006-01-02", endDate, loc)
 	if err != nil {
 		utils.RespondError(c, http.StatusBadRequest, errors.New("format end_date tidak valid"))
 		return
@@ -739,7 +775,7 @@ func (ac *AdminController) ExportData(c *gin.Context) {
 	writer := csv.NewWriter(c.Writer)
 
 	// Write headers
-	headers := []string{"Order ID", "Tanggal", "Meja", "Total", "Status", "Item", "Jumlah", "Harga"}
+	headers := []string{"Order ID", "No Order", "Tanggal", "Meja", "Total (" + profile.Currency + ")", "Status", "Item", "Jumlah", "Harga (" + profile.Currency + ")"}
 	if err := writer.Write(headers); err != nil {
 		utils.RespondError(c, http.StatusInternalServerError, err)
 		return
@@ -750,13 +786,14 @@ func (ac *AdminController) ExportData(c *gin.Context) {
 		for _, item := range order.OrderItems {
 			row := []string{
 				fmt.Sprintf("%d", order.ID),
-				order.CreatedAt.Format("2006-01-02 15:04:05"),
+				order.OrderNumber,
+				order.CreatedAt.In(loc).Format("2006-01-02 15:04:05"),
 				order.Table.TableNumber,
-				fmt.Sprintf("%.2f", order.TotalAmount),
+				order.TotalAmount.Decimal(),
 				order.Status,
-				item.Menu.Name,
+				item.DisplayName(),
 				fmt.Sprintf("%d", item.Quantity),
-				fmt.Sprintf("%.2f", item.Price),
+				item.Price.Decimal(),
 			}
 			if err := writer.Write(row); err != nil {
 				utils.RespondError(c, http.StatusInternalServerError, err)
@@ -789,13 +826,16 @@ func (ac *AdminController) ExportPDF(c *gin.Context) {
 	}
 
 	// Parse dates
-	start, err := time.Parse("2006-01-02", startDate)
+	// Tanggal laporan mengikuti zona waktu restoran
+	profile := services.CurrentRestaurantProfile()
+	loc := profile.Location()
+	start, err := time.ParseInLocation("2006-01-02", startDate, loc)
 	if err != nil {
 		utils.RespondError(c, http.StatusBadRequest, errors.New("format start_date tidak valid"))
 		return
 	}
 
-	end, err := time.Parse("2006-01-02", endDate)
+	end, err := time.ParseInLocation("2006-01-02", endDate, loc)
 	if err != nil {
 		utils.RespondError(c, http.StatusBadRequest, errors.New("format end_date tidak valid"))
 		return
@@ -813,8 +853,8 @@ func (ac *AdminController) ExportPDF(c *gin.Context) {
 
 	// Get sales trend data
 	var salesTrend []struct {
-		Date   string  `json:"date"`
-		Amount float64 `json:"amount"`
+		Date   string      `json:"date"`
+		Amount utils.Money `json:"amount"`
 	}
 	ac.DB.Raw(`
 		WITH daily_sales AS (
@@ -836,8 +876,8 @@ func (ac *AdminController) ExportPDF(c *gin.Context) {
 	// Buat data dummy untuk setiap hari dalam rentang tanggal
 	currentDate := start
 	dummySalesTrend := make([]struct {
-		Date   string  `json:"date"`
-		Amount float64 `json:"amount"`
+		Date   string      `json:"date"`
+		Amount utils.Money `json:"amount"`
 	}, 0)
 
 	for currentDate.Before(end) || currentDate.Equal(end) {
@@ -856,8 +896,8 @@ func (ac *AdminController) ExportPDF(c *gin.Context) {
 		// Jika tidak ada data untuk tanggal ini, tambahkan data dummy
 		if !found {
 			dummySalesTrend = append(dummySalesTrend, struct {
-				Date   string  `json:"date"`
-				Amount float64 `json:"amount"`
+				Date   string      `json:"date"`
+				Amount utils.Money `json:"amount"`
 			}{
 				Date:   dateStr,
 				Amount: 0,
@@ -921,7 +961,7 @@ func (ac *AdminController) ExportPDF(c *gin.Context) {
 				YValues: func() []float64 {
 					values := make([]float64, len(salesTrend))
 					for i, sale := range salesTrend {
-						values[i] = sale.Amount
+						values[i] = sale.Amount.Float64()
 					}
 					return values
 				}(),
@@ -931,8 +971,8 @@ func (ac *AdminController) ExportPDF(c *gin.Context) {
 
 	// Get category performance data
 	var categoryPerformance []struct {
-		Name  string  `json:"name"`
-		Total float64 `json:"total"`
+		Name  string      `json:"name"`
+		Total utils.Money `json:"total"`
 	}
 	ac.DB.Raw(`
 		SELECT c.name, COALESCE(SUM(oi.price * oi.quantity), 0) as total
@@ -949,8 +989,8 @@ func (ac *AdminController) ExportPDF(c *gin.Context) {
 	// Jika tidak ada data kategori, buat data dummy
 	if len(categoryPerformance) == 0 {
 		categoryPerformance = append(categoryPerformance, struct {
-			Name  string  `json:"name"`
-			Total float64 `json:"total"`
+			Name  string      `json:"name"`
+			Total utils.Money `json:"total"`
 		}{
 			Name:  "Tidak ada data",
 			Total: 0,
@@ -1020,7 +1060,7 @@ func (ac *AdminController) ExportPDF(c *gin.Context) {
 				YValues: func() []float64 {
 					values := make([]float64, len(categoryPerformance))
 					for i, cat := range categoryPerformance {
-						values[i] = cat.Total
+						values[i] = cat.Total.Float64()
 					}
 					return values
 				}(),
@@ -1185,7 +1225,7 @@ func (ac *AdminController) ExportPDF(c *gin.Context) {
 	pdf.SetFont("Arial", "B", 24)
 	pdf.SetTextColor(255, 255, 255)
 	pdf.SetY(15)
-	pdf.Cell(0, 10, "LAPORAN PENJUALAN")
+	pdf.Cell(0, 10, "LAPORAN PENJUALAN - "+profile.Name)
 
 	// Add date range
 	pdf.SetFont("Arial", "", 12)
@@ -1213,11 +1253,11 @@ func (ac *AdminController) ExportPDF(c *gin.Context) {
 	pdf.SetFont("Arial", "", 12)
 	pdf.SetTextColor(100, 100, 100)
 	pdf.SetXY(25, pdf.GetY()+15)
-	pdf.Cell(0, 10, fmt.Sprintf("Total Penjualan: Rp %s", utils.FormatCurrency(analytics.TotalSales)))
+	pdf.Cell(0, 10, "Total Penjualan: "+utils.FormatCurrencyIDR(analytics.TotalSales))
 	pdf.SetXY(25, pdf.GetY()+10)
 	pdf.Cell(0, 10, fmt.Sprintf("Total Pesanan: %d", analytics.TotalOrders))
 	pdf.SetXY(120, pdf.GetY()-10)
-	pdf.Cell(0, 10, fmt.Sprintf("Rata-rata Pesanan: Rp %s", utils.FormatCurrency(analytics.AverageOrder)))
+	pdf.Cell(0, 10, "Rata-rata Pesanan: "+utils.FormatCurrencyIDR(analytics.AverageOrder))
 
 	// Move to charts section
 	pdf.SetY(pdf.GetY() + 30)
@@ -1277,14 +1317,14 @@ func (ac *AdminController) ExportPDF(c *gin.Context) {
 
 // Helper function untuk mendapatkan data analitik
 func (ac *AdminController) getAnalyticsData(start, end time.Time) (struct {
-	TotalSales   float64
+	TotalSales   utils.Money
 	TotalOrders  int64
-	AverageOrder float64
+	AverageOrder utils.Money
 }, error) {
 	var result struct {
-		TotalSales   float64
+		TotalSales   utils.Money
 		TotalOrders  int64
-		AverageOrder float64
+		AverageOrder utils.Money
 	}
 
 	// Query total orders
@@ -1304,7 +1344,7 @@ func (ac *AdminController) getAnalyticsData(start, end time.Time) (struct {
 
 	// Calculate average order
 	if result.TotalOrders > 0 {
-		result.AverageOrder = result.TotalSales / float64(result.TotalOrders)
+		result.AverageOrder = result.TotalSales.Div(result.TotalOrders)
 	}
 
 	return result, nil
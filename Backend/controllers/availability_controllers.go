@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yeremiapane/restaurant-app/services"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+type AvailabilityController struct {
+	DB *gorm.DB
+}
+
+func NewAvailabilityController(db *gorm.DB) *AvailabilityController {
+	return &AvailabilityController{DB: db}
+}
+
+// GetAvailabilitySchedules -> Melihat jadwal ketersediaan menu/kategori
+// Query: menu_id, category_id
+func (ac *AvailabilityController) GetAvailabilitySchedules(c *gin.Context) {
+	menuID, _ := strconv.ParseUint(c.Query("menu_id"), 10, 64)
+	categoryID, _ := strconv.ParseUint(c.Query("category_id"), 10, 64)
+
+	schedules, err := services.NewMenuAvailabilityService(ac.DB).ListSchedules(uint(menuID), uint(categoryID))
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "List of availability schedules", schedules)
+}
+
+// CreateAvailabilitySchedule -> Admin membuat jadwal ketersediaan untuk menu atau kategori
+func (ac *AvailabilityController) CreateAvailabilitySchedule(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	var input services.AvailabilityScheduleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	schedule, err := services.NewMenuAvailabilityService(ac.DB).CreateSchedule(input)
+	if err != nil {
+		respondAvailabilityError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusCreated, "Availability schedule created", schedule)
+}
+
+// UpdateAvailabilitySchedule -> Admin mengganti jadwal ketersediaan
+func (ac *AvailabilityController) UpdateAvailabilitySchedule(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+	scheduleID, err := strconv.ParseUint(c.Param("schedule_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("invalid schedule id"))
+		return
+	}
+
+	var input services.AvailabilityScheduleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	schedule, err := services.NewMenuAvailabilityService(ac.DB).UpdateSchedule(uint(scheduleID), input)
+	if err != nil {
+		respondAvailabilityError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "Availability schedule updated", schedule)
+}
+
+// DeleteAvailabilitySchedule -> Admin menghapus jadwal ketersediaan
+func (ac *AvailabilityController) DeleteAvailabilitySchedule(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+	scheduleID, err := strconv.ParseUint(c.Param("schedule_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("invalid schedule id"))
+		return
+	}
+
+	if err := services.NewMenuAvailabilityService(ac.DB).DeleteSchedule(uint(scheduleID)); err != nil {
+		respondAvailabilityError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "Availability schedule deleted", nil)
+}
+
+// respondAvailabilityError memetakan error MenuAvailabilityService ke status HTTP
+func respondAvailabilityError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.RespondError(c, http.StatusNotFound, err)
+	case errors.Is(err, services.ErrInvalidAvailabilitySchedule):
+		utils.RespondError(c, http.StatusBadRequest, err)
+	default:
+		utils.ErrorLogger.Printf("Availability schedule error: %v", err)
+		utils.RespondError(c, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yeremiapane/restaurant-app/services"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+// maxBackupUploadSize membatasi ukuran file backup yang bisa diimpor (50MB)
+const maxBackupUploadSize = 50 << 20
+
+type BackupController struct {
+	DB *gorm.DB
+}
+
+func NewBackupController(db *gorm.DB) *BackupController {
+	return &BackupController{DB: db}
+}
+
+// ExportBackup -> Admin mengunduh arsip JSON berisi data aplikasi
+// Query: sections=menus,categories (opsional), include_password_hashes=true (opsional)
+func (bc *BackupController) ExportBackup(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	opts := services.BackupExportOptions{
+		Sections:              splitSections(c.Query("sections")),
+		IncludePasswordHashes: c.Query("include_password_hashes") == "true",
+	}
+
+	archive, err := services.NewBackupService(bc.DB).Export(opts)
+	if err != nil {
+		utils.ErrorLogger.Printf("Failed to export backup: %v", err)
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.InfoLogger.Printf("Backup exported: sections=%v, password_hashes=%v", archive.Sections, archive.IncludesPasswordHashes)
+
+	filename := fmt.Sprintf("backup_%s.json", time.Now().Format("20060102_150405"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.JSON(http.StatusOK, archive)
+}
+
+// ImportBackup -> Admin memulihkan / meng-clone data dari arsip backup
+// Body bisa berupa JSON arsip langsung atau multipart dengan field "file".
+// Query: mode=remap|preserve, dry_run=true, sections=... (opsional)
+func (bc *BackupController) ImportBackup(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	var reader io.Reader
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			utils.RespondError(c, http.StatusBadRequest, errors.New("backup file is required"))
+			return
+		}
+		if fileHeader.Size > maxBackupUploadSize {
+			utils.RespondError(c, http.StatusBadRequest, errors.New("backup file is too large"))
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			utils.RespondError(c, http.StatusBadRequest, err)
+			return
+		}
+		defer file.Close()
+		reader = file
+	} else {
+		reader = http.MaxBytesReader(c.Writer, c.Request.Body, maxBackupUploadSize)
+	}
+
+	var archive services.BackupArchive
+	if err := json.NewDecoder(reader).Decode(&archive); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("invalid backup file: %w", err))
+		return
+	}
+
+	opts := services.BackupImportOptions{
+		Sections: splitSections(c.Query("sections")),
+		Mode:     c.DefaultQuery("mode", services.BackupImportModeRemap),
+		DryRun:   c.Query("dry_run") == "true",
+	}
+
+	result, err := services.NewBackupService(bc.DB).Import(&archive, opts)
+	if err != nil {
+		var validationErr *services.BackupValidationError
+		if errors.As(err, &validationErr) {
+			utils.RespondJSON(c, http.StatusUnprocessableEntity, "backup archive contains invalid references",
+				gin.H{"problems": validationErr.Problems})
+			return
+		}
+		utils.ErrorLogger.Printf("Failed to import backup: %v", err)
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.InfoLogger.Printf("Backup imported: mode=%s, dry_run=%v, created=%v", result.Mode, result.DryRun, result.Created)
+
+	message := "Backup imported successfully"
+	if result.DryRun {
+		message = "Backup validated successfully (dry run, no data written)"
+	}
+	utils.RespondJSON(c, http.StatusOK, message, result)
+}
+
+// splitSections memecah query "a,b,c" menjadi slice
+func splitSections(raw string) []string {
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+	return strings.Split(raw, ",")
+}
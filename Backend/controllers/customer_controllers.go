@@ -1,9 +1,12 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"net/mail"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yeremiapane/restaurant-app/models"
@@ -19,6 +22,20 @@ func NewCustomerController(db *gorm.DB) *CustomerController {
 	return &CustomerController{DB: db}
 }
 
+// customerDetail adalah customer beserta email-nya, hanya untuk staff atau pemilik sesi
+type customerDetail struct {
+	models.Customer
+	Email *string
+}
+
+func withEmail(customers []models.Customer) []customerDetail {
+	details := make([]customerDetail, len(customers))
+	for i, customer := range customers {
+		details[i] = customerDetail{Customer: customer, Email: customer.Email}
+	}
+	return details
+}
+
 // GetAllCustomers -> Mendapatkan semua customer (aktif/finished)
 func (cc *CustomerController) GetAllCustomers(c *gin.Context) {
 	var customers []models.Customer
@@ -27,6 +44,12 @@ func (cc *CustomerController) GetAllCustomers(c *gin.Context) {
 		return
 	}
 
+	// Route publik tidak menampilkan email customer
+	roleInterface, _ := c.Get("role")
+	if roleInterface == "admin" || roleInterface == "staff" {
+		utils.RespondJSON(c, http.StatusOK, "List of customers", withEmail(customers))
+		return
+	}
 	utils.RespondJSON(c, http.StatusOK, "List of customers", customers)
 }
 
@@ -91,7 +114,7 @@ func (cc *CustomerController) GetCustomerByID(c *gin.Context) {
 		return
 	}
 
-	utils.RespondJSON(c, http.StatusOK, "Customer detail", customer)
+	utils.RespondJSON(c, http.StatusOK, "Customer detail", customerDetail{Customer: customer, Email: customer.Email})
 }
 
 // UpdateCustomer -> Contoh update status 'finished' jika customer meninggalkan meja
@@ -130,7 +153,51 @@ func (cc *CustomerController) UpdateCustomer(c *gin.Context) {
 		}
 	}
 
-	utils.RespondJSON(c, http.StatusOK, "Customer updated", customer)
+	utils.RespondJSON(c, http.StatusOK, "Customer updated", customerDetail{Customer: customer, Email: customer.Email})
+}
+
+// SetEmail -> Customer mengisi (atau menghapus) email untuk struk digital, divalidasi dengan session key
+func (cc *CustomerController) SetEmail(c *gin.Context) {
+	idStr := c.Param("customer_id")
+	id, _ := strconv.Atoi(idStr)
+
+	var req struct {
+		SessionKey string `json:"session_key" binding:"required"`
+		Email      string `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var customer models.Customer
+	if err := cc.DB.First(&customer, id).Error; err != nil {
+		utils.RespondError(c, http.StatusNotFound, err)
+		return
+	}
+	if customer.SessionKey == nil || *customer.SessionKey != req.SessionKey || customer.Status != "active" {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("invalid session key"))
+		return
+	}
+
+	// Email kosong = customer tidak ingin struk digital
+	var email *string
+	if trimmed := strings.TrimSpace(req.Email); trimmed != "" {
+		addr, err := mail.ParseAddress(trimmed)
+		if err != nil || addr.Address != trimmed || len(trimmed) > 100 {
+			utils.RespondError(c, http.StatusBadRequest, errors.New("invalid email address"))
+			return
+		}
+		email = &trimmed
+	}
+
+	if err := cc.DB.Model(&customer).Update("email", email).Error; err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	customer.Email = email
+
+	utils.RespondJSON(c, http.StatusOK, "Customer email updated", customerDetail{Customer: customer, Email: email})
 }
 
 // DeleteCustomer -> Menghapus record customer (opsional)
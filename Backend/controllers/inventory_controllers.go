@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yeremiapane/restaurant-app/services"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+type InventoryController struct {
+	DB *gorm.DB
+}
+
+func NewInventoryController(db *gorm.DB) *InventoryController {
+	return &InventoryController{DB: db}
+}
+
+// GetIngredients -> Melihat semua bahan beserta stoknya
+func (ic *InventoryController) GetIngredients(c *gin.Context) {
+	ingredients, err := services.NewInventoryService(ic.DB).ListIngredients()
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "List of ingredients", ingredients)
+}
+
+// CreateIngredient -> Admin menambah bahan baku
+func (ic *InventoryController) CreateIngredient(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	var input services.IngredientInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	ingredient, err := services.NewInventoryService(ic.DB).CreateIngredient(input, optionalUserID(c))
+	if err != nil {
+		respondInventoryError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusCreated, "Ingredient created", ingredient)
+}
+
+// UpdateIngredient -> Admin mengganti nama, satuan atau batas stok minimum
+func (ic *InventoryController) UpdateIngredient(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+	ingredientID, err := strconv.ParseUint(c.Param("ingredient_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("invalid ingredient id"))
+		return
+	}
+
+	var input services.IngredientInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	ingredient, err := services.NewInventoryService(ic.DB).UpdateIngredient(uint(ingredientID), input)
+	if err != nil {
+		respondInventoryError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "Ingredient updated", ingredient)
+}
+
+// DeleteIngredient -> Admin menghapus bahan yang tidak dipakai resep
+func (ic *InventoryController) DeleteIngredient(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+	ingredientID, err := strconv.ParseUint(c.Param("ingredient_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("invalid ingredient id"))
+		return
+	}
+
+	if err := services.NewInventoryService(ic.DB).DeleteIngredient(uint(ingredientID)); err != nil {
+		respondInventoryError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "Ingredient deleted", nil)
+}
+
+// AdjustIngredientStock -> Staff mencatat barang masuk atau koreksi stok
+func (ic *InventoryController) AdjustIngredientStock(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" && roleInterface != "staff" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+	ingredientID, err := strconv.ParseUint(c.Param("ingredient_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("invalid ingredient id"))
+		return
+	}
+
+	var input services.StockAdjustmentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	ingredient, err := services.NewInventoryService(ic.DB).AdjustStock(uint(ingredientID), input, optionalUserID(c))
+	if err != nil {
+		respondInventoryError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "Ingredient stock updated", ingredient)
+}
+
+// GetIngredientMovements -> Riwayat perubahan stok satu bahan
+// Query: limit (default 100, maks 500)
+func (ic *InventoryController) GetIngredientMovements(c *gin.Context) {
+	ingredientID, err := strconv.ParseUint(c.Param("ingredient_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("invalid ingredient id"))
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	movements, err := services.NewInventoryService(ic.DB).ListMovements(uint(ingredientID), limit)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "List of stock movements", movements)
+}
+
+// GetMenuRecipe -> Melihat resep (bahan per porsi) satu menu
+func (ic *InventoryController) GetMenuRecipe(c *gin.Context) {
+	menuID, err := strconv.ParseUint(c.Param("menu_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("invalid menu id"))
+		return
+	}
+
+	recipe, err := services.NewInventoryService(ic.DB).GetRecipe(uint(menuID))
+	if err != nil {
+		respondInventoryError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "Menu recipe", recipe)
+}
+
+// SetMenuRecipe -> Admin mengganti seluruh resep satu menu
+// Body: {"items": [{"ingredient_id": 1, "variant_id": null, "quantity": 150}]}
+func (ic *InventoryController) SetMenuRecipe(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+	menuID, err := strconv.ParseUint(c.Param("menu_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("invalid menu id"))
+		return
+	}
+
+	var input struct {
+		Items []services.RecipeItemInput `json:"items"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	recipe, err := services.NewInventoryService(ic.DB).SetRecipe(uint(menuID), input.Items)
+	if err != nil {
+		respondInventoryError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "Menu recipe updated", recipe)
+}
+
+// optionalUserID mengembalikan ID user yang login, atau nil
+func optionalUserID(c *gin.Context) *uint {
+	if id, ok := currentUserID(c); ok {
+		return &id
+	}
+	return nil
+}
+
+// respondInventoryError memetakan error InventoryService ke status HTTP
+func respondInventoryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.RespondError(c, http.StatusNotFound, err)
+	case errors.Is(err, services.ErrInvalidIngredient), errors.Is(err, services.ErrInvalidRecipe):
+		utils.RespondError(c, http.StatusBadRequest, err)
+	case errors.Is(err, services.ErrIngredientInUse):
+		utils.RespondError(c, http.StatusConflict, err)
+	default:
+		utils.ErrorLogger.Printf("Inventory error: %v", err)
+		utils.RespondError(c, http.StatusInternalServerError, err)
+	}
+}
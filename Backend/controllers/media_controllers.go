@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yeremiapane/restaurant-app/services"
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+type MediaController struct{}
+
+func NewMediaController() *MediaController {
+	return &MediaController{}
+}
+
+// GetMedia -> Mengarahkan ke URL sementara objek di storage (mis. presigned URL S3).
+// URL /media/... inilah yang disimpan di database, sehingga tidak pernah kedaluwarsa.
+func (mc *MediaController) GetMedia(c *gin.Context) {
+	key, err := services.CleanObjectKey(c.Param("key"))
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+	// Hanya gambar menu yang publik; objek lain di bucket privat tidak boleh ditandatangani
+	if !services.IsMenuImageKey(key) {
+		utils.RespondError(c, http.StatusNotFound, errors.New("media not found"))
+		return
+	}
+
+	expiry := services.SignedURLExpiry()
+	signedURL, err := services.GetStorage().SignedURL(key, expiry)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidObjectKey) {
+			utils.RespondError(c, http.StatusBadRequest, err)
+			return
+		}
+		utils.ErrorLogger.Printf("Failed to sign media URL %s: %v", key, err)
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Browser boleh menyimpan redirect selama separuh masa berlaku URL-nya
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", int(expiry.Seconds()/2)))
+	c.Redirect(http.StatusFound, signedURL)
+}
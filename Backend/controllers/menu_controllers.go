@@ -4,14 +4,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"mime/multipart"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/services"
 	"github.com/yeremiapane/restaurant-app/utils"
 	"gorm.io/gorm"
 )
@@ -24,26 +24,173 @@ func NewMenuController(db *gorm.DB) *MenuController {
 	return &MenuController{DB: db}
 }
 
+// saveMenuImages memproses dan menyimpan semua upload, mengembalikan URL rendisi large.
+// Jika satu file gagal, file yang sudah tersimpan dihapus lagi.
+func saveMenuImages(files []*multipart.FileHeader) ([]string, error) {
+	var imageUrls []string
+	for _, file := range files {
+		imageURL, err := saveMenuImage(file)
+		if err != nil {
+			for _, url := range imageUrls {
+				services.DeleteMenuImage(url)
+			}
+			return nil, err
+		}
+		imageUrls = append(imageUrls, imageURL)
+	}
+	return imageUrls, nil
+}
+
+func saveMenuImage(file *multipart.FileHeader) (string, error) {
+	if file.Size > services.MaxMenuImageBytes {
+		return "", fmt.Errorf("%s: %w", file.Filename, services.ErrImageTooLarge)
+	}
+	f, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", file.Filename, err)
+	}
+	defer f.Close()
+
+	processed, err := services.ProcessMenuImage(f)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", file.Filename, err)
+	}
+	return processed.Store(services.GetStorage())
+}
+
+// respondMenuImageError memetakan error pemrosesan gambar ke status HTTP
+func respondMenuImageError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrImageTooLarge):
+		utils.RespondError(c, http.StatusRequestEntityTooLarge, err)
+	case errors.Is(err, services.ErrUnsupportedImage):
+		utils.RespondError(c, http.StatusUnsupportedMediaType, err)
+	default:
+		utils.ErrorLogger.Printf("Menu image error: %v", err)
+		utils.RespondError(c, http.StatusInternalServerError, errors.New("error saving image"))
+	}
+}
+
+// orderedVariants mengurutkan variant yang di-preload sesuai urutan tampil
+func orderedVariants(db *gorm.DB) *gorm.DB {
+	return db.Order("sort_order ASC, id ASC")
+}
+
+// applyMenuAvailability menandai ketersediaan setiap menu. Customer (tanpa login) hanya
+// melihat menu yang tersedia saat ini; staff dan admin melihat semua menu.
+func (mc *MenuController) applyMenuAvailability(c *gin.Context, menus []models.Menu) ([]models.Menu, error) {
+	availability, err := services.NewMenuAvailabilityService(mc.DB).Current()
+	if err != nil {
+		return nil, err
+	}
+	if _, isStaff := c.Get("role"); isStaff {
+		availability.Mark(menus)
+		return menus, nil
+	}
+	return availability.Filter(menus), nil
+}
+
 // GetAllMenus
 func (mc *MenuController) GetAllMenus(c *gin.Context) {
 	var menus []models.Menu
 
-	result := mc.DB.Preload("Category").Find(&menus)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  false,
-			"message": result.Error.Error(),
-		})
+	if err := mc.DB.Preload("Category").Preload("Variants", orderedVariants).Preload("DietaryTags").
+		Find(&menus).Error; err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	menus, err := mc.applyMenuAvailability(c, menus)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "List of menus", menus)
+}
+
+// SearchMenus mencari menu dengan filter, urutan dan cursor pagination
+// Endpoint: GET /menus/search?q=&category=1,2&min_price=&max_price=&in_stock=true&tags=vegan,halal&sort=name_asc&limit=20&cursor=
+func (mc *MenuController) SearchMenus(c *gin.Context) {
+	query := services.MenuSearchQuery{
+		Query:   c.Query("q"),
+		InStock: c.Query("in_stock") == "true",
+		Sort:    c.Query("sort"),
+		Cursor:  c.Query("cursor"),
+	}
+	for _, raw := range splitSections(c.Query("category")) {
+		categoryID, err := strconv.ParseUint(strings.TrimSpace(raw), 10, 32)
+		if err != nil {
+			utils.RespondError(c, http.StatusBadRequest, errors.New("invalid category ID"))
+			return
+		}
+		query.CategoryIDs = append(query.CategoryIDs, uint(categoryID))
+	}
+	var err error
+	if query.MinPrice, err = queryMoney(c, "min_price"); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+	if query.MaxPrice, err = queryMoney(c, "max_price"); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+	if raw := c.Query("tags"); raw != "" {
+		query.Tags = splitSections(raw)
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			utils.RespondError(c, http.StatusBadRequest, errors.New("invalid limit"))
+			return
+		}
+		query.Limit = limit
+	}
+
+	// Customer hanya melihat menu yang tersedia; penyaringan dilakukan saat paginasi
+	// agar setiap halaman tetap berisi limit menu
+	availability, err := services.NewMenuAvailabilityService(mc.DB).Current()
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, err)
 		return
 	}
+	if _, isStaff := c.Get("role"); !isStaff {
+		query.Available = availability.IsAvailable
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":  true,
-		"message": "List of menus",
-		"data":    menus,
+	result, err := services.NewMenuSearchService(mc.DB).Search(query)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidMenuSearch) || errors.Is(err, services.ErrInvalidDietaryTag) {
+			utils.RespondError(c, http.StatusBadRequest, err)
+			return
+		}
+		utils.ErrorLogger.Printf("Failed to search menus: %v", err)
+		utils.RespondError(c, http.StatusInternalServerError, errors.New("failed to search menus"))
+		return
+	}
+
+	availability.Mark(result.Menus)
+
+	utils.RespondJSON(c, http.StatusOK, "List of menus", utils.CursorPage{
+		Items:      result.Menus,
+		NextCursor: result.NextCursor,
+		HasMore:    result.NextCursor != "",
 	})
 }
 
+// queryMoney membaca nominal opsional dari query string
+func queryMoney(c *gin.Context, param string) (*utils.Money, error) {
+	raw := c.Query(param)
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := utils.ParseMoney(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s", param)
+	}
+	return &value, nil
+}
+
 // CreateMenu
 func (mc *MenuController) CreateMenu(c *gin.Context) {
 	// Batasi ukuran upload ke 10MB
@@ -56,7 +203,7 @@ func (mc *MenuController) CreateMenu(c *gin.Context) {
 		return
 	}
 
-	price, err := strconv.ParseFloat(c.PostForm("price"), 64)
+	price, err := utils.ParseMoney(c.PostForm("price"))
 	if err != nil {
 		utils.RespondError(c, http.StatusBadRequest, errors.New("invalid price"))
 		return
@@ -68,6 +215,13 @@ func (mc *MenuController) CreateMenu(c *gin.Context) {
 		return
 	}
 
+	// Tag diet dipisah koma, mis. "vegan,halal"
+	dietaryTags, err := services.NormalizeDietaryTags(splitSections(c.PostForm("dietary_tags")))
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
 	// Ambil file gambar
 	form, err := c.MultipartForm()
 	if err != nil {
@@ -81,39 +235,13 @@ func (mc *MenuController) CreateMenu(c *gin.Context) {
 		return
 	}
 
-	// Buat direktori untuk menyimpan gambar jika belum ada
-	uploadDir := "public/uploads/menu_images"
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		utils.RespondError(c, http.StatusInternalServerError, errors.New("error creating upload directory"))
+	// Proses gambar menjadi rendisi JPEG dan kumpulkan URL-nya
+	imageUrls, err := saveMenuImages(files)
+	if err != nil {
+		respondMenuImageError(c, err)
 		return
 	}
 
-	// Simpan gambar dan kumpulkan URL-nya
-	var imageUrls []string
-	baseURL := "http://localhost:8080" // Sesuaikan dengan domain aplikasi
-
-	for _, file := range files {
-		// Generate nama file unik
-		filename := fmt.Sprintf("%d-%s", time.Now().UnixNano(), file.Filename)
-		filepath := fmt.Sprintf("%s/%s", uploadDir, filename)
-
-		// Simpan file
-		if err := c.SaveUploadedFile(file, filepath); err != nil {
-			// Hapus file yang sudah diupload jika ada error
-			for _, url := range imageUrls {
-				// Hapus file berdasarkan URL
-				localPath := strings.Replace(url, baseURL+"/uploads/menu_images/", uploadDir+"/", 1)
-				os.Remove(localPath)
-			}
-			utils.RespondError(c, http.StatusInternalServerError, errors.New("error saving image"))
-			return
-		}
-
-		// Simpan URL publik ke database
-		imageUrl := fmt.Sprintf("%s/uploads/menu_images/%s", baseURL, filename)
-		imageUrls = append(imageUrls, imageUrl)
-	}
-
 	// Buat menu baru
 	menu := models.Menu{
 		CategoryID:  uint(categoryID),
@@ -121,13 +249,13 @@ func (mc *MenuController) CreateMenu(c *gin.Context) {
 		Price:       price,
 		Stock:       stock,
 		Description: c.PostForm("description"),
+		DietaryTags: services.MenuDietaryTags(0, dietaryTags),
 	}
 
 	if err := menu.SetImageUrls(imageUrls); err != nil {
 		// Hapus gambar yang sudah diupload jika gagal
 		for _, url := range imageUrls {
-			localPath := strings.Replace(url, baseURL+"/uploads/menu_images/", uploadDir+"/", 1)
-			os.Remove(localPath)
+			services.DeleteMenuImage(url)
 		}
 		utils.RespondError(c, http.StatusInternalServerError, errors.New("error processing image urls"))
 		return
@@ -136,9 +264,7 @@ func (mc *MenuController) CreateMenu(c *gin.Context) {
 	if err := mc.DB.Create(&menu).Error; err != nil {
 		// Hapus gambar yang sudah diupload jika gagal membuat menu
 		for _, url := range imageUrls {
-			// Hapus file berdasarkan URL
-			localPath := strings.Replace(url, baseURL+"/uploads/menu_images/", uploadDir+"/", 1)
-			os.Remove(localPath)
+			services.DeleteMenuImage(url)
 		}
 		utils.RespondError(c, http.StatusInternalServerError, err)
 		return
@@ -153,7 +279,7 @@ func (mc *MenuController) GetMenuByID(c *gin.Context) {
 	id, _ := strconv.Atoi(idStr)
 
 	var menu models.Menu
-	if err := mc.DB.Preload("Category").First(&menu, id).Error; err != nil {
+	if err := mc.DB.Preload("Category").Preload("Variants", orderedVariants).Preload("DietaryTags").First(&menu, id).Error; err != nil {
 		utils.RespondError(c, http.StatusNotFound, err)
 		return
 	}
@@ -177,13 +303,19 @@ func (mc *MenuController) GetMenuByCategory(c *gin.Context) {
 	}
 
 	var menus []models.Menu
-	if err := mc.DB.Preload("Category").
+	if err := mc.DB.Preload("Category").Preload("Variants", orderedVariants).Preload("DietaryTags").
 		Where("category_id = ?", categoryID).
 		Find(&menus).Error; err != nil {
 		utils.RespondError(c, http.StatusInternalServerError, err)
 		return
 	}
 
+	menus, err = mc.applyMenuAvailability(c, menus)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
 	utils.RespondJSON(c, http.StatusOK, fmt.Sprintf("List of menus for category ID: %d", categoryID), menus)
 }
 
@@ -216,7 +348,7 @@ func (mc *MenuController) UpdateMenu(c *gin.Context) {
 	// Parse price (hapus karakter non-numerik jika ada)
 	priceStr = strings.ReplaceAll(priceStr, ".", "")
 	priceStr = strings.ReplaceAll(priceStr, ",", "")
-	price, err := strconv.ParseFloat(priceStr, 64)
+	price, err := utils.ParseMoney(priceStr)
 	if err != nil {
 		utils.RespondError(c, http.StatusBadRequest, errors.New("invalid price format"))
 		return
@@ -229,6 +361,14 @@ func (mc *MenuController) UpdateMenu(c *gin.Context) {
 		return
 	}
 
+	// Tag diet hanya diganti jika field dietary_tags dikirim (kosong = hapus semua tag)
+	dietaryTagsStr, replaceTags := c.GetPostForm("dietary_tags")
+	dietaryTags, err := services.NormalizeDietaryTags(splitSections(dietaryTagsStr))
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
 	// Ambil menu yang akan diupdate
 	var menu models.Menu
 	if err := mc.DB.First(&menu, id).Error; err != nil {
@@ -256,15 +396,14 @@ func (mc *MenuController) UpdateMenu(c *gin.Context) {
 	currentImages := menu.GetImageUrls()
 	var newImageList []string
 
-	// Filter gambar yang tidak dihapus
+	// Filter gambar yang tidak dihapus; file-nya baru dihapus setelah menu tersimpan
+	var droppedImages []string
 	for _, img := range currentImages {
 		isRemoved := false
 		for _, removedImg := range removedImages {
 			if img == removedImg {
 				isRemoved = true
-				// Hapus file gambar yang dihapus
-				localPath := strings.Replace(img, "http://localhost:8080/uploads/menu_images/", "public/uploads/menu_images/", 1)
-				os.Remove(localPath)
+				droppedImages = append(droppedImages, img)
 				break
 			}
 		}
@@ -274,46 +413,51 @@ func (mc *MenuController) UpdateMenu(c *gin.Context) {
 	}
 
 	// Handle file gambar baru jika ada
+	var uploadedImages []string
 	form, _ := c.MultipartForm()
 	if form != nil && form.File != nil {
 		if files := form.File["images"]; len(files) > 0 {
-			baseURL := "http://localhost:8080"
-			uploadDir := "public/uploads/menu_images"
-
-			// Pastikan direktori upload ada
-			if err := os.MkdirAll(uploadDir, 0755); err != nil {
-				utils.RespondError(c, http.StatusInternalServerError, errors.New("error creating upload directory"))
+			uploadedImages, err = saveMenuImages(files)
+			if err != nil {
+				respondMenuImageError(c, err)
 				return
 			}
-
-			// Upload gambar baru
-			for _, file := range files {
-				filename := fmt.Sprintf("%d-%s", time.Now().UnixNano(), file.Filename)
-				filepath := fmt.Sprintf("%s/%s", uploadDir, filename)
-
-				if err := c.SaveUploadedFile(file, filepath); err != nil {
-					utils.RespondError(c, http.StatusInternalServerError, errors.New("error saving image"))
-					return
-				}
-
-				imageUrl := fmt.Sprintf("%s/uploads/menu_images/%s", baseURL, filename)
-				newImageList = append(newImageList, imageUrl)
-			}
+			newImageList = append(newImageList, uploadedImages...)
 		}
 	}
 
 	// Update image URLs di menu dengan gabungan gambar lama (yang tidak dihapus) dan gambar baru
 	if err := menu.SetImageUrls(newImageList); err != nil {
+		for _, url := range uploadedImages {
+			services.DeleteMenuImage(url)
+		}
 		utils.RespondError(c, http.StatusInternalServerError, errors.New("error processing image urls"))
 		return
 	}
 
 	// Simpan perubahan ke database
-	if err := mc.DB.Save(&menu).Error; err != nil {
+	err = mc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&menu).Error; err != nil {
+			return err
+		}
+		if replaceTags {
+			return services.ReplaceMenuDietaryTags(tx, &menu, dietaryTags)
+		}
+		return tx.Where("menu_id = ?", menu.ID).Find(&menu.DietaryTags).Error
+	})
+	if err != nil {
+		for _, url := range uploadedImages {
+			services.DeleteMenuImage(url)
+		}
 		utils.RespondError(c, http.StatusInternalServerError, err)
 		return
 	}
 
+	// Hapus file gambar yang sudah tidak dipakai
+	for _, img := range droppedImages {
+		services.DeleteMenuImage(img)
+	}
+
 	utils.RespondJSON(c, http.StatusOK, "Menu updated successfully", menu)
 }
 
@@ -322,9 +466,19 @@ func (mc *MenuController) DeleteMenu(c *gin.Context) {
 	idStr := c.Param("menu_id")
 	id, _ := strconv.Atoi(idStr)
 
-	if err := mc.DB.Delete(&models.Menu{}, id).Error; err != nil {
+	var menu models.Menu
+	if err := mc.DB.First(&menu, id).Error; err != nil {
+		utils.RespondError(c, http.StatusNotFound, errors.New("menu not found"))
+		return
+	}
+	if err := mc.DB.Delete(&menu).Error; err != nil {
 		utils.RespondError(c, http.StatusInternalServerError, err)
 		return
 	}
+
+	// Gambar menu yang dihapus tidak dipakai lagi
+	for _, img := range menu.GetImageUrls() {
+		services.DeleteMenuImage(img)
+	}
 	utils.RespondJSON(c, http.StatusOK, "Menu deleted", gin.H{"menu_id": id})
 }
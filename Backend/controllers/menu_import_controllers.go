@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yeremiapane/restaurant-app/services"
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+const maxMenuImportUploadSize = 10 << 20
+
+// ImportMenus -> Admin mengimpor kategori & menu secara massal dari file CSV/XLSX
+// Multipart field "file". Query: dry_run=true untuk validasi saja
+func (mc *MenuController) ImportMenus(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("import file is required"))
+		return
+	}
+	if fileHeader.Size > maxMenuImportUploadSize {
+		utils.RespondError(c, http.StatusRequestEntityTooLarge, errors.New("import file is too large"))
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	records, err := services.ParseMenuFile(fileHeader.Filename, data)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+	report, err := services.NewMenuImportService(mc.DB).Import(records, c.Query("dry_run") == "true")
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidMenuImport) {
+			utils.RespondError(c, http.StatusBadRequest, err)
+			return
+		}
+		utils.ErrorLogger.Printf("Failed to import menus: %v", err)
+		utils.RespondError(c, http.StatusInternalServerError, errors.New("failed to import menus"))
+		return
+	}
+
+	if report.Failed > 0 {
+		utils.RespondJSON(c, http.StatusUnprocessableEntity, "menu import contains invalid rows, nothing was saved", report)
+		return
+	}
+	utils.InfoLogger.Printf("Menus imported: dry_run=%v, created=%d, updated=%d, unchanged=%d",
+		report.DryRun, report.Created, report.Updated, report.Unchanged)
+
+	message := "Menus imported successfully"
+	if report.DryRun {
+		message = "Menu import validated successfully (dry run, no data written)"
+	}
+	utils.RespondJSON(c, http.StatusOK, message, report)
+}
+
+// ExportMenus -> Admin mengunduh semua menu dengan kolom yang sama seperti file impor
+// Query: format=csv|xlsx (default csv)
+func (mc *MenuController) ExportMenus(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	format := c.DefaultQuery("format", services.MenuFileCSV)
+	contentType := "text/csv"
+	switch format {
+	case services.MenuFileCSV:
+	case services.MenuFileXLSX:
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		utils.RespondError(c, http.StatusBadRequest, errors.New("format must be csv or xlsx"))
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := services.NewMenuImportService(mc.DB).Export(&buf, format); err != nil {
+		utils.ErrorLogger.Printf("Failed to export menus: %v", err)
+		utils.RespondError(c, http.StatusInternalServerError, errors.New("failed to export menus"))
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=menus_%s.%s", time.Now().Format("20060102"), format))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
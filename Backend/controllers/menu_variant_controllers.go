@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yeremiapane/restaurant-app/services"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+type MenuVariantController struct {
+	DB *gorm.DB
+}
+
+func NewMenuVariantController(db *gorm.DB) *MenuVariantController {
+	return &MenuVariantController{DB: db}
+}
+
+// GetMenuVariants -> Melihat variant satu menu beserta stoknya
+func (vc *MenuVariantController) GetMenuVariants(c *gin.Context) {
+	menuID, err := strconv.ParseUint(c.Param("menu_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("invalid menu id"))
+		return
+	}
+
+	variants, err := services.NewMenuVariantService(vc.DB).ListVariants(uint(menuID))
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "List of menu variants", variants)
+}
+
+// CreateMenuVariant -> Admin menambah variant (ukuran, porsi, suhu) pada menu
+func (vc *MenuVariantController) CreateMenuVariant(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+	menuID, err := strconv.ParseUint(c.Param("menu_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("invalid menu id"))
+		return
+	}
+
+	var input services.MenuVariantInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	variant, err := services.NewMenuVariantService(vc.DB).CreateVariant(uint(menuID), input)
+	if err != nil {
+		respondMenuVariantError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusCreated, "Menu variant created", variant)
+}
+
+// UpdateMenuVariant -> Admin mengganti harga, SKU atau stok variant
+func (vc *MenuVariantController) UpdateMenuVariant(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+	variantID, err := strconv.ParseUint(c.Param("variant_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("invalid variant id"))
+		return
+	}
+
+	var input services.MenuVariantInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	variant, err := services.NewMenuVariantService(vc.DB).UpdateVariant(uint(variantID), input)
+	if err != nil {
+		respondMenuVariantError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "Menu variant updated", variant)
+}
+
+// DeleteMenuVariant -> Admin menghapus variant
+func (vc *MenuVariantController) DeleteMenuVariant(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+	variantID, err := strconv.ParseUint(c.Param("variant_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("invalid variant id"))
+		return
+	}
+
+	if err := services.NewMenuVariantService(vc.DB).DeleteVariant(uint(variantID)); err != nil {
+		respondMenuVariantError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "Menu variant deleted", nil)
+}
+
+// respondMenuVariantError memetakan error MenuVariantService ke status HTTP
+func respondMenuVariantError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.RespondError(c, http.StatusNotFound, err)
+	case errors.Is(err, services.ErrInvalidMenuVariant):
+		utils.RespondError(c, http.StatusBadRequest, err)
+	default:
+		utils.ErrorLogger.Printf("Menu variant error: %v", err)
+		utils.RespondError(c, http.StatusInternalServerError, err)
+	}
+}
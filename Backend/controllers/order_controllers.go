@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/services"
 	"github.com/yeremiapane/restaurant-app/utils"
 
 	// import kds untuk broadcast (jika masih ingin menyiarkan event ke websocket)
@@ -68,17 +70,18 @@ func (oc *OrderController) GetAllOrders(c *gin.Context) {
 // CreateOrder -> buat order baru
 func (oc *OrderController) CreateOrder(c *gin.Context) {
 	var req struct {
-		TableID     uint    `json:"table_id" binding:"required"`
-		CustomerID  uint    `json:"customer_id" binding:"required"`
-		SessionKey  string  `json:"session_key" binding:"required"`
-		Status      string  `json:"status"`
-		TotalAmount float64 `json:"total_amount"`
+		TableID     uint        `json:"table_id" binding:"required"`
+		CustomerID  uint        `json:"customer_id" binding:"required"`
+		SessionKey  string      `json:"session_key" binding:"required"`
+		Status      string      `json:"status"`
+		TotalAmount utils.Money `json:"total_amount"`
 		Items       []struct {
-			MenuID   uint    `json:"menu_id" binding:"required"`
-			Quantity int     `json:"quantity" binding:"required,min=1"`
-			Price    float64 `json:"price"`
-			Notes    string  `json:"notes"`
-			Status   string  `json:"status"`
+			MenuID    uint        `json:"menu_id" binding:"required"`
+			VariantID *uint       `json:"variant_id"`
+			Quantity  int         `json:"quantity" binding:"required,min=1"`
+			Price     utils.Money `json:"price"`
+			Notes     string      `json:"notes"`
+			Status    string      `json:"status"`
 		} `json:"Items" binding:"required,min=1"`
 	}
 
@@ -119,6 +122,16 @@ func (oc *OrderController) CreateOrder(c *gin.Context) {
 		return
 	}
 
+	// Jadwal ketersediaan menu dievaluasi sekali untuk seluruh item
+	availability, err := services.NewMenuAvailabilityService(oc.DB).Current()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": err.Error(),
+		})
+		return
+	}
+
 	// Buat order baru
 	order := models.Order{
 		TableID:     req.TableID,
@@ -129,8 +142,29 @@ func (oc *OrderController) CreateOrder(c *gin.Context) {
 		UpdatedAt:   time.Now(),
 	}
 
+	// Baris nomor order hari ini dibuat di luar transaksi agar tidak deadlock di MySQL
+	if err := services.OrderNumberScheme().EnsurePeriod(oc.DB, order.CreatedAt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": err.Error(),
+		})
+		return
+	}
+
 	tx := oc.DB.Begin()
 
+	// Nomor order harian diambil di transaksi yang sama agar tidak loncat jika order gagal dibuat
+	orderNumber, err := services.OrderNumberScheme().Next(tx, order.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": err.Error(),
+		})
+		return
+	}
+	order.OrderNumber = orderNumber
+
 	if err := tx.Create(&order).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -140,7 +174,9 @@ func (oc *OrderController) CreateOrder(c *gin.Context) {
 		return
 	}
 
-	// Proses items
+	// Proses items. Total order dihitung ulang dari harga item yang sudah di-resolve
+	// (harga variant), bukan dari total_amount kiriman client.
+	var totalAmount utils.Money
 	for _, item := range req.Items {
 		var menu models.Menu
 		if err := tx.First(&menu, item.MenuID).Error; err != nil {
@@ -151,6 +187,14 @@ func (oc *OrderController) CreateOrder(c *gin.Context) {
 			})
 			return
 		}
+		if !availability.IsAvailable(&menu) {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  false,
+				"message": fmt.Sprintf("%s: %s", services.ErrMenuUnavailable.Error(), menu.Name),
+			})
+			return
+		}
 
 		orderItem := models.OrderItem{
 			OrderID:   order.ID,
@@ -163,6 +207,30 @@ func (oc *OrderController) CreateOrder(c *gin.Context) {
 			UpdatedAt: time.Now(),
 		}
 
+		// Menu dengan variant: harga dan stok mengikuti variant yang dipilih
+		variant, err := services.ResolveVariant(tx, &menu, item.VariantID)
+		if err == nil && variant != nil {
+			err = services.TakeVariantStock(tx, variant, item.Quantity)
+		}
+		if err != nil {
+			tx.Rollback()
+			status := http.StatusInternalServerError
+			if errors.Is(err, services.ErrVariantRequired) || errors.Is(err, services.ErrInvalidMenuVariant) ||
+				errors.Is(err, services.ErrInsufficientVariantStock) {
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, gin.H{
+				"status":  false,
+				"message": err.Error(),
+			})
+			return
+		}
+		if variant != nil {
+			orderItem.VariantID = &variant.ID
+			orderItem.VariantName = variant.Name
+			orderItem.Price = variant.Price
+		}
+
 		if err := tx.Create(&orderItem).Error; err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -171,6 +239,17 @@ func (oc *OrderController) CreateOrder(c *gin.Context) {
 			})
 			return
 		}
+		totalAmount += orderItem.Price.Mul(orderItem.Quantity)
+	}
+
+	order.TotalAmount = totalAmount
+	if err := tx.Model(&order).Update("total_amount", totalAmount).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  false,
+			"message": err.Error(),
+		})
+		return
 	}
 
 	tx.Commit()
@@ -270,8 +349,15 @@ func (oc *OrderController) UpdateOrder(c *gin.Context) {
 			}
 		}
 
-		order.Status = *req.Status
-		if err := tx.Save(&order).Error; err != nil {
+		// Order yang dibatalkan mengembalikan stok variant yang diambil saat order dibuat
+		var err error
+		if *req.Status == services.OrderStatusCancelled {
+			err = services.CancelOrder(tx, &order)
+		} else {
+			order.Status = *req.Status
+			err = tx.Save(&order).Error
+		}
+		if err != nil {
 			tx.Rollback()
 			utils.RespondError(c, http.StatusInternalServerError, err)
 			return
@@ -686,10 +772,10 @@ func (oc *OrderController) GetOrderAnalytics(c *gin.Context) {
 
 	var analytics struct {
 		PopularItems []struct {
-			MenuID   uint    `json:"menu_id"`
-			MenuName string  `json:"menu_name"`
-			Count    int     `json:"count"`
-			Revenue  float64 `json:"revenue"`
+			MenuID   uint        `json:"menu_id"`
+			MenuName string      `json:"menu_name"`
+			Count    int         `json:"count"`
+			Revenue  utils.Money `json:"revenue"`
 		} `json:"popular_items"`
 		AveragePrepTime float64 `json:"average_prep_time"`
 		PeakHours       []struct {
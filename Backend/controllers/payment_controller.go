@@ -1,14 +1,11 @@
 package controllers
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
-	"math"
 	"net/http"
 	"os"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/yeremiapane/restaurant-app/kds"
@@ -67,6 +64,18 @@ func initMidtransConfig() {
 		MerchantEmail: os.Getenv("MIDTRANS_MERCHANT_EMAIL"),
 		MerchantPhone: os.Getenv("MIDTRANS_MERCHANT_PHONE"),
 	}
+
+	// Detail merchant yang kosong diambil dari profil restoran
+	profile := services.CurrentRestaurantProfile()
+	if midtransConfig.MerchantName == "" {
+		midtransConfig.MerchantName = profile.Name
+	}
+	if midtransConfig.MerchantEmail == "" {
+		midtransConfig.MerchantEmail = profile.Email
+	}
+	if midtransConfig.MerchantPhone == "" {
+		midtransConfig.MerchantPhone = profile.Phone
+	}
 }
 
 // ValidateMidtransConfig memvalidasi konfigurasi Midtrans
@@ -107,11 +116,14 @@ func ValidateMidtransConfig() error {
 
 // PaymentRequest adalah struktur untuk request pembuatan pembayaran
 type PaymentRequest struct {
-	OrderID       uint    `json:"order_id" binding:"required"`
-	PaymentMethod string  `json:"payment_method" binding:"required,oneof=cash qris"`
-	Amount        float64 `json:"amount" binding:"required,min=0"`
-	ReferenceID   string  `json:"reference_id" binding:"required"`
-	CashReceived  float64 `json:"cash_received"`
+	OrderID        uint        `json:"order_id" binding:"required"`
+	PaymentMethod  string      `json:"payment_method" binding:"required,oneof=cash qris bank_transfer"`
+	Amount         utils.Money `json:"amount" binding:"required,min=0"`
+	ReferenceID    string      `json:"reference_id" binding:"required"`
+	CashReceived   utils.Money `json:"cash_received"`
+	Bank           string      `json:"bank"`             // Wajib untuk bank_transfer: bca, bni, bri, permata, cimb, mandiri
+	Tip            utils.Money `json:"tip"`              // Tip di atas amount, tidak mengurangi tagihan order
+	TipRecipientID *uint       `json:"tip_recipient_id"` // Staf penerima tip, kosong berarti tip masuk pool
 }
 
 // PaymentCallbackRequest adalah struktur untuk request callback dari payment gateway
@@ -193,12 +205,37 @@ func CreatePayment(c *gin.Context) {
 		return
 	}
 
-	// Validasi pecahan desimal (hanya boleh 2 angka dibelakang koma)
-	if math.Mod(req.Amount*100, 1) != 0 {
-		utils.RespondError(c, http.StatusBadRequest, errors.New("amount can only have up to 2 decimal places"))
+	if err := services.ValidateTip(req.Tip); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Tunai hanya boleh dicatat kasir (staff/admin) agar selalu masuk ke shift yang terbuka;
+	// route publik hanya untuk QRIS dan bank transfer
+	role, _ := c.Get("role")
+	cashierID, isUser := currentUserID(c)
+	isCashier := isUser && (role == "admin" || role == "staff")
+	if req.PaymentMethod == "cash" && !isCashier {
+		utils.RespondError(c, http.StatusForbidden, errors.New("cash payments must be recorded by a cashier"))
+		return
+	}
+
+	// Payment gateway hanya menerima Rupiah utuh, sen hanya berlaku untuk tunai
+	if req.PaymentMethod != "cash" && !(req.Amount + req.Tip).IsWholeRupiah() {
+		utils.RespondError(c, http.StatusBadRequest, services.ErrFractionalRupiah)
 		return
 	}
 
+	// Bank transfer wajib memilih bank virtual account
+	if req.PaymentMethod == "bank_transfer" {
+		bank, err := services.NormalizeVABank(req.Bank)
+		if err != nil {
+			utils.RespondError(c, http.StatusBadRequest, err)
+			return
+		}
+		req.Bank = bank
+	}
+
 	db := utils.GetDB()
 
 	// Ambil order dari database
@@ -207,106 +244,128 @@ func CreatePayment(c *gin.Context) {
 		utils.RespondError(c, http.StatusNotFound, errors.New("order not found"))
 		return
 	}
+	if err := validateTipRecipient(db, req.TipRecipientID); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
 
 	// Generate payment ID unik
 	paymentUUID := uuid.New().String()
 
-	// Set default expired time (15 menit dari sekarang)
-	expiredAt := time.Now().Add(15 * time.Minute)
+	// Default expired time per metode (QRIS_EXPIRY_MINUTES / BANK_TRANSFER_EXPIRY_MINUTES),
+	// dipakai jika provider tidak mengembalikan expiry_time
+	expiryMinutes := services.PaymentExpiryMinutes(req.PaymentMethod)
+	expiredAt := time.Now().Add(time.Duration(expiryMinutes) * time.Minute)
 
 	payment := models.Payment{
-		OrderID:       req.OrderID,
-		Amount:        req.Amount,
-		Status:        "pending",
-		PaymentMethod: req.PaymentMethod,
-		ReferenceID:   req.ReferenceID,
-		ExpiredAt:     &expiredAt, // Set default expired time sebagai pointer
+		OrderID:        req.OrderID,
+		Amount:         req.Amount,
+		Tip:            req.Tip,
+		TipRecipientID: req.TipRecipientID,
+		Status:         "pending",
+		PaymentMethod:  req.PaymentMethod,
+		ReferenceID:    req.ReferenceID,
+		ExpiredAt:      &expiredAt, // Set default expired time sebagai pointer
 	}
 
 	// Log payment request
-	utils.InfoLogger.Printf("Creating payment for order #%d with method %s, amount: %.2f",
-		payment.OrderID, payment.PaymentMethod, payment.Amount)
+	utils.InfoLogger.Printf("Creating payment for order #%d with method %s, amount: %s, tip: %s",
+		payment.OrderID, payment.PaymentMethod, payment.Amount, payment.Tip)
 
-	// Jika pembayaran tunai, langsung sukses
+	// Jika pembayaran tunai, langsung sukses. Uang diterima tidak boleh kurang dari nominal + tip;
+	// jika tidak diisi dianggap uang pas. Untuk rincian pecahan / split bill gunakan endpoint tender.
 	if req.PaymentMethod == "cash" {
+		cashReceived := req.CashReceived
+		if cashReceived == 0 {
+			cashReceived = payment.ChargedAmount()
+		}
+		if cashReceived < payment.ChargedAmount() {
+			utils.RespondError(c, http.StatusBadRequest, services.ErrUnderTender)
+			return
+		}
+
+		now := time.Now()
 		payment.Status = "success"
+		payment.PaymentTime = &now
 		payment.ReferenceID = "CSH-" + paymentUUID
-		if req.CashReceived > 0 {
-			payment.Details = fmt.Sprintf("Cash received: %.2f, Change: %.2f", req.CashReceived, req.CashReceived-req.Amount)
-		}
-	} else if req.PaymentMethod == "qris" {
-		// Untuk QRIS (Midtrans)
-		midtransService := services.GetMidtransService()
+		payment.CashReceived = cashReceived
+		payment.Change = cashReceived - payment.ChargedAmount()
+	} else {
+		// Untuk QRIS dan bank transfer (virtual account) lewat payment provider aktif
+		provider := services.GetPaymentProvider()
 
 		// Buat ID unik untuk transaksi
 		transactionID := fmt.Sprintf("ORDER-%d-%s", order.ID, paymentUUID[:8])
 
-		// Create transaction di Midtrans
-		resp, err := midtransService.CreateTransaction(transactionID, float64(payment.Amount), order)
+		chargeReq := services.ChargeRequest{
+			OrderRef:      transactionID,
+			Amount:        payment.ChargedAmount(), // Tip ikut ditagihkan di transaksi yang sama
+			CustomerName:  order.GetCustomerName(),
+			CustomerEmail: order.GetCustomerEmail(),
+			Method:        req.PaymentMethod,
+			Bank:          req.Bank,
+			ExpiryMinutes: expiryMinutes,
+		}
+
+		// Create transaction di provider
+		charge, err := provider.CreateCharge(chargeReq)
 		if err != nil {
-			utils.ErrorLogger.Printf("Failed to create QRIS transaction: %v", err)
+			utils.ErrorLogger.Printf("Failed to create %s transaction via %s: %v", req.PaymentMethod, provider.Name(), err)
 			utils.RespondError(c, http.StatusInternalServerError, err)
 			return
 		}
 
 		// Log response untuk debugging
-		utils.InfoLogger.Printf("Midtrans response for order #%d: %+v", order.ID, resp)
-
-		// Update payment dengan data dari Midtrans
-		payment.ReferenceID = resp.TransactionID
-		payment.QRCode = resp.QRCodeURL // QRIS data string
-
-		// Prioritaskan URL dari actions jika tersedia
-		if len(resp.Actions) > 0 {
-			for _, action := range resp.Actions {
-				if action.Name == "generate-qr-code" || action.Name == "display-qr-code" {
-					payment.QRImageURL = action.URL
-					break
-				}
-			}
-		}
-
-		// Jika tidak ada URL dari actions, generate dari QR code data
-		if payment.QRImageURL == "" && payment.QRCode != "" {
-			payment.QRImageURL = midtransService.GenerateQRImageURL(payment.QRCode)
-		}
-
-		// Jika masih tidak ada QR image URL, coba gunakan transaction ID
-		if payment.QRImageURL == "" && resp.TransactionID != "" {
-			payment.QRImageURL = midtransService.GenerateQRImageURL(resp.TransactionID)
+		utils.InfoLogger.Printf("%s charge for order #%d: %+v", provider.Name(), order.ID, charge)
+
+		// Update payment dengan data dari provider
+		payment.PaymentType = provider.Name()
+		payment.ReferenceID = charge.TransactionID
+		payment.ProviderReference = &charge.OrderRef
+		payment.QRCode = charge.QRString // QRIS data string
+		payment.QRImageURL = charge.QRImageURL
+		payment.Bank = charge.Bank
+		payment.VANumber = charge.VANumber
+		payment.BillerCode = charge.BillerCode
+
+		// Jika provider memberikan waktu kadaluarsa, gunakan itu
+		if charge.ExpiresAt != nil {
+			payment.ExpiredAt = charge.ExpiresAt
+			utils.InfoLogger.Printf("Using provider expiry time: %s", charge.ExpiresAt.Format(time.RFC3339))
 		}
 
-		// Jika ada expiry_time dari Midtrans, gunakan itu
-		if resp.ExpiryTime != "" {
-			midtransExpiry, err := time.Parse("2006-01-02 15:04:05", resp.ExpiryTime)
-			if err == nil {
-				payment.ExpiredAt = &midtransExpiry
-				utils.InfoLogger.Printf("Using Midtrans expiry time: %s", midtransExpiry.Format(time.RFC3339))
-			} else {
-				utils.ErrorLogger.Printf("Error parsing Midtrans expiry time: %v, using default", err)
-			}
+		if payment.VANumber != "" {
+			utils.InfoLogger.Printf("Virtual account %s %s created", payment.Bank, payment.VANumber)
+		} else {
+			utils.InfoLogger.Printf("QR code image URL: %s", payment.QRImageURL)
 		}
-
-		utils.InfoLogger.Printf("QR code image URL: %s", payment.QRImageURL)
 	}
 
-	// Save payment ke database
-	if err := db.Create(&payment).Error; err != nil {
-		utils.RespondError(c, http.StatusInternalServerError, err)
+	// Save payment ke database. Jika dibuat oleh staff/admin, payment tertaut ke shift kasir
+	// yang terbuka (wajib untuk tunai); dari route publik (hanya non-tunai) disimpan tanpa shift.
+	var saveErr error
+	if isCashier {
+		saveErr = services.NewShiftService(db).RecordPayment(cashierID, &payment, req.PaymentMethod == "cash")
+	} else {
+		saveErr = db.Create(&payment).Error
+	}
+	if saveErr != nil {
+		if errors.Is(saveErr, services.ErrNoOpenShift) {
+			utils.RespondError(c, http.StatusConflict, errors.New("open a cashier shift before taking cash payments"))
+			return
+		}
+		utils.RespondError(c, http.StatusInternalServerError, saveErr)
 		return
 	}
 
+	// Payment pending di-expire tepat pada deadline-nya
+	services.SchedulePaymentExpiry(&payment)
+
 	// Load order untuk response
 	db.Preload("OrderItems.Menu").Preload("Customer").First(&order, req.OrderID)
 
-	// Jika QRIS, tambahkan ke retry queue
-	if req.PaymentMethod == "qris" && payment.Status == "pending" {
-		paymentMonitor := services.NewPaymentMonitor(db)
-		paymentMonitor.AddToRetryQueue(payment.ID)
-	}
-
-	// Kirim notifikasi via WebSocket untuk QRIS
-	if req.PaymentMethod == "qris" {
+	// Kirim notifikasi via WebSocket untuk QRIS dan bank transfer
+	if req.PaymentMethod != "cash" {
 		// Prepare data for websocket broadcast
 		go sendPaymentEvent(payment, order)
 	}
@@ -314,11 +373,20 @@ func CreatePayment(c *gin.Context) {
 	// Log payment creation
 	utils.InfoLogger.Printf("Payment %d created successfully with status: %s", payment.ID, payment.Status)
 
-	utils.RespondJSON(c, http.StatusOK, "Payment created successfully", gin.H{
+	response := gin.H{
 		"payment":      payment,
 		"order":        order,
 		"qr_image_url": payment.QRImageURL,
-	})
+	}
+	if req.PaymentMethod == "bank_transfer" {
+		response["bank"] = payment.Bank
+		response["va_number"] = payment.VANumber
+		response["biller_code"] = payment.BillerCode
+		response["expired_at"] = payment.ExpiredAt
+		response["instructions"] = services.BankTransferInstructions(payment.Bank, payment.VANumber, payment.BillerCode, payment.ChargedAmount())
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Payment created successfully", response)
 }
 
 // VerifyPayment memverifikasi pembayaran
@@ -357,9 +425,7 @@ func VerifyPayment(c *gin.Context) {
 	payment.Status = "success"
 
 	// Get user ID from JWT token
-	userID, exists := c.Get("userId")
-	if exists {
-		uid := userID.(uint)
+	if uid, ok := currentUserID(c); ok {
 		payment.VerifiedBy = &uid
 	}
 
@@ -617,130 +683,59 @@ func HandlePaymentCallback(c *gin.Context) {
 		return
 	}
 
-	// Parse request body
-	var request struct {
-		OrderID           string `json:"order_id"`
-		TransactionStatus string `json:"transaction_status"`
-		StatusCode        string `json:"status_code"`
-		GrossAmount       string `json:"gross_amount"`
-		SignatureKey      string `json:"signature_key"`
-	}
-
-	if err := json.Unmarshal(body, &request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request",
-			"message": "Failed to parse request body",
-		})
-		return
-	}
-
-	// Validate signature
-	midtrans := services.GetMidtransService()
-	if !midtrans.ValidateSignature(request.OrderID, request.StatusCode, request.GrossAmount, request.SignatureKey) {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":   "Invalid signature",
-			"message": "The signature is invalid",
-		})
-		return
-	}
-
-	// Extract payment ID from order ID
-	paymentID, err := strconv.ParseUint(strings.TrimPrefix(request.OrderID, "ORDER-"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid order ID",
-			"message": "Failed to parse order ID",
-		})
-		return
-	}
-
-	// Get payment with lock
-	var payment models.Payment
+	// Simpan notifikasi ke inbox, validasi signature, lalu proses secara idempotent
 	db := utils.GetDB()
-	tx := db.Begin()
-	if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&payment, paymentID).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Payment not found",
-			"message": "The specified payment does not exist",
-		})
-		return
-	}
-
-	// Validate amount
-	expectedAmount := fmt.Sprintf("%.2f", payment.Amount)
-	if request.GrossAmount != expectedAmount {
-		tx.Rollback()
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid amount",
-			"message": "The payment amount does not match",
-		})
-		return
-	}
-
-	// Map Midtrans status to our status
-	var status string
-	switch request.TransactionStatus {
-	case "settlement", "capture":
-		status = "success"
-	case "pending", "authorize":
-		status = "pending"
-	case "deny", "cancel", "expire", "failure":
-		status = "failed"
-	default:
-		status = "unknown"
-	}
-
-	// Update payment status
-	payment.Status = status
-	payment.UpdatedAt = time.Now()
-	if err := tx.Save(&payment).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Database error",
-			"message": "Failed to update payment status",
-		})
-		return
-	}
-
-	// Update order status if payment is successful
-	if status == "success" {
-		var order models.Order
-		if err := tx.First(&order, payment.OrderID).Error; err != nil {
-			tx.Rollback()
+	event, payment, err := services.NewWebhookInboxService(db).Receive(services.GetPaymentProvider(), body)
+	if err != nil {
+		switch {
+		case event == nil:
+			utils.ErrorLogger.Printf("Failed to store payment notification: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Database error",
+				"message": "Failed to store notification",
+			})
+		case errors.Is(err, services.ErrInvalidWebhookSignature):
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "Invalid signature",
+				"message": "The signature is invalid",
+			})
+		case errors.Is(err, services.ErrPaymentNotFound):
 			c.JSON(http.StatusNotFound, gin.H{
-				"error":   "Order not found",
-				"message": "The specified order does not exist",
+				"error":   "Payment not found",
+				"message": "No payment matches the provider reference",
 			})
-			return
-		}
-
-		order.Status = "paid"
-		order.UpdatedAt = time.Now()
-		if err := tx.Save(&order).Error; err != nil {
-			tx.Rollback()
+		case errors.Is(err, services.ErrPaymentAmountMismatch):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid amount",
+				"message": "The payment amount does not match",
+			})
+		case event.ProcessStatus == models.WebhookStatusFailed && !event.SignatureValid:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request",
+				"message": "Failed to parse request body",
+			})
+		default:
+			utils.ErrorLogger.Printf("Failed to process webhook event %d: %v", event.ID, err)
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error":   "Database error",
-				"message": "Failed to update order status",
+				"message": "Failed to update payment status",
 			})
-			return
 		}
+		return
 	}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Database error",
-			"message": "Failed to commit transaction",
+	// Duplikat atau notifikasi yang terlambat tetap dijawab 200 agar provider berhenti mengirim ulang
+	if event.ProcessStatus != models.WebhookStatusProcessed {
+		utils.InfoLogger.Printf("Webhook event %d %s: %s", event.ID, event.ProcessStatus, event.ProcessError)
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Notification already handled",
+			"event":   event.ProcessStatus,
 		})
 		return
 	}
 
-	// Add to retry queue if pending
-	if status == "pending" {
-		monitor := services.NewPaymentMonitor(utils.GetDB())
-		monitor.AddToRetryQueue(payment.ID)
-	}
+	status := payment.Status
 
 	// Create notification for staff
 	notification := models.Notification{
@@ -767,6 +762,7 @@ func GetMidtransConfig(c *gin.Context) {
 		"merchant_name":  os.Getenv("MIDTRANS_MERCHANT_NAME"),
 		"merchant_email": os.Getenv("MIDTRANS_MERCHANT_EMAIL"),
 		"merchant_phone": os.Getenv("MIDTRANS_MERCHANT_PHONE"),
+		"provider":       services.GetPaymentProvider().Name(),
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -793,51 +789,33 @@ func CheckPaymentStatus(c *gin.Context) {
 	utils.InfoLogger.Printf("Found payment: ID=%d, Method=%s, Status=%s, RefID=%s",
 		payment.ID, payment.PaymentMethod, payment.Status, payment.ReferenceID)
 
-	// Hanya periksa pembayaran QRIS dengan status pending
-	if payment.PaymentMethod != "qris" || payment.Status != "pending" {
+	// Hanya periksa pembayaran QRIS / bank transfer dengan status pending
+	if payment.PaymentMethod == "cash" || payment.Status != "pending" {
 		utils.InfoLogger.Printf("Payment cannot be checked: method=%s, status=%s",
 			payment.PaymentMethod, payment.Status)
-		utils.RespondError(c, http.StatusBadRequest, errors.New("can only check pending qris payments"))
+		utils.RespondError(c, http.StatusBadRequest, errors.New("can only check pending qris or bank transfer payments"))
 		return
 	}
 
-	// Dapatkan order ID dari referenceID payment
-	orderRefID := payment.ReferenceID
-	if orderRefID == "" {
-		utils.ErrorLogger.Printf("Payment reference ID is empty for payment ID: %s", id)
-		utils.RespondError(c, http.StatusBadRequest, errors.New("payment reference ID is empty"))
+	// Referensi transaksi di provider (fallback ke ReferenceID untuk payment lama)
+	providerRef := payment.ProviderRef()
+	if providerRef == "" {
+		utils.ErrorLogger.Printf("Payment provider reference is empty for payment ID: %d", payment.ID)
+		utils.RespondError(c, http.StatusBadRequest, errors.New("payment provider reference is empty"))
 		return
 	}
 
-	// Jika referenceID mengandung prefix ORDER-, kita perlu mengekstrak ID sebenarnya
-	var transactionID string
-	if strings.HasPrefix(orderRefID, "ORDER-") {
-		// Format: ORDER-<order_id>-<uuid>
-		parts := strings.Split(orderRefID, "-")
-		if len(parts) >= 3 {
-			// Kita ambil uuid di bagian terakhir sebagai transaction ID
-			transactionID = parts[len(parts)-1]
-			utils.InfoLogger.Printf("Extracted transaction ID %s from reference ID %s",
-				transactionID, orderRefID)
-		} else {
-			transactionID = orderRefID
-		}
-	} else {
-		transactionID = orderRefID
-	}
-
-	utils.InfoLogger.Printf("Checking transaction status in Midtrans for transaction ID: %s", transactionID)
+	utils.InfoLogger.Printf("Checking transaction status at payment provider for reference: %s", providerRef)
 
-	// Cek status di Midtrans
-	midtransService := services.GetMidtransService()
-	status, err := midtransService.CheckTransactionStatus(transactionID)
+	// Cek status di payment provider
+	status, err := services.GetPaymentProvider().CheckStatus(providerRef)
 	if err != nil {
-		utils.ErrorLogger.Printf("Error checking Midtrans status: %v", err)
-		utils.RespondError(c, http.StatusInternalServerError, fmt.Errorf("error checking midtrans status: %v", err))
+		utils.ErrorLogger.Printf("Error checking payment provider status: %v", err)
+		utils.RespondError(c, http.StatusInternalServerError, fmt.Errorf("error checking payment provider status: %v", err))
 		return
 	}
 
-	utils.InfoLogger.Printf("Midtrans transaction %s status: %s", transactionID, status)
+	utils.InfoLogger.Printf("Provider transaction %s status: %s", providerRef, status)
 
 	// Update payment status jika status berbeda
 	if status != payment.Status {
@@ -902,11 +880,11 @@ func CheckPaymentStatus(c *gin.Context) {
 	}
 
 	utils.RespondJSON(c, http.StatusOK, "Payment status checked", gin.H{
-		"payment_id":     payment.ID,
-		"status":         status,
-		"reference_id":   orderRefID,
-		"transaction_id": transactionID,
-		"was_updated":    status != payment.Status,
+		"payment_id":         payment.ID,
+		"status":             status,
+		"reference_id":       payment.ReferenceID,
+		"provider_reference": providerRef,
+		"was_updated":        status != payment.Status,
 	})
 }
 
@@ -936,8 +914,8 @@ func CheckOrderPaymentStatus(c *gin.Context) {
 	utils.InfoLogger.Printf("Found latest payment: ID=%d, Method=%s, Status=%s, RefID=%s",
 		payment.ID, payment.PaymentMethod, payment.Status, payment.ReferenceID)
 
-	// Jika pembayaran bukan QRIS, kembalikan status saat ini
-	if payment.PaymentMethod != "qris" {
+	// Jika pembayaran tunai, kembalikan status saat ini
+	if payment.PaymentMethod == "cash" {
 		utils.RespondJSON(c, http.StatusOK, "Payment status retrieved", gin.H{
 			"payment_id":   payment.ID,
 			"order_id":     payment.OrderID,
@@ -962,43 +940,25 @@ func CheckOrderPaymentStatus(c *gin.Context) {
 		return
 	}
 
-	// Dapatkan order ID dari referenceID payment
-	orderRefID := payment.ReferenceID
-	if orderRefID == "" {
-		utils.ErrorLogger.Printf("Payment reference ID is empty for payment ID: %d", payment.ID)
-		utils.RespondError(c, http.StatusBadRequest, errors.New("payment reference ID is empty"))
+	// Referensi transaksi di provider (fallback ke ReferenceID untuk payment lama)
+	providerRef := payment.ProviderRef()
+	if providerRef == "" {
+		utils.ErrorLogger.Printf("Payment provider reference is empty for payment ID: %d", payment.ID)
+		utils.RespondError(c, http.StatusBadRequest, errors.New("payment provider reference is empty"))
 		return
 	}
 
-	// Jika referenceID mengandung prefix ORDER-, kita perlu mengekstrak ID sebenarnya
-	var transactionID string
-	if strings.HasPrefix(orderRefID, "ORDER-") {
-		// Format: ORDER-<order_id>-<uuid>
-		parts := strings.Split(orderRefID, "-")
-		if len(parts) >= 3 {
-			// Kita ambil uuid di bagian terakhir sebagai transaction ID
-			transactionID = parts[len(parts)-1]
-			utils.InfoLogger.Printf("Extracted transaction ID %s from reference ID %s",
-				transactionID, orderRefID)
-		} else {
-			transactionID = orderRefID
-		}
-	} else {
-		transactionID = orderRefID
-	}
-
-	utils.InfoLogger.Printf("Checking transaction status in Midtrans for transaction ID: %s", transactionID)
+	utils.InfoLogger.Printf("Checking transaction status at payment provider for reference: %s", providerRef)
 
-	// Cek status di Midtrans
-	midtransService := services.GetMidtransService()
-	status, err := midtransService.CheckTransactionStatus(transactionID)
+	// Cek status di payment provider
+	status, err := services.GetPaymentProvider().CheckStatus(providerRef)
 	if err != nil {
-		utils.ErrorLogger.Printf("Error checking Midtrans status: %v", err)
-		utils.RespondError(c, http.StatusInternalServerError, fmt.Errorf("error checking midtrans status: %v", err))
+		utils.ErrorLogger.Printf("Error checking payment provider status: %v", err)
+		utils.RespondError(c, http.StatusInternalServerError, fmt.Errorf("error checking payment provider status: %v", err))
 		return
 	}
 
-	utils.InfoLogger.Printf("Midtrans transaction %s status: %s", transactionID, status)
+	utils.InfoLogger.Printf("Provider transaction %s status: %s", providerRef, status)
 
 	// Update payment status jika status berbeda
 	wasUpdated := false
@@ -1065,11 +1025,159 @@ func CheckOrderPaymentStatus(c *gin.Context) {
 	}
 
 	utils.RespondJSON(c, http.StatusOK, "Payment status checked", gin.H{
-		"payment_id":     payment.ID,
-		"order_id":       payment.OrderID,
-		"status":         status,
-		"reference_id":   orderRefID,
-		"transaction_id": transactionID,
-		"was_updated":    wasUpdated,
+		"payment_id":         payment.ID,
+		"order_id":           payment.OrderID,
+		"status":             status,
+		"reference_id":       payment.ReferenceID,
+		"provider_reference": providerRef,
+		"was_updated":        wasUpdated,
+	})
+}
+
+// CancelPayment membatalkan pembayaran QRIS / bank transfer yang masih pending di payment provider
+func CancelPayment(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" && roleInterface != "staff" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	db := utils.GetDB()
+	var payment models.Payment
+	if err := db.First(&payment, c.Param("payment_id")).Error; err != nil {
+		utils.RespondError(c, http.StatusNotFound, errors.New("payment not found"))
+		return
+	}
+
+	if payment.PaymentMethod == "cash" || payment.Status != services.PaymentStatusPending {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("can only cancel pending qris or bank transfer payments"))
+		return
+	}
+
+	provider := services.GetPaymentProvider()
+	if err := provider.Cancel(payment.ProviderRef()); err != nil {
+		utils.ErrorLogger.Printf("Failed to cancel payment %d via %s: %v", payment.ID, provider.Name(), err)
+		utils.RespondError(c, http.StatusBadGateway, fmt.Errorf("failed to cancel payment: %v", err))
+		return
+	}
+
+	handlePaymentCallback(payment.ID, services.PaymentStatusCancelled)
+	db.Preload("Order").First(&payment, payment.ID)
+
+	utils.RespondJSON(c, http.StatusOK, "Payment cancelled", payment)
+}
+
+// RefundPayment mengembalikan dana pembayaran QRIS yang sudah sukses (Admin only)
+func RefundPayment(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	var req struct {
+		Amount utils.Money `json:"amount"`
+		Reason string      `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	db := utils.GetDB()
+	var payment models.Payment
+	if err := db.First(&payment, c.Param("payment_id")).Error; err != nil {
+		utils.RespondError(c, http.StatusNotFound, errors.New("payment not found"))
+		return
+	}
+
+	if payment.PaymentMethod != "qris" || payment.Status != services.PaymentStatusSuccess {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("can only refund successful qris payments"))
+		return
+	}
+
+	// Default refund seluruh sisa tagihan; refund berikutnya hanya sampai sisa tersebut
+	remaining := payment.RefundableAmount()
+	if req.Amount <= 0 {
+		req.Amount = remaining
+	}
+	if req.Amount > remaining {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("refund amount exceeds refundable amount %s", remaining))
+		return
+	}
+
+	provider := services.GetPaymentProvider()
+	if err := provider.Refund(payment.ProviderRef(), req.Amount, req.Reason); err != nil {
+		utils.ErrorLogger.Printf("Failed to refund payment %d via %s: %v", payment.ID, provider.Name(), err)
+		utils.RespondError(c, http.StatusBadGateway, fmt.Errorf("failed to refund payment: %v", err))
+		return
+	}
+
+	// Payment baru berstatus refunded jika seluruh tagihan sudah dikembalikan
+	payment.RefundedAmount += req.Amount
+	if payment.RefundedAmount >= payment.ChargedAmount() {
+		payment.Status = services.PaymentStatusRefunded
+	}
+	payment.Details = fmt.Sprintf("Refunded %s: %s", req.Amount, req.Reason)
+	if err := db.Save(&payment).Error; err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.InfoLogger.Printf("Payment %d refunded %s via %s", payment.ID, req.Amount, provider.Name())
+	go sendPaymentEvent(payment, models.Order{})
+
+	utils.RespondJSON(c, http.StatusOK, "Payment refunded", payment)
+}
+
+// SimulatePayment mensimulasikan settle/expire/fail pada fake payment provider.
+// Hanya tersedia jika PAYMENT_PROVIDER=fake.
+func SimulatePayment(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" && roleInterface != "staff" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	fake, ok := services.GetPaymentProvider().(*services.FakePaymentProvider)
+	if !ok {
+		utils.RespondError(c, http.StatusNotFound, errors.New("payment simulation is only available with the fake payment provider"))
+		return
+	}
+
+	var req struct {
+		Action string `json:"action" binding:"required,oneof=settle expire fail"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	db := utils.GetDB()
+	var payment models.Payment
+	if err := db.First(&payment, c.Param("payment_id")).Error; err != nil {
+		utils.RespondError(c, http.StatusNotFound, errors.New("payment not found"))
+		return
+	}
+
+	payload, err := fake.Simulate(payment.ProviderRef(), req.Action)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Proses payload lewat inbox seperti webhook asli agar alur verifikasi ikut teruji
+	event, _, err := services.NewWebhookInboxService(db).Receive(fake, payload)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	db.Preload("Order").First(&payment, payment.ID)
+	go sendPaymentEvent(payment, payment.Order)
+
+	utils.RespondJSON(c, http.StatusOK, "Payment simulated", gin.H{
+		"payment": payment,
+		"event":   event,
 	})
 }
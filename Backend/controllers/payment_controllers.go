@@ -35,9 +35,9 @@ func (pc *PaymentController) GetAllPayments(c *gin.Context) {
 // CreatePayment -> Memproses pembayaran
 func (pc *PaymentController) CreatePayment(c *gin.Context) {
 	type reqBody struct {
-		OrderID       uint    `json:"order_id" binding:"required"`
-		PaymentMethod string  `json:"payment_method" binding:"required"` // cash, qris, dll
-		Amount        float64 `json:"amount" binding:"required"`
+		OrderID       uint        `json:"order_id" binding:"required"`
+		PaymentMethod string      `json:"payment_method" binding:"required"` // cash, qris, dll
+		Amount        utils.Money `json:"amount" binding:"required"`
 	}
 
 	var body reqBody
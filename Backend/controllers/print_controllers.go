@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yeremiapane/restaurant-app/services"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+type PrintController struct {
+	DB *gorm.DB
+}
+
+func NewPrintController(db *gorm.DB) *PrintController {
+	return &PrintController{DB: db}
+}
+
+type printRequest struct {
+	Printer    string `json:"printer"`     // host:port dari daftar printer di env, kosong = printer default
+	OpenDrawer bool   `json:"open_drawer"` // buka laci uang (struk saja)
+}
+
+// PrintReceipt -> Kasir mencetak (ulang) struk ke printer thermal
+func (pc *PrintController) PrintReceipt(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" && roleInterface != "staff" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	receiptID, err := strconv.ParseUint(c.Param("receipt_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("invalid receipt id"))
+		return
+	}
+	var req printRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	job, err := services.NewPrintService(pc.DB).PrintReceipt(uint(receiptID), req.Printer, req.OpenDrawer, requesterID(c))
+	if err != nil {
+		respondPrintError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusAccepted, "Print job queued", job)
+}
+
+// PrintKitchenTicket -> Mencetak tiket dapur untuk order ke printer dapur
+func (pc *PrintController) PrintKitchenTicket(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" && roleInterface != "staff" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	orderID, err := strconv.ParseUint(c.Param("order_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("invalid order id"))
+		return
+	}
+	var req printRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	job, err := services.NewPrintService(pc.DB).PrintKitchenTicket(uint(orderID), req.Printer, requesterID(c))
+	if err != nil {
+		respondPrintError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusAccepted, "Print job queued", job)
+}
+
+// GetPrintJobs -> Melihat antrean cetak. Query opsional: status, limit
+func (pc *PrintController) GetPrintJobs(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" && roleInterface != "staff" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	jobs, err := services.NewPrintService(pc.DB).ListJobs(c.Query("status"), limit)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "Print jobs", jobs)
+}
+
+// RetryPrintJob -> Mengantrekan ulang print job yang gagal (mis. printer sempat mati)
+func (pc *PrintController) RetryPrintJob(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" && roleInterface != "staff" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	jobID, err := strconv.ParseUint(c.Param("job_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("invalid print job id"))
+		return
+	}
+
+	job, err := services.NewPrintService(pc.DB).RetryJob(uint(jobID))
+	if err != nil {
+		respondPrintError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusAccepted, "Print job requeued", job)
+}
+
+// requesterID mengembalikan ID user yang login, nil jika tidak ada
+func requesterID(c *gin.Context) *uint {
+	if id, ok := currentUserID(c); ok {
+		return &id
+	}
+	return nil
+}
+
+// respondPrintError memetakan error PrintService ke status HTTP
+func respondPrintError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.RespondError(c, http.StatusNotFound, err)
+	case errors.Is(err, services.ErrNoPrinterConfigured), errors.Is(err, services.ErrPrinterNotAllowed):
+		utils.RespondError(c, http.StatusBadRequest, err)
+	case errors.Is(err, services.ErrPrintJobNotFailed):
+		utils.RespondError(c, http.StatusConflict, err)
+	default:
+		utils.ErrorLogger.Printf("Print operation failed: %v", err)
+		utils.RespondError(c, http.StatusInternalServerError, err)
+	}
+}
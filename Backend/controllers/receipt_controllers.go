@@ -1,13 +1,18 @@
 package controllers
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
-	"math"
+	"html"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/services"
 	"github.com/yeremiapane/restaurant-app/utils"
 	"gorm.io/gorm"
 )
@@ -20,241 +25,316 @@ func NewReceiptController(db *gorm.DB) *ReceiptController {
 	return &ReceiptController{DB: db}
 }
 
-// GenerateReceipt membuat struk pembayaran
+// GenerateReceipt membuat struk pembayaran. Struk hanya dibuat sekali per bill;
+// pemanggilan ulang (cetak ulang) mengembalikan snapshot yang sama.
 func (rc *ReceiptController) GenerateReceipt(c *gin.Context) {
-	paymentID := c.Param("payment_id")
-
-	// Ambil data payment dengan relasi
-	var payment models.Payment
-	if err := rc.DB.Preload("Order").
-		Preload("Order.OrderItems").
-		Preload("Order.OrderItems.Menu").
-		Preload("Order.Customer").
-		Preload("Order.Customer.Table").
-		First(&payment, paymentID).Error; err != nil {
-		utils.RespondError(c, http.StatusNotFound, err)
+	paymentID, err := strconv.ParseUint(c.Param("payment_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("invalid payment id"))
 		return
 	}
+	cashierID, _ := currentUserID(c)
 
-	// Pastikan payment sudah success
-	if payment.Status != "success" {
-		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("payment belum selesai"))
+	receipt, created, err := services.NewReceiptService(rc.DB).GenerateReceipt(uint(paymentID), cashierID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.RespondError(c, http.StatusNotFound, err)
+		return
+	case errors.Is(err, services.ErrPaymentNotSettled):
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	case err != nil:
+		utils.ErrorLogger.Printf("Failed to generate receipt for payment %d: %v", paymentID, err)
+		utils.RespondError(c, http.StatusInternalServerError, err)
 		return
 	}
 
-	// Generate receipt number
-	receiptNumber := fmt.Sprintf("RCP/%s/%06d",
-		time.Now().Format("20060102"),
-		payment.ID)
-
-	// Buat receipt
-	receipt := models.Receipt{
-		OrderID:       payment.OrderID,
-		PaymentID:     payment.ID,
-		ReceiptNumber: receiptNumber,
-		CreatedAt:     time.Now(),
+	message := "Receipt generated"
+	if !created {
+		message = "Receipt already generated"
+	} else {
+		// Struk digital dikirim otomatis jika customer mengisi email
+		go func(receiptID uint) {
+			if _, err := services.NewReceiptService(rc.DB).EmailReceipt(receiptID, ""); err != nil && !errors.Is(err, services.ErrNoRecipient) {
+				utils.ErrorLogger.Printf("Failed to email receipt %d: %v", receiptID, err)
+			}
+		}(receipt.ID)
 	}
+	utils.RespondJSON(c, http.StatusOK, message, newReceiptView(receipt))
+}
 
-	if err := rc.DB.Create(&receipt).Error; err != nil {
-		utils.RespondError(c, http.StatusInternalServerError, err)
+// GetReceiptByID mengambil detail struk berdasarkan ID, dirender dari snapshot struk.
+// Akhiran .pdf (/receipts/:id.pdf) mengembalikan struk sebagai PDF.
+func (rc *ReceiptController) GetReceiptByID(c *gin.Context) {
+	param, asPDF := strings.CutSuffix(c.Param("receipt_id"), ".pdf")
+	receiptID, err := strconv.ParseUint(param, 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("invalid receipt id"))
 		return
 	}
 
-	// Format data untuk struk dengan detail harga lengkap
-	receiptData := struct {
-		RestaurantInfo struct {
-			Name    string `json:"name"`
-			Address string `json:"address"`
-			Phone   string `json:"phone"`
-		} `json:"restaurant_info"`
-		ReceiptInfo struct {
-			Number      string    `json:"number"`
-			DateTime    time.Time `json:"date_time"`
-			TableNumber string    `json:"table_number"`
-			Cashier     string    `json:"cashier"`
-		} `json:"receipt_info"`
-		OrderDetails struct {
-			Items []struct {
-				Name      string  `json:"name"`
-				Quantity  int     `json:"quantity"`
-				UnitPrice float64 `json:"unit_price"`
-				Subtotal  float64 `json:"subtotal"`
-				Notes     string  `json:"notes,omitempty"`
-				Addons    []struct {
-					Name     string  `json:"name"`
-					Price    float64 `json:"price"`
-					Quantity int     `json:"quantity"`
-				} `json:"addons,omitempty"`
-			} `json:"items"`
-			PriceDetails struct {
-				Subtotal      float64 `json:"subtotal"`
-				ServiceCharge float64 `json:"service_charge"`
-				Tax           float64 `json:"tax"`
-				Total         float64 `json:"total"`
-				RoundedTotal  float64 `json:"rounded_total"`
-			} `json:"price_details"`
-		} `json:"order_details"`
-		PaymentDetails struct {
-			Method     string  `json:"method"`
-			Amount     float64 `json:"amount_paid"`
-			Change     float64 `json:"change"`
-			Time       string  `json:"time"`
-			Status     string  `json:"status"`
-			References string  `json:"references,omitempty"` // untuk QRIS/kartu
-		} `json:"payment_details"`
-		Footer struct {
-			ThankYouNote string `json:"thank_you_note"`
-			Terms        string `json:"terms"`
-		} `json:"footer"`
-	}{}
-
-	// Isi informasi restoran
-	receiptData.RestaurantInfo = struct {
-		Name    string `json:"name"`
-		Address string `json:"address"`
-		Phone   string `json:"phone"`
-	}{
-		Name:    "Restaurant Name",
-		Address: "Restaurant Address",
-		Phone:   "Restaurant Phone",
+	receipt, err := services.NewReceiptService(rc.DB).GetReceipt(uint(receiptID))
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, err)
+		return
 	}
 
-	// Isi informasi struk
-	receiptData.ReceiptInfo = struct {
-		Number      string    `json:"number"`
-		DateTime    time.Time `json:"date_time"`
-		TableNumber string    `json:"table_number"`
-		Cashier     string    `json:"cashier"`
-	}{
-		Number:   receipt.ReceiptNumber,
-		DateTime: receipt.CreatedAt,
-		TableNumber: func() string {
-			if payment.Order.TableID > 0 {
-				var table models.Table
-				err := rc.DB.First(&table, payment.Order.TableID).Error
-				if err == nil {
-					return table.TableNumber
-				}
-			}
-			return "N/A"
-		}(),
-		Cashier: "Cashier Name", // Bisa diambil dari context user yang login
+	if asPDF {
+		rc.renderReceiptPDF(c, receipt)
+		return
 	}
+	utils.RespondJSON(c, http.StatusOK, "Receipt detail", newReceiptView(receipt))
+}
 
-	// Hitung detail harga
-	var subtotal float64
-	receiptData.OrderDetails.Items = make([]struct {
-		Name      string  `json:"name"`
-		Quantity  int     `json:"quantity"`
-		UnitPrice float64 `json:"unit_price"`
-		Subtotal  float64 `json:"subtotal"`
-		Notes     string  `json:"notes,omitempty"`
-		Addons    []struct {
-			Name     string  `json:"name"`
-			Price    float64 `json:"price"`
-			Quantity int     `json:"quantity"`
-		} `json:"addons,omitempty"`
-	}, len(payment.Order.OrderItems))
-
-	// Isi detail item dan harga
-	for i, item := range payment.Order.OrderItems {
-		itemSubtotal := float64(item.Quantity) * item.Price
-		subtotal += itemSubtotal
-
-		// Struktur item dengan harga
-		receiptData.OrderDetails.Items[i] = struct {
-			Name      string  `json:"name"`
-			Quantity  int     `json:"quantity"`
-			UnitPrice float64 `json:"unit_price"`
-			Subtotal  float64 `json:"subtotal"`
-			Notes     string  `json:"notes,omitempty"`
-			Addons    []struct {
-				Name     string  `json:"name"`
-				Price    float64 `json:"price"`
-				Quantity int     `json:"quantity"`
-			} `json:"addons,omitempty"`
-		}{
-			Name:      item.Menu.Name,
-			Quantity:  item.Quantity,
-			UnitPrice: item.Price,
-			Subtotal:  itemSubtotal,
-			Notes:     item.Notes,
+// EmailReceipt mengirim ulang struk PDF lewat email. Body {"email": "..."} opsional;
+// jika kosong dipakai email yang diisi customer.
+func (rc *ReceiptController) EmailReceipt(c *gin.Context) {
+	receiptID, err := strconv.ParseUint(c.Param("receipt_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("invalid receipt id"))
+		return
+	}
+	var req struct {
+		Email string `json:"email"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, err)
+			return
 		}
+	}
 
-		// Jika ada addon, tambahkan ke item
-		if item.ParentItemID != nil {
-			// Logic untuk addon
-		}
+	to, err := services.NewReceiptService(rc.DB).EmailReceipt(uint(receiptID), req.Email)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.RespondError(c, http.StatusNotFound, err)
+		return
+	case errors.Is(err, services.ErrNoRecipient), errors.Is(err, services.ErrInvalidMail):
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	case err != nil:
+		utils.ErrorLogger.Printf("Failed to email receipt %d: %v", receiptID, err)
+		utils.RespondError(c, http.StatusBadGateway, err)
+		return
 	}
+	utils.RespondJSON(c, http.StatusOK, "Receipt sent", gin.H{"receipt_id": receiptID, "email": to})
+}
 
-	// Hitung detail harga final
-	serviceCharge := subtotal * 0.05 // 5% service charge
-	tax := subtotal * 0.10           // 10% tax
-	total := subtotal + serviceCharge + tax
-	roundedTotal := math.Ceil(total/1000) * 1000 // Pembulatan ke atas (1000)
-
-	receiptData.OrderDetails.PriceDetails = struct {
-		Subtotal      float64 `json:"subtotal"`
-		ServiceCharge float64 `json:"service_charge"`
-		Tax           float64 `json:"tax"`
-		Total         float64 `json:"total"`
-		RoundedTotal  float64 `json:"rounded_total"`
-	}{
-		Subtotal:      subtotal,
-		ServiceCharge: serviceCharge,
-		Tax:           tax,
-		Total:         total,
-		RoundedTotal:  roundedTotal,
+// VerifyReceipt -> Publik: memeriksa keaslian struk dari QR
+// (/receipts/verify?number=&total=&ts=&sig=). Browser mendapat halaman HTML, klien lain JSON.
+func (rc *ReceiptController) VerifyReceipt(c *gin.Context) {
+	total, totalErr := strconv.ParseInt(c.Query("total"), 10, 64)
+	issuedAt, tsErr := strconv.ParseInt(c.Query("ts"), 10, 64)
+	if c.Query("number") == "" || c.Query("sig") == "" || totalErr != nil || tsErr != nil {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("number, total, ts and sig are required"))
+		return
 	}
 
-	// Isi detail pembayaran
-	change := payment.Amount - roundedTotal
-	if change < 0 {
-		change = 0
+	result, err := services.NewReceiptService(rc.DB).VerifyReceipt(services.ReceiptVerificationQuery{
+		Number:    c.Query("number"),
+		Total:     utils.Money(total),
+		IssuedAt:  issuedAt,
+		Signature: c.Query("sig"),
+	})
+	if err != nil {
+		utils.ErrorLogger.Printf("Failed to verify receipt %s: %v", c.Query("number"), err)
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
 	}
 
-	receiptData.PaymentDetails = struct {
-		Method     string  `json:"method"`
-		Amount     float64 `json:"amount_paid"`
-		Change     float64 `json:"change"`
-		Time       string  `json:"time"`
-		Status     string  `json:"status"`
-		References string  `json:"references,omitempty"`
-	}{
-		Method: payment.PaymentType,
-		Amount: payment.Amount,
-		Change: change,
-		Time:   payment.PaymentTime.Format("15:04:05"),
-		Status: payment.Status,
-		References: func() string {
-			if payment.PaymentType == "qris" {
-				return "QRIS REF: xxx"
-			}
-			return ""
-		}(),
+	if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(receiptVerificationHTML(result)))
+		return
 	}
+	utils.RespondJSON(c, http.StatusOK, "Receipt verification", result)
+}
 
-	// Isi footer
-	receiptData.Footer = struct {
-		ThankYouNote string `json:"thank_you_note"`
-		Terms        string `json:"terms"`
-	}{
-		ThankYouNote: "Terima kasih atas kunjungan Anda!",
-		Terms:        "Struk ini adalah bukti pembayaran yang sah",
+// receiptVerificationHTML merender hasil verifikasi sebagai halaman sederhana untuk pemindai QR
+func receiptVerificationHTML(result *services.ReceiptVerification) string {
+	headline, color := "Struk tidak valid", "#c62828"
+	switch result.Status {
+	case services.ReceiptVerificationValid:
+		headline, color = "Struk asli", "#2e7d32"
+	case services.ReceiptVerificationRefunded:
+		headline, color = "Struk asli, pembayaran sudah direfund", "#ef6c00"
+	case services.ReceiptVerificationNotFound:
+		headline = "Struk tidak ditemukan"
+	case services.ReceiptVerificationModified:
+		headline = "Data struk tidak cocok"
 	}
 
-	utils.RespondJSON(c, http.StatusOK, "Receipt generated", receiptData)
+	var details strings.Builder
+	if result.Genuine {
+		profile := services.CurrentRestaurantProfile()
+		fmt.Fprintf(&details, "<p>No. %s<br>%s<br>Total %s</p>",
+			html.EscapeString(result.ReceiptNumber),
+			result.IssuedAt.In(profile.Location()).Format("02/01/2006 15:04"),
+			utils.FormatCurrencyIDR(result.Total))
+		if result.Refunded {
+			fmt.Fprintf(&details, "<p>Direfund %s</p>", utils.FormatCurrencyIDR(result.RefundedAmount))
+		}
+	}
+	return fmt.Sprintf(`<!DOCTYPE html><html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1">`+
+		`<title>Verifikasi Struk</title></head><body style="font-family:sans-serif;text-align:center;padding:2em">`+
+		`<h3>%s</h3><h2 style="color:%s">%s</h2>%s</body></html>`,
+		html.EscapeString(result.RestaurantName), color, headline, details.String())
 }
 
-// GetReceiptByID mengambil detail struk berdasarkan ID
-func (rc *ReceiptController) GetReceiptByID(c *gin.Context) {
-	receiptID := c.Param("receipt_id")
+// renderReceiptPDF mengirim struk sebagai PDF. Query layout: 80mm (default), 58mm atau a5.
+func (rc *ReceiptController) renderReceiptPDF(c *gin.Context, receipt *models.Receipt) {
+	layout := strings.ToLower(c.DefaultQuery("layout", services.ReceiptLayout80mm))
 
-	var receipt models.Receipt
-	if err := rc.DB.Preload("Order").Preload("ReceiptItems").First(&receipt, receiptID).Error; err != nil {
-		utils.RespondError(c, http.StatusNotFound, err)
+	var buf bytes.Buffer
+	err := services.RenderReceiptPDF(&buf, receipt, layout, services.CurrentReceiptBranding())
+	if errors.Is(err, services.ErrInvalidReceiptLayout) {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		utils.ErrorLogger.Printf("Failed to render receipt %d PDF: %v", receipt.ID, err)
+		utils.RespondError(c, http.StatusInternalServerError, err)
 		return
 	}
 
-	utils.RespondJSON(c, http.StatusOK, "Receipt detail", receipt)
+	filename := strings.ReplaceAll(receipt.ReceiptNumber, "/", "-") + ".pdf"
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%s", filename))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// receiptView adalah format struk yang dikirim ke frontend / printer
+type receiptView struct {
+	ID             uint `json:"id"`
+	RestaurantInfo struct {
+		Name    string `json:"name"`
+		Address string `json:"address"`
+		Phone   string `json:"phone"`
+		TaxID   string `json:"tax_id,omitempty"`
+		Footer  string `json:"footer,omitempty"`
+	} `json:"restaurant_info"`
+	ReceiptInfo struct {
+		Number      string    `json:"number"`
+		DateTime    time.Time `json:"date_time"`
+		TableNumber string    `json:"table_number"`
+		Cashier     string    `json:"cashier"`
+		VerifyURL   string    `json:"verify_url"` // Link yang dicetak sebagai QR
+	} `json:"receipt_info"`
+	OrderDetails struct {
+		Items        []receiptItemLine `json:"items"`
+		PriceDetails struct {
+			Subtotal      utils.Money `json:"subtotal"`
+			ServiceCharge utils.Money `json:"service_charge"`
+			Tax           utils.Money `json:"tax"`
+			Total         utils.Money `json:"total"`
+			RoundedTotal  utils.Money `json:"rounded_total"`
+			Tip           utils.Money `json:"tip,omitempty"` // Baris tip terpisah, tidak termasuk total order
+		} `json:"price_details"`
+	} `json:"order_details"`
+	PaymentDetails struct {
+		Method     string      `json:"method"`
+		Amount     utils.Money `json:"amount_paid"`
+		Change     utils.Money `json:"change"`
+		Time       string      `json:"time"`
+		Status     string      `json:"status"`
+		References string      `json:"references,omitempty"` // untuk QRIS/kartu
+	} `json:"payment_details"`
+	Tenders []receiptTenderLine `json:"tenders"`
+	Footer  struct {
+		ThankYouNote string `json:"thank_you_note"`
+		Terms        string `json:"terms"`
+	} `json:"footer"`
+}
+
+// receiptItemLine adalah satu item di struk beserta add-on-nya
+type receiptItemLine struct {
+	Name      string             `json:"name"`
+	Quantity  int                `json:"quantity"`
+	UnitPrice utils.Money        `json:"unit_price"`
+	Subtotal  utils.Money        `json:"subtotal"` // Termasuk add-on
+	Notes     string             `json:"notes,omitempty"`
+	Addons    []receiptAddonLine `json:"addons,omitempty"`
+}
+
+type receiptAddonLine struct {
+	Name     string      `json:"name"`
+	Price    utils.Money `json:"price"`
+	Quantity int         `json:"quantity"`
+}
+
+// receiptTenderLine adalah satu baris pembayaran di struk
+type receiptTenderLine struct {
+	Method        string                    `json:"method"`
+	Amount        utils.Money               `json:"amount"`
+	Tip           utils.Money               `json:"tip,omitempty"`
+	Tendered      utils.Money               `json:"tendered,omitempty"`
+	Change        utils.Money               `json:"change,omitempty"`
+	Reference     string                    `json:"reference,omitempty"`
+	Denominations []models.CashDenomination `json:"denominations,omitempty"`
+}
+
+// newReceiptView merender snapshot struk, tanpa membaca data menu/order terkini
+func newReceiptView(receipt *models.Receipt) receiptView {
+	var view receiptView
+	view.ID = receipt.ID
+
+	branding := services.CurrentReceiptBranding()
+	view.RestaurantInfo.Name = branding.Name
+	view.RestaurantInfo.Address = branding.Address
+	view.RestaurantInfo.Phone = branding.Phone
+	view.RestaurantInfo.TaxID = branding.TaxID
+	view.RestaurantInfo.Footer = branding.Footer
+
+	view.ReceiptInfo.Number = receipt.ReceiptNumber
+	view.ReceiptInfo.DateTime = receipt.CreatedAt
+	view.ReceiptInfo.TableNumber = receipt.TableNumber
+	view.ReceiptInfo.Cashier = receipt.CashierName
+	view.ReceiptInfo.VerifyURL = branding.VerificationURL(receipt)
+
+	view.OrderDetails.Items = make([]receiptItemLine, 0, len(receipt.ReceiptItems))
+	for _, item := range receipt.ReceiptItems {
+		line := receiptItemLine{
+			Name:      item.DisplayName(),
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Subtotal:  item.Subtotal,
+			Notes:     item.Notes,
+		}
+		for _, addon := range item.AddOnItems {
+			line.Addons = append(line.Addons, receiptAddonLine{Name: addon.Name, Price: addon.Price, Quantity: addon.Quantity})
+		}
+		view.OrderDetails.Items = append(view.OrderDetails.Items, line)
+	}
+
+	prices := &view.OrderDetails.PriceDetails
+	prices.Subtotal = receipt.Subtotal
+	prices.ServiceCharge = receipt.ServiceCharge
+	prices.Tax = receipt.Tax
+	prices.Total = receipt.Total
+	prices.RoundedTotal = receipt.RoundedTotal
+	prices.Tip = receipt.Tip
+
+	view.PaymentDetails.Method = receipt.PaymentMethod
+	view.PaymentDetails.Amount = receipt.AmountPaid
+	view.PaymentDetails.Change = receipt.Change
+	if receipt.PaymentTime != nil {
+		view.PaymentDetails.Time = receipt.PaymentTime.Format("15:04:05")
+	}
+	view.PaymentDetails.Status = receipt.PaymentStatus
+	view.PaymentDetails.References = receipt.PaymentReference
+
+	// Baris tender: satu baris per metode, tunai menampilkan uang diterima dan kembalian
+	for _, tender := range receipt.Tenders {
+		view.Tenders = append(view.Tenders, receiptTenderLine{
+			Method:        tender.Method,
+			Amount:        tender.Amount,
+			Tip:           tender.Tip,
+			Tendered:      tender.Tendered,
+			Change:        tender.Change,
+			Reference:     tender.Reference,
+			Denominations: tender.Denominations,
+		})
+	}
+
+	view.Footer.ThankYouNote = "Terima kasih atas kunjungan Anda!"
+	view.Footer.Terms = "Struk ini adalah bukti pembayaran yang sah"
+	return view
 }
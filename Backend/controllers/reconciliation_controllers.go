@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yeremiapane/restaurant-app/services"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+type ReconciliationController struct {
+	DB *gorm.DB
+}
+
+func NewReconciliationController(db *gorm.DB) *ReconciliationController {
+	return &ReconciliationController{DB: db}
+}
+
+// RunReconciliation -> Admin menjalankan rekonsiliasi payment secara manual (berjalan di background)
+func (rc *ReconciliationController) RunReconciliation(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	var triggeredBy *uint
+	if userID, ok := c.Get("user_id"); ok {
+		if id, ok := userID.(uint); ok {
+			triggeredBy = &id
+		}
+	}
+
+	svc := services.NewReconciliationService(rc.DB)
+	if lookback, err := strconv.Atoi(c.Query("lookback_days")); err == nil && lookback > 0 {
+		svc.LookbackDays = lookback
+	}
+
+	run, err := svc.Begin(services.ReconciliationTriggerManual, triggeredBy)
+	if errors.Is(err, services.ErrReconciliationRunning) {
+		utils.RespondError(c, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	go func() {
+		if err := svc.Execute(run); err != nil {
+			utils.ErrorLogger.Printf("Manual reconciliation run %d failed: %v", run.ID, err)
+		}
+	}()
+
+	utils.InfoLogger.Printf("Manual reconciliation run %d started", run.ID)
+	utils.RespondJSON(c, http.StatusAccepted, "Reconciliation started", run)
+}
+
+// GetReconciliationRuns -> Admin melihat riwayat rekonsiliasi
+// Query: page, limit
+func (rc *ReconciliationController) GetReconciliationRuns(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	runs, total, err := services.NewReconciliationService(rc.DB).ListRuns(page, limit)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Reconciliation runs", gin.H{
+		"runs":  runs,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetReconciliationRunByID -> Admin melihat laporan selisih satu run
+// Query: type (missing_locally, missing_at_provider, amount_mismatch, status_mismatch)
+func (rc *ReconciliationController) GetReconciliationRunByID(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	runID, err := strconv.ParseUint(c.Param("run_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("invalid run id"))
+		return
+	}
+
+	run, err := services.NewReconciliationService(rc.DB).GetRun(uint(runID), c.Query("type"))
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, errors.New("reconciliation run not found"))
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "Reconciliation run detail", run)
+}
+
+// ExportReconciliationRun -> Admin mengunduh laporan selisih dalam format CSV
+func (rc *ReconciliationController) ExportReconciliationRun(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	runID, err := strconv.ParseUint(c.Param("run_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("invalid run id"))
+		return
+	}
+
+	run, err := services.NewReconciliationService(rc.DB).GetRun(uint(runID), c.Query("type"))
+	if err != nil {
+		utils.RespondError(c, http.StatusNotFound, errors.New("reconciliation run not found"))
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=reconciliation_%d_%s.csv", run.ID, run.StartedAt.Format("20060102_150405")))
+
+	writer := csv.NewWriter(c.Writer)
+	headers := []string{"Type", "Payment ID", "Order ID", "Provider Ref", "Local Status", "Provider Status",
+		"Local Amount", "Provider Amount", "Auto Fixed", "Note"}
+	if err := writer.Write(headers); err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	for _, item := range run.Items {
+		row := []string{
+			item.Type,
+			optionalID(item.PaymentID),
+			optionalID(item.OrderID),
+			item.ProviderRef,
+			item.LocalStatus,
+			item.ProviderStatus,
+			item.LocalAmount.Decimal(),
+			item.ProviderAmount.Decimal(),
+			strconv.FormatBool(item.AutoFixed),
+			item.Note,
+		}
+		if err := writer.Write(row); err != nil {
+			utils.RespondError(c, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		utils.ErrorLogger.Printf("Failed to write reconciliation export %d: %v", run.ID, err)
+	}
+}
+
+func optionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
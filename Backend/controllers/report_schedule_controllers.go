@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/services"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+type ReportScheduleController struct {
+	DB *gorm.DB
+}
+
+func NewReportScheduleController(db *gorm.DB) *ReportScheduleController {
+	return &ReportScheduleController{DB: db}
+}
+
+// GetReportSchedules -> Admin melihat jadwal email laporan penjualan harian
+func (rc *ReportScheduleController) GetReportSchedules(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	schedules, err := services.NewSalesReportService(rc.DB).ListSchedules()
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "List of report schedules", schedules)
+}
+
+// CreateReportSchedule -> Admin membuat jadwal laporan (nama, penerima dipisah koma, jam kirim HH:MM)
+func (rc *ReportScheduleController) CreateReportSchedule(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	var input services.ReportScheduleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	schedule, err := services.NewSalesReportService(rc.DB).CreateSchedule(input, requesterID(c))
+	if err != nil {
+		respondReportScheduleError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusCreated, "Report schedule created", schedule)
+}
+
+// UpdateReportSchedule -> Admin mengubah jadwal laporan
+func (rc *ReportScheduleController) UpdateReportSchedule(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+	scheduleID, err := strconv.ParseUint(c.Param("schedule_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("invalid schedule id"))
+		return
+	}
+
+	var input services.ReportScheduleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	schedule, err := services.NewSalesReportService(rc.DB).UpdateSchedule(uint(scheduleID), input)
+	if err != nil {
+		respondReportScheduleError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "Report schedule updated", schedule)
+}
+
+// DeleteReportSchedule -> Admin menghapus jadwal laporan
+func (rc *ReportScheduleController) DeleteReportSchedule(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+	scheduleID, err := strconv.ParseUint(c.Param("schedule_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("invalid schedule id"))
+		return
+	}
+
+	if err := services.NewSalesReportService(rc.DB).DeleteSchedule(uint(scheduleID)); err != nil {
+		respondReportScheduleError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "Report schedule deleted", nil)
+}
+
+// SendReportSchedule -> Admin mengirim laporan penjualan kemarin sekarang juga (mis. setelah gagal kirim)
+func (rc *ReportScheduleController) SendReportSchedule(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+	scheduleID, err := strconv.ParseUint(c.Param("schedule_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, fmt.Errorf("invalid schedule id"))
+		return
+	}
+
+	var schedule models.ReportSchedule
+	if err := rc.DB.First(&schedule, scheduleID).Error; err != nil {
+		utils.RespondError(c, http.StatusNotFound, err)
+		return
+	}
+
+	sendErr := services.NewSalesReportService(rc.DB).SendSchedule(&schedule)
+	updates := map[string]interface{}{"last_error": ""}
+	if sendErr != nil {
+		updates["last_error"] = sendErr.Error()
+	} else {
+		profile := services.CurrentRestaurantProfile()
+		updates["last_sent_on"] = time.Now().In(profile.Location()).Format("2006-01-02")
+	}
+	rc.DB.Model(&schedule).Updates(updates)
+
+	if sendErr != nil {
+		utils.ErrorLogger.Printf("Failed to send report schedule %d: %v", schedule.ID, sendErr)
+		utils.RespondError(c, http.StatusBadGateway, sendErr)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "Report sent", schedule)
+}
+
+// respondReportScheduleError memetakan error SalesReportService ke status HTTP
+func respondReportScheduleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.RespondError(c, http.StatusNotFound, err)
+	case errors.Is(err, services.ErrInvalidReportSchedule):
+		utils.RespondError(c, http.StatusBadRequest, err)
+	default:
+		utils.ErrorLogger.Printf("Report schedule error: %v", err)
+		utils.RespondError(c, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yeremiapane/restaurant-app/services"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+// restaurantLogoDir adalah folder logo restoran, disajikan di /uploads/branding
+const restaurantLogoDir = "public/uploads/branding"
+
+// maxRestaurantLogoSize membatasi ukuran file logo (2 MB)
+const maxRestaurantLogoSize = 2 << 20
+
+type RestaurantProfileController struct {
+	DB *gorm.DB
+}
+
+func NewRestaurantProfileController(db *gorm.DB) *RestaurantProfileController {
+	return &RestaurantProfileController{DB: db}
+}
+
+// GetProfile -> Melihat profil restoran (nama, alamat, NPWP, zona waktu, dll)
+func (pc *RestaurantProfileController) GetProfile(c *gin.Context) {
+	profile, err := services.NewRestaurantProfileService(pc.DB).Get()
+	if err != nil {
+		utils.ErrorLogger.Printf("Failed to load restaurant profile: %v", err)
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "Restaurant profile", profile)
+}
+
+// UpdateProfile -> Admin mengubah profil restoran; struk, ekspor dan URL gambar langsung memakai nilai baru
+func (pc *RestaurantProfileController) UpdateProfile(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	var input services.RestaurantProfileInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	profile, err := services.NewRestaurantProfileService(pc.DB).Update(input, requesterID(c))
+	if errors.Is(err, services.ErrInvalidRestaurantProfile) {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		utils.ErrorLogger.Printf("Failed to update restaurant profile: %v", err)
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "Restaurant profile updated", profile)
+}
+
+// UploadLogo -> Admin mengganti logo struk (form field "logo", PNG/JPG maksimal 2 MB)
+func (pc *RestaurantProfileController) UploadLogo(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	file, err := c.FormFile("logo")
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("logo file is required"))
+		return
+	}
+	if file.Size > maxRestaurantLogoSize {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("logo must be at most 2 MB"))
+		return
+	}
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if ext != ".png" && ext != ".jpg" && ext != ".jpeg" {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("logo must be a PNG or JPG image"))
+		return
+	}
+
+	if err := os.MkdirAll(restaurantLogoDir, 0755); err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, errors.New("error creating upload directory"))
+		return
+	}
+	path := fmt.Sprintf("%s/logo-%d%s", restaurantLogoDir, time.Now().UnixNano(), ext)
+	if err := c.SaveUploadedFile(file, path); err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, errors.New("error saving logo"))
+		return
+	}
+
+	profile, previous, err := services.NewRestaurantProfileService(pc.DB).UpdateLogo(path, requesterID(c))
+	if err != nil {
+		os.Remove(path)
+		utils.ErrorLogger.Printf("Failed to update restaurant logo: %v", err)
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+	// Logo lama hanya dihapus jika memang hasil upload sebelumnya
+	if strings.HasPrefix(previous, restaurantLogoDir+"/") {
+		os.Remove(previous)
+	}
+	utils.RespondJSON(c, http.StatusOK, "Restaurant logo updated", profile)
+}
@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yeremiapane/restaurant-app/services"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+type ShiftController struct {
+	DB *gorm.DB
+}
+
+func NewShiftController(db *gorm.DB) *ShiftController {
+	return &ShiftController{DB: db}
+}
+
+// OpenShift -> Kasir membuka shift dengan modal awal di laci kas
+func (sc *ShiftController) OpenShift(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" && roleInterface != "staff" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		utils.RespondError(c, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	var body struct {
+		OpeningFloat utils.Money `json:"opening_float"`
+		Note         string      `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	shift, err := services.NewShiftService(sc.DB).OpenShift(userID, body.OpeningFloat, body.Note)
+	if err != nil {
+		respondShiftError(c, err)
+		return
+	}
+
+	utils.InfoLogger.Printf("Shift %d opened by user %d", shift.ID, userID)
+	utils.RespondJSON(c, http.StatusCreated, "Shift opened", shift)
+}
+
+// GetCurrentShift -> Kasir melihat shift yang sedang terbuka (tanpa angka expected, hitungan tetap blind)
+func (sc *ShiftController) GetCurrentShift(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" && roleInterface != "staff" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		utils.RespondError(c, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	shift, err := services.NewShiftService(sc.DB).GetOpenShift(userID)
+	if err != nil {
+		respondShiftError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "Current shift", shift)
+}
+
+// AddCashMovement -> Kasir mencatat paid-in / paid-out di shift yang terbuka
+func (sc *ShiftController) AddCashMovement(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" && roleInterface != "staff" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		utils.RespondError(c, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	shiftID, err := strconv.ParseUint(c.Param("shift_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("invalid shift id"))
+		return
+	}
+
+	var body struct {
+		Type   string      `json:"type" binding:"required,oneof=paid_in paid_out"`
+		Amount utils.Money `json:"amount" binding:"required"`
+		Reason string      `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	movement, err := services.NewShiftService(sc.DB).AddCashMovement(uint(shiftID), userID, roleInterface == "admin",
+		body.Type, body.Amount, body.Reason)
+	if err != nil {
+		respondShiftError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusCreated, "Cash movement recorded", movement)
+}
+
+// CloseShift -> Kasir menutup shift dengan hitungan blind per metode pembayaran.
+// Angka expected dan selisih hanya ditampilkan ke admin.
+func (sc *ShiftController) CloseShift(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" && roleInterface != "staff" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		utils.RespondError(c, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	shiftID, err := strconv.ParseUint(c.Param("shift_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("invalid shift id"))
+		return
+	}
+
+	var body struct {
+		Counts map[string]utils.Money `json:"counts" binding:"required"` // contoh: {"cash": 512000, "qris": 230000}
+		Note   string                 `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := services.NewShiftService(sc.DB).CloseShift(uint(shiftID), services.ShiftCloseRequest{
+		ClosedBy:    userID,
+		AsAdmin:     roleInterface == "admin",
+		Counts:      body.Counts,
+		ClosingNote: body.Note,
+	})
+	if err != nil {
+		respondShiftError(c, err)
+		return
+	}
+
+	utils.InfoLogger.Printf("Shift %d closed by user %d (flagged: %v)", report.Shift.ID, userID, report.VarianceFlagged)
+	if roleInterface == "admin" {
+		utils.RespondJSON(c, http.StatusOK, "Shift closed", report)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "Shift closed", gin.H{
+		"shift_id":  report.Shift.ID,
+		"closed_at": report.Shift.ClosedAt,
+		"counts":    body.Counts,
+	})
+}
+
+// GetShifts -> Admin melihat daftar shift
+// Query: cashier_id, status, flagged=true, page, limit
+func (sc *ShiftController) GetShifts(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	cashierID, _ := strconv.ParseUint(c.Query("cashier_id"), 10, 64)
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	shifts, total, err := services.NewShiftService(sc.DB).ListShifts(uint(cashierID), c.Query("status"),
+		c.Query("flagged") == "true", page, limit)
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Shifts", gin.H{
+		"shifts": shifts,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// GetShiftZReport -> Admin melihat Z report (expected vs counted per metode)
+func (sc *ShiftController) GetShiftZReport(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	shiftID, err := strconv.ParseUint(c.Param("shift_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("invalid shift id"))
+		return
+	}
+
+	report, err := services.NewShiftService(sc.DB).GetZReport(uint(shiftID))
+	if err != nil {
+		respondShiftError(c, err)
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "Z report", report)
+}
+
+// respondShiftError memetakan error ShiftService ke status HTTP
+func respondShiftError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.RespondError(c, http.StatusNotFound, errors.New("shift not found"))
+	case errors.Is(err, services.ErrNoOpenShift):
+		utils.RespondError(c, http.StatusNotFound, err)
+	case errors.Is(err, services.ErrShiftAlreadyOpen), errors.Is(err, services.ErrShiftClosed):
+		utils.RespondError(c, http.StatusConflict, err)
+	case errors.Is(err, services.ErrShiftNotOwned):
+		utils.RespondError(c, http.StatusForbidden, err)
+	case errors.Is(err, services.ErrCashCountRequired), errors.Is(err, services.ErrInvalidCashMovement):
+		utils.RespondError(c, http.StatusBadRequest, err)
+	default:
+		utils.ErrorLogger.Printf("Shift operation failed: %v", err)
+		utils.RespondError(c, http.StatusInternalServerError, err)
+	}
+}
+
+// currentUserID mengambil ID user yang login dari context (diset oleh auth middleware)
+func currentUserID(c *gin.Context) (uint, bool) {
+	value, exists := c.Get("user_id")
+	if !exists {
+		return 0, false
+	}
+	id, ok := value.(uint)
+	return id, ok
+}
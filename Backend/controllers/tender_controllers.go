@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/services"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+type TenderController struct {
+	DB *gorm.DB
+}
+
+func NewTenderController(db *gorm.DB) *TenderController {
+	return &TenderController{DB: db}
+}
+
+// TenderOrder -> Kasir melunasi bill dengan tunai (dengan kembalian dan rincian pecahan),
+// QRIS, atau kombinasi keduanya
+func (tc *TenderController) TenderOrder(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" && roleInterface != "staff" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		utils.RespondError(c, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	orderID, err := strconv.ParseUint(c.Param("order_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("invalid order id"))
+		return
+	}
+
+	var body struct {
+		Tenders []struct {
+			Method        string                       `json:"method" binding:"required,oneof=cash qris"`
+			Amount        utils.Money                  `json:"amount" binding:"required"`
+			Tip           utils.Money                  `json:"tip"`
+			Tendered      utils.Money                  `json:"tendered"`
+			Denominations []services.DenominationCount `json:"denominations"`
+		} `json:"tenders" binding:"required,min=1,dive"`
+		TipRecipientID *uint `json:"tip_recipient_id"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := validateTipRecipient(tc.DB, body.TipRecipientID); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	req := services.TenderRequest{OrderID: uint(orderID), CashierID: userID, TipRecipientID: body.TipRecipientID}
+	for _, t := range body.Tenders {
+		req.Tenders = append(req.Tenders, services.TenderLine{
+			Method:        t.Method,
+			Amount:        t.Amount,
+			Tip:           t.Tip,
+			Tendered:      t.Tendered,
+			Denominations: t.Denominations,
+		})
+	}
+
+	result, err := services.NewTenderService(tc.DB).Tender(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			utils.RespondError(c, http.StatusNotFound, errors.New("order not found"))
+		case errors.Is(err, services.ErrInvalidTender), errors.Is(err, services.ErrUnderTender),
+			errors.Is(err, services.ErrTenderMismatch):
+			utils.RespondError(c, http.StatusBadRequest, err)
+		case errors.Is(err, services.ErrOrderAlreadyPaid), errors.Is(err, services.ErrTenderPending):
+			utils.RespondError(c, http.StatusConflict, err)
+		case errors.Is(err, services.ErrNoOpenShift):
+			utils.RespondError(c, http.StatusConflict, errors.New("open a cashier shift before taking cash payments"))
+		default:
+			utils.ErrorLogger.Printf("Failed to tender order %d: %v", orderID, err)
+			utils.RespondError(c, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	for _, payment := range result.Payments {
+		go sendPaymentEvent(payment, models.Order{})
+	}
+
+	utils.InfoLogger.Printf("Order %d tendered by user %d (group %s)", orderID, userID, result.TenderGroup)
+	utils.RespondJSON(c, http.StatusOK, "Tender recorded", result)
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/services"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+type TipController struct {
+	DB *gorm.DB
+}
+
+func NewTipController(db *gorm.DB) *TipController {
+	return &TipController{DB: db}
+}
+
+// GetTipSuggestions -> Pelanggan melihat pilihan tip (persentase dan nominal) untuk order.
+// Query opsional: amount untuk menghitung dari nominal lain (mis. bagian split bill)
+func (tc *TipController) GetTipSuggestions(c *gin.Context) {
+	var order models.Order
+	if err := tc.DB.Select("id", "total_amount").First(&order, c.Param("order_id")).Error; err != nil {
+		utils.RespondError(c, http.StatusNotFound, errors.New("order not found"))
+		return
+	}
+
+	amount := order.TotalAmount
+	if raw := c.Query("amount"); raw != "" {
+		value, err := utils.ParseMoney(raw)
+		if err != nil || value <= 0 {
+			utils.RespondError(c, http.StatusBadRequest, errors.New("invalid amount"))
+			return
+		}
+		amount = value
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Tip suggestions", gin.H{
+		"order_id":    order.ID,
+		"amount":      amount,
+		"suggestions": services.SuggestTips(amount),
+	})
+}
+
+// GetTipReport -> Admin melihat distribusi tip per staf dan per shift.
+// Query: start_date, end_date (YYYY-MM-DD, default hari ini), mode (pooled/shift, default env TIP_DISTRIBUTION)
+func (tc *TipController) GetTipReport(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	today := time.Now().Format("2006-01-02")
+	start, err := time.ParseInLocation("2006-01-02", c.DefaultQuery("start_date", today), time.Local)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("format start_date tidak valid"))
+		return
+	}
+	end, err := time.ParseInLocation("2006-01-02", c.DefaultQuery("end_date", today), time.Local)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("format end_date tidak valid"))
+		return
+	}
+	if end.Before(start) {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("end_date tidak boleh sebelum start_date"))
+		return
+	}
+
+	mode := c.Query("mode")
+	if mode != "" && mode != services.TipModePooled && mode != services.TipModeShift {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("mode must be pooled or shift"))
+		return
+	}
+
+	report, err := services.NewTipService(tc.DB).DistributionReport(start, end.AddDate(0, 0, 1), mode)
+	if err != nil {
+		utils.ErrorLogger.Printf("Failed to build tip report: %v", err)
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Tip distribution report", report)
+}
+
+// validateTipRecipient memastikan staf penerima tip (jika diisi) ada
+func validateTipRecipient(db *gorm.DB, recipientID *uint) error {
+	if recipientID == nil {
+		return nil
+	}
+	var user models.User
+	if err := db.Select("id").First(&user, *recipientID).Error; err != nil {
+		return errors.New("tip recipient not found")
+	}
+	return nil
+}
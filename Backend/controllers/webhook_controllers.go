@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/services"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+type WebhookController struct {
+	DB *gorm.DB
+}
+
+func NewWebhookController(db *gorm.DB) *WebhookController {
+	return &WebhookController{DB: db}
+}
+
+// GetWebhookEvents -> Admin melihat daftar notifikasi payment provider yang tersimpan
+// Query: provider, status, order_ref, page, limit
+func (wc *WebhookController) GetWebhookEvents(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	events, total, err := services.NewWebhookInboxService(wc.DB).List(services.WebhookEventFilter{
+		Provider:      c.Query("provider"),
+		ProcessStatus: c.Query("status"),
+		OrderRef:      c.Query("order_ref"),
+		Page:          page,
+		Limit:         limit,
+	})
+	if err != nil {
+		utils.RespondError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.RespondJSON(c, http.StatusOK, "Webhook events", gin.H{
+		"events": events,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// GetWebhookEventByID -> Admin melihat detail satu notifikasi termasuk payload mentah
+func (wc *WebhookController) GetWebhookEventByID(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	var event models.WebhookEvent
+	if err := wc.DB.First(&event, c.Param("event_id")).Error; err != nil {
+		utils.RespondError(c, http.StatusNotFound, errors.New("webhook event not found"))
+		return
+	}
+	utils.RespondJSON(c, http.StatusOK, "Webhook event detail", event)
+}
+
+// ReprocessWebhookEvent -> Admin memproses ulang notifikasi tersimpan (mis. setelah payment diperbaiki)
+func (wc *WebhookController) ReprocessWebhookEvent(c *gin.Context) {
+	roleInterface, _ := c.Get("role")
+	if roleInterface != "admin" {
+		utils.RespondError(c, http.StatusForbidden, ErrNoPermission)
+		return
+	}
+
+	eventID, err := strconv.ParseUint(c.Param("event_id"), 10, 64)
+	if err != nil {
+		utils.RespondError(c, http.StatusBadRequest, errors.New("invalid event id"))
+		return
+	}
+
+	event, payment, err := services.NewWebhookInboxService(wc.DB).Reprocess(uint(eventID), services.GetPaymentProvider())
+	if event == nil {
+		utils.RespondError(c, http.StatusNotFound, errors.New("webhook event not found"))
+		return
+	}
+	if err != nil {
+		utils.ErrorLogger.Printf("Failed to reprocess webhook event %d: %v", eventID, err)
+		utils.RespondJSON(c, http.StatusUnprocessableEntity, err.Error(), gin.H{"event": event})
+		return
+	}
+
+	if payment != nil && event.ProcessStatus == models.WebhookStatusProcessed {
+		go sendPaymentEvent(*payment, models.Order{})
+	}
+
+	utils.InfoLogger.Printf("Webhook event %d reprocessed: %s", event.ID, event.ProcessStatus)
+	utils.RespondJSON(c, http.StatusOK, "Webhook event reprocessed", gin.H{
+		"event":   event,
+		"payment": payment,
+	})
+}
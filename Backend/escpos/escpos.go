@@ -0,0 +1,274 @@
+// Package escpos menyusun byte stream ESC/POS untuk printer thermal (Epson dan kompatibel)
+package escpos
+
+import (
+	"bytes"
+	"image"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	esc = 0x1B
+	gs  = 0x1D
+)
+
+// Perataan teks
+const (
+	AlignLeft   = 0
+	AlignCenter = 1
+	AlignRight  = 2
+)
+
+// Lebar kertas dalam karakter (font A) dan titik raster
+const (
+	Columns80mm = 48
+	Columns58mm = 32
+	Dots80mm    = 576
+	Dots58mm    = 384
+)
+
+// CodePageWPC1252 adalah nomor tabel karakter Windows-1252 pada printer Epson (ESC t 16).
+// Cukup untuk teks Indonesia dan huruf Latin beraksen pada nama menu.
+const CodePageWPC1252 = 16
+
+// Builder menyusun perintah ESC/POS ke dalam buffer
+type Builder struct {
+	buf     bytes.Buffer
+	columns int
+}
+
+// NewBuilder membuat builder untuk kertas dengan lebar columns karakter,
+// lalu menulis perintah inisialisasi dan memilih code page WPC1252
+func NewBuilder(columns int) *Builder {
+	if columns <= 0 {
+		columns = Columns80mm
+	}
+	b := &Builder{columns: columns}
+	b.buf.Write([]byte{esc, '@'})
+	b.buf.Write([]byte{esc, 't', CodePageWPC1252})
+	return b
+}
+
+// Columns mengembalikan lebar kertas dalam karakter
+func (b *Builder) Columns() int {
+	return b.columns
+}
+
+// Bytes mengembalikan byte stream yang siap dikirim ke printer
+func (b *Builder) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+// Align mengatur perataan teks untuk baris berikutnya
+func (b *Builder) Align(align int) *Builder {
+	b.buf.Write([]byte{esc, 'a', byte(align)})
+	return b
+}
+
+// Bold menyalakan / mematikan huruf tebal
+func (b *Builder) Bold(on bool) *Builder {
+	b.buf.Write([]byte{esc, 'E', boolByte(on)})
+	return b
+}
+
+// Size mengatur perbesaran huruf (1-8 kali lebar dan tinggi)
+func (b *Builder) Size(width, height int) *Builder {
+	b.buf.Write([]byte{gs, '!', byte((clamp(width, 1, 8)-1)<<4 | (clamp(height, 1, 8) - 1))})
+	return b
+}
+
+// Text menulis teks yang sudah dikonversi ke code page printer
+func (b *Builder) Text(text string) *Builder {
+	b.buf.Write(Encode(text))
+	return b
+}
+
+// Line menulis teks lalu baris baru
+func (b *Builder) Line(text string) *Builder {
+	return b.Text(text).Feed(1)
+}
+
+// Row menulis label di kiri dan nilai rata kanan dalam satu baris selebar kertas.
+// Label yang terlalu panjang dipotong agar nilai tetap terbaca.
+func (b *Builder) Row(label, value string) *Builder {
+	return b.RowWidth(label, value, b.columns)
+}
+
+// RowWidth sama dengan Row untuk lebar tertentu (mis. saat huruf diperbesar)
+func (b *Builder) RowWidth(label, value string, columns int) *Builder {
+	space := columns - utf8.RuneCountInString(value) - 1
+	if space < 1 {
+		return b.Line(label).Align(AlignRight).Line(value).Align(AlignLeft)
+	}
+	label = truncate(label, space)
+	return b.Line(label + strings.Repeat(" ", columns-utf8.RuneCountInString(label)-utf8.RuneCountInString(value)) + value)
+}
+
+// Separator menulis garis selebar kertas
+func (b *Builder) Separator(char rune) *Builder {
+	return b.Line(strings.Repeat(string(char), b.columns))
+}
+
+// Feed menambahkan n baris kosong
+func (b *Builder) Feed(lines int) *Builder {
+	if lines == 1 {
+		b.buf.WriteByte('\n')
+		return b
+	}
+	b.buf.Write([]byte{esc, 'd', byte(clamp(lines, 0, 255))})
+	return b
+}
+
+// Cut memajukan kertas lalu memotongnya (sebagian jika partial)
+func (b *Builder) Cut(partial bool) *Builder {
+	mode := byte(65) // potong penuh setelah feed
+	if partial {
+		mode = 66
+	}
+	b.buf.Write([]byte{gs, 'V', mode, 3})
+	return b
+}
+
+// KickDrawer membuka laci uang yang terhubung ke port pin 2
+func (b *Builder) KickDrawer() *Builder {
+	b.buf.Write([]byte{esc, 'p', 0, 25, 250})
+	return b
+}
+
+// QRCode mencetak QR code memakai generator bawaan printer (GS ( k), module 1-16 titik
+func (b *Builder) QRCode(data string, module int) *Builder {
+	payload := []byte(data)
+	store := len(payload) + 3
+	b.buf.Write([]byte{gs, '(', 'k', 4, 0, '1', 'A', '2', 0})                     // model 2
+	b.buf.Write([]byte{gs, '(', 'k', 3, 0, '1', 'C', byte(clamp(module, 1, 16))}) // ukuran modul
+	b.buf.Write([]byte{gs, '(', 'k', 3, 0, '1', 'E', '1'})                        // error correction M
+	b.buf.Write([]byte{gs, '(', 'k', byte(store), byte(store >> 8), '1', 'P', '0'})
+	b.buf.Write(payload)
+	b.buf.Write([]byte{gs, '(', 'k', 3, 0, '1', 'Q', '0'}) // cetak
+	return b
+}
+
+// Image mencetak gambar (mis. logo) sebagai raster hitam-putih (GS v 0). Gambar yang
+// lebih lebar dari maxDots diperkecil; piksel gelap (luminance < 50%) dicetak hitam.
+func (b *Builder) Image(img image.Image, maxDots int) *Builder {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return b
+	}
+	scale := 1.0
+	if maxDots > 0 && width > maxDots {
+		scale = float64(maxDots) / float64(width)
+	}
+	outWidth := int(float64(width) * scale)
+	outHeight := int(float64(height) * scale)
+	if outWidth == 0 || outHeight == 0 {
+		return b
+	}
+
+	rowBytes := (outWidth + 7) / 8
+	raster := make([]byte, rowBytes*outHeight)
+	for y := 0; y < outHeight; y++ {
+		for x := 0; x < outWidth; x++ {
+			src := img.At(bounds.Min.X+int(float64(x)/scale), bounds.Min.Y+int(float64(y)/scale))
+			r, g, bl, a := src.RGBA()
+			// Piksel transparan dianggap putih (kertas)
+			luminance := (299*r + 587*g + 114*bl) / 1000
+			if a > 0x7FFF && luminance < 0x8000 {
+				raster[y*rowBytes+x/8] |= 0x80 >> uint(x%8)
+			}
+		}
+	}
+
+	b.buf.Write([]byte{gs, 'v', '0', 0, byte(rowBytes), byte(rowBytes >> 8), byte(outHeight), byte(outHeight >> 8)})
+	b.buf.Write(raster)
+	return b
+}
+
+// cp1252Extra memetakan karakter Unicode di luar Latin-1 ke byte Windows-1252 (0x80-0x9F)
+var cp1252Extra = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B,
+	'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// Encode mengubah teks UTF-8 ke Windows-1252. Karakter kontrol selain baris baru dibuang
+// agar teks (mis. catatan pelanggan) tidak bisa menyisipkan perintah printer, dan
+// karakter yang tidak ada di code page diganti '?'.
+func Encode(text string) []byte {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r == '\n':
+			out = append(out, '\n')
+		case r < 0x20 || r == 0x7F:
+			continue
+		case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+			out = append(out, byte(r))
+		default:
+			if c, ok := cp1252Extra[r]; ok {
+				out = append(out, c)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
+
+// Wrap memecah teks menjadi baris-baris dengan lebar maksimal columns karakter
+func Wrap(text string, columns int) []string {
+	var lines []string
+	var current []rune
+	for _, word := range strings.Fields(text) {
+		w := []rune(word)
+		for len(w) > columns {
+			if len(current) > 0 {
+				lines = append(lines, string(current))
+				current = nil
+			}
+			lines = append(lines, string(w[:columns]))
+			w = w[columns:]
+		}
+		switch {
+		case len(current) == 0:
+			current = w
+		case len(current)+1+len(w) <= columns:
+			current = append(append(current, ' '), w...)
+		default:
+			lines = append(lines, string(current))
+			current = w
+		}
+	}
+	if len(current) > 0 {
+		lines = append(lines, string(current))
+	}
+	return lines
+}
+
+func truncate(text string, columns int) string {
+	runes := []rune(text)
+	if len(runes) <= columns {
+		return text
+	}
+	return string(runes[:columns])
+}
+
+func boolByte(on bool) byte {
+	if on {
+		return 1
+	}
+	return 0
+}
+
+func clamp(value, min, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
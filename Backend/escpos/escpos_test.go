@@ -0,0 +1,74 @@
+package escpos
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []byte
+	}{
+		{name: "ascii", text: "Nasi Goreng", want: []byte("Nasi Goreng")},
+		{name: "latin-1 accent", text: "Café", want: []byte{'C', 'a', 'f', 0xE9}},
+		{name: "cp1252 punctuation", text: "“Pedas” – €", want: []byte{0x93, 'P', 'e', 'd', 'a', 's', 0x94, ' ', 0x96, ' ', 0x80}},
+		{name: "control bytes stripped", text: "es\x1b@teh\x1dV", want: []byte("es@tehV")},
+		{name: "unsupported rune", text: "🌶 sambal", want: []byte("? sambal")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Encode(tt.text); !bytes.Equal(got, tt.want) {
+				t.Errorf("Encode(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	b := NewBuilder(Columns58mm)
+	b.Row("Subtotal", "Rp 70.000").Cut(true).KickDrawer()
+
+	want := []byte{esc, '@', esc, 't', CodePageWPC1252}
+	// Baris Row tepat selebar kertas
+	want = append(want, "Subtotal               Rp 70.000\n"...)
+	want = append(want, gs, 'V', 66, 3, esc, 'p', 0, 25, 250)
+	if got := b.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("Bytes() = %q, want %q", got, want)
+	}
+}
+
+func TestBuilder_Image(t *testing.T) {
+	// 10x2 piksel: kolom genap hitam
+	img := image.NewGray(image.Rect(0, 0, 10, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 10; x++ {
+			if x%2 == 0 {
+				img.SetGray(x, y, color.Gray{Y: 0})
+			} else {
+				img.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+
+	got := NewBuilder(Columns80mm).Image(img, Dots80mm).Bytes()[5:]
+	want := []byte{gs, 'v', '0', 0, 2, 0, 2, 0, 0xAA, 0x80, 0xAA, 0x80}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Image() = %v, want %v", got, want)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	got := Wrap("Nasi goreng kampung spesial pedas", 12)
+	want := []string{"Nasi goreng", "kampung", "spesial", "pedas"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Wrap() = %q, want %q", got, want)
+	}
+	if got := Wrap("Supercalifragilistic", 8); !reflect.DeepEqual(got, []string{"Supercal", "ifragili", "stic"}) {
+		t.Errorf("Wrap(long word) = %q", got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gorilla/websocket"
 	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
 )
 
 // Event types
@@ -102,9 +103,9 @@ func BroadcastOrderUpdate(order models.Order) {
 
 	// Tambahkan informasi pendapatan jika order sudah dibayar/selesai
 	if order.Status == "completed" || order.Status == "paid" {
-		var totalAmount float64
+		var totalAmount utils.Money
 		for _, item := range order.OrderItems {
-			totalAmount += float64(item.Quantity) * item.Price
+			totalAmount += item.Price.Mul(item.Quantity)
 		}
 
 		dashboardData["revenue"] = map[string]interface{}{
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"time"
+	_ "time/tzdata" // Zona waktu profil restoran tetap terbaca di image tanpa tzdata
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -99,6 +100,11 @@ func main() {
 
 	autoMigrate(db)
 
+	// Muat profil restoran ke cache (dibuat dari env RESTAURANT_* jika belum ada)
+	if _, err := services.NewRestaurantProfileService(db).Get(); err != nil {
+		utils.ErrorLogger.Printf("Error loading restaurant profile: %v", err)
+	}
+
 	// Setup rate limiter (10 requests per second per IP)
 	rateLimiter := middlewares.NewRateLimiter(50, 1)
 
@@ -108,13 +114,22 @@ func main() {
 	monitor.Start()
 	defer monitor.Stop()
 
-	// Initialize payment monitor untuk menangani retry dan metrics
-	paymentMonitor := services.NewPaymentMonitor(db)
-	paymentMonitor.Start()
+	// Jalankan rekonsiliasi payment dengan provider secara berkala
+	services.NewReconciliationService(db).Start()
+
+	// Expire payment pending tepat pada deadline-nya (jadwal dibangun ulang dari database)
+	services.NewPaymentExpiryScheduler(db).Start()
 
-	// Initialize payment service dan start timeout checker
-	paymentService := services.NewPaymentService(db)
-	paymentService.StartTimeoutChecker()
+	// Kirim laporan penjualan harian sesuai jadwal email yang diatur admin
+	services.NewSalesReportService(db).Start()
+
+	// Kurangi stok bahan sesuai resep untuk order yang sudah lunas / selesai dimasak
+	services.NewInventoryService(db).Start()
+
+	// Kirim print job ESC/POS ke printer jaringan
+	printQueue := services.NewPrintQueue(db)
+	printQueue.Start()
+	defer printQueue.Stop()
 
 	// Setup router
 	r := router.SetupRouter(db)
@@ -155,6 +170,20 @@ func autoMigrate(db *gorm.DB) {
 		}
 	}
 
+	// Struk ganda per payment harus dibersihkan sebelum AutoMigrate membuat unique index payment_id
+	if removed, err := services.DedupeReceipts(db); err != nil {
+		utils.ErrorLogger.Fatalf("Failed to remove duplicate receipts: %v", err)
+	} else if removed > 0 {
+		utils.InfoLogger.Printf("Removed %d duplicate receipts, kept the oldest receipt per payment", removed)
+	}
+
+	// Key webhook ganda dikosongkan sebelum AutoMigrate membuat unique index event_key
+	if cleared, err := services.DedupeWebhookEventKeys(db); err != nil {
+		utils.ErrorLogger.Fatalf("Failed to deduplicate webhook event keys: %v", err)
+	} else if cleared > 0 {
+		utils.InfoLogger.Printf("Cleared %d duplicate webhook event keys", cleared)
+	}
+
 	// Kemudian lakukan AutoMigrate
 	err := db.AutoMigrate(
 		&models.User{},
@@ -166,17 +195,40 @@ func autoMigrate(db *gorm.DB) {
 		&models.Order{},
 		&models.OrderItem{},
 		&models.Payment{},
+		&models.CashDenomination{},
 		&models.Notification{},
 		&models.Receipt{},
 		&models.ReceiptItem{},
 		&models.ReceiptAddOn{},
+		&models.ReceiptTender{},
 		&models.DBChange{},
+		&models.WebhookEvent{},
+		&models.ReconciliationRun{},
+		&models.ReconciliationDiscrepancy{},
+		&models.CashierShift{},
+		&models.CashMovement{},
+		&models.CashierShiftCount{},
+		&models.PrintJob{},
+		&models.NumberSequence{},
+		&models.RestaurantProfile{},
+		&models.ReportSchedule{},
+		&models.AvailabilitySchedule{},
+		&models.MenuVariant{},
+		&models.MenuDietaryTag{},
+		&models.Ingredient{},
+		&models.RecipeItem{},
+		&models.IngredientMovement{},
 	)
 	if err != nil {
 		utils.ErrorLogger.Fatalf("Failed to AutoMigrate: %v", err)
 	}
 	utils.InfoLogger.Println("AutoMigrate completed.")
 
+	// Index FULLTEXT untuk pencarian menu (hanya MySQL)
+	if err := services.EnsureMenuSearchIndex(db); err != nil {
+		utils.ErrorLogger.Printf("Error creating menu search index: %v", err)
+	}
+
 	// Execute triggers
 	if err := database.ExecuteTriggers(db); err != nil {
 		utils.ErrorLogger.Printf("Error setting up triggers: %v", err)
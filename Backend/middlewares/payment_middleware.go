@@ -41,9 +41,9 @@ func PaymentRateLimiter() gin.HandlerFunc {
 func ValidatePaymentRequest() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var request struct {
-			Amount      float64 `json:"amount" binding:"required,gt=0"`
-			OrderID     uint    `json:"order_id" binding:"required"`
-			PaymentType string  `json:"payment_type" binding:"required,oneof=cash qris"`
+			Amount      utils.Money `json:"amount" binding:"required,gt=0"` // Lebih dari 2 angka desimal ditolak saat binding
+			OrderID     uint        `json:"order_id" binding:"required"`
+			PaymentType string      `json:"payment_type" binding:"required,oneof=cash qris"`
 		}
 
 		if err := c.ShouldBindJSON(&request); err != nil {
@@ -55,16 +55,6 @@ func ValidatePaymentRequest() gin.HandlerFunc {
 			return
 		}
 
-		// Validate amount format (max 2 decimal places)
-		if request.Amount != float64(int64(request.Amount*100))/100 {
-			c.JSON(400, gin.H{
-				"error":   "Invalid amount",
-				"message": "Amount must have at most 2 decimal places",
-			})
-			c.Abort()
-			return
-		}
-
 		c.Next()
 	}
 }
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Jenis aturan ketersediaan
+const (
+	AvailabilityAvailable   = "available"   // Menu hanya tersedia di jendela ini (mis. sarapan 06:00-10:30)
+	AvailabilityUnavailable = "unavailable" // Menu tidak tersedia di jendela ini (mis. tutup saat hari libur)
+)
+
+// AvailabilitySchedule adalah aturan ketersediaan untuk satu menu atau satu kategori,
+// dievaluasi di zona waktu restoran. Semua syarat yang diisi harus terpenuhi agar aturan
+// berlaku; syarat yang kosong berarti "kapan saja".
+type AvailabilitySchedule struct {
+	ID         uint    `gorm:"primaryKey" json:"id"`
+	MenuID     *uint   `gorm:"index" json:"menu_id,omitempty"`
+	CategoryID *uint   `gorm:"index" json:"category_id,omitempty"`
+	Name       string  `gorm:"type:varchar(100)" json:"name"`
+	Kind       string  `gorm:"type:varchar(20);not null;default:'available'" json:"kind"`
+	Days       string  `gorm:"type:varchar(20)" json:"days"`       // Hari ISO dipisah koma: 1=Senin ... 7=Minggu
+	StartTime  string  `gorm:"type:varchar(5)" json:"start_time"`  // HH:MM
+	EndTime    string  `gorm:"type:varchar(5)" json:"end_time"`    // HH:MM, lebih kecil dari StartTime = lewat tengah malam
+	StartDate  *string `gorm:"type:varchar(10)" json:"start_date"` // 2006-01-02
+	EndDate    *string `gorm:"type:varchar(10)" json:"end_date"`   // 2006-01-02, inklusif
+
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time `gorm:"not null" json:"updated_at"`
+}
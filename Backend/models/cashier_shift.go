@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+// Status shift kasir
+const (
+	ShiftStatusOpen   = "open"
+	ShiftStatusClosed = "closed"
+)
+
+// Jenis pergerakan kas di luar penjualan
+const (
+	CashMovementPaidIn  = "paid_in"
+	CashMovementPaidOut = "paid_out"
+)
+
+// CashierShift adalah satu sesi kerja kasir, dimulai dengan modal awal (opening float)
+// dan diakhiri dengan hitungan kas tanpa melihat angka sistem (blind count)
+type CashierShift struct {
+	ID              uint                `gorm:"primaryKey" json:"id"`
+	CashierID       uint                `gorm:"not null;index" json:"cashier_id"`
+	Cashier         User                `gorm:"foreignKey:CashierID" json:"-"`
+	Status          string              `gorm:"type:varchar(20);not null;default:'open';index" json:"status"`
+	OpeningFloat    utils.Money         `gorm:"not null;default:0" json:"opening_float"`
+	OpeningNote     string              `gorm:"type:text" json:"opening_note,omitempty"`
+	OpenedAt        time.Time           `json:"opened_at"`
+	ClosedAt        *time.Time          `json:"closed_at"`
+	ClosingNote     string              `gorm:"type:text" json:"closing_note,omitempty"`
+	ExpectedCash    utils.Money         `json:"expected_cash"`
+	CountedCash     *utils.Money        `json:"counted_cash"`
+	Variance        utils.Money         `json:"variance"`
+	VarianceFlagged bool                `gorm:"not null;default:false;index" json:"variance_flagged"`
+	Movements       []CashMovement      `gorm:"foreignKey:ShiftID" json:"movements,omitempty"`
+	Counts          []CashierShiftCount `gorm:"foreignKey:ShiftID" json:"counts,omitempty"`
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+}
+
+// CashMovement mencatat uang masuk (paid-in) atau keluar (paid-out) laci kas selama shift
+type CashMovement struct {
+	ID        uint        `gorm:"primaryKey" json:"id"`
+	ShiftID   uint        `gorm:"not null;index" json:"shift_id"`
+	Type      string      `gorm:"type:varchar(20);not null" json:"type"`
+	Amount    utils.Money `gorm:"not null" json:"amount"`
+	Reason    string      `gorm:"type:varchar(255);not null" json:"reason"`
+	CreatedBy uint        `gorm:"not null" json:"created_by"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// CashierShiftCount adalah baris Z report per metode pembayaran saat shift ditutup
+type CashierShiftCount struct {
+	ID            uint         `gorm:"primaryKey" json:"id"`
+	ShiftID       uint         `gorm:"not null;index" json:"shift_id"`
+	PaymentMethod string       `gorm:"type:varchar(20);not null" json:"payment_method"`
+	PaymentCount  int          `gorm:"not null;default:0" json:"payment_count"`
+	Sales         utils.Money  `gorm:"not null;default:0" json:"sales"`
+	Tips          utils.Money  `gorm:"not null;default:0" json:"tips"`
+	Expected      utils.Money  `gorm:"not null;default:0" json:"expected"`
+	Counted       *utils.Money `json:"counted"`
+	Variance      utils.Money  `gorm:"not null;default:0" json:"variance"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
@@ -9,6 +9,7 @@ type Customer struct {
 	TableID    *uint     `gorm:"index"`
 	Table      Table     `gorm:"foreignKey:TableID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
 	SessionKey *string   `gorm:"type:varchar(255)"`
+	Email      *string   `gorm:"type:varchar(100)" json:"-"` // Opsional, untuk struk digital; tidak ikut di JSON publik
 	Status     string    `gorm:"type:varchar(20);not null;default:'inactive'"`
 	CreatedAt  time.Time `gorm:"not null"`
 	UpdatedAt  time.Time `gorm:"not null"`
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Satuan bahan. Jumlah di resep memakai satuan bahannya, tanpa konversi.
+const (
+	IngredientUnitGram       = "g"
+	IngredientUnitKilogram   = "kg"
+	IngredientUnitMilliliter = "ml"
+	IngredientUnitLiter      = "l"
+	IngredientUnitPiece      = "pcs"
+)
+
+// IngredientUnits adalah daftar satuan bahan yang valid
+var IngredientUnits = []string{
+	IngredientUnitGram,
+	IngredientUnitKilogram,
+	IngredientUnitMilliliter,
+	IngredientUnitLiter,
+	IngredientUnitPiece,
+}
+
+// Alasan perubahan stok bahan
+const (
+	IngredientMovementSale       = "sale"       // Dipakai oleh order
+	IngredientMovementRestock    = "restock"    // Barang masuk
+	IngredientMovementAdjustment = "adjustment" // Koreksi hasil stock opname, susut, dll.
+)
+
+// Ingredient adalah bahan baku yang dipakai bersama oleh beberapa menu
+type Ingredient struct {
+	ID                uint    `gorm:"primaryKey" json:"id"`
+	Name              string  `gorm:"type:varchar(100);not null;uniqueIndex" json:"name"`
+	Unit              string  `gorm:"type:varchar(10);not null" json:"unit"`
+	Stock             float64 `gorm:"type:decimal(14,3);not null;default:0" json:"stock"`
+	LowStockThreshold float64 `gorm:"type:decimal(14,3);not null;default:0" json:"low_stock_threshold"`
+
+	// Diisi saat notifikasi stok menipis dikirim, dikosongkan lagi setelah restock di atas batas
+	LowStockNotifiedAt *time.Time `json:"low_stock_notified_at,omitempty"`
+
+	CreatedAt time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// IsLow menandakan stok sudah di bawah atau sama dengan batas minimum
+func (i *Ingredient) IsLow() bool {
+	return i.Stock <= i.LowStockThreshold
+}
+
+// RecipeItem adalah jumlah satu bahan untuk satu porsi menu. Baris dengan VariantID
+// hanya berlaku untuk variant itu dan ditambahkan ke baris tanpa VariantID. Add-on
+// adalah menu biasa, jadi resepnya diatur dengan cara yang sama.
+type RecipeItem struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	MenuID       uint       `gorm:"not null;index" json:"menu_id"`
+	VariantID    *uint      `gorm:"index" json:"variant_id,omitempty"`
+	IngredientID uint       `gorm:"not null;index" json:"ingredient_id"`
+	Ingredient   Ingredient `gorm:"foreignKey:IngredientID" json:"ingredient"`
+	Quantity     float64    `gorm:"type:decimal(14,3);not null" json:"quantity"`
+	CreatedAt    time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"not null" json:"updated_at"`
+}
+
+// IngredientMovement adalah catatan setiap perubahan stok bahan
+type IngredientMovement struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	IngredientID uint      `gorm:"not null;index" json:"ingredient_id"`
+	OrderID      *uint     `gorm:"index" json:"order_id,omitempty"`
+	UserID       *uint     `json:"user_id,omitempty"`
+	Reason       string    `gorm:"type:varchar(20);not null" json:"reason"`
+	Change       float64   `gorm:"type:decimal(14,3);not null" json:"change"`
+	StockAfter   float64   `gorm:"type:decimal(14,3);not null" json:"stock_after"`
+	Note         string    `gorm:"type:varchar(255)" json:"note"`
+	CreatedAt    time.Time `gorm:"not null;index" json:"created_at"`
+}
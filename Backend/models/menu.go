@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 
+	"github.com/yeremiapane/restaurant-app/utils"
 	"gorm.io/gorm"
 )
 
@@ -10,11 +11,24 @@ type Menu struct {
 	gorm.Model
 	CategoryID  uint         `json:"category_id"`
 	Category    MenuCategory `json:"category"`
-	Name        string       `json:"name"`
-	Price       float64      `json:"price"`
+	SKU         *string      `json:"sku,omitempty" gorm:"type:varchar(50);uniqueIndex"` // Kunci impor/ekspor massal, opsional
+	Name        string       `json:"name" gorm:"type:varchar(255);index"`
+	Price       utils.Money  `json:"price" gorm:"index"`
 	Stock       int          `json:"stock"`
 	Description string       `json:"description"`
-	ImageUrls   string       `json:"image_urls" gorm:"type:text"`
+	ImageUrls   string       `json:"image_urls" gorm:"type:text"` // URL rendisi large, lihat MenuImage
+
+	// Semua rendisi (thumbnail, medium, large) dari setiap gambar, diisi saat dibaca
+	Images []MenuImage `json:"images" gorm:"-"`
+
+	// Pilihan ukuran/porsi/suhu; jika ada, order harus memilih salah satunya
+	Variants []MenuVariant `json:"variants,omitempty" gorm:"foreignKey:MenuID"`
+
+	// Tag diet (vegan, halal, ...), lihat DietaryTags
+	DietaryTags []MenuDietaryTag `json:"dietary_tags,omitempty" gorm:"foreignKey:MenuID"`
+
+	// Hasil jadwal ketersediaan saat ini, hanya diisi di daftar menu (lihat services.MenuAvailability)
+	Available *bool `json:"available,omitempty" gorm:"-"`
 }
 
 // BeforeCreate - Hook untuk mengatur nilai default sebelum create
@@ -33,6 +47,20 @@ func (m *Menu) BeforeSave(tx *gorm.DB) error {
 	return nil
 }
 
+// AfterFind - Hook untuk menyusun rendisi gambar dari ImageUrls
+func (m *Menu) AfterFind(tx *gorm.DB) error {
+	m.fillImages()
+	return nil
+}
+
+func (m *Menu) fillImages() {
+	urls := m.GetImageUrls()
+	m.Images = make([]MenuImage, 0, len(urls))
+	for _, url := range urls {
+		m.Images = append(m.Images, NewMenuImage(url))
+	}
+}
+
 // Getter untuk ImageUrls
 func (m *Menu) GetImageUrls() []string {
 	var urls []string
@@ -49,5 +77,6 @@ func (m *Menu) SetImageUrls(urls []string) error {
 		return err
 	}
 	m.ImageUrls = string(jsonData)
+	m.fillImages()
 	return nil
 }
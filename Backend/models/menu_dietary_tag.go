@@ -0,0 +1,38 @@
+package models
+
+import "encoding/json"
+
+// Tag diet yang bisa dipasang di menu
+const (
+	DietaryTagVegetarian = "vegetarian"
+	DietaryTagVegan      = "vegan"
+	DietaryTagHalal      = "halal"
+	DietaryTagGlutenFree = "gluten_free"
+	DietaryTagDairyFree  = "dairy_free"
+	DietaryTagNutFree    = "nut_free"
+	DietaryTagSpicy      = "spicy"
+)
+
+// DietaryTags adalah daftar tag diet yang valid, sesuai urutan tampil
+var DietaryTags = []string{
+	DietaryTagVegetarian,
+	DietaryTagVegan,
+	DietaryTagHalal,
+	DietaryTagGlutenFree,
+	DietaryTagDairyFree,
+	DietaryTagNutFree,
+	DietaryTagSpicy,
+}
+
+// MenuDietaryTag menghubungkan menu dengan satu tag diet. Disimpan per baris (bukan JSON
+// di kolom menus) agar filter tag bisa memakai index.
+type MenuDietaryTag struct {
+	ID     uint   `gorm:"primaryKey"`
+	MenuID uint   `gorm:"not null;uniqueIndex:idx_menu_dietary_tag"`
+	Tag    string `gorm:"type:varchar(20);not null;uniqueIndex:idx_menu_dietary_tag;index"`
+}
+
+// MarshalJSON menulis tag sebagai string biasa, mis. "dietary_tags": ["vegan", "halal"]
+func (t MenuDietaryTag) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Tag)
+}
@@ -0,0 +1,41 @@
+package models
+
+import "strings"
+
+// Rendisi gambar menu. Nama file: <id acak>-<rendisi>.jpg
+const (
+	MenuImageThumbnail = "thumb"
+	MenuImageMedium    = "medium"
+	MenuImageLarge     = "large"
+)
+
+// MenuImage adalah URL semua rendisi satu gambar menu
+type MenuImage struct {
+	Thumbnail string `json:"thumbnail"`
+	Medium    string `json:"medium"`
+	Large     string `json:"large"`
+}
+
+// MenuImageFilename menyusun nama file satu rendisi
+func MenuImageFilename(id, rendition string) string {
+	return id + "-" + rendition + ".jpg"
+}
+
+// MenuImageRenditionURL mengganti rendisi pada URL gambar besar (yang disimpan di ImageUrls).
+// Gambar lama yang diupload sebelum ada rendisi dikembalikan apa adanya.
+func MenuImageRenditionURL(largeURL, rendition string) string {
+	suffix := "-" + MenuImageLarge + ".jpg"
+	if !strings.HasSuffix(largeURL, suffix) {
+		return largeURL
+	}
+	return strings.TrimSuffix(largeURL, suffix) + "-" + rendition + ".jpg"
+}
+
+// NewMenuImage menyusun semua rendisi dari URL gambar besar
+func NewMenuImage(largeURL string) MenuImage {
+	return MenuImage{
+		Thumbnail: MenuImageRenditionURL(largeURL, MenuImageThumbnail),
+		Medium:    MenuImageRenditionURL(largeURL, MenuImageMedium),
+		Large:     largeURL,
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+// Jenis variant menu
+const (
+	VariantKindSize        = "size"
+	VariantKindPortion     = "portion"
+	VariantKindTemperature = "temperature"
+)
+
+// MenuVariant adalah pilihan di bawah satu menu (mis. Es Teh - Large) dengan harga,
+// SKU dan stok sendiri. Dihapus secara soft delete agar order lama tetap bisa dibaca.
+type MenuVariant struct {
+	ID        uint        `gorm:"primaryKey" json:"id"`
+	MenuID    uint        `gorm:"not null;index" json:"menu_id"`
+	Kind      string      `gorm:"type:varchar(20)" json:"kind"` // size, portion atau temperature
+	Name      string      `gorm:"type:varchar(50);not null" json:"name"`
+	SKU       *string     `gorm:"type:varchar(50);uniqueIndex" json:"sku,omitempty"`
+	Price     utils.Money `gorm:"type:decimal(10,2);not null;default:0" json:"price"`
+	Stock     int         `gorm:"not null;default:0" json:"stock"`
+	SortOrder int         `gorm:"not null;default:0" json:"sort_order"`
+
+	CreatedAt time.Time      `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"not null" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
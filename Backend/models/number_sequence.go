@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// NumberSequence menyimpan nomor terakhir yang sudah dipakai untuk satu jenis dokumen,
+// per cabang dan per periode reset (mis. per hari). Baris dikunci di dalam transaksi
+// yang membuat dokumen sehingga nomor tidak pernah loncat atau dobel.
+type NumberSequence struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"type:varchar(30);not null;uniqueIndex:idx_number_sequence" json:"name"`   // receipt / order
+	Branch    string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_number_sequence" json:"branch"` // Kode cabang, kosong = satu cabang
+	Period    string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_number_sequence" json:"period"` // 20261018 / 202610 / 2026 / kosong
+	LastValue int64     `gorm:"not null;default:0" json:"last_value"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time `gorm:"not null" json:"updated_at"`
+}
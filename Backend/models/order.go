@@ -3,14 +3,17 @@ package models
 import (
 	"fmt"
 	"time"
+
+	"github.com/yeremiapane/restaurant-app/utils"
 )
 
 type Order struct {
 	ID                uint        `gorm:"primaryKey" json:"id"`
+	OrderNumber       string      `gorm:"type:varchar(30);index" json:"order_number"` // Nomor harian untuk tamu, lihat services.OrderNumberScheme
 	CustomerID        uint        `gorm:"not null" json:"customer_id"`
 	Customer          Customer    `gorm:"foreignKey:CustomerID" json:"customer"`
 	Status            string      `gorm:"type:varchar(20);not null;default:'pending_payment'" json:"status"`
-	TotalAmount       float64     `gorm:"type:decimal(10,2);not null;default:0.00" json:"total_amount"`
+	TotalAmount       utils.Money `gorm:"type:decimal(10,2);not null;default:0" json:"total_amount"`
 	ChefID            *uint       `gorm:"index" json:"chef_id,omitempty"`
 	Chef              *User       `gorm:"foreignKey:ChefID" json:"chef,omitempty"`
 	StartCookingTime  *time.Time  `json:"start_cooking_time,omitempty"`
@@ -20,6 +23,9 @@ type Order struct {
 	OrderItems        []OrderItem `gorm:"foreignKey:OrderID" json:"order_items"`
 	TableID           uint        `json:"table_id"`
 	Table             Table       `gorm:"foreignKey:TableID" json:"table"`
+
+	// Diisi saat stok bahan sudah dikurangi untuk order ini, lihat services.InventoryService
+	InventoryDepletedAt *time.Time `gorm:"index" json:"inventory_depleted_at,omitempty"`
 }
 
 // GenerateCustomerIdentifier menghasilkan identifier untuk customer berdasarkan ID
@@ -27,9 +33,13 @@ func (o *Order) GenerateCustomerIdentifier() string {
 	return fmt.Sprintf("CUST-%d-%d", o.CustomerID, o.ID)
 }
 
-// GetCustomerEmail menghasilkan email berdasarkan ID customer
+// GetCustomerEmail mengembalikan email yang diisi customer (Customer harus di-preload),
+// kosong jika customer tidak mengisinya
 func (o *Order) GetCustomerEmail() string {
-	return fmt.Sprintf("customer%d@example.com", o.CustomerID)
+	if o.Customer.Email == nil {
+		return ""
+	}
+	return *o.Customer.Email
 }
 
 // GetCustomerName menghasilkan nama berdasarkan ID customer
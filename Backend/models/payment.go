@@ -2,27 +2,79 @@ package models
 
 import (
 	"time"
+
+	"github.com/yeremiapane/restaurant-app/utils"
 )
 
 // Payment represents a payment transaction for an order
 type Payment struct {
-	ID            uint       `json:"id" gorm:"primaryKey"`
-	OrderID       uint       `json:"order_id"`
-	Order         Order      `json:"order" gorm:"foreignKey:OrderID"`
-	Amount        float64    `json:"amount"`
-	Status        string     `json:"status" gorm:"type:enum('pending','success','failed','expired');default:'pending'"`
-	PaymentMethod string     `json:"payment_method" gorm:"type:enum('cash','qris','bank_transfer');default:'cash'"`
-	PaymentType   string     `json:"payment_type"`
-	ReferenceID   string     `json:"reference_id"`
-	QRCode        string     `json:"qr_code"`       // Raw QR code data for QRIS
-	QRImageURL    string     `json:"qr_image_url"`  // URL to QR code image
-	PaymentURL    string     `json:"payment_url"`   // URL for redirect payment methods
-	Details       string     `json:"details"`       // Additional payment details in JSON
-	CashReceived  float64    `json:"cash_received"` // Amount of cash received for cash payments
-	Change        float64    `json:"change"`        // Change amount for cash payments
-	PaymentTime   *time.Time `json:"payment_time"`  // Time when payment was processed
-	ExpiredAt     *time.Time `json:"expired_at"`    // Time when payment will expire (nullable)
-	VerifiedBy    *uint      `json:"verified_by"`   // Staff who verified the payment
-	CreatedAt     time.Time  `json:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at"`
+	ID                uint               `json:"id" gorm:"primaryKey"`
+	OrderID           uint               `json:"order_id"`
+	Order             Order              `json:"order" gorm:"foreignKey:OrderID"`
+	Amount            utils.Money        `json:"amount"`
+	Tip               utils.Money        `json:"tip"`                           // Gratuity on top of Amount, not part of the order total
+	TipRecipientID    *uint              `json:"tip_recipient_id" gorm:"index"` // Staff the tip is attributed to, nil means pooled
+	Status            string             `json:"status" gorm:"type:enum('pending','success','failed','expired','cancelled','refunded');default:'pending'"`
+	PaymentMethod     string             `json:"payment_method" gorm:"type:enum('cash','qris','bank_transfer');default:'cash'"`
+	PaymentType       string             `json:"payment_type"`
+	ReferenceID       string             `json:"reference_id"`
+	ProviderReference *string            `json:"provider_reference" gorm:"type:varchar(100);uniqueIndex"` // Order ID at the payment provider, unique per attempt
+	QRCode            string             `json:"qr_code"`                                                 // Raw QR code data for QRIS
+	QRImageURL        string             `json:"qr_image_url"`                                            // URL to QR code image
+	PaymentURL        string             `json:"payment_url"`                                             // URL for redirect payment methods
+	Bank              string             `json:"bank,omitempty" gorm:"type:varchar(20)"`                  // Bank of the virtual account for bank transfers
+	VANumber          string             `json:"va_number,omitempty" gorm:"type:varchar(50)"`             // Virtual account number (bill key for Mandiri)
+	BillerCode        string             `json:"biller_code,omitempty" gorm:"type:varchar(20)"`           // Biller code for Mandiri bill payments
+	Details           string             `json:"details"`                                                 // Additional payment details in JSON
+	CashReceived      utils.Money        `json:"cash_received"`                                           // Amount of cash received for cash payments
+	Change            utils.Money        `json:"change"`                                                  // Change amount for cash payments
+	RefundedAmount    utils.Money        `json:"refunded_amount" gorm:"not null;default:0"`               // Total refunded so far; the payment stays success until fully refunded
+	PaymentTime       *time.Time         `json:"payment_time"`                                            // Time when payment was processed
+	ExpiredAt         *time.Time         `json:"expired_at"`                                              // Time when payment will expire (nullable)
+	VerifiedBy        *uint              `json:"verified_by"`                                             // Staff who verified the payment (cashier for cash payments)
+	ShiftID           *uint              `json:"shift_id" gorm:"index"`                                   // Cashier shift the payment was taken in
+	TenderGroup       string             `json:"tender_group,omitempty" gorm:"type:varchar(36);index"`    // Shared by all tenders (cash + QRIS) that settle one bill
+	Denominations     []CashDenomination `json:"denominations,omitempty" gorm:"foreignKey:PaymentID"`
+	CreatedAt         time.Time          `json:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at"`
+}
+
+// ProviderRef mengembalikan referensi transaksi di payment provider.
+// Payment lama (sebelum kolom provider_reference ada) memakai ReferenceID sebagai fallback.
+func (p *Payment) ProviderRef() string {
+	if p.ProviderReference != nil && *p.ProviderReference != "" {
+		return *p.ProviderReference
+	}
+	return p.ReferenceID
+}
+
+// ChargedAmount mengembalikan total yang ditagihkan ke pelanggan (bagian bill + tip)
+func (p *Payment) ChargedAmount() utils.Money {
+	return p.Amount + p.Tip
+}
+
+// RefundedTotal mengembalikan total yang sudah direfund. Payment refunded dari sebelum
+// kolom refunded_amount ada dianggap direfund penuh.
+func (p *Payment) RefundedTotal() utils.Money {
+	if p.Status == "refunded" && p.RefundedAmount == 0 {
+		return p.ChargedAmount()
+	}
+	return p.RefundedAmount
+}
+
+// RefundableAmount mengembalikan sisa tagihan yang masih bisa direfund
+func (p *Payment) RefundableAmount() utils.Money {
+	if p.Status != "success" {
+		return 0
+	}
+	return p.ChargedAmount() - p.RefundedAmount
+}
+
+// CashDenomination adalah rincian pecahan uang yang diserahkan pelanggan untuk pembayaran tunai
+type CashDenomination struct {
+	ID        uint        `json:"id" gorm:"primaryKey"`
+	PaymentID uint        `json:"payment_id" gorm:"not null;index"`
+	Value     utils.Money `json:"value" gorm:"not null"`
+	Quantity  int         `json:"quantity" gorm:"not null"`
+	CreatedAt time.Time   `json:"created_at"`
 }
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// Status print job di antrean
+const (
+	PrintJobStatusPending  = "pending"
+	PrintJobStatusPrinting = "printing"
+	PrintJobStatusPrinted  = "printed"
+	PrintJobStatusFailed   = "failed"
+)
+
+// Jenis dokumen yang dicetak
+const (
+	PrintJobKindReceipt       = "receipt"
+	PrintJobKindKitchenTicket = "kitchen_ticket"
+)
+
+// PrintJob adalah satu dokumen ESC/POS yang menunggu dikirim ke printer jaringan
+type PrintJob struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Kind        string     `gorm:"type:varchar(20);not null;index" json:"kind"`
+	ReferenceID uint       `gorm:"not null;index" json:"reference_id"`        // ID struk atau order
+	Printer     string     `gorm:"type:varchar(100);not null" json:"printer"` // host:port printer (raw TCP 9100)
+	Payload     []byte     `gorm:"type:mediumblob;not null" json:"-"`         // Byte stream ESC/POS
+	Status      string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	LastError   string     `gorm:"type:text" json:"last_error,omitempty"`
+	NextAttempt *time.Time `json:"next_attempt,omitempty"` // Retry berikutnya setelah gagal kirim
+	PrintedAt   *time.Time `json:"printed_at,omitempty"`
+	RequestedBy *uint      `json:"requested_by,omitempty"`
+	CreatedAt   time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"not null" json:"updated_at"`
+}
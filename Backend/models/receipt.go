@@ -1,27 +1,44 @@
 package models
 
-import "time"
+import (
+	"time"
 
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+// Receipt adalah snapshot struk yang dibuat sekali per bill. Cetak ulang selalu dirender
+// dari snapshot ini, tidak dari data menu/order yang mungkin sudah berubah.
 type Receipt struct {
-	ID           uint    `gorm:"primaryKey" json:"id"`
-	OrderID      uint    `json:"order_id"`
-	Order        Order   `gorm:"foreignKey:OrderID" json:"order"`
-	PaymentID    uint    `json:"payment_id"`
-	Payment      Payment `gorm:"foreignKey:PaymentID" json:"payment"`
-	Total        float64 `gorm:"type:decimal(12,2);not null" json:"total"`
-	RoundedTotal float64 `gorm:"type:decimal(12,2);not null" json:"rounded_total"`
+	ID            uint        `gorm:"primaryKey" json:"id"`
+	OrderID       uint        `json:"order_id"`
+	Order         Order       `gorm:"foreignKey:OrderID" json:"order"`
+	PaymentID     uint        `gorm:"uniqueIndex" json:"payment_id"` // Satu struk per payment (bill)
+	Payment       Payment     `gorm:"foreignKey:PaymentID" json:"payment"`
+	Subtotal      utils.Money `gorm:"type:decimal(12,2);not null;default:0" json:"subtotal"`
+	ServiceCharge utils.Money `gorm:"type:decimal(12,2);not null;default:0" json:"service_charge"`
+	Tax           utils.Money `gorm:"type:decimal(12,2);not null;default:0" json:"tax"`
+	Total         utils.Money `gorm:"type:decimal(12,2);not null" json:"total"`
+	RoundedTotal  utils.Money `gorm:"type:decimal(12,2);not null" json:"rounded_total"`
+	TableNumber   string      `gorm:"type:varchar(20)" json:"table_number"`
+	CashierName   string      `gorm:"type:varchar(100)" json:"cashier_name"`
 
 	// Detail Pembayaran
-	PaymentMethod    string  `gorm:"type:varchar(50);not null" json:"payment_method"`
-	AmountPaid       float64 `gorm:"type:decimal(12,2);not null" json:"amount_paid"`
-	Change           float64 `gorm:"type:decimal(12,2);not null" json:"change"`
-	PaymentStatus    string  `gorm:"type:varchar(20);not null" json:"payment_status"`
-	PaymentReference string  `gorm:"type:varchar(100)" json:"payment_reference"`
+	PaymentMethod    string      `gorm:"type:varchar(50);not null" json:"payment_method"`
+	AmountPaid       utils.Money `gorm:"type:decimal(12,2);not null" json:"amount_paid"`
+	Tip              utils.Money `gorm:"type:decimal(12,2);not null;default:0" json:"tip"`
+	Change           utils.Money `gorm:"type:decimal(12,2);not null" json:"change"`
+	PaymentStatus    string      `gorm:"type:varchar(20);not null" json:"payment_status"`
+	PaymentReference string      `gorm:"type:varchar(100)" json:"payment_reference"`
+	PaymentTime      *time.Time  `json:"payment_time"`
 
 	// Items Detail akan disimpan dalam tabel terpisah
 	ReceiptItems []ReceiptItem `gorm:"foreignKey:ReceiptID" json:"receipt_items"`
 
+	// Satu baris per bagian pembayaran (tunai / QRIS) dari bill
+	Tenders []ReceiptTender `gorm:"foreignKey:ReceiptID" json:"tenders"`
+
 	ReceiptNumber string    `json:"receipt_number"`
+	Signature     string    `gorm:"type:varchar(64)" json:"signature,omitempty"` // HMAC nomor, total dan waktu struk, dicetak di QR verifikasi
 	CreatedAt     time.Time `gorm:"not null" json:"created_at"`
 	UpdatedAt     time.Time `gorm:"not null" json:"updated_at"`
 }
@@ -32,12 +49,13 @@ type ReceiptItem struct {
 	Receipt   Receipt `gorm:"-" json:"-"`
 
 	// Item Info
-	MenuID    uint    `gorm:"not null" json:"menu_id"`
-	MenuName  string  `gorm:"type:varchar(100);not null" json:"menu_name"`
-	Quantity  int     `gorm:"not null" json:"quantity"`
-	UnitPrice float64 `gorm:"type:decimal(12,2);not null" json:"unit_price"`
-	Subtotal  float64 `gorm:"type:decimal(12,2);not null" json:"subtotal"`
-	Notes     string  `gorm:"type:text" json:"notes"`
+	MenuID      uint        `gorm:"not null" json:"menu_id"`
+	MenuName    string      `gorm:"type:varchar(100);not null" json:"menu_name"`
+	VariantName string      `gorm:"type:varchar(50)" json:"variant_name,omitempty"`
+	Quantity    int         `gorm:"not null" json:"quantity"`
+	UnitPrice   utils.Money `gorm:"type:decimal(12,2);not null" json:"unit_price"`
+	Subtotal    utils.Money `gorm:"type:decimal(12,2);not null" json:"subtotal"`
+	Notes       string      `gorm:"type:text" json:"notes"`
 
 	// Add-on items akan disimpan dalam tabel terpisah
 	AddOnItems []ReceiptAddOn `gorm:"foreignKey:ReceiptItemID" json:"add_on_items"`
@@ -46,16 +64,42 @@ type ReceiptItem struct {
 	UpdatedAt time.Time `gorm:"not null" json:"updated_at"`
 }
 
+// DisplayName mengembalikan nama item beserta variant-nya, mis. "Es Teh (Large)"
+func (i *ReceiptItem) DisplayName() string {
+	if i.VariantName == "" {
+		return i.MenuName
+	}
+	return i.MenuName + " (" + i.VariantName + ")"
+}
+
 type ReceiptAddOn struct {
 	ID            uint        `gorm:"primaryKey" json:"id"`
 	ReceiptItemID uint        `gorm:"not null" json:"receipt_item_id"`
 	ReceiptItem   ReceiptItem `gorm:"-" json:"-"`
 
-	MenuID   uint    `gorm:"not null" json:"menu_id"`
-	Name     string  `gorm:"type:varchar(100);not null" json:"name"`
-	Quantity int     `gorm:"not null" json:"quantity"`
-	Price    float64 `gorm:"type:decimal(12,2);not null" json:"price"`
+	MenuID   uint        `gorm:"not null" json:"menu_id"`
+	Name     string      `gorm:"type:varchar(100);not null" json:"name"`
+	Quantity int         `gorm:"not null" json:"quantity"`
+	Price    utils.Money `gorm:"type:decimal(12,2);not null" json:"price"`
 
 	CreatedAt time.Time `gorm:"not null" json:"created_at"`
 	UpdatedAt time.Time `gorm:"not null" json:"updated_at"`
 }
+
+// ReceiptTender adalah snapshot satu bagian pembayaran di struk
+type ReceiptTender struct {
+	ID        uint        `gorm:"primaryKey" json:"id"`
+	ReceiptID uint        `gorm:"not null;index" json:"receipt_id"`
+	PaymentID uint        `gorm:"not null" json:"payment_id"`
+	Method    string      `gorm:"type:varchar(20);not null" json:"method"`
+	Amount    utils.Money `gorm:"type:decimal(12,2);not null" json:"amount"`
+	Tip       utils.Money `gorm:"type:decimal(12,2);not null;default:0" json:"tip"`
+	Tendered  utils.Money `gorm:"type:decimal(12,2);not null;default:0" json:"tendered"`
+	Change    utils.Money `gorm:"type:decimal(12,2);not null;default:0" json:"change"`
+	Reference string      `gorm:"type:varchar(100)" json:"reference"`
+
+	// Rincian pecahan tunai tercatat di payment dan tidak pernah berubah
+	Denominations []CashDenomination `gorm:"foreignKey:PaymentID;references:PaymentID" json:"denominations,omitempty"`
+
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+// Status eksekusi job rekonsiliasi
+const (
+	ReconciliationStatusRunning   = "running"
+	ReconciliationStatusCompleted = "completed"
+	ReconciliationStatusFailed    = "failed"
+)
+
+// Jenis selisih yang ditemukan saat rekonsiliasi
+const (
+	DiscrepancyMissingLocally    = "missing_locally"     // transaksi ada di provider, tidak ada payment lokal
+	DiscrepancyMissingAtProvider = "missing_at_provider" // payment lokal tidak dikenal provider
+	DiscrepancyAmountMismatch    = "amount_mismatch"
+	DiscrepancyStatusMismatch    = "status_mismatch"
+)
+
+// ReconciliationRun mencatat satu kali eksekusi rekonsiliasi payment dengan provider
+type ReconciliationRun struct {
+	ID            uint                        `gorm:"primaryKey" json:"id"`
+	Provider      string                      `gorm:"type:varchar(30);not null" json:"provider"`
+	Trigger       string                      `gorm:"type:varchar(20);not null" json:"trigger"` // scheduler atau manual
+	TriggeredBy   *uint                       `json:"triggered_by,omitempty"`
+	Status        string                      `gorm:"type:varchar(20);not null;default:'running';index" json:"status"`
+	LookbackDays  int                         `gorm:"not null" json:"lookback_days"`
+	Checked       int                         `gorm:"not null;default:0" json:"checked"`
+	AutoFixed     int                         `gorm:"not null;default:0" json:"auto_fixed"`
+	Discrepancies int                         `gorm:"not null;default:0" json:"discrepancies"`
+	Error         string                      `gorm:"type:text" json:"error,omitempty"`
+	StartedAt     time.Time                   `json:"started_at"`
+	FinishedAt    *time.Time                  `json:"finished_at"`
+	Items         []ReconciliationDiscrepancy `gorm:"foreignKey:RunID" json:"items,omitempty"`
+	CreatedAt     time.Time                   `json:"created_at"`
+	UpdatedAt     time.Time                   `json:"updated_at"`
+}
+
+// ReconciliationDiscrepancy adalah satu baris laporan selisih dari sebuah run
+type ReconciliationDiscrepancy struct {
+	ID             uint        `gorm:"primaryKey" json:"id"`
+	RunID          uint        `gorm:"not null;index" json:"run_id"`
+	PaymentID      *uint       `gorm:"index" json:"payment_id,omitempty"`
+	OrderID        *uint       `json:"order_id,omitempty"`
+	ProviderRef    string      `gorm:"type:varchar(100);index" json:"provider_ref"`
+	Type           string      `gorm:"type:varchar(30);not null;index" json:"type"`
+	LocalStatus    string      `gorm:"type:varchar(20)" json:"local_status"`
+	ProviderStatus string      `gorm:"type:varchar(20)" json:"provider_status"`
+	LocalAmount    utils.Money `json:"local_amount"`
+	ProviderAmount utils.Money `json:"provider_amount"`
+	AutoFixed      bool        `gorm:"not null;default:false" json:"auto_fixed"`
+	Note           string      `gorm:"type:text" json:"note"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
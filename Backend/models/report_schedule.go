@@ -0,0 +1,31 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// ReportSchedule adalah jadwal email laporan penjualan harian yang diatur admin
+type ReportSchedule struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Name       string    `gorm:"type:varchar(100);not null" json:"name"`
+	Recipients string    `gorm:"type:text;not null" json:"recipients"`      // Email dipisah koma
+	SendTime   string    `gorm:"type:varchar(5);not null" json:"send_time"` // HH:MM, zona waktu restoran
+	Enabled    bool      `gorm:"not null;default:true" json:"enabled"`
+	LastSentOn string    `gorm:"type:varchar(10)" json:"last_sent_on,omitempty"` // Tanggal kirim terakhir (2006-01-02)
+	LastError  string    `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedBy  *uint     `json:"created_by,omitempty"`
+	CreatedAt  time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"not null" json:"updated_at"`
+}
+
+// RecipientList mengembalikan daftar email penerima
+func (r *ReportSchedule) RecipientList() []string {
+	var recipients []string
+	for _, email := range strings.Split(r.Recipients, ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			recipients = append(recipients, email)
+		}
+	}
+	return recipients
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// RestaurantProfileID adalah ID satu-satunya baris profil restoran
+const RestaurantProfileID = 1
+
+// RestaurantProfile adalah identitas restoran yang diatur admin: dipakai di kepala struk,
+// ekspor laporan, URL gambar publik dan detail merchant payment gateway
+type RestaurantProfile struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	Name          string    `gorm:"type:varchar(100);not null" json:"name"`
+	Address       string    `gorm:"type:varchar(255)" json:"address"`
+	Phone         string    `gorm:"type:varchar(30)" json:"phone"`
+	Email         string    `gorm:"type:varchar(100)" json:"email"`
+	TaxID         string    `gorm:"type:varchar(30)" json:"tax_id"`       // NPWP
+	LogoPath      string    `gorm:"type:varchar(255)" json:"logo_path"`   // File PNG/JPG lokal untuk struk
+	FooterText    string    `gorm:"type:varchar(255)" json:"footer_text"` // Dicetak di bawah struk
+	Timezone      string    `gorm:"type:varchar(50);not null;default:'Asia/Jakarta'" json:"timezone"`
+	Currency      string    `gorm:"type:varchar(3);not null;default:'IDR'" json:"currency"`
+	PublicBaseURL string    `gorm:"type:varchar(255)" json:"public_base_url"` // mis. https://resto.example.com
+	UpdatedBy     *uint     `json:"updated_by,omitempty"`
+	CreatedAt     time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"not null" json:"updated_at"`
+}
+
+// Location mengembalikan zona waktu restoran; zona yang tidak dikenal dianggap waktu lokal server
+func (p *RestaurantProfile) Location() *time.Location {
+	if p.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// PublicURL menyusun URL publik untuk path (mis. "/uploads/menu_images/a.jpg")
+func (p *RestaurantProfile) PublicURL(path string) string {
+	return strings.TrimRight(p.PublicBaseURL, "/") + "/" + strings.TrimLeft(path, "/")
+}
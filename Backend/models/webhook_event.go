@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// Status pemrosesan webhook di inbox
+const (
+	WebhookStatusReceived         = "received"
+	WebhookStatusProcessed        = "processed"
+	WebhookStatusDuplicate        = "duplicate"
+	WebhookStatusIgnored          = "ignored"
+	WebhookStatusFailed           = "failed"
+	WebhookStatusInvalidSignature = "invalid_signature"
+)
+
+// WebhookEvent menyimpan setiap notifikasi payment provider apa adanya
+type WebhookEvent struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	Provider          string     `gorm:"type:varchar(30);not null;index" json:"provider"`
+	EventKey          *string    `gorm:"type:varchar(191);uniqueIndex" json:"event_key"` // provider + transaksi + status, unik; kosong untuk duplikat dan signature tidak valid
+	OrderRef          string     `gorm:"type:varchar(100);index" json:"order_ref"`
+	TransactionID     string     `gorm:"type:varchar(100)" json:"transaction_id"`
+	TransactionStatus string     `gorm:"type:varchar(30)" json:"transaction_status"`
+	PaymentID         *uint      `gorm:"index" json:"payment_id,omitempty"`
+	Payload           string     `gorm:"type:text;not null" json:"payload"`
+	SignatureValid    bool       `gorm:"not null;default:false" json:"signature_valid"`
+	ProcessStatus     string     `gorm:"type:varchar(20);not null;default:'received';index" json:"process_status"`
+	ProcessError      string     `gorm:"type:text" json:"process_error,omitempty"`
+	Attempts          int        `gorm:"not null;default:0" json:"attempts"`
+	ProcessedAt       *time.Time `json:"processed_at,omitempty"`
+	CreatedAt         time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt         time.Time  `gorm:"not null" json:"updated_at"`
+}
@@ -51,10 +51,6 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	// Serve static files
 	r.Static("/Frontend", frontendPath)
 
-	// Middleware untuk membatasi akses ke direktori uploads
-	uploadsPath := filepath.Join(workDir, "public", "uploads")
-	r.Static("/uploads", uploadsPath)
-
 	// Root path handler - redirect to login page
 	r.GET("/", func(c *gin.Context) {
 		c.Redirect(http.StatusMovedPermanently, "/Frontend/auth/login/index.html")
@@ -75,6 +71,10 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 		c.Next()
 	})
 
+	// Direktori uploads didaftarkan setelah middleware di atas agar pembatasan tipe file ikut berlaku
+	uploadsPath := filepath.Join(workDir, "public", "uploads")
+	r.Static("/uploads", uploadsPath)
+
 	// Apply security middlewares
 	r.Use(middlewares.SecurityHeaders())
 	r.Use(middlewares.CORSMiddlewares())
@@ -86,11 +86,24 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	customerCtrl := controllers.NewCustomerController(db)
 	categoryCtrl := controllers.NewMenuCategoryController(db)
 	menuCtrl := controllers.NewMenuController(db)
+	availabilityCtrl := controllers.NewAvailabilityController(db)
+	variantCtrl := controllers.NewMenuVariantController(db)
+	inventoryCtrl := controllers.NewInventoryController(db)
 	orderCtrl := controllers.NewOrderController(db)
 	cleanLogCtrl := controllers.NewCleaningLogController(db)
 	notificationCtrl := controllers.NewNotificationController(db)
 	adminCtrl := controllers.NewAdminController(db)
 	receiptCtrl := controllers.NewReceiptController(db)
+	backupCtrl := controllers.NewBackupController(db)
+	webhookCtrl := controllers.NewWebhookController(db)
+	reconciliationCtrl := controllers.NewReconciliationController(db)
+	reportScheduleCtrl := controllers.NewReportScheduleController(db)
+	shiftCtrl := controllers.NewShiftController(db)
+	tenderCtrl := controllers.NewTenderController(db)
+	tipCtrl := controllers.NewTipController(db)
+	printCtrl := controllers.NewPrintController(db)
+	profileCtrl := controllers.NewRestaurantProfileController(db)
+	mediaCtrl := controllers.NewMediaController()
 
 	// Melayani File Statis
 
@@ -119,6 +132,8 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	// Lihat menu
 	r.GET("/menus", menuCtrl.GetAllMenus)
 	r.GET("/menus/by-category", menuCtrl.GetMenuByCategory)
+	r.GET("/menus/search", menuCtrl.SearchMenus)
+	r.GET("/media/*key", mediaCtrl.GetMedia) // Gambar di storage privat (redirect ke URL sementara)
 
 	// Membuat order (Customer tidak perlu login)
 	r.POST("/orders", orderCtrl.CreateOrder)
@@ -128,12 +143,15 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	// Membayar (mis. cash/QRIS) tanpa login (sesuai kebutuhan)
 	r.POST("/payments", controllers.CreatePayment)
 	r.POST("/payments/callback", controllers.HandlePaymentCallback)
+	r.GET("/orders/:order_id/tip-suggestions", tipCtrl.GetTipSuggestions) // Pilihan tip untuk layar pelanggan
+	r.GET("/receipts/verify", receiptCtrl.VerifyReceipt)                  // Cek keaslian struk dari QR
 
 	// Public routes untuk customer
 	r.GET("/tables/:table_id/scan", customerCtrl.ScanTable)           // Scan QR
 	r.GET("/tables/:table_id/session", customerCtrl.GetActiveSession) // Cek sesi aktif
 	r.GET("/tables", tableCtrl.GetAllTables)                          // Get all tables
 	r.GET("/customers", customerCtrl.GetAllCustomers)                 // Get all customers
+	r.PUT("/customers/:customer_id/email", customerCtrl.SetEmail)     // Email untuk struk digital (opsional)
 
 	// ----------------------------------------------------------------
 	//                      AUTHENTICATED ROUTES
@@ -163,10 +181,31 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 
 	// MENUS (staff/admin)
 	auth.GET("/menus", menuCtrl.GetAllMenus) // Get all menus
+	auth.GET("/menus/search", menuCtrl.SearchMenus)
 	auth.POST("/menus", menuCtrl.CreateMenu)
+	auth.POST("/menus/import", menuCtrl.ImportMenus)
+	auth.GET("/menus/export", menuCtrl.ExportMenus)
 	auth.GET("/menus/:menu_id", menuCtrl.GetMenuByID) // detail 1 menu
 	auth.PATCH("/menus/:menu_id", menuCtrl.UpdateMenu)
 	auth.DELETE("/menus/:menu_id", menuCtrl.DeleteMenu)
+	auth.GET("/menus/:menu_id/variants", variantCtrl.GetMenuVariants)
+	auth.POST("/menus/:menu_id/variants", variantCtrl.CreateMenuVariant)
+	auth.PUT("/menu-variants/:variant_id", variantCtrl.UpdateMenuVariant)
+	auth.DELETE("/menu-variants/:variant_id", variantCtrl.DeleteMenuVariant)
+	auth.GET("/menus/:menu_id/recipe", inventoryCtrl.GetMenuRecipe)
+	auth.PUT("/menus/:menu_id/recipe", inventoryCtrl.SetMenuRecipe)
+	auth.GET("/ingredients", inventoryCtrl.GetIngredients)
+	auth.POST("/ingredients", inventoryCtrl.CreateIngredient)
+	auth.PUT("/ingredients/:ingredient_id", inventoryCtrl.UpdateIngredient)
+	auth.DELETE("/ingredients/:ingredient_id", inventoryCtrl.DeleteIngredient)
+	auth.POST("/ingredients/:ingredient_id/adjust", inventoryCtrl.AdjustIngredientStock)
+	auth.GET("/ingredients/:ingredient_id/movements", inventoryCtrl.GetIngredientMovements)
+
+	// JADWAL KETERSEDIAAN MENU/KATEGORI (ubah: Admin)
+	auth.GET("/availability-schedules", availabilityCtrl.GetAvailabilitySchedules)
+	auth.POST("/availability-schedules", availabilityCtrl.CreateAvailabilitySchedule)
+	auth.PUT("/availability-schedules/:schedule_id", availabilityCtrl.UpdateAvailabilitySchedule)
+	auth.DELETE("/availability-schedules/:schedule_id", availabilityCtrl.DeleteAvailabilitySchedule)
 
 	// ORDERS (staff/admin)
 	auth.GET("/orders", orderCtrl.GetAllOrders)            // melihat semua orders
@@ -182,8 +221,37 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	auth.DELETE("/payments/:payment_id", controllers.DeletePayment)
 	auth.POST("/payments/:payment_id/verify", controllers.VerifyPayment)
 	auth.GET("/payments/:payment_id/check", controllers.CheckPaymentStatus)
+	auth.POST("/payments/:payment_id/cancel", controllers.CancelPayment)
+	auth.POST("/payments/:payment_id/refund", controllers.RefundPayment)
+	auth.POST("/payments/:payment_id/simulate", controllers.SimulatePayment) // hanya untuk PAYMENT_PROVIDER=fake
 	auth.GET("/orders/:order_id/check-payment", controllers.CheckOrderPaymentStatus)
 	auth.GET("/payments/config", controllers.GetMidtransConfig)
+	auth.POST("/orders/:order_id/tender", tenderCtrl.TenderOrder)
+
+	// CASHIER SHIFTS (staff/admin, laporan untuk admin)
+	auth.POST("/shifts/open", shiftCtrl.OpenShift)
+	auth.GET("/shifts/current", shiftCtrl.GetCurrentShift)
+	auth.POST("/shifts/:shift_id/movements", shiftCtrl.AddCashMovement)
+	auth.POST("/shifts/:shift_id/close", shiftCtrl.CloseShift)
+	auth.GET("/shifts", shiftCtrl.GetShifts)
+	auth.GET("/shifts/:shift_id/z-report", shiftCtrl.GetShiftZReport)
+	auth.GET("/tips/report", tipCtrl.GetTipReport)
+
+	// WEBHOOK INBOX (Admin)
+	auth.GET("/webhooks", webhookCtrl.GetWebhookEvents)
+	auth.GET("/webhooks/:event_id", webhookCtrl.GetWebhookEventByID)
+	auth.POST("/webhooks/:event_id/reprocess", webhookCtrl.ReprocessWebhookEvent)
+	auth.POST("/reconciliation/run", reconciliationCtrl.RunReconciliation)
+	auth.GET("/reconciliation/runs", reconciliationCtrl.GetReconciliationRuns)
+	auth.GET("/reconciliation/runs/:run_id", reconciliationCtrl.GetReconciliationRunByID)
+	auth.GET("/reconciliation/runs/:run_id/export", reconciliationCtrl.ExportReconciliationRun)
+
+	// LAPORAN PENJUALAN HARIAN VIA EMAIL (Admin)
+	auth.GET("/report-schedules", reportScheduleCtrl.GetReportSchedules)
+	auth.POST("/report-schedules", reportScheduleCtrl.CreateReportSchedule)
+	auth.PUT("/report-schedules/:schedule_id", reportScheduleCtrl.UpdateReportSchedule)
+	auth.DELETE("/report-schedules/:schedule_id", reportScheduleCtrl.DeleteReportSchedule)
+	auth.POST("/report-schedules/:schedule_id/send", reportScheduleCtrl.SendReportSchedule)
 
 	// Routes untuk receipt dengan middleware logger
 	receiptGroup := auth.Group("/payments")
@@ -192,6 +260,13 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 		receiptGroup.POST("/:payment_id/receipt", receiptCtrl.GenerateReceipt)
 	}
 	auth.GET("/receipts/:receipt_id", receiptCtrl.GetReceiptByID)
+	auth.POST("/receipts/:receipt_id/email", receiptCtrl.EmailReceipt)
+
+	// PRINTER THERMAL (ESC/POS lewat antrean cetak)
+	auth.POST("/receipts/:receipt_id/print", printCtrl.PrintReceipt)
+	auth.POST("/orders/:order_id/kitchen-ticket", printCtrl.PrintKitchenTicket)
+	auth.GET("/print-jobs", printCtrl.GetPrintJobs)
+	auth.POST("/print-jobs/:job_id/retry", printCtrl.RetryPrintJob)
 
 	// CLEANING LOGS (Cleaner, staff, admin)
 	auth.GET("/cleaning-logs", cleanLogCtrl.GetAllCleaningLogs)
@@ -231,6 +306,15 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	auth.GET("/reports/export", adminCtrl.ExportData)
 	auth.GET("/reports/export-pdf", adminCtrl.ExportPDF)
 
+	// Backup & restore (Admin)
+	auth.GET("/backup/export", backupCtrl.ExportBackup)
+	auth.POST("/backup/import", backupCtrl.ImportBackup)
+
+	// Profil restoran (ubah: Admin)
+	auth.GET("/restaurant-profile", profileCtrl.GetProfile)
+	auth.PUT("/restaurant-profile", profileCtrl.UpdateProfile)
+	auth.POST("/restaurant-profile/logo", profileCtrl.UploadLogo)
+
 	// WebSocket endpoint dengan middleware khusus
 	wsGroup := r.Group("/ws")
 	wsGroup.Use(middlewares.WebSocketAuthMiddleware())
@@ -0,0 +1,1442 @@
+package services
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BackupFormatVersion adalah versi format arsip backup yang dihasilkan aplikasi ini
+const BackupFormatVersion = 1
+
+// Section yang bisa diekspor / diimpor
+const (
+	BackupSectionCategories = "categories"
+	BackupSectionMenus      = "menus"
+	BackupSectionVariants   = "menu_variants"
+	BackupSectionTables     = "tables"
+	BackupSectionUsers      = "users"
+	BackupSectionCustomers  = "customers"
+	BackupSectionOrders     = "orders"
+	BackupSectionPayments   = "payments"
+	BackupSectionReceipts   = "receipts"
+)
+
+// AllBackupSections berisi semua section dalam urutan dependensinya
+var AllBackupSections = []string{
+	BackupSectionCategories,
+	BackupSectionMenus,
+	BackupSectionVariants,
+	BackupSectionTables,
+	BackupSectionUsers,
+	BackupSectionCustomers,
+	BackupSectionOrders,
+	BackupSectionPayments,
+	BackupSectionReceipts,
+}
+
+// Mode import
+const (
+	// BackupImportModeRemap membuat record baru dengan ID baru (untuk clone ke cabang lain)
+	BackupImportModeRemap = "remap"
+	// BackupImportModePreserve mempertahankan ID asli dan menimpa record yang ada (untuk restore).
+	// Item order, item/add-on/tender struk yang tidak ada di arsip ikut dihapus.
+	BackupImportModePreserve = "preserve"
+)
+
+// BackupArchive adalah isi lengkap file backup
+type BackupArchive struct {
+	Version                int              `json:"version"`
+	ExportedAt             time.Time        `json:"exported_at"`
+	Sections               []string         `json:"sections"`
+	IncludesPasswordHashes bool             `json:"includes_password_hashes"`
+	Categories             []BackupCategory `json:"categories,omitempty"`
+	Menus                  []BackupMenu     `json:"menus,omitempty"`
+	Variants               []BackupVariant  `json:"menu_variants,omitempty"`
+	Tables                 []BackupTable    `json:"tables,omitempty"`
+	Users                  []BackupUser     `json:"users,omitempty"`
+	Customers              []BackupCustomer `json:"customers,omitempty"`
+	Orders                 []BackupOrder    `json:"orders,omitempty"`
+	Payments               []BackupPayment  `json:"payments,omitempty"`
+	Receipts               []BackupReceipt  `json:"receipts,omitempty"`
+}
+
+type BackupCategory struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type BackupMenu struct {
+	ID          uint        `json:"id"`
+	CategoryID  uint        `json:"category_id"`
+	SKU         *string     `json:"sku,omitempty"`
+	Name        string      `json:"name"`
+	Price       utils.Money `json:"price"`
+	Stock       int         `json:"stock"`
+	Description string      `json:"description"`
+	ImageUrls   []string    `json:"image_urls"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+type BackupVariant struct {
+	ID        uint        `json:"id"`
+	MenuID    uint        `json:"menu_id"`
+	Kind      string      `json:"kind"`
+	Name      string      `json:"name"`
+	SKU       *string     `json:"sku,omitempty"`
+	Price     utils.Money `json:"price"`
+	Stock     int         `json:"stock"`
+	SortOrder int         `json:"sort_order"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+	DeletedAt *time.Time  `json:"deleted_at,omitempty"` // Variant yang dihapus tetap disimpan karena masih dirujuk order lama
+}
+
+type BackupTable struct {
+	ID          uint      `json:"id"`
+	TableNumber string    `json:"table_number"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type BackupUser struct {
+	ID           uint      `json:"id"`
+	Name         string    `json:"name"`
+	Email        string    `json:"email"`
+	Role         string    `json:"role"`
+	PasswordHash string    `json:"password_hash,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type BackupCustomer struct {
+	ID        uint      `json:"id"`
+	TableID   *uint     `json:"table_id,omitempty"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type BackupOrder struct {
+	ID                uint              `json:"id"`
+	OrderNumber       string            `json:"order_number,omitempty"`
+	CustomerID        uint              `json:"customer_id"`
+	TableID           uint              `json:"table_id"`
+	Status            string            `json:"status"`
+	TotalAmount       utils.Money       `json:"total_amount"`
+	ChefID            *uint             `json:"chef_id,omitempty"`
+	StartCookingTime  *time.Time        `json:"start_cooking_time,omitempty"`
+	FinishCookingTime *time.Time        `json:"finish_cooking_time,omitempty"`
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+	Items             []BackupOrderItem `json:"items"`
+
+	InventoryDepletedAt *time.Time `json:"inventory_depleted_at,omitempty"`
+}
+
+type BackupOrderItem struct {
+	ID           uint        `json:"id"`
+	MenuID       uint        `json:"menu_id"`
+	VariantID    *uint       `json:"variant_id,omitempty"`
+	VariantName  string      `json:"variant_name,omitempty"`
+	Quantity     int         `json:"quantity"`
+	Price        utils.Money `json:"price"`
+	Notes        string      `json:"notes"`
+	ParentItemID *uint       `json:"parent_item_id,omitempty"`
+	Status       string      `json:"status"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+}
+
+type BackupPayment struct {
+	ID            uint        `json:"id"`
+	OrderID       uint        `json:"order_id"`
+	Amount        utils.Money `json:"amount"`
+	Tip           utils.Money `json:"tip,omitempty"`
+	TipRecipient  *uint       `json:"tip_recipient_id,omitempty"`
+	Status        string      `json:"status"`
+	PaymentMethod string      `json:"payment_method"`
+	PaymentType   string      `json:"payment_type"`
+	ReferenceID   string      `json:"reference_id"`
+	ProviderRef   *string     `json:"provider_reference,omitempty"`
+	Details       string      `json:"details"`
+	CashReceived  utils.Money `json:"cash_received"`
+	Change        utils.Money `json:"change"`
+	Refunded      utils.Money `json:"refunded_amount,omitempty"`
+	PaymentTime   *time.Time  `json:"payment_time,omitempty"`
+	ExpiredAt     *time.Time  `json:"expired_at,omitempty"`
+	VerifiedBy    *uint       `json:"verified_by,omitempty"`
+	ShiftID       *uint       `json:"shift_id,omitempty"` // Shift kasir tidak ikut diarsip, lihat paymentShiftID
+	TenderGroup   string      `json:"tender_group,omitempty"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+
+	Denominations []BackupDenomination `json:"denominations,omitempty"`
+}
+
+type BackupDenomination struct {
+	ID        uint        `json:"id"`
+	Value     utils.Money `json:"value"`
+	Quantity  int         `json:"quantity"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type BackupReceipt struct {
+	ID               uint                  `json:"id"`
+	OrderID          uint                  `json:"order_id"`
+	PaymentID        uint                  `json:"payment_id"`
+	ReceiptNumber    string                `json:"receipt_number"`
+	Subtotal         utils.Money           `json:"subtotal"`
+	ServiceCharge    utils.Money           `json:"service_charge"`
+	Tax              utils.Money           `json:"tax"`
+	Total            utils.Money           `json:"total"`
+	RoundedTotal     utils.Money           `json:"rounded_total"`
+	TableNumber      string                `json:"table_number,omitempty"`
+	CashierName      string                `json:"cashier_name,omitempty"`
+	PaymentMethod    string                `json:"payment_method"`
+	AmountPaid       utils.Money           `json:"amount_paid"`
+	Tip              utils.Money           `json:"tip"`
+	Change           utils.Money           `json:"change"`
+	PaymentStatus    string                `json:"payment_status"`
+	PaymentReference string                `json:"payment_reference"`
+	PaymentTime      *time.Time            `json:"payment_time,omitempty"`
+	CreatedAt        time.Time             `json:"created_at"`
+	UpdatedAt        time.Time             `json:"updated_at"`
+	Items            []BackupReceiptItem   `json:"items"`
+	Tenders          []BackupReceiptTender `json:"tenders,omitempty"`
+}
+
+type BackupReceiptItem struct {
+	ID          uint                 `json:"id"`
+	MenuID      uint                 `json:"menu_id"`
+	MenuName    string               `json:"menu_name"`
+	VariantName string               `json:"variant_name,omitempty"`
+	Quantity    int                  `json:"quantity"`
+	UnitPrice   utils.Money          `json:"unit_price"`
+	Subtotal    utils.Money          `json:"subtotal"`
+	Notes       string               `json:"notes"`
+	AddOns      []BackupReceiptAddOn `json:"add_ons"`
+}
+
+type BackupReceiptAddOn struct {
+	ID       uint        `json:"id"`
+	MenuID   uint        `json:"menu_id"`
+	Name     string      `json:"name"`
+	Quantity int         `json:"quantity"`
+	Price    utils.Money `json:"price"`
+}
+
+type BackupReceiptTender struct {
+	ID        uint        `json:"id"`
+	PaymentID uint        `json:"payment_id"`
+	Method    string      `json:"method"`
+	Amount    utils.Money `json:"amount"`
+	Tip       utils.Money `json:"tip"`
+	Tendered  utils.Money `json:"tendered"`
+	Change    utils.Money `json:"change"`
+	Reference string      `json:"reference,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// BackupExportOptions mengatur isi arsip yang diekspor
+type BackupExportOptions struct {
+	Sections              []string
+	IncludePasswordHashes bool
+}
+
+// BackupImportOptions mengatur perilaku import
+type BackupImportOptions struct {
+	Sections []string
+	Mode     string
+	DryRun   bool
+}
+
+// BackupImportResult adalah laporan hasil import
+type BackupImportResult struct {
+	DryRun   bool                     `json:"dry_run"`
+	Mode     string                   `json:"mode"`
+	Sections []string                 `json:"sections"`
+	Created  map[string]int           `json:"created"`
+	Updated  map[string]int           `json:"updated"`
+	Skipped  map[string]int           `json:"skipped"`
+	IDMap    map[string]map[uint]uint `json:"id_map"`
+	Warnings []string                 `json:"warnings"`
+}
+
+// BackupValidationError berisi daftar referensi yang tidak valid di dalam arsip
+type BackupValidationError struct {
+	Problems []string
+}
+
+func (e *BackupValidationError) Error() string {
+	return fmt.Sprintf("backup archive is invalid: %s", strings.Join(e.Problems, "; "))
+}
+
+// BackupService menangani export dan import data aplikasi
+type BackupService struct {
+	db *gorm.DB
+}
+
+// NewBackupService membuat instance baru BackupService
+func NewBackupService(db *gorm.DB) *BackupService {
+	return &BackupService{db: db}
+}
+
+// NormalizeBackupSections memvalidasi daftar section dan mengurutkannya sesuai dependensi.
+// Daftar kosong berarti semua section.
+func NormalizeBackupSections(sections []string) ([]string, error) {
+	if len(sections) == 0 {
+		return append([]string(nil), AllBackupSections...), nil
+	}
+
+	requested := make(map[string]bool)
+	for _, s := range sections {
+		s = strings.TrimSpace(strings.ToLower(s))
+		if s == "" {
+			continue
+		}
+		if !containsString(AllBackupSections, s) {
+			return nil, fmt.Errorf("unknown backup section: %s", s)
+		}
+		requested[s] = true
+	}
+
+	var ordered []string
+	for _, s := range AllBackupSections {
+		if requested[s] {
+			ordered = append(ordered, s)
+		}
+	}
+	if len(ordered) == 0 {
+		return nil, fmt.Errorf("no backup section selected")
+	}
+	return ordered, nil
+}
+
+// Export membaca data dari database dan menyusunnya menjadi arsip backup
+func (s *BackupService) Export(opts BackupExportOptions) (*BackupArchive, error) {
+	sections, err := NormalizeBackupSections(opts.Sections)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &BackupArchive{
+		Version:                BackupFormatVersion,
+		ExportedAt:             time.Now(),
+		Sections:               sections,
+		IncludesPasswordHashes: opts.IncludePasswordHashes && containsString(sections, BackupSectionUsers),
+	}
+
+	// Semua section dibaca dari satu snapshot agar referensi antar section konsisten
+	// walaupun ada transaksi lain yang berjalan selama export
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, section := range sections {
+			switch section {
+			case BackupSectionCategories:
+				var categories []models.MenuCategory
+				if err := tx.Order("id").Find(&categories).Error; err != nil {
+					return fmt.Errorf("failed to export categories: %w", err)
+				}
+				for _, cat := range categories {
+					archive.Categories = append(archive.Categories, BackupCategory{
+						ID:        cat.ID,
+						Name:      cat.Name,
+						CreatedAt: cat.CreatedAt,
+						UpdatedAt: cat.UpdatedAt,
+					})
+				}
+
+			case BackupSectionMenus:
+				var menus []models.Menu
+				if err := tx.Order("id").Find(&menus).Error; err != nil {
+					return fmt.Errorf("failed to export menus: %w", err)
+				}
+				for _, menu := range menus {
+					archive.Menus = append(archive.Menus, BackupMenu{
+						ID:          menu.ID,
+						CategoryID:  menu.CategoryID,
+						SKU:         menu.SKU,
+						Name:        menu.Name,
+						Price:       menu.Price,
+						Stock:       menu.Stock,
+						Description: menu.Description,
+						ImageUrls:   menu.GetImageUrls(),
+						CreatedAt:   menu.CreatedAt,
+						UpdatedAt:   menu.UpdatedAt,
+					})
+				}
+
+			case BackupSectionVariants:
+				var variants []models.MenuVariant
+				if err := tx.Unscoped().Order("id").Find(&variants).Error; err != nil {
+					return fmt.Errorf("failed to export menu variants: %w", err)
+				}
+				for _, variant := range variants {
+					v := BackupVariant{
+						ID:        variant.ID,
+						MenuID:    variant.MenuID,
+						Kind:      variant.Kind,
+						Name:      variant.Name,
+						SKU:       variant.SKU,
+						Price:     variant.Price,
+						Stock:     variant.Stock,
+						SortOrder: variant.SortOrder,
+						CreatedAt: variant.CreatedAt,
+						UpdatedAt: variant.UpdatedAt,
+					}
+					if variant.DeletedAt.Valid {
+						deletedAt := variant.DeletedAt.Time
+						v.DeletedAt = &deletedAt
+					}
+					archive.Variants = append(archive.Variants, v)
+				}
+
+			case BackupSectionTables:
+				var tables []models.Table
+				if err := tx.Order("id").Find(&tables).Error; err != nil {
+					return fmt.Errorf("failed to export tables: %w", err)
+				}
+				for _, table := range tables {
+					archive.Tables = append(archive.Tables, BackupTable{
+						ID:          table.ID,
+						TableNumber: table.TableNumber,
+						Status:      table.Status,
+						CreatedAt:   table.CreatedAt,
+						UpdatedAt:   table.UpdatedAt,
+					})
+				}
+
+			case BackupSectionUsers:
+				var users []models.User
+				if err := tx.Order("id").Find(&users).Error; err != nil {
+					return fmt.Errorf("failed to export users: %w", err)
+				}
+				for _, user := range users {
+					u := BackupUser{
+						ID:        user.ID,
+						Name:      user.Name,
+						Email:     user.Email,
+						Role:      user.Role,
+						CreatedAt: user.CreatedAt,
+						UpdatedAt: user.UpdatedAt,
+					}
+					if archive.IncludesPasswordHashes {
+						u.PasswordHash = user.Password
+					}
+					archive.Users = append(archive.Users, u)
+				}
+
+			case BackupSectionCustomers:
+				var customers []models.Customer
+				if err := tx.Order("id").Find(&customers).Error; err != nil {
+					return fmt.Errorf("failed to export customers: %w", err)
+				}
+				for _, customer := range customers {
+					archive.Customers = append(archive.Customers, BackupCustomer{
+						ID:        customer.ID,
+						TableID:   customer.TableID,
+						Status:    customer.Status,
+						CreatedAt: customer.CreatedAt,
+						UpdatedAt: customer.UpdatedAt,
+					})
+				}
+
+			case BackupSectionOrders:
+				var orders []models.Order
+				if err := tx.Preload("OrderItems", func(db *gorm.DB) *gorm.DB {
+					return db.Order("id")
+				}).Order("id").Find(&orders).Error; err != nil {
+					return fmt.Errorf("failed to export orders: %w", err)
+				}
+				for _, order := range orders {
+					o := BackupOrder{
+						ID:                order.ID,
+						OrderNumber:       order.OrderNumber,
+						CustomerID:        order.CustomerID,
+						TableID:           order.TableID,
+						Status:            order.Status,
+						TotalAmount:       order.TotalAmount,
+						ChefID:            order.ChefID,
+						StartCookingTime:  order.StartCookingTime,
+						FinishCookingTime: order.FinishCookingTime,
+						CreatedAt:         order.CreatedAt,
+						UpdatedAt:         order.UpdatedAt,
+						Items:             make([]BackupOrderItem, 0, len(order.OrderItems)),
+					}
+					o.InventoryDepletedAt = order.InventoryDepletedAt
+					for _, item := range order.OrderItems {
+						o.Items = append(o.Items, BackupOrderItem{
+							ID:           item.ID,
+							MenuID:       item.MenuID,
+							VariantID:    item.VariantID,
+							VariantName:  item.VariantName,
+							Quantity:     item.Quantity,
+							Price:        item.Price,
+							Notes:        item.Notes,
+							ParentItemID: item.ParentItemID,
+							Status:       item.Status,
+							CreatedAt:    item.CreatedAt,
+							UpdatedAt:    item.UpdatedAt,
+						})
+					}
+					archive.Orders = append(archive.Orders, o)
+				}
+
+			case BackupSectionPayments:
+				var payments []models.Payment
+				if err := tx.Preload("Denominations", func(db *gorm.DB) *gorm.DB {
+					return db.Order("id")
+				}).Order("id").Find(&payments).Error; err != nil {
+					return fmt.Errorf("failed to export payments: %w", err)
+				}
+				for _, payment := range payments {
+					p := BackupPayment{
+						ID:            payment.ID,
+						OrderID:       payment.OrderID,
+						Amount:        payment.Amount,
+						Tip:           payment.Tip,
+						TipRecipient:  payment.TipRecipientID,
+						Status:        payment.Status,
+						PaymentMethod: payment.PaymentMethod,
+						PaymentType:   payment.PaymentType,
+						ReferenceID:   payment.ReferenceID,
+						ProviderRef:   payment.ProviderReference,
+						Details:       payment.Details,
+						CashReceived:  payment.CashReceived,
+						Change:        payment.Change,
+						Refunded:      payment.RefundedAmount,
+						PaymentTime:   payment.PaymentTime,
+						ExpiredAt:     payment.ExpiredAt,
+						VerifiedBy:    payment.VerifiedBy,
+						ShiftID:       payment.ShiftID,
+						TenderGroup:   payment.TenderGroup,
+						CreatedAt:     payment.CreatedAt,
+						UpdatedAt:     payment.UpdatedAt,
+					}
+					for _, d := range payment.Denominations {
+						p.Denominations = append(p.Denominations, BackupDenomination{
+							ID:        d.ID,
+							Value:     d.Value,
+							Quantity:  d.Quantity,
+							CreatedAt: d.CreatedAt,
+						})
+					}
+					archive.Payments = append(archive.Payments, p)
+				}
+
+			case BackupSectionReceipts:
+				var receipts []models.Receipt
+				if err := tx.Preload("ReceiptItems.AddOnItems").Preload("Tenders").Order("id").Find(&receipts).Error; err != nil {
+					return fmt.Errorf("failed to export receipts: %w", err)
+				}
+				for _, receipt := range receipts {
+					r := BackupReceipt{
+						ID:               receipt.ID,
+						OrderID:          receipt.OrderID,
+						PaymentID:        receipt.PaymentID,
+						ReceiptNumber:    receipt.ReceiptNumber,
+						Subtotal:         receipt.Subtotal,
+						ServiceCharge:    receipt.ServiceCharge,
+						Tax:              receipt.Tax,
+						Total:            receipt.Total,
+						RoundedTotal:     receipt.RoundedTotal,
+						TableNumber:      receipt.TableNumber,
+						CashierName:      receipt.CashierName,
+						PaymentMethod:    receipt.PaymentMethod,
+						AmountPaid:       receipt.AmountPaid,
+						Tip:              receipt.Tip,
+						Change:           receipt.Change,
+						PaymentStatus:    receipt.PaymentStatus,
+						PaymentReference: receipt.PaymentReference,
+						PaymentTime:      receipt.PaymentTime,
+						CreatedAt:        receipt.CreatedAt,
+						UpdatedAt:        receipt.UpdatedAt,
+						Items:            make([]BackupReceiptItem, 0, len(receipt.ReceiptItems)),
+					}
+					for _, item := range receipt.ReceiptItems {
+						ri := BackupReceiptItem{
+							ID:          item.ID,
+							MenuID:      item.MenuID,
+							MenuName:    item.MenuName,
+							VariantName: item.VariantName,
+							Quantity:    item.Quantity,
+							UnitPrice:   item.UnitPrice,
+							Subtotal:    item.Subtotal,
+							Notes:       item.Notes,
+							AddOns:      make([]BackupReceiptAddOn, 0, len(item.AddOnItems)),
+						}
+						for _, addOn := range item.AddOnItems {
+							ri.AddOns = append(ri.AddOns, BackupReceiptAddOn{
+								ID:       addOn.ID,
+								MenuID:   addOn.MenuID,
+								Name:     addOn.Name,
+								Quantity: addOn.Quantity,
+								Price:    addOn.Price,
+							})
+						}
+						r.Items = append(r.Items, ri)
+					}
+					for _, tender := range receipt.Tenders {
+						r.Tenders = append(r.Tenders, BackupReceiptTender{
+							ID:        tender.ID,
+							PaymentID: tender.PaymentID,
+							Method:    tender.Method,
+							Amount:    tender.Amount,
+							Tip:       tender.Tip,
+							Tendered:  tender.Tendered,
+							Change:    tender.Change,
+							Reference: tender.Reference,
+							CreatedAt: tender.CreatedAt,
+						})
+					}
+					archive.Receipts = append(archive.Receipts, r)
+				}
+			}
+		}
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+// Import memvalidasi arsip lalu menulis isinya ke database dalam satu transaksi.
+// Pada mode dry run transaksi selalu di-rollback sehingga hanya laporan yang dikembalikan.
+func (s *BackupService) Import(archive *BackupArchive, opts BackupImportOptions) (*BackupImportResult, error) {
+	if archive == nil {
+		return nil, fmt.Errorf("backup archive is empty")
+	}
+	if archive.Version < 1 || archive.Version > BackupFormatVersion {
+		return nil, fmt.Errorf("unsupported backup version %d (supported: 1-%d)", archive.Version, BackupFormatVersion)
+	}
+
+	mode := opts.Mode
+	if mode == "" {
+		mode = BackupImportModeRemap
+	}
+	if mode != BackupImportModeRemap && mode != BackupImportModePreserve {
+		return nil, fmt.Errorf("unknown import mode: %s", mode)
+	}
+
+	sections := opts.Sections
+	if len(sections) == 0 {
+		sections = archive.Sections
+	}
+	sections, err := NormalizeBackupSections(sections)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BackupImportResult{
+		DryRun:   opts.DryRun,
+		Mode:     mode,
+		Sections: sections,
+		Created:  make(map[string]int),
+		Updated:  make(map[string]int),
+		Skipped:  make(map[string]int),
+		IDMap:    make(map[string]map[uint]uint),
+	}
+	for _, section := range sections {
+		result.IDMap[section] = make(map[uint]uint)
+	}
+
+	imp := &backupImporter{
+		db:       s.db,
+		archive:  archive,
+		mode:     mode,
+		sections: sections,
+		result:   result,
+	}
+
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	imp.db = tx
+
+	// Validasi di dalam transaksi agar referensi ke record yang sudah ada (mode preserve)
+	// diperiksa terhadap data yang sama dengan yang ditulis
+	if err := imp.validate(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := imp.run(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if opts.DryRun {
+		tx.Rollback()
+		return result, nil
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return result, nil
+}
+
+// backupImporter menyimpan state selama satu proses import
+type backupImporter struct {
+	db       *gorm.DB
+	archive  *BackupArchive
+	mode     string
+	sections []string
+	result   *BackupImportResult
+}
+
+func (imp *backupImporter) has(section string) bool {
+	return containsString(imp.sections, section)
+}
+
+// validate memeriksa semua referensi antar record sebelum ada data yang ditulis
+func (imp *backupImporter) validate() error {
+	var problems []string
+	a := imp.archive
+
+	ids := func(section string) map[uint]bool {
+		set := make(map[uint]bool)
+		if !imp.has(section) {
+			return set
+		}
+		switch section {
+		case BackupSectionCategories:
+			for _, r := range a.Categories {
+				set[r.ID] = true
+			}
+		case BackupSectionMenus:
+			for _, r := range a.Menus {
+				set[r.ID] = true
+			}
+		case BackupSectionVariants:
+			for _, r := range a.Variants {
+				set[r.ID] = true
+			}
+		case BackupSectionTables:
+			for _, r := range a.Tables {
+				set[r.ID] = true
+			}
+		case BackupSectionUsers:
+			for _, r := range a.Users {
+				set[r.ID] = true
+			}
+		case BackupSectionCustomers:
+			for _, r := range a.Customers {
+				set[r.ID] = true
+			}
+		case BackupSectionOrders:
+			for _, r := range a.Orders {
+				set[r.ID] = true
+			}
+		case BackupSectionPayments:
+			for _, r := range a.Payments {
+				set[r.ID] = true
+			}
+		}
+		return set
+	}
+
+	categoryIDs := ids(BackupSectionCategories)
+	menuIDs := ids(BackupSectionMenus)
+	variantIDs := ids(BackupSectionVariants)
+	tableIDs := ids(BackupSectionTables)
+	userIDs := ids(BackupSectionUsers)
+	customerIDs := ids(BackupSectionCustomers)
+	orderIDs := ids(BackupSectionOrders)
+	paymentIDs := ids(BackupSectionPayments)
+
+	// Pada mode preserve referensi boleh menunjuk ke record yang sudah ada di database.
+	// Pada mode remap semua referensi harus bisa dipetakan dari isi arsip.
+	resolvable := func(set map[uint]bool, model interface{}, id uint) bool {
+		if set[id] {
+			return true
+		}
+		if imp.mode != BackupImportModePreserve {
+			return false
+		}
+		var count int64
+		imp.db.Model(model).Where("id = ?", id).Count(&count)
+		return count > 0
+	}
+
+	if imp.has(BackupSectionMenus) {
+		for _, m := range a.Menus {
+			if !resolvable(categoryIDs, &models.MenuCategory{}, m.CategoryID) {
+				problems = append(problems, fmt.Sprintf("menu %d references unknown category %d", m.ID, m.CategoryID))
+			}
+		}
+	}
+
+	if imp.has(BackupSectionVariants) {
+		for _, v := range a.Variants {
+			if !resolvable(menuIDs, &models.Menu{}, v.MenuID) {
+				problems = append(problems, fmt.Sprintf("menu variant %d references unknown menu %d", v.ID, v.MenuID))
+			}
+		}
+	}
+
+	if imp.has(BackupSectionUsers) {
+		emails := make(map[string]bool)
+		for _, u := range a.Users {
+			email := strings.ToLower(strings.TrimSpace(u.Email))
+			if email == "" {
+				problems = append(problems, fmt.Sprintf("user %d has no email", u.ID))
+				continue
+			}
+			if emails[email] {
+				problems = append(problems, fmt.Sprintf("duplicate user email %s", u.Email))
+			}
+			emails[email] = true
+		}
+	}
+
+	if imp.has(BackupSectionCustomers) {
+		for _, cu := range a.Customers {
+			if cu.TableID != nil && !resolvable(tableIDs, &models.Table{}, *cu.TableID) {
+				problems = append(problems, fmt.Sprintf("customer %d references unknown table %d", cu.ID, *cu.TableID))
+			}
+		}
+	}
+
+	if imp.has(BackupSectionOrders) {
+		for _, o := range a.Orders {
+			if !resolvable(customerIDs, &models.Customer{}, o.CustomerID) {
+				problems = append(problems, fmt.Sprintf("order %d references unknown customer %d", o.ID, o.CustomerID))
+			}
+			if o.TableID != 0 && !resolvable(tableIDs, &models.Table{}, o.TableID) {
+				problems = append(problems, fmt.Sprintf("order %d references unknown table %d", o.ID, o.TableID))
+			}
+			if o.ChefID != nil && !resolvable(userIDs, &models.User{}, *o.ChefID) {
+				problems = append(problems, fmt.Sprintf("order %d references unknown chef %d", o.ID, *o.ChefID))
+			}
+			itemIDs := make(map[uint]bool)
+			for _, item := range o.Items {
+				itemIDs[item.ID] = true
+			}
+			for _, item := range o.Items {
+				if !resolvable(menuIDs, &models.Menu{}, item.MenuID) {
+					problems = append(problems, fmt.Sprintf("order item %d references unknown menu %d", item.ID, item.MenuID))
+				}
+				if item.VariantID != nil && !resolvable(variantIDs, &models.MenuVariant{}, *item.VariantID) {
+					problems = append(problems, fmt.Sprintf("order item %d references unknown menu variant %d", item.ID, *item.VariantID))
+				}
+				if item.ParentItemID != nil && !itemIDs[*item.ParentItemID] {
+					problems = append(problems, fmt.Sprintf("order item %d references parent item %d outside its order", item.ID, *item.ParentItemID))
+				}
+			}
+		}
+	}
+
+	if imp.has(BackupSectionPayments) {
+		for _, p := range a.Payments {
+			if !resolvable(orderIDs, &models.Order{}, p.OrderID) {
+				problems = append(problems, fmt.Sprintf("payment %d references unknown order %d", p.ID, p.OrderID))
+			}
+			if p.VerifiedBy != nil && !resolvable(userIDs, &models.User{}, *p.VerifiedBy) {
+				problems = append(problems, fmt.Sprintf("payment %d references unknown verifier %d", p.ID, *p.VerifiedBy))
+			}
+			if p.TipRecipient != nil && !resolvable(userIDs, &models.User{}, *p.TipRecipient) {
+				problems = append(problems, fmt.Sprintf("payment %d references unknown tip recipient %d", p.ID, *p.TipRecipient))
+			}
+		}
+	}
+
+	if imp.has(BackupSectionReceipts) {
+		for _, r := range a.Receipts {
+			if !resolvable(orderIDs, &models.Order{}, r.OrderID) {
+				problems = append(problems, fmt.Sprintf("receipt %d references unknown order %d", r.ID, r.OrderID))
+			}
+			if !resolvable(paymentIDs, &models.Payment{}, r.PaymentID) {
+				problems = append(problems, fmt.Sprintf("receipt %d references unknown payment %d", r.ID, r.PaymentID))
+			}
+			for _, item := range r.Items {
+				if !resolvable(menuIDs, &models.Menu{}, item.MenuID) {
+					problems = append(problems, fmt.Sprintf("receipt item %d references unknown menu %d", item.ID, item.MenuID))
+				}
+			}
+			for _, tender := range r.Tenders {
+				if !resolvable(paymentIDs, &models.Payment{}, tender.PaymentID) {
+					problems = append(problems, fmt.Sprintf("receipt tender %d references unknown payment %d", tender.ID, tender.PaymentID))
+				}
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &BackupValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// mapID menerjemahkan ID dari arsip ke ID di database tujuan
+func (imp *backupImporter) mapID(section string, id uint) uint {
+	if mapped, ok := imp.result.IDMap[section][id]; ok {
+		return mapped
+	}
+	return id
+}
+
+func (imp *backupImporter) mapIDPtr(section string, id *uint) *uint {
+	if id == nil {
+		return nil
+	}
+	mapped := imp.mapID(section, *id)
+	return &mapped
+}
+
+// save menulis record baru (remap) atau upsert berdasarkan ID asli (preserve)
+func (imp *backupImporter) save(section string, value interface{}, originalID uint, newID func() uint) error {
+	if imp.mode == BackupImportModePreserve {
+		var count int64
+		imp.db.Unscoped().Model(value).Where("id = ?", originalID).Count(&count)
+		if err := imp.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(value).Error; err != nil {
+			return fmt.Errorf("failed to import %s %d: %w", section, originalID, err)
+		}
+		if count > 0 {
+			imp.result.Updated[section]++
+		} else {
+			imp.result.Created[section]++
+		}
+	} else {
+		if err := imp.db.Create(value).Error; err != nil {
+			return fmt.Errorf("failed to import %s %d: %w", section, originalID, err)
+		}
+		imp.result.Created[section]++
+	}
+	imp.result.IDMap[section][originalID] = newID()
+	return nil
+}
+
+// paymentShiftID menentukan shift_id payment yang diimpor. Shift kasir dan cash movement
+// tidak ikut diarsip, jadi shift_id dari arsip hanya dipakai jika shift-nya ada di database
+// tujuan; jika tidak, shift_id yang sudah tersimpan dipertahankan agar expected cash di
+// Z report tidak berubah. Pada mode remap shift tidak bisa dipetakan sehingga dikosongkan.
+func (imp *backupImporter) paymentShiftID(paymentID uint, archived *uint) (*uint, error) {
+	if imp.mode != BackupImportModePreserve {
+		return nil, nil
+	}
+	if archived != nil {
+		var count int64
+		if err := imp.db.Model(&models.CashierShift{}).Where("id = ?", *archived).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to check shift of payment %d: %w", paymentID, err)
+		}
+		if count > 0 {
+			return archived, nil
+		}
+	}
+	var existing models.Payment
+	err := imp.db.Select("id", "shift_id").First(&existing, paymentID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check shift of payment %d: %w", paymentID, err)
+	}
+	return existing.ShiftID, nil
+}
+
+// keepID mengembalikan ID yang dipakai saat insert: ID asli pada mode preserve, 0 pada mode remap
+func (imp *backupImporter) keepID(id uint) uint {
+	if imp.mode == BackupImportModePreserve {
+		return id
+	}
+	return 0
+}
+
+func (imp *backupImporter) run() error {
+	a := imp.archive
+
+	for _, section := range imp.sections {
+		switch section {
+		case BackupSectionCategories:
+			for _, r := range a.Categories {
+				// Nama kategori unik: saat remap, kategori dengan nama sama dipakai ulang
+				if imp.mode == BackupImportModeRemap {
+					var existing models.MenuCategory
+					if err := imp.db.Where("name = ?", r.Name).First(&existing).Error; err == nil {
+						imp.result.IDMap[section][r.ID] = existing.ID
+						imp.result.Skipped[section]++
+						continue
+					}
+				}
+				cat := models.MenuCategory{
+					ID:        imp.keepID(r.ID),
+					Name:      r.Name,
+					CreatedAt: r.CreatedAt,
+					UpdatedAt: r.UpdatedAt,
+				}
+				if err := imp.save(section, &cat, r.ID, func() uint { return cat.ID }); err != nil {
+					return err
+				}
+			}
+
+		case BackupSectionMenus:
+			for _, r := range a.Menus {
+				menu := models.Menu{
+					CategoryID:  imp.mapID(BackupSectionCategories, r.CategoryID),
+					SKU:         r.SKU,
+					Name:        r.Name,
+					Price:       r.Price,
+					Stock:       r.Stock,
+					Description: r.Description,
+				}
+				// Saat remap, SKU yang sudah dipakai menu lain tidak ikut agar tidak bentrok
+				if imp.mode == BackupImportModeRemap && r.SKU != nil {
+					var count int64
+					imp.db.Unscoped().Model(&models.Menu{}).Where("sku = ?", *r.SKU).Count(&count)
+					if count > 0 {
+						menu.SKU = nil
+					}
+				}
+				menu.ID = imp.keepID(r.ID)
+				menu.CreatedAt = r.CreatedAt
+				menu.UpdatedAt = r.UpdatedAt
+				urls := r.ImageUrls
+				if urls == nil {
+					urls = []string{}
+				}
+				if err := menu.SetImageUrls(urls); err != nil {
+					return fmt.Errorf("failed to import menu %d: %w", r.ID, err)
+				}
+				if err := imp.save(section, &menu, r.ID, func() uint { return menu.ID }); err != nil {
+					return err
+				}
+			}
+
+		case BackupSectionVariants:
+			for _, r := range a.Variants {
+				variant := models.MenuVariant{
+					ID:        imp.keepID(r.ID),
+					MenuID:    imp.mapID(BackupSectionMenus, r.MenuID),
+					Kind:      r.Kind,
+					Name:      r.Name,
+					SKU:       r.SKU,
+					Price:     r.Price,
+					Stock:     r.Stock,
+					SortOrder: r.SortOrder,
+					CreatedAt: r.CreatedAt,
+					UpdatedAt: r.UpdatedAt,
+				}
+				if r.DeletedAt != nil {
+					variant.DeletedAt = gorm.DeletedAt{Time: *r.DeletedAt, Valid: true}
+				}
+				// Sama seperti menu: SKU yang sudah dipakai variant lain tidak ikut saat remap
+				if imp.mode == BackupImportModeRemap && r.SKU != nil {
+					var count int64
+					imp.db.Unscoped().Model(&models.MenuVariant{}).Where("sku = ?", *r.SKU).Count(&count)
+					if count > 0 {
+						variant.SKU = nil
+					}
+				}
+				if err := imp.save(section, &variant, r.ID, func() uint { return variant.ID }); err != nil {
+					return err
+				}
+			}
+
+		case BackupSectionTables:
+			for _, r := range a.Tables {
+				if imp.mode == BackupImportModeRemap {
+					var existing models.Table
+					if err := imp.db.Where("table_number = ?", r.TableNumber).First(&existing).Error; err == nil {
+						imp.result.IDMap[section][r.ID] = existing.ID
+						imp.result.Skipped[section]++
+						continue
+					}
+				}
+				table := models.Table{
+					ID:          imp.keepID(r.ID),
+					TableNumber: r.TableNumber,
+					Status:      r.Status,
+					CreatedAt:   r.CreatedAt,
+					UpdatedAt:   r.UpdatedAt,
+				}
+				if imp.mode == BackupImportModeRemap {
+					// Meja hasil clone selalu dimulai dalam keadaan kosong
+					table.Status = "available"
+				}
+				if err := imp.save(section, &table, r.ID, func() uint { return table.ID }); err != nil {
+					return err
+				}
+			}
+
+		case BackupSectionUsers:
+			for _, r := range a.Users {
+				var existing models.User
+				err := imp.db.Where("email = ?", r.Email).First(&existing).Error
+				if err == nil && (imp.mode == BackupImportModeRemap || existing.ID != r.ID) {
+					// Email unik: pakai user yang sudah ada daripada membuat duplikat
+					imp.result.IDMap[section][r.ID] = existing.ID
+					imp.result.Skipped[section]++
+					continue
+				}
+
+				passwordHash := r.PasswordHash
+				if passwordHash == "" {
+					if err == nil {
+						passwordHash = existing.Password
+					} else {
+						hash, hashErr := unusablePasswordHash()
+						if hashErr != nil {
+							return hashErr
+						}
+						passwordHash = hash
+						imp.result.Warnings = append(imp.result.Warnings,
+							fmt.Sprintf("user %s imported without password hash and must reset its password", r.Email))
+					}
+				}
+
+				user := models.User{
+					ID:        imp.keepID(r.ID),
+					Name:      r.Name,
+					Email:     r.Email,
+					Password:  passwordHash,
+					Role:      r.Role,
+					CreatedAt: r.CreatedAt,
+					UpdatedAt: r.UpdatedAt,
+				}
+				if err := imp.save(section, &user, r.ID, func() uint { return user.ID }); err != nil {
+					return err
+				}
+			}
+
+		case BackupSectionCustomers:
+			for _, r := range a.Customers {
+				customer := models.Customer{
+					ID:        imp.keepID(r.ID),
+					TableID:   imp.mapIDPtr(BackupSectionTables, r.TableID),
+					Status:    r.Status,
+					CreatedAt: r.CreatedAt,
+					UpdatedAt: r.UpdatedAt,
+				}
+				if err := imp.save(section, &customer, r.ID, func() uint { return customer.ID }); err != nil {
+					return err
+				}
+			}
+
+		case BackupSectionOrders:
+			for _, r := range a.Orders {
+				order := models.Order{
+					ID:                imp.keepID(r.ID),
+					OrderNumber:       r.OrderNumber,
+					CustomerID:        imp.mapID(BackupSectionCustomers, r.CustomerID),
+					TableID:           imp.mapID(BackupSectionTables, r.TableID),
+					Status:            r.Status,
+					TotalAmount:       r.TotalAmount,
+					ChefID:            imp.mapIDPtr(BackupSectionUsers, r.ChefID),
+					StartCookingTime:  r.StartCookingTime,
+					FinishCookingTime: r.FinishCookingTime,
+					CreatedAt:         r.CreatedAt,
+					UpdatedAt:         r.UpdatedAt,
+				}
+				// Order yang dipulihkan adalah riwayat dan tidak boleh mengurangi stok bahan lagi;
+				// arsip lama tanpa field ini ditandai dengan waktu restore
+				order.InventoryDepletedAt = r.InventoryDepletedAt
+				if order.InventoryDepletedAt == nil {
+					now := time.Now()
+					order.InventoryDepletedAt = &now
+				}
+				if err := imp.save(section, &order, r.ID, func() uint { return order.ID }); err != nil {
+					return err
+				}
+
+				// Item induk harus dibuat lebih dulu agar ParentItemID bisa dipetakan
+				itemIDs := make(map[uint]uint)
+				pending := append([]BackupOrderItem(nil), r.Items...)
+				for len(pending) > 0 {
+					var next []BackupOrderItem
+					for _, item := range pending {
+						var parentID *uint
+						if item.ParentItemID != nil {
+							mapped, ok := itemIDs[*item.ParentItemID]
+							if !ok {
+								next = append(next, item)
+								continue
+							}
+							parentID = &mapped
+						}
+						orderItem := models.OrderItem{
+							ID:           imp.keepID(item.ID),
+							OrderID:      order.ID,
+							MenuID:       imp.mapID(BackupSectionMenus, item.MenuID),
+							VariantID:    imp.mapIDPtr(BackupSectionVariants, item.VariantID),
+							VariantName:  item.VariantName,
+							Quantity:     item.Quantity,
+							Price:        item.Price,
+							Notes:        item.Notes,
+							ParentItemID: parentID,
+							Status:       item.Status,
+							CreatedAt:    item.CreatedAt,
+							UpdatedAt:    item.UpdatedAt,
+						}
+						if err := imp.saveChild(&orderItem, "order item", item.ID); err != nil {
+							return err
+						}
+						itemIDs[item.ID] = orderItem.ID
+					}
+					if len(next) == len(pending) {
+						return fmt.Errorf("order %d has circular add-on references", r.ID)
+					}
+					pending = next
+				}
+				keep := make([]uint, 0, len(itemIDs))
+				for _, id := range itemIDs {
+					keep = append(keep, id)
+				}
+				if err := imp.pruneChildren(&models.OrderItem{}, "order_id", order.ID, keep, "order items"); err != nil {
+					return err
+				}
+			}
+
+		case BackupSectionPayments:
+			// Grup tender hasil clone diberi UUID baru agar tidak tergabung dengan split bill aslinya
+			tenderGroups := make(map[string]string)
+			for _, r := range a.Payments {
+				payment := models.Payment{
+					ID:                imp.keepID(r.ID),
+					OrderID:           imp.mapID(BackupSectionOrders, r.OrderID),
+					Amount:            r.Amount,
+					Tip:               r.Tip,
+					TipRecipientID:    imp.mapIDPtr(BackupSectionUsers, r.TipRecipient),
+					Status:            r.Status,
+					PaymentMethod:     r.PaymentMethod,
+					PaymentType:       r.PaymentType,
+					ReferenceID:       r.ReferenceID,
+					ProviderReference: r.ProviderRef,
+					Details:           r.Details,
+					CashReceived:      r.CashReceived,
+					Change:            r.Change,
+					RefundedAmount:    r.Refunded,
+					PaymentTime:       r.PaymentTime,
+					ExpiredAt:         r.ExpiredAt,
+					VerifiedBy:        imp.mapIDPtr(BackupSectionUsers, r.VerifiedBy),
+					TenderGroup:       r.TenderGroup,
+					CreatedAt:         r.CreatedAt,
+					UpdatedAt:         r.UpdatedAt,
+				}
+				if imp.mode == BackupImportModeRemap {
+					// provider_reference unik: salinan hasil clone tidak boleh menerima callback provider
+					payment.ProviderReference = nil
+					if r.TenderGroup != "" {
+						if _, ok := tenderGroups[r.TenderGroup]; !ok {
+							tenderGroups[r.TenderGroup] = uuid.New().String()
+						}
+						payment.TenderGroup = tenderGroups[r.TenderGroup]
+					}
+				}
+				shiftID, err := imp.paymentShiftID(r.ID, r.ShiftID)
+				if err != nil {
+					return err
+				}
+				payment.ShiftID = shiftID
+				if err := imp.save(section, &payment, r.ID, func() uint { return payment.ID }); err != nil {
+					return err
+				}
+
+				denominationIDs := make([]uint, 0, len(r.Denominations))
+				for _, d := range r.Denominations {
+					denomination := models.CashDenomination{
+						ID:        imp.keepID(d.ID),
+						PaymentID: payment.ID,
+						Value:     d.Value,
+						Quantity:  d.Quantity,
+						CreatedAt: d.CreatedAt,
+					}
+					if err := imp.saveChild(&denomination, "cash denomination", d.ID); err != nil {
+						return err
+					}
+					denominationIDs = append(denominationIDs, denomination.ID)
+				}
+				if err := imp.pruneChildren(&models.CashDenomination{}, "payment_id", payment.ID, denominationIDs, "cash denominations"); err != nil {
+					return err
+				}
+			}
+
+		case BackupSectionReceipts:
+			for _, r := range a.Receipts {
+				receipt := models.Receipt{
+					ID:               imp.keepID(r.ID),
+					OrderID:          imp.mapID(BackupSectionOrders, r.OrderID),
+					PaymentID:        imp.mapID(BackupSectionPayments, r.PaymentID),
+					ReceiptNumber:    r.ReceiptNumber,
+					Subtotal:         r.Subtotal,
+					ServiceCharge:    r.ServiceCharge,
+					Tax:              r.Tax,
+					Total:            r.Total,
+					RoundedTotal:     r.RoundedTotal,
+					TableNumber:      r.TableNumber,
+					CashierName:      r.CashierName,
+					PaymentMethod:    r.PaymentMethod,
+					AmountPaid:       r.AmountPaid,
+					Tip:              r.Tip,
+					Change:           r.Change,
+					PaymentStatus:    r.PaymentStatus,
+					PaymentReference: r.PaymentReference,
+					PaymentTime:      r.PaymentTime,
+					CreatedAt:        r.CreatedAt,
+					UpdatedAt:        r.UpdatedAt,
+				}
+				if err := imp.save(section, &receipt, r.ID, func() uint { return receipt.ID }); err != nil {
+					return err
+				}
+
+				itemIDs := make([]uint, 0, len(r.Items))
+				for _, item := range r.Items {
+					receiptItem := models.ReceiptItem{
+						ID:          imp.keepID(item.ID),
+						ReceiptID:   receipt.ID,
+						MenuID:      imp.mapID(BackupSectionMenus, item.MenuID),
+						MenuName:    item.MenuName,
+						VariantName: item.VariantName,
+						Quantity:    item.Quantity,
+						UnitPrice:   item.UnitPrice,
+						Subtotal:    item.Subtotal,
+						Notes:       item.Notes,
+					}
+					if err := imp.saveChild(&receiptItem, "receipt item", item.ID); err != nil {
+						return err
+					}
+					itemIDs = append(itemIDs, receiptItem.ID)
+					addOnIDs := make([]uint, 0, len(item.AddOns))
+					for _, addOn := range item.AddOns {
+						receiptAddOn := models.ReceiptAddOn{
+							ID:            imp.keepID(addOn.ID),
+							ReceiptItemID: receiptItem.ID,
+							MenuID:        imp.mapID(BackupSectionMenus, addOn.MenuID),
+							Name:          addOn.Name,
+							Quantity:      addOn.Quantity,
+							Price:         addOn.Price,
+						}
+						if err := imp.saveChild(&receiptAddOn, "receipt add-on", addOn.ID); err != nil {
+							return err
+						}
+						addOnIDs = append(addOnIDs, receiptAddOn.ID)
+					}
+					if err := imp.pruneChildren(&models.ReceiptAddOn{}, "receipt_item_id", receiptItem.ID, addOnIDs, "receipt add-ons"); err != nil {
+						return err
+					}
+				}
+				if err := imp.pruneReceiptItems(receipt.ID, itemIDs); err != nil {
+					return err
+				}
+				tenderIDs := make([]uint, 0, len(r.Tenders))
+				for _, tender := range r.Tenders {
+					receiptTender := models.ReceiptTender{
+						ID:        imp.keepID(tender.ID),
+						ReceiptID: receipt.ID,
+						PaymentID: imp.mapID(BackupSectionPayments, tender.PaymentID),
+						Method:    tender.Method,
+						Amount:    tender.Amount,
+						Tip:       tender.Tip,
+						Tendered:  tender.Tendered,
+						Change:    tender.Change,
+						Reference: tender.Reference,
+						CreatedAt: tender.CreatedAt,
+					}
+					if err := imp.saveChild(&receiptTender, "receipt tender", tender.ID); err != nil {
+						return err
+					}
+					tenderIDs = append(tenderIDs, receiptTender.ID)
+				}
+				if err := imp.pruneChildren(&models.ReceiptTender{}, "receipt_id", receipt.ID, tenderIDs, "receipt tenders"); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// saveChild menulis record anak (item order/struk) tanpa mencatatnya di laporan per section
+func (imp *backupImporter) saveChild(value interface{}, label string, originalID uint) error {
+	query := imp.db
+	if imp.mode == BackupImportModePreserve {
+		query = query.Clauses(clause.OnConflict{UpdateAll: true})
+	}
+	if err := query.Create(value).Error; err != nil {
+		return fmt.Errorf("failed to import %s %d: %w", label, originalID, err)
+	}
+	return nil
+}
+
+// pruneChildren menghapus record anak milik parentID yang tidak ada di arsip. Hanya berlaku
+// pada mode preserve: restore harus menghasilkan isi yang sama persis dengan arsip,
+// sedangkan pada mode remap parent selalu baru sehingga tidak punya anak lama.
+func (imp *backupImporter) pruneChildren(model interface{}, column string, parentID uint, keep []uint, label string) error {
+	if imp.mode != BackupImportModePreserve {
+		return nil
+	}
+	query := imp.db.Where(column+" = ?", parentID)
+	if len(keep) > 0 {
+		query = query.Where("id NOT IN ?", keep)
+	}
+	if err := query.Delete(model).Error; err != nil {
+		return fmt.Errorf("failed to remove stale %s: %w", label, err)
+	}
+	return nil
+}
+
+// pruneReceiptItems menghapus item struk lama (beserta add-on-nya) yang tidak ada di arsip
+func (imp *backupImporter) pruneReceiptItems(receiptID uint, keep []uint) error {
+	if imp.mode != BackupImportModePreserve {
+		return nil
+	}
+	query := imp.db.Model(&models.ReceiptItem{}).Where("receipt_id = ?", receiptID)
+	if len(keep) > 0 {
+		query = query.Where("id NOT IN ?", keep)
+	}
+	var stale []uint
+	if err := query.Pluck("id", &stale).Error; err != nil {
+		return fmt.Errorf("failed to find stale receipt items: %w", err)
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	if err := imp.db.Where("receipt_item_id IN ?", stale).Delete(&models.ReceiptAddOn{}).Error; err != nil {
+		return fmt.Errorf("failed to remove stale receipt add-ons: %w", err)
+	}
+	if err := imp.db.Where("id IN ?", stale).Delete(&models.ReceiptItem{}).Error; err != nil {
+		return fmt.Errorf("failed to remove stale receipt items: %w", err)
+	}
+	return nil
+}
+
+// unusablePasswordHash membuat hash dari password acak sehingga user wajib reset password
+func unusablePasswordHash() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate placeholder password: %w", err)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(buf)), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash placeholder password: %w", err)
+	}
+	return string(hash), nil
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
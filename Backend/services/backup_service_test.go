@@ -0,0 +1,316 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+func newBackupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, _ := newShiftTestDB(t)
+	if err := db.AutoMigrate(&models.MenuCategory{}, &models.Menu{}, &models.MenuVariant{}, &models.Table{}, &models.OrderItem{}, &models.CashDenomination{}); err != nil {
+		t.Fatalf("failed to migrate menu tables: %v", err)
+	}
+	// CreateTable, bukan AutoMigrate: AutoMigrate ikut memigrasi models.Payment (tag enum MySQL)
+	if err := db.Migrator().CreateTable(&models.Receipt{}, &models.ReceiptItem{}, &models.ReceiptAddOn{}, &models.ReceiptTender{}); err != nil {
+		t.Fatalf("failed to create receipt tables: %v", err)
+	}
+	return db
+}
+
+// seedBackupData membuat satu order lengkap: item dengan add-on, payment dan struk
+func seedBackupData(t *testing.T, db *gorm.DB) (models.Order, models.Receipt) {
+	t.Helper()
+
+	category := models.MenuCategory{Name: "Makanan"}
+	db.Create(&category)
+	nasi := models.Menu{CategoryID: category.ID, Name: "Nasi Goreng", Price: utils.Rupiah(25000), Stock: 10}
+	telur := models.Menu{CategoryID: category.ID, Name: "Telur Ceplok", Price: utils.Rupiah(5000), Stock: 10}
+	db.Create(&nasi)
+	db.Create(&telur)
+	jumbo := models.MenuVariant{MenuID: nasi.ID, Kind: "portion", Name: "Jumbo", Price: utils.Rupiah(30000), Stock: 5}
+	db.Create(&jumbo)
+	table := models.Table{TableNumber: "A1", Status: "occupied"}
+	db.Create(&table)
+	customer := models.Customer{TableID: &table.ID, Status: "active"}
+	db.Create(&customer)
+
+	order := models.Order{CustomerID: customer.ID, TableID: table.ID, Status: OrderStatusPaid, TotalAmount: utils.Rupiah(30000)}
+	db.Create(&order)
+	nasiItem := models.OrderItem{OrderID: order.ID, MenuID: nasi.ID, VariantID: &jumbo.ID, VariantName: jumbo.Name, Quantity: 1, Price: utils.Rupiah(25000)}
+	db.Create(&nasiItem)
+	db.Create(&models.OrderItem{OrderID: order.ID, MenuID: telur.ID, Quantity: 1, Price: utils.Rupiah(5000), ParentItemID: &nasiItem.ID})
+
+	var waiter models.User
+	db.First(&waiter)
+	shift := models.CashierShift{CashierID: waiter.ID, OpenedAt: time.Now()}
+	db.Create(&shift)
+	paidAt := time.Now()
+	payment := models.Payment{OrderID: order.ID, Amount: utils.Rupiah(30000), Tip: utils.Rupiah(5000), TipRecipientID: &waiter.ID,
+		Status: PaymentStatusSuccess, PaymentMethod: "cash", PaymentTime: &paidAt, ShiftID: &shift.ID,
+		TenderGroup: "0b6f3c1e-5d2a-4c8e-9f11-2a7d4e6b8c90"}
+	db.Create(&payment)
+	db.Create(&models.CashDenomination{PaymentID: payment.ID, Value: utils.Rupiah(20000), Quantity: 1})
+	db.Create(&models.CashDenomination{PaymentID: payment.ID, Value: utils.Rupiah(10000), Quantity: 1})
+
+	receipt := models.Receipt{OrderID: order.ID, PaymentID: payment.ID, ReceiptNumber: "RCP/20261018/000001",
+		Subtotal: utils.Rupiah(30000), Total: utils.Rupiah(30000), RoundedTotal: utils.Rupiah(30000), AmountPaid: utils.Rupiah(30000)}
+	db.Create(&receipt)
+	receiptItem := models.ReceiptItem{ReceiptID: receipt.ID, MenuID: nasi.ID, MenuName: "Nasi Goreng", Quantity: 1,
+		UnitPrice: utils.Rupiah(25000), Subtotal: utils.Rupiah(30000)}
+	db.Create(&receiptItem)
+	db.Create(&models.ReceiptAddOn{ReceiptItemID: receiptItem.ID, MenuID: telur.ID, Name: "Telur Ceplok", Quantity: 1, Price: utils.Rupiah(5000)})
+	db.Create(&models.ReceiptTender{ReceiptID: receipt.ID, PaymentID: payment.ID, Method: "cash", Amount: utils.Rupiah(30000)})
+	return order, receipt
+}
+
+func countRows(t *testing.T, db *gorm.DB, model interface{}) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(model).Count(&count).Error; err != nil {
+		t.Fatalf("failed to count rows: %v", err)
+	}
+	return count
+}
+
+func TestBackupService_RoundTrip(t *testing.T) {
+	source := newBackupTestDB(t)
+	order, receipt := seedBackupData(t, source)
+
+	archive, err := NewBackupService(source).Export(BackupExportOptions{})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if len(archive.Orders) != 1 || len(archive.Orders[0].Items) != 2 || len(archive.Receipts) != 1 ||
+		len(archive.Receipts[0].Items) != 1 || len(archive.Receipts[0].Items[0].AddOns) != 1 || len(archive.Receipts[0].Tenders) != 1 {
+		t.Fatalf("Export() archive = %+v, want one order with two items and one receipt", archive)
+	}
+
+	tests := []struct {
+		name   string
+		mode   string
+		dryRun bool
+		// prepare mengisi database tujuan sebelum import
+		prepare func(t *testing.T) *gorm.DB
+		check   func(t *testing.T, db *gorm.DB, result *BackupImportResult)
+	}{
+		{
+			name: "remap into a branch with existing data",
+			mode: BackupImportModeRemap,
+			prepare: func(t *testing.T) *gorm.DB {
+				db := newBackupTestDB(t)
+				// Data yang sudah ada menggeser ID sehingga semua referensi harus dipetakan
+				db.Create(&models.MenuCategory{Name: "Minuman"})
+				db.Create(&models.Table{TableNumber: "B9"})
+				db.Create(&models.Customer{Status: "inactive"})
+				db.Create(&models.Order{CustomerID: 1, Status: OrderStatusCompleted})
+				return db
+			},
+			check: func(t *testing.T, db *gorm.DB, result *BackupImportResult) {
+				newOrderID := result.IDMap[BackupSectionOrders][order.ID]
+				if newOrderID == 0 || newOrderID == order.ID {
+					t.Fatalf("order id map = %v, want a new id", result.IDMap[BackupSectionOrders])
+				}
+				var imported models.Order
+				if err := db.Preload("OrderItems").First(&imported, newOrderID).Error; err != nil {
+					t.Fatalf("imported order not found: %v", err)
+				}
+				if imported.CustomerID != result.IDMap[BackupSectionCustomers][order.CustomerID] ||
+					imported.TableID != result.IDMap[BackupSectionTables][order.TableID] {
+					t.Errorf("imported order customer/table = %d/%d, want remapped ids", imported.CustomerID, imported.TableID)
+				}
+				if len(imported.OrderItems) != 2 {
+					t.Fatalf("imported order items = %d, want 2", len(imported.OrderItems))
+				}
+				var parent, addOn models.OrderItem
+				for _, item := range imported.OrderItems {
+					if item.ParentItemID == nil {
+						parent = item
+					} else {
+						addOn = item
+					}
+				}
+				if addOn.ParentItemID == nil || *addOn.ParentItemID != parent.ID {
+					t.Errorf("add-on parent = %v, want %d", addOn.ParentItemID, parent.ID)
+				}
+				var variant models.MenuVariant
+				if parent.VariantID == nil || parent.VariantName != "Jumbo" || db.First(&variant, *parent.VariantID).Error != nil ||
+					variant.MenuID != parent.MenuID || variant.Price != utils.Rupiah(30000) {
+					t.Errorf("imported item variant = %v %q, want the remapped Jumbo variant of its menu", parent.VariantID, parent.VariantName)
+				}
+
+				var importedReceipt models.Receipt
+				if err := db.Preload("Tenders").Where("order_id = ?", newOrderID).First(&importedReceipt).Error; err != nil {
+					t.Fatalf("imported receipt not found: %v", err)
+				}
+				newPaymentID := result.IDMap[BackupSectionPayments][receipt.PaymentID]
+				if importedReceipt.PaymentID != newPaymentID || len(importedReceipt.Tenders) != 1 || importedReceipt.Tenders[0].PaymentID != newPaymentID {
+					t.Errorf("imported receipt payment = %d, tenders %+v, want payment %d", importedReceipt.PaymentID, importedReceipt.Tenders, newPaymentID)
+				}
+				var payment models.Payment
+				db.First(&payment, newPaymentID)
+				wantRecipient := result.IDMap[BackupSectionUsers][*archive.Payments[0].TipRecipient]
+				if payment.Tip != utils.Rupiah(5000) || payment.TipRecipientID == nil || *payment.TipRecipientID != wantRecipient {
+					t.Errorf("imported tip = %s to %v, want 5000 to the mapped waiter", payment.Tip, payment.TipRecipientID)
+				}
+				// Shift kasir tidak ikut diarsip, jadi salinan clone tidak menempel ke shift mana pun
+				if payment.ShiftID != nil {
+					t.Errorf("cloned payment shift = %d, want none", *payment.ShiftID)
+				}
+				if payment.TenderGroup == "" || payment.TenderGroup == archive.Payments[0].TenderGroup {
+					t.Errorf("cloned tender group = %q, want a new group", payment.TenderGroup)
+				}
+				var denominations int64
+				db.Model(&models.CashDenomination{}).Where("payment_id = ?", newPaymentID).Count(&denominations)
+				if denominations != 2 {
+					t.Errorf("cloned cash denominations = %d, want 2", denominations)
+				}
+				var table models.Table
+				db.First(&table, imported.TableID)
+				if table.Status != "available" {
+					t.Errorf("cloned table status = %q, want available", table.Status)
+				}
+			},
+		},
+		{
+			name:    "dry run writes nothing",
+			mode:    BackupImportModeRemap,
+			dryRun:  true,
+			prepare: newBackupTestDB,
+			check: func(t *testing.T, db *gorm.DB, result *BackupImportResult) {
+				if result.Created[BackupSectionOrders] != 1 || result.Created[BackupSectionReceipts] != 1 {
+					t.Errorf("dry run created = %v, want one order and one receipt", result.Created)
+				}
+				for _, model := range []interface{}{&models.Menu{}, &models.Order{}, &models.OrderItem{}, &models.Payment{}, &models.Receipt{}} {
+					if n := countRows(t, db, model); n != 0 {
+						t.Errorf("dry run left %d rows of %T, want 0", n, model)
+					}
+				}
+			},
+		},
+		{
+			name: "preserve restores the archive over changed data",
+			mode: BackupImportModePreserve,
+			prepare: func(t *testing.T) *gorm.DB {
+				db := newBackupTestDB(t)
+				seedBackupData(t, db)
+				// Perubahan setelah backup: harga diubah, item dan tender baru ditambahkan
+				db.Model(&models.Order{}).Where("id = ?", order.ID).Update("total_amount", utils.Rupiah(99000))
+				db.Create(&models.OrderItem{OrderID: order.ID, MenuID: 1, Quantity: 3, Price: utils.Rupiah(25000)})
+				var item models.ReceiptItem
+				db.Where("receipt_id = ?", receipt.ID).First(&item)
+				db.Create(&models.ReceiptAddOn{ReceiptItemID: item.ID, MenuID: 2, Name: "Telur Dadar", Quantity: 1, Price: utils.Rupiah(6000)})
+				db.Create(&models.ReceiptItem{ReceiptID: receipt.ID, MenuID: 1, MenuName: "Nasi Goreng", Quantity: 1})
+				db.Create(&models.ReceiptTender{ReceiptID: receipt.ID, PaymentID: receipt.PaymentID, Method: "qris", Amount: utils.Rupiah(1000)})
+				db.Model(&models.OrderItem{}).Where("variant_id IS NOT NULL").Update("variant_id", nil)
+				db.Model(&models.Payment{}).Where("id = ?", receipt.PaymentID).
+					Updates(map[string]interface{}{"tip": 0, "tip_recipient_id": nil, "shift_id": nil, "tender_group": ""})
+				db.Create(&models.CashDenomination{PaymentID: receipt.PaymentID, Value: utils.Rupiah(50000), Quantity: 1})
+				return db
+			},
+			check: func(t *testing.T, db *gorm.DB, result *BackupImportResult) {
+				if result.Updated[BackupSectionOrders] != 1 || result.Created[BackupSectionOrders] != 0 {
+					t.Errorf("preserve orders created/updated = %d/%d, want 0/1", result.Created[BackupSectionOrders], result.Updated[BackupSectionOrders])
+				}
+				var restored models.Order
+				db.First(&restored, order.ID)
+				if restored.TotalAmount != utils.Rupiah(30000) {
+					t.Errorf("restored total = %s, want 30000", restored.TotalAmount)
+				}
+				var variantItems int64
+				db.Model(&models.OrderItem{}).Where("variant_id = ?", archive.Variants[0].ID).Count(&variantItems)
+				if variantItems != 1 {
+					t.Errorf("restored items with variant %d = %d, want 1", archive.Variants[0].ID, variantItems)
+				}
+				var payment models.Payment
+				db.First(&payment, receipt.PaymentID)
+				if payment.Tip != utils.Rupiah(5000) || payment.TipRecipientID == nil {
+					t.Errorf("restored tip = %s to %v, want 5000 to the waiter", payment.Tip, payment.TipRecipientID)
+				}
+				if payment.ShiftID == nil || *payment.ShiftID != *archive.Payments[0].ShiftID {
+					t.Errorf("restored shift = %v, want %d", payment.ShiftID, *archive.Payments[0].ShiftID)
+				}
+				if payment.TenderGroup != archive.Payments[0].TenderGroup {
+					t.Errorf("restored tender group = %q, want %q", payment.TenderGroup, archive.Payments[0].TenderGroup)
+				}
+				want := map[string][2]int64{
+					"order items":     {countRows(t, db, &models.OrderItem{}), 2},
+					"receipt items":   {countRows(t, db, &models.ReceiptItem{}), 1},
+					"receipt add-ons": {countRows(t, db, &models.ReceiptAddOn{}), 1},
+					"receipt tenders": {countRows(t, db, &models.ReceiptTender{}), 1},
+					"receipts":        {countRows(t, db, &models.Receipt{}), 1},
+					"payments":        {countRows(t, db, &models.Payment{}), 1},
+					"denominations":   {countRows(t, db, &models.CashDenomination{}), 2},
+					"customers":       {countRows(t, db, &models.Customer{}), 1},
+					"menus":           {countRows(t, db, &models.Menu{}), 2},
+					"menu variants":   {countRows(t, db, &models.MenuVariant{}), 1},
+					"menu categories": {countRows(t, db, &models.MenuCategory{}), 1},
+				}
+				for name, got := range want {
+					if got[0] != got[1] {
+						t.Errorf("%s after restore = %d, want %d", name, got[0], got[1])
+					}
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := tt.prepare(t)
+			result, err := NewBackupService(db).Import(archive, BackupImportOptions{Mode: tt.mode, DryRun: tt.dryRun})
+			if err != nil {
+				t.Fatalf("Import() error = %v", err)
+			}
+			tt.check(t, db, result)
+		})
+	}
+}
+
+func TestBackupService_ImportRejectsUnknownReferences(t *testing.T) {
+	db := newBackupTestDB(t)
+	archive := &BackupArchive{
+		Version:  BackupFormatVersion,
+		Sections: []string{BackupSectionOrders},
+		Orders:   []BackupOrder{{ID: 5, CustomerID: 42, Status: OrderStatusPaid}},
+	}
+
+	_, err := NewBackupService(db).Import(archive, BackupImportOptions{Mode: BackupImportModeRemap})
+	var validationErr *BackupValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Import() error = %v, want BackupValidationError", err)
+	}
+	if n := countRows(t, db, &models.Order{}); n != 0 {
+		t.Errorf("rejected import left %d orders, want 0", n)
+	}
+}
+
+func TestBackupService_PreserveKeepsShiftWhenArchivedShiftIsMissing(t *testing.T) {
+	db := newBackupTestDB(t)
+	_, receipt := seedBackupData(t, db)
+	archive, err := NewBackupService(db).Export(BackupExportOptions{Sections: []string{BackupSectionPayments}})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	// Shift di arsip tidak ada di database tujuan: shift_id yang tersimpan tidak ditimpa
+	missing := uint(999)
+	archive.Payments[0].ShiftID = &missing
+	var before models.Payment
+	db.First(&before, receipt.PaymentID)
+
+	if _, err := NewBackupService(db).Import(archive, BackupImportOptions{Mode: BackupImportModePreserve}); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	var after models.Payment
+	db.First(&after, receipt.PaymentID)
+	if after.ShiftID == nil || *after.ShiftID != *before.ShiftID {
+		t.Errorf("shift after restore = %v, want %d", after.ShiftID, *before.ShiftID)
+	}
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+// Bank virtual account yang didukung. Mandiri memakai bill payment (echannel) dengan biller code + bill key.
+const (
+	BankBCA     = "bca"
+	BankBNI     = "bni"
+	BankBRI     = "bri"
+	BankPermata = "permata"
+	BankCIMB    = "cimb"
+	BankMandiri = "mandiri"
+)
+
+// SupportedVABanks adalah daftar bank yang bisa dipilih pelanggan untuk bank transfer
+var SupportedVABanks = []string{BankBCA, BankBNI, BankBRI, BankPermata, BankCIMB, BankMandiri}
+
+// ErrUnsupportedBank dikembalikan jika bank virtual account tidak didukung
+var ErrUnsupportedBank = errors.New("unsupported bank for virtual account")
+
+// defaultBankTransferExpiryMinutes adalah masa berlaku VA jika BANK_TRANSFER_EXPIRY_MINUTES kosong (24 jam)
+const defaultBankTransferExpiryMinutes = 24 * 60
+
+var vaBankNames = map[string]string{
+	BankBCA:     "BCA",
+	BankBNI:     "BNI",
+	BankBRI:     "BRI",
+	BankPermata: "Permata",
+	BankCIMB:    "CIMB Niaga",
+	BankMandiri: "Mandiri",
+}
+
+// NormalizeVABank mengembalikan kode bank dalam huruf kecil atau ErrUnsupportedBank
+func NormalizeVABank(bank string) (string, error) {
+	bank = strings.ToLower(strings.TrimSpace(bank))
+	if _, ok := vaBankNames[bank]; !ok {
+		return "", fmt.Errorf("%w: %q (supported: %s)", ErrUnsupportedBank, bank, strings.Join(SupportedVABanks, ", "))
+	}
+	return bank, nil
+}
+
+// BankTransferExpiryMinutes mengembalikan masa berlaku virtual account dari env BANK_TRANSFER_EXPIRY_MINUTES
+func BankTransferExpiryMinutes() int {
+	return envInt("BANK_TRANSFER_EXPIRY_MINUTES", defaultBankTransferExpiryMinutes)
+}
+
+// BankTransferInstructions menyusun langkah pembayaran virtual account untuk ditampilkan ke pelanggan
+func BankTransferInstructions(bank, vaNumber, billerCode string, amount utils.Money) []string {
+	name := vaBankNames[bank]
+	if name == "" {
+		name = strings.ToUpper(bank)
+	}
+
+	if bank == BankMandiri {
+		return []string{
+			"Buka Livin' by Mandiri atau ATM Mandiri, pilih menu Bayar / Multipayment.",
+			fmt.Sprintf("Masukkan kode perusahaan (biller code) %s.", billerCode),
+			fmt.Sprintf("Masukkan kode pembayaran (bill key) %s.", vaNumber),
+			fmt.Sprintf("Pastikan total tagihan %s, lalu konfirmasi pembayaran.", amount),
+			"Status pesanan akan diperbarui otomatis setelah pembayaran diterima.",
+		}
+	}
+
+	return []string{
+		fmt.Sprintf("Buka mobile banking atau ATM %s, pilih menu Transfer ke Virtual Account.", name),
+		fmt.Sprintf("Masukkan nomor virtual account %s %s.", name, vaNumber),
+		fmt.Sprintf("Pastikan total tagihan %s, lalu konfirmasi pembayaran.", amount),
+		"Status pesanan akan diperbarui otomatis setelah pembayaran diterima.",
+	}
+}
@@ -0,0 +1,341 @@
+package services
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+// Aksi simulasi yang didukung FakePaymentProvider
+const (
+	FakeActionSettle = "settle"
+	FakeActionExpire = "expire"
+	FakeActionFail   = "fail"
+)
+
+// defaultFakePaymentSecret dipakai untuk signature webhook jika FAKE_PAYMENT_SECRET kosong
+const defaultFakePaymentSecret = "fake-payment-secret"
+
+// fakeTransaction menyimpan state transaksi di FakePaymentProvider
+type fakeTransaction struct {
+	TransactionID     string
+	OrderRef          string
+	Amount            utils.Money
+	Refunded          utils.Money
+	TransactionStatus string
+	CreatedAt         time.Time
+	ExpiresAt         time.Time
+}
+
+// FakePaymentProvider adalah provider lokal tanpa jaringan untuk development dan test.
+// Transaksi disimpan di memory dan statusnya diubah lewat Simulate.
+type FakePaymentProvider struct {
+	secret       string
+	transactions map[string]*fakeTransaction
+	mutex        sync.Mutex
+}
+
+// Pastikan FakePaymentProvider memenuhi interface PaymentProvider dan TransactionLister
+var (
+	_ PaymentProvider   = (*FakePaymentProvider)(nil)
+	_ TransactionLister = (*FakePaymentProvider)(nil)
+)
+
+// NewFakePaymentProvider membuat instance baru FakePaymentProvider
+func NewFakePaymentProvider(secret string) *FakePaymentProvider {
+	if secret == "" {
+		secret = defaultFakePaymentSecret
+	}
+	return &FakePaymentProvider{
+		secret:       secret,
+		transactions: make(map[string]*fakeTransaction),
+	}
+}
+
+// Name mengembalikan nama provider
+func (fp *FakePaymentProvider) Name() string {
+	return PaymentProviderFake
+}
+
+// CreateCharge membuat transaksi pending di memory
+func (fp *FakePaymentProvider) CreateCharge(req ChargeRequest) (*ChargeResult, error) {
+	if req.OrderRef == "" {
+		return nil, fmt.Errorf("order reference is required")
+	}
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be greater than 0")
+	}
+
+	fp.mutex.Lock()
+	defer fp.mutex.Unlock()
+
+	bank := ""
+	if req.Method == ChargeMethodBankTransfer {
+		normalized, err := NormalizeVABank(req.Bank)
+		if err != nil {
+			return nil, err
+		}
+		bank = normalized
+	}
+	expiry := 15 * time.Minute
+	if req.ExpiryMinutes > 0 {
+		expiry = time.Duration(req.ExpiryMinutes) * time.Minute
+	}
+
+	if _, exists := fp.transactions[req.OrderRef]; exists {
+		return nil, fmt.Errorf("transaction %s already exists", req.OrderRef)
+	}
+
+	trx := &fakeTransaction{
+		TransactionID:     "FAKE-" + uuid.New().String(),
+		OrderRef:          req.OrderRef,
+		Amount:            req.Amount,
+		TransactionStatus: "pending",
+		CreatedAt:         time.Now(),
+		ExpiresAt:         time.Now().Add(expiry),
+	}
+	fp.transactions[req.OrderRef] = trx
+	fp.transactions[trx.TransactionID] = trx
+
+	expiresAt := trx.ExpiresAt
+	result := &ChargeResult{
+		TransactionID: trx.TransactionID,
+		OrderRef:      trx.OrderRef,
+		ExpiresAt:     &expiresAt,
+	}
+	if bank == "" {
+		result.QRString = fmt.Sprintf("FAKEQRIS|%s|%s", trx.TransactionID, trx.Amount.Decimal())
+		return result, nil
+	}
+
+	// Nomor VA palsu: prefix 8808 + 12 digit dari waktu pembuatan
+	result.Bank = bank
+	result.VANumber = fmt.Sprintf("8808%012d", trx.CreatedAt.UnixNano()%1e12)
+	if bank == BankMandiri {
+		result.BillerCode = "70012"
+	}
+	return result, nil
+}
+
+// CheckStatus mengembalikan status transaksi dalam format internal
+func (fp *FakePaymentProvider) CheckStatus(providerRef string) (string, error) {
+	fp.mutex.Lock()
+	defer fp.mutex.Unlock()
+
+	trx, err := fp.find(providerRef)
+	if err != nil {
+		return "", err
+	}
+	return mapFakeTransactionStatus(trx.TransactionStatus), nil
+}
+
+// GetTransaction mengembalikan detail transaksi di memory
+func (fp *FakePaymentProvider) GetTransaction(providerRef string) (*ProviderTransaction, error) {
+	fp.mutex.Lock()
+	defer fp.mutex.Unlock()
+
+	trx, ok := fp.transactions[providerRef]
+	if !ok {
+		return nil, ErrTransactionNotFound
+	}
+	result := trx.toProviderTransaction()
+	return &result, nil
+}
+
+// ListTransactions mengembalikan semua transaksi yang dibuat sejak waktu tertentu
+func (fp *FakePaymentProvider) ListTransactions(since time.Time) ([]ProviderTransaction, error) {
+	fp.mutex.Lock()
+	defer fp.mutex.Unlock()
+
+	var result []ProviderTransaction
+	for key, trx := range fp.transactions {
+		// Setiap transaksi tersimpan dua kali (order ref dan transaction ID)
+		if key != trx.OrderRef || trx.CreatedAt.Before(since) {
+			continue
+		}
+		result = append(result, trx.toProviderTransaction())
+	}
+	return result, nil
+}
+
+// SetAmount mengubah nominal transaksi di sisi provider (untuk mensimulasikan selisih nominal)
+func (fp *FakePaymentProvider) SetAmount(providerRef string, amount utils.Money) error {
+	fp.mutex.Lock()
+	defer fp.mutex.Unlock()
+
+	trx, err := fp.find(providerRef)
+	if err != nil {
+		return err
+	}
+	trx.Amount = amount
+	return nil
+}
+
+func (trx *fakeTransaction) toProviderTransaction() ProviderTransaction {
+	createdAt := trx.CreatedAt
+	return ProviderTransaction{
+		OrderRef:          trx.OrderRef,
+		TransactionID:     trx.TransactionID,
+		TransactionStatus: trx.TransactionStatus,
+		Status:            mapFakeTransactionStatus(trx.TransactionStatus),
+		Amount:            trx.Amount,
+		TransactionTime:   &createdAt,
+	}
+}
+
+// Cancel membatalkan transaksi yang masih pending
+func (fp *FakePaymentProvider) Cancel(providerRef string) error {
+	fp.mutex.Lock()
+	defer fp.mutex.Unlock()
+
+	trx, err := fp.find(providerRef)
+	if err != nil {
+		return err
+	}
+	if trx.TransactionStatus != "pending" {
+		return fmt.Errorf("cannot cancel transaction with status %s", trx.TransactionStatus)
+	}
+	trx.TransactionStatus = "cancel"
+	return nil
+}
+
+// Refund mengembalikan dana transaksi yang sudah settlement
+func (fp *FakePaymentProvider) Refund(providerRef string, amount utils.Money, reason string) error {
+	fp.mutex.Lock()
+	defer fp.mutex.Unlock()
+
+	trx, err := fp.find(providerRef)
+	if err != nil {
+		return err
+	}
+	if trx.TransactionStatus != "settlement" && trx.TransactionStatus != "partial_refund" {
+		return fmt.Errorf("cannot refund transaction with status %s", trx.TransactionStatus)
+	}
+	if amount <= 0 || amount > trx.Amount-trx.Refunded {
+		return fmt.Errorf("invalid refund amount %s", amount.Decimal())
+	}
+	trx.Refunded += amount
+	trx.TransactionStatus = "partial_refund"
+	if trx.Refunded == trx.Amount {
+		trx.TransactionStatus = "refund"
+	}
+	return nil
+}
+
+// Simulate mengubah status transaksi (settle/expire/fail) lalu mengembalikan
+// payload webhook bertanda tangan, sama seperti notifikasi dari provider asli
+func (fp *FakePaymentProvider) Simulate(providerRef, action string) ([]byte, error) {
+	fp.mutex.Lock()
+	defer fp.mutex.Unlock()
+
+	trx, err := fp.find(providerRef)
+	if err != nil {
+		return nil, err
+	}
+	if trx.TransactionStatus != "pending" {
+		return nil, fmt.Errorf("cannot simulate %s on transaction with status %s", action, trx.TransactionStatus)
+	}
+
+	switch strings.ToLower(action) {
+	case FakeActionSettle:
+		trx.TransactionStatus = "settlement"
+	case FakeActionExpire:
+		trx.TransactionStatus = "expire"
+	case FakeActionFail:
+		trx.TransactionStatus = "deny"
+	default:
+		return nil, fmt.Errorf("unknown simulate action: %s", action)
+	}
+
+	return fp.buildWebhook(trx)
+}
+
+// VerifyWebhook memvalidasi signature payload yang dibuat oleh Simulate
+func (fp *FakePaymentProvider) VerifyWebhook(payload []byte) (*WebhookNotification, error) {
+	var request fakeWebhookPayload
+	if err := json.Unmarshal(payload, &request); err != nil {
+		return nil, fmt.Errorf("error parsing notification: %v", err)
+	}
+
+	expected := fp.sign(request.OrderID, request.StatusCode, request.GrossAmount)
+	if expected != request.SignatureKey {
+		return nil, ErrInvalidWebhookSignature
+	}
+
+	return &WebhookNotification{
+		OrderRef:          request.OrderID,
+		TransactionID:     request.TransactionID,
+		TransactionStatus: request.TransactionStatus,
+		Status:            mapFakeTransactionStatus(request.TransactionStatus),
+		StatusCode:        request.StatusCode,
+		GrossAmount:       request.GrossAmount,
+	}, nil
+}
+
+// fakeWebhookPayload mengikuti format notifikasi Midtrans
+type fakeWebhookPayload struct {
+	OrderID           string `json:"order_id"`
+	TransactionID     string `json:"transaction_id"`
+	TransactionStatus string `json:"transaction_status"`
+	StatusCode        string `json:"status_code"`
+	GrossAmount       string `json:"gross_amount"`
+	SignatureKey      string `json:"signature_key"`
+}
+
+func (fp *FakePaymentProvider) buildWebhook(trx *fakeTransaction) ([]byte, error) {
+	statusCode := "200"
+	if trx.TransactionStatus == "expire" || trx.TransactionStatus == "deny" {
+		statusCode = "202"
+	}
+	grossAmount := trx.Amount.Decimal()
+
+	return json.Marshal(fakeWebhookPayload{
+		OrderID:           trx.OrderRef,
+		TransactionID:     trx.TransactionID,
+		TransactionStatus: trx.TransactionStatus,
+		StatusCode:        statusCode,
+		GrossAmount:       grossAmount,
+		SignatureKey:      fp.sign(trx.OrderRef, statusCode, grossAmount),
+	})
+}
+
+func (fp *FakePaymentProvider) sign(orderRef, statusCode, grossAmount string) string {
+	hash := sha512.Sum512([]byte(orderRef + statusCode + grossAmount + fp.secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// find mencari transaksi berdasarkan order ref atau transaction ID (mutex harus sudah dipegang)
+func (fp *FakePaymentProvider) find(providerRef string) (*fakeTransaction, error) {
+	trx, ok := fp.transactions[providerRef]
+	if !ok {
+		return nil, fmt.Errorf("transaction %s not found", providerRef)
+	}
+	return trx, nil
+}
+
+// mapFakeTransactionStatus memetakan status fake provider ke status internal
+func mapFakeTransactionStatus(status string) string {
+	switch status {
+	case "settlement", "partial_refund":
+		return PaymentStatusSuccess
+	case "pending":
+		return PaymentStatusPending
+	case "deny":
+		return PaymentStatusFailed
+	case "expire":
+		return PaymentStatusExpired
+	case "cancel":
+		return PaymentStatusCancelled
+	case "refund":
+		return PaymentStatusRefunded
+	default:
+		return "unknown"
+	}
+}
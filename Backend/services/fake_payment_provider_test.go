@@ -0,0 +1,170 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+func TestFakePaymentProvider_Simulate(t *testing.T) {
+	tests := []struct {
+		name       string
+		action     string
+		wantStatus string
+		wantErr    bool
+	}{
+		{
+			name:       "settle",
+			action:     FakeActionSettle,
+			wantStatus: PaymentStatusSuccess,
+		},
+		{
+			name:       "expire",
+			action:     FakeActionExpire,
+			wantStatus: PaymentStatusExpired,
+		},
+		{
+			name:       "fail",
+			action:     FakeActionFail,
+			wantStatus: PaymentStatusFailed,
+		},
+		{
+			name:       "unknown action",
+			action:     "explode",
+			wantStatus: PaymentStatusPending,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := NewFakePaymentProvider("test-secret")
+
+			charge, err := fp.CreateCharge(ChargeRequest{OrderRef: "ORDER-1-abc", Amount: utils.Rupiah(25000)})
+			if err != nil {
+				t.Fatalf("CreateCharge() error = %v", err)
+			}
+
+			payload, err := fp.Simulate(charge.TransactionID, tt.action)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Simulate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			status, err := fp.CheckStatus(charge.TransactionID)
+			if err != nil {
+				t.Fatalf("CheckStatus() error = %v", err)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("CheckStatus() status = %v, want %v", status, tt.wantStatus)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			notification, err := fp.VerifyWebhook(payload)
+			if err != nil {
+				t.Fatalf("VerifyWebhook() error = %v", err)
+			}
+			if notification.Status != tt.wantStatus {
+				t.Errorf("VerifyWebhook() status = %v, want %v", notification.Status, tt.wantStatus)
+			}
+			if notification.OrderRef != "ORDER-1-abc" {
+				t.Errorf("VerifyWebhook() order ref = %v, want ORDER-1-abc", notification.OrderRef)
+			}
+		})
+	}
+}
+
+func TestFakePaymentProvider_VerifyWebhookRejectsForgedSignature(t *testing.T) {
+	fp := NewFakePaymentProvider("test-secret")
+	charge, err := fp.CreateCharge(ChargeRequest{OrderRef: "ORDER-2-abc", Amount: utils.Rupiah(10000)})
+	if err != nil {
+		t.Fatalf("CreateCharge() error = %v", err)
+	}
+
+	payload, err := fp.Simulate(charge.OrderRef, FakeActionSettle)
+	if err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+
+	// Provider dengan secret berbeda tidak boleh menerima payload ini
+	other := NewFakePaymentProvider("other-secret")
+	if _, err := other.VerifyWebhook(payload); !errors.Is(err, ErrInvalidWebhookSignature) {
+		t.Errorf("VerifyWebhook() error = %v, want %v", err, ErrInvalidWebhookSignature)
+	}
+}
+
+func TestFakePaymentProvider_CancelAndRefund(t *testing.T) {
+	fp := NewFakePaymentProvider("")
+
+	pending, _ := fp.CreateCharge(ChargeRequest{OrderRef: "ORDER-3-a", Amount: utils.Rupiah(10000)})
+	if err := fp.Refund(pending.TransactionID, utils.Rupiah(10000), "test"); err == nil {
+		t.Errorf("Refund() on pending transaction should fail")
+	}
+	if err := fp.Cancel(pending.TransactionID); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if status, _ := fp.CheckStatus(pending.TransactionID); status != PaymentStatusCancelled {
+		t.Errorf("CheckStatus() after cancel = %v, want %v", status, PaymentStatusCancelled)
+	}
+
+	paid, _ := fp.CreateCharge(ChargeRequest{OrderRef: "ORDER-3-b", Amount: utils.Rupiah(10000)})
+	if _, err := fp.Simulate(paid.TransactionID, FakeActionSettle); err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+	if err := fp.Cancel(paid.TransactionID); err == nil {
+		t.Errorf("Cancel() on settled transaction should fail")
+	}
+	// Refund sebagian tidak mengubah status; refund berikutnya dibatasi sisa nominal
+	if err := fp.Refund(paid.TransactionID, utils.Rupiah(4000), "missing item"); err != nil {
+		t.Fatalf("Refund(partial) error = %v", err)
+	}
+	if status, _ := fp.CheckStatus(paid.TransactionID); status != PaymentStatusSuccess {
+		t.Errorf("CheckStatus() after partial refund = %v, want %v", status, PaymentStatusSuccess)
+	}
+	if err := fp.Refund(paid.TransactionID, utils.Rupiah(10000), "customer request"); err == nil {
+		t.Errorf("Refund() above the remaining amount should fail")
+	}
+	if err := fp.Refund(paid.TransactionID, utils.Rupiah(6000), "customer request"); err != nil {
+		t.Fatalf("Refund() error = %v", err)
+	}
+	if status, _ := fp.CheckStatus(paid.TransactionID); status != PaymentStatusRefunded {
+		t.Errorf("CheckStatus() after refund = %v, want %v", status, PaymentStatusRefunded)
+	}
+}
+
+func TestFakePaymentProvider_BankTransfer(t *testing.T) {
+	fp := NewFakePaymentProvider("test-secret")
+
+	if _, err := fp.CreateCharge(ChargeRequest{OrderRef: "ORDER-3-abc", Amount: utils.Rupiah(10000),
+		Method: ChargeMethodBankTransfer, Bank: "xyz"}); !errors.Is(err, ErrUnsupportedBank) {
+		t.Fatalf("CreateCharge() with unknown bank error = %v, want %v", err, ErrUnsupportedBank)
+	}
+
+	charge, err := fp.CreateCharge(ChargeRequest{OrderRef: "ORDER-3-abc", Amount: utils.Rupiah(10000),
+		Method: ChargeMethodBankTransfer, Bank: "BNI", ExpiryMinutes: 60})
+	if err != nil {
+		t.Fatalf("CreateCharge() error = %v", err)
+	}
+	if charge.Bank != BankBNI || charge.VANumber == "" || charge.QRString != "" {
+		t.Errorf("CreateCharge() = %+v, want BNI virtual account without QR", charge)
+	}
+	if charge.ExpiresAt == nil || charge.ExpiresAt.Before(time.Now().Add(59*time.Minute)) {
+		t.Errorf("CreateCharge() expires at %v, want about 60 minutes from now", charge.ExpiresAt)
+	}
+
+	payload, err := fp.Simulate(charge.OrderRef, FakeActionSettle)
+	if err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+	notification, err := fp.VerifyWebhook(payload)
+	if err != nil {
+		t.Fatalf("VerifyWebhook() error = %v", err)
+	}
+	if notification.Status != PaymentStatusSuccess {
+		t.Errorf("VerifyWebhook() status = %v, want %v", notification.Status, PaymentStatusSuccess)
+	}
+}
@@ -0,0 +1,559 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"gorm.io/gorm"
+)
+
+// Kapan stok bahan dikurangi (env INVENTORY_DEPLETE_ON)
+const (
+	InventoryDepleteOnPaid   = "paid"   // Begitu order lunas (default)
+	InventoryDepleteOnCooked = "cooked" // Setelah dapur selesai memasak (status ready)
+)
+
+// inventorySweepInterval adalah jarak pengecekan order yang stok bahannya belum dikurangi
+const inventorySweepInterval = 15 * time.Second
+
+var (
+	// ErrInvalidIngredient dikembalikan jika isian bahan atau perubahan stok tidak valid
+	ErrInvalidIngredient = errors.New("invalid ingredient")
+	// ErrIngredientInUse dikembalikan saat menghapus bahan yang masih dipakai resep
+	ErrIngredientInUse = errors.New("ingredient is used in recipes")
+	// ErrInvalidRecipe dikembalikan jika isian resep tidak valid
+	ErrInvalidRecipe = errors.New("invalid recipe")
+)
+
+// IngredientInput adalah isian bahan dari admin. Stock hanya dipakai saat membuat bahan;
+// setelah itu stok diubah lewat AdjustStock agar setiap perubahan tercatat.
+type IngredientInput struct {
+	Name              string  `json:"name"`
+	Unit              string  `json:"unit"`
+	Stock             float64 `json:"stock"`
+	LowStockThreshold float64 `json:"low_stock_threshold"`
+}
+
+// StockAdjustmentInput adalah perubahan stok manual: positif untuk barang masuk,
+// negatif untuk susut atau koreksi stock opname
+type StockAdjustmentInput struct {
+	Change float64 `json:"change"`
+	Reason string  `json:"reason"` // restock atau adjustment
+	Note   string  `json:"note"`
+}
+
+// RecipeItemInput adalah satu baris resep; VariantID kosong berarti berlaku untuk semua variant
+type RecipeItemInput struct {
+	IngredientID uint    `json:"ingredient_id"`
+	VariantID    *uint   `json:"variant_id"`
+	Quantity     float64 `json:"quantity"`
+}
+
+// InventoryService mengelola bahan baku, resep menu dan pengurangan stok otomatis
+type InventoryService struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+// NewInventoryService membuat instance baru InventoryService
+func NewInventoryService(db *gorm.DB) *InventoryService {
+	return &InventoryService{db: db, now: time.Now}
+}
+
+// InventoryDepleteOn membaca env INVENTORY_DEPLETE_ON (paid atau cooked)
+func InventoryDepleteOn() string {
+	if strings.ToLower(strings.TrimSpace(os.Getenv("INVENTORY_DEPLETE_ON"))) == InventoryDepleteOnCooked {
+		return InventoryDepleteOnCooked
+	}
+	return InventoryDepleteOnPaid
+}
+
+// inventoryDepletionStatuses adalah status order yang sudah melewati titik pengurangan stok
+func inventoryDepletionStatuses(mode string) []string {
+	if mode == InventoryDepleteOnCooked {
+		return []string{OrderStatusReady, OrderStatusServed, OrderStatusCompleted}
+	}
+	return []string{OrderStatusPaid, "in_progress", OrderStatusProcessing, OrderStatusReady, OrderStatusServed, OrderStatusCompleted}
+}
+
+// ListIngredients mengembalikan semua bahan urut nama
+func (s *InventoryService) ListIngredients() ([]models.Ingredient, error) {
+	var ingredients []models.Ingredient
+	err := s.db.Order("name ASC").Find(&ingredients).Error
+	return ingredients, err
+}
+
+// CreateIngredient menambah bahan; stok awal dicatat sebagai restock
+func (s *InventoryService) CreateIngredient(input IngredientInput, userID *uint) (*models.Ingredient, error) {
+	ingredient := models.Ingredient{}
+	if err := s.applyInput(&ingredient, input); err != nil {
+		return nil, err
+	}
+	if input.Stock < 0 {
+		return nil, fmt.Errorf("%w: stock must not be negative", ErrInvalidIngredient)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&ingredient).Error; err != nil {
+			return fmt.Errorf("failed to create ingredient: %w", err)
+		}
+		if input.Stock == 0 {
+			return nil
+		}
+		updated, err := s.move(tx, ingredient.ID, models.IngredientMovement{
+			UserID: userID,
+			Reason: models.IngredientMovementRestock,
+			Change: roundQuantity(input.Stock),
+			Note:   "initial stock",
+		})
+		if err != nil {
+			return err
+		}
+		ingredient = *updated
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ingredient, nil
+}
+
+// UpdateIngredient mengganti nama, satuan atau batas stok minimum
+func (s *InventoryService) UpdateIngredient(id uint, input IngredientInput) (*models.Ingredient, error) {
+	var ingredient models.Ingredient
+	if err := s.db.First(&ingredient, id).Error; err != nil {
+		return nil, err
+	}
+	if err := s.applyInput(&ingredient, input); err != nil {
+		return nil, err
+	}
+	// Batas baru bisa membuat stok saat ini tidak lagi dianggap menipis
+	if !ingredient.IsLow() {
+		ingredient.LowStockNotifiedAt = nil
+	}
+	if err := s.db.Save(&ingredient).Error; err != nil {
+		return nil, fmt.Errorf("failed to update ingredient: %w", err)
+	}
+	return &ingredient, nil
+}
+
+// DeleteIngredient menghapus bahan yang tidak lagi dipakai resep mana pun
+func (s *InventoryService) DeleteIngredient(id uint) error {
+	var ingredient models.Ingredient
+	if err := s.db.First(&ingredient, id).Error; err != nil {
+		return err
+	}
+	var count int64
+	if err := s.db.Model(&models.RecipeItem{}).Where("ingredient_id = ?", id).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check recipes: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s is used in %d recipe lines", ErrIngredientInUse, ingredient.Name, count)
+	}
+	return s.db.Delete(&ingredient).Error
+}
+
+// AdjustStock mencatat barang masuk atau koreksi stok manual
+func (s *InventoryService) AdjustStock(id uint, input StockAdjustmentInput, userID *uint) (*models.Ingredient, error) {
+	reason := strings.ToLower(strings.TrimSpace(input.Reason))
+	if reason == "" {
+		reason = models.IngredientMovementAdjustment
+	}
+	if reason != models.IngredientMovementRestock && reason != models.IngredientMovementAdjustment {
+		return nil, fmt.Errorf("%w: reason must be restock or adjustment", ErrInvalidIngredient)
+	}
+	change := roundQuantity(input.Change)
+	if change == 0 {
+		return nil, fmt.Errorf("%w: change must not be zero", ErrInvalidIngredient)
+	}
+	if reason == models.IngredientMovementRestock && change < 0 {
+		return nil, fmt.Errorf("%w: restock must be positive", ErrInvalidIngredient)
+	}
+	if len(input.Note) > 255 {
+		return nil, fmt.Errorf("%w: note must be at most 255 characters", ErrInvalidIngredient)
+	}
+
+	var ingredient *models.Ingredient
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.Ingredient{}, id).Error; err != nil {
+			return err
+		}
+		var err error
+		ingredient, err = s.move(tx, id, models.IngredientMovement{
+			UserID: userID,
+			Reason: reason,
+			Change: change,
+			Note:   strings.TrimSpace(input.Note),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ingredient, nil
+}
+
+// ListMovements mengembalikan riwayat stok satu bahan, terbaru lebih dulu
+func (s *InventoryService) ListMovements(ingredientID uint, limit int) ([]models.IngredientMovement, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	var movements []models.IngredientMovement
+	err := s.db.Where("ingredient_id = ?", ingredientID).Order("id DESC").Limit(limit).Find(&movements).Error
+	return movements, err
+}
+
+// GetRecipe mengembalikan resep satu menu beserta bahannya
+func (s *InventoryService) GetRecipe(menuID uint) ([]models.RecipeItem, error) {
+	if err := s.db.First(&models.Menu{}, menuID).Error; err != nil {
+		return nil, err
+	}
+	var items []models.RecipeItem
+	err := s.db.Preload("Ingredient").Where("menu_id = ?", menuID).Order("variant_id ASC, id ASC").Find(&items).Error
+	return items, err
+}
+
+// SetRecipe mengganti seluruh resep satu menu. Daftar kosong menghapus resepnya.
+func (s *InventoryService) SetRecipe(menuID uint, inputs []RecipeItemInput) ([]models.RecipeItem, error) {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrInvalidRecipe, fmt.Sprintf(format, args...))
+	}
+	if err := s.db.First(&models.Menu{}, menuID).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]models.RecipeItem, 0, len(inputs))
+	seen := make(map[string]bool)
+	for i, input := range inputs {
+		quantity := roundQuantity(input.Quantity)
+		if quantity <= 0 {
+			return nil, invalid("line %d: quantity must be positive", i+1)
+		}
+		if err := s.db.First(&models.Ingredient{}, input.IngredientID).Error; err != nil {
+			return nil, invalid("line %d: ingredient %d not found", i+1, input.IngredientID)
+		}
+		key := fmt.Sprint(input.IngredientID)
+		if input.VariantID != nil {
+			var variant models.MenuVariant
+			if err := s.db.Where("id = ? AND menu_id = ?", *input.VariantID, menuID).First(&variant).Error; err != nil {
+				return nil, invalid("line %d: variant %d does not belong to this menu", i+1, *input.VariantID)
+			}
+			key += "/" + fmt.Sprint(*input.VariantID)
+		}
+		if seen[key] {
+			return nil, invalid("line %d: ingredient %d is listed twice", i+1, input.IngredientID)
+		}
+		seen[key] = true
+		items = append(items, models.RecipeItem{
+			MenuID:       menuID,
+			VariantID:    input.VariantID,
+			IngredientID: input.IngredientID,
+			Quantity:     quantity,
+		})
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("menu_id = ?", menuID).Delete(&models.RecipeItem{}).Error; err != nil {
+			return fmt.Errorf("failed to clear recipe: %w", err)
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		if err := tx.Create(&items).Error; err != nil {
+			return fmt.Errorf("failed to save recipe: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetRecipe(menuID)
+}
+
+// Start menjalankan pengurangan stok bahan secara berkala untuk order yang sudah
+// melewati titik INVENTORY_DEPLETE_ON. Semua jalur pembayaran (kasir, QRIS, transfer,
+// split tender) cukup mengubah status order; tidak perlu memanggil service ini.
+func (s *InventoryService) Start() {
+	mode := InventoryDepleteOn()
+	go func() {
+		ticker := time.NewTicker(inventorySweepInterval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := s.DepletePendingOrders(mode); err != nil {
+				log.Printf("Inventory depletion failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+	log.Printf("Inventory depletion started (on %s, interval %s)", mode, inventorySweepInterval)
+}
+
+// DepletePendingOrders mengurangi stok bahan untuk semua order yang sudah lunas (atau
+// selesai dimasak) tetapi belum diproses. Order yang dibuat sebelum bahan pertama
+// didaftarkan dilewati agar riwayat lama tidak ikut mengurangi stok.
+func (s *InventoryService) DepletePendingOrders(mode string) (int, error) {
+	var first models.Ingredient
+	err := s.db.Unscoped().Order("created_at ASC").First(&first).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to load ingredients: %w", err)
+	}
+
+	var orderIDs []uint
+	if err := s.db.Model(&models.Order{}).
+		Where("status IN ? AND inventory_depleted_at IS NULL AND created_at >= ?", inventoryDepletionStatuses(mode), first.CreatedAt).
+		Order("id ASC").Limit(200).Pluck("id", &orderIDs).Error; err != nil {
+		return 0, fmt.Errorf("failed to load orders: %w", err)
+	}
+
+	depleted := 0
+	for _, orderID := range orderIDs {
+		done, err := s.DepleteOrder(orderID)
+		if err != nil {
+			log.Printf("Failed to deplete inventory for order %d: %v", orderID, err)
+			continue
+		}
+		if done {
+			depleted++
+		}
+	}
+	return depleted, nil
+}
+
+// DepleteOrder mengurangi stok bahan sesuai resep setiap item order (termasuk add-on).
+// Aman dipanggil berulang: order ditandai lebih dulu dalam transaksi yang sama, jadi
+// stoknya hanya dikurangi sekali. Stok boleh menjadi negatif, karena bahannya memang
+// sudah terpakai; selisihnya dikoreksi lewat AdjustStock.
+func (s *InventoryService) DepleteOrder(orderID uint) (bool, error) {
+	depleted := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		claim := tx.Model(&models.Order{}).Where("id = ? AND inventory_depleted_at IS NULL", orderID).
+			UpdateColumn("inventory_depleted_at", s.now())
+		if claim.Error != nil {
+			return fmt.Errorf("failed to mark order: %w", claim.Error)
+		}
+		if claim.RowsAffected == 0 {
+			return nil
+		}
+		depleted = true
+
+		var order models.Order
+		if err := tx.Preload("OrderItems").First(&order, orderID).Error; err != nil {
+			return err
+		}
+		usage, err := recipeUsage(tx, order.OrderItems)
+		if err != nil {
+			return err
+		}
+
+		ingredientIDs := make([]uint, 0, len(usage))
+		for id := range usage {
+			ingredientIDs = append(ingredientIDs, id)
+		}
+		// Urutan tetap agar dua transaksi tidak saling mengunci
+		sort.Slice(ingredientIDs, func(i, j int) bool { return ingredientIDs[i] < ingredientIDs[j] })
+
+		label := order.OrderNumber
+		if label == "" {
+			label = fmt.Sprint(order.ID)
+		}
+		for _, id := range ingredientIDs {
+			if _, err := s.move(tx, id, models.IngredientMovement{
+				OrderID: &order.ID,
+				Reason:  models.IngredientMovementSale,
+				Change:  -roundQuantity(usage[id]),
+				Note:    "order " + label,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return depleted, err
+}
+
+// recipeUsage menjumlahkan kebutuhan bahan untuk item-item order
+func recipeUsage(tx *gorm.DB, items []models.OrderItem) (map[uint]float64, error) {
+	menuIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		menuIDs = append(menuIDs, item.MenuID)
+	}
+	usage := make(map[uint]float64)
+	if len(menuIDs) == 0 {
+		return usage, nil
+	}
+
+	// Bahan yang sudah dihapus tidak ikut dikurangi
+	var lines []models.RecipeItem
+	if err := tx.Joins("JOIN ingredients ON ingredients.id = recipe_items.ingredient_id AND ingredients.deleted_at IS NULL").
+		Where("recipe_items.menu_id IN ?", menuIDs).Find(&lines).Error; err != nil {
+		return nil, fmt.Errorf("failed to load recipes: %w", err)
+	}
+	recipes := make(map[uint][]models.RecipeItem)
+	for _, line := range lines {
+		recipes[line.MenuID] = append(recipes[line.MenuID], line)
+	}
+
+	for _, item := range items {
+		for _, line := range recipes[item.MenuID] {
+			if line.VariantID != nil && (item.VariantID == nil || *line.VariantID != *item.VariantID) {
+				continue
+			}
+			usage[line.IngredientID] += line.Quantity * float64(item.Quantity)
+		}
+	}
+	return usage, nil
+}
+
+// move mengubah stok secara atomik, mencatat movement dan mengirim notifikasi saat stok
+// menipis atau habis. movement.Change sudah harus diisi.
+func (s *InventoryService) move(tx *gorm.DB, ingredientID uint, movement models.IngredientMovement) (*models.Ingredient, error) {
+	if err := tx.Model(&models.Ingredient{}).Where("id = ?", ingredientID).
+		UpdateColumn("stock", gorm.Expr("stock + ?", movement.Change)).Error; err != nil {
+		return nil, fmt.Errorf("failed to update ingredient stock: %w", err)
+	}
+	var ingredient models.Ingredient
+	if err := tx.First(&ingredient, ingredientID).Error; err != nil {
+		return nil, err
+	}
+	ingredient.Stock = roundQuantity(ingredient.Stock)
+
+	movement.IngredientID = ingredientID
+	movement.StockAfter = ingredient.Stock
+	if err := tx.Create(&movement).Error; err != nil {
+		return nil, fmt.Errorf("failed to record stock movement: %w", err)
+	}
+
+	previous := roundQuantity(ingredient.Stock - movement.Change)
+	var notification *models.Notification
+	switch {
+	case ingredient.Stock <= 0 && previous > 0:
+		menus, err := recipeMenuNames(tx, ingredientID)
+		if err != nil {
+			return nil, err
+		}
+		message := fmt.Sprintf("%s is out of stock (%s %s left).", ingredient.Name, formatQuantity(ingredient.Stock), ingredient.Unit)
+		if len(menus) > 0 {
+			message += " Unavailable menus: " + strings.Join(menus, ", ")
+		}
+		notification = &models.Notification{Title: "Ingredient Out of Stock", Message: message}
+	case ingredient.IsLow() && ingredient.LowStockNotifiedAt == nil:
+		notification = &models.Notification{
+			Title: "Low Ingredient Stock",
+			Message: fmt.Sprintf("%s is running low: %s %s left (minimum %s %s)", ingredient.Name,
+				formatQuantity(ingredient.Stock), ingredient.Unit, formatQuantity(ingredient.LowStockThreshold), ingredient.Unit),
+		}
+	}
+
+	notifiedAt := ingredient.LowStockNotifiedAt
+	if notification != nil {
+		notification.Type = "inventory"
+		notification.Status = "unread"
+		if err := tx.Create(notification).Error; err != nil {
+			return nil, fmt.Errorf("failed to create stock notification: %w", err)
+		}
+		now := s.now()
+		notifiedAt = &now
+	}
+	if !ingredient.IsLow() {
+		// Stok kembali di atas batas: notifikasi berikutnya dikirim lagi saat menipis
+		notifiedAt = nil
+	}
+	if (notifiedAt == nil) != (ingredient.LowStockNotifiedAt == nil) {
+		if err := tx.Model(&models.Ingredient{}).Where("id = ?", ingredientID).
+			UpdateColumn("low_stock_notified_at", notifiedAt).Error; err != nil {
+			return nil, fmt.Errorf("failed to update ingredient: %w", err)
+		}
+		ingredient.LowStockNotifiedAt = notifiedAt
+	}
+	return &ingredient, nil
+}
+
+// recipeMenuNames mengembalikan nama menu yang resep dasarnya memakai bahan ini
+func recipeMenuNames(tx *gorm.DB, ingredientID uint) ([]string, error) {
+	var names []string
+	err := tx.Model(&models.Menu{}).
+		Where("id IN (SELECT menu_id FROM recipe_items WHERE ingredient_id = ? AND variant_id IS NULL)", ingredientID).
+		Order("name ASC").Pluck("name", &names).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load menus for ingredient: %w", err)
+	}
+	return names, nil
+}
+
+// OutOfStockMenuIDs mengembalikan menu yang resep dasarnya memakai bahan yang sudah habis.
+// Bahan khusus variant tidak membuat seluruh menu tidak tersedia.
+func OutOfStockMenuIDs(db *gorm.DB) (map[uint]bool, error) {
+	var menuIDs []uint
+	err := db.Model(&models.RecipeItem{}).
+		Joins("JOIN ingredients ON ingredients.id = recipe_items.ingredient_id AND ingredients.deleted_at IS NULL").
+		Where("recipe_items.variant_id IS NULL AND ingredients.stock <= 0").
+		Distinct().Pluck("recipe_items.menu_id", &menuIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ingredient stock: %w", err)
+	}
+	outOfStock := make(map[uint]bool, len(menuIDs))
+	for _, id := range menuIDs {
+		outOfStock[id] = true
+	}
+	return outOfStock, nil
+}
+
+func (s *InventoryService) applyInput(ingredient *models.Ingredient, input IngredientInput) error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrInvalidIngredient, fmt.Sprintf(format, args...))
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > 100 {
+		return invalid("name is required and must be at most 100 characters")
+	}
+	unit := strings.ToLower(strings.TrimSpace(input.Unit))
+	validUnit := false
+	for _, known := range models.IngredientUnits {
+		if unit == known {
+			validUnit = true
+			break
+		}
+	}
+	if !validUnit {
+		return invalid("unit must be one of %s", strings.Join(models.IngredientUnits, ", "))
+	}
+	if input.LowStockThreshold < 0 {
+		return invalid("low_stock_threshold must not be negative")
+	}
+
+	// Unscoped: nama bahan yang sudah dihapus masih memegang unique index
+	var count int64
+	if err := s.db.Unscoped().Model(&models.Ingredient{}).Where("name = ? AND id <> ?", name, ingredient.ID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check ingredient name: %w", err)
+	}
+	if count > 0 {
+		return invalid("name %s is already used", name)
+	}
+
+	ingredient.Name = name
+	ingredient.Unit = unit
+	ingredient.LowStockThreshold = roundQuantity(input.LowStockThreshold)
+	return nil
+}
+
+// roundQuantity membulatkan jumlah bahan ke 3 desimal, sesuai kolom decimal(14,3)
+func roundQuantity(quantity float64) float64 {
+	return math.Round(quantity*1000) / 1000
+}
+
+func formatQuantity(quantity float64) string {
+	return strconv.FormatFloat(quantity, 'f', -1, 64)
+}
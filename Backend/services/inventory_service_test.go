@@ -0,0 +1,164 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+func TestInventoryDepletion(t *testing.T) {
+	db := newPaymentTestDB(t)
+	if err := db.AutoMigrate(&models.MenuCategory{}, &models.Menu{}, &models.MenuVariant{}, &models.OrderItem{},
+		&models.Ingredient{}, &models.RecipeItem{}, &models.IngredientMovement{}, &models.Notification{}, &models.AvailabilitySchedule{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	// Order lama (sebelum bahan pertama didaftarkan) tidak boleh mengurangi stok
+	old := models.Order{CustomerID: 1, Status: OrderStatusCompleted, CreatedAt: time.Now().Add(-time.Hour)}
+	db.Create(&old)
+
+	service := NewInventoryService(db)
+	rice, err := service.CreateIngredient(IngredientInput{Name: "Beras", Unit: "KG", Stock: 1, LowStockThreshold: 0.5}, nil)
+	if err != nil {
+		t.Fatalf("CreateIngredient() error = %v", err)
+	}
+	egg, _ := service.CreateIngredient(IngredientInput{Name: "Telur", Unit: "pcs", Stock: 10, LowStockThreshold: 2}, nil)
+	milk, _ := service.CreateIngredient(IngredientInput{Name: "Susu", Unit: "ml", Stock: 1000}, nil)
+
+	nasi := models.Menu{Name: "Nasi Goreng", Price: utils.Rupiah(25000)}
+	extraEgg := models.Menu{Name: "Telur Ceplok", Price: utils.Rupiah(5000)}
+	kopi := models.Menu{Name: "Kopi", Price: utils.Rupiah(12000)}
+	db.Create(&nasi)
+	db.Create(&extraEgg)
+	db.Create(&kopi)
+	latte := models.MenuVariant{MenuID: kopi.ID, Name: "Latte", Price: utils.Rupiah(18000)}
+	db.Create(&latte)
+
+	if _, err := service.SetRecipe(nasi.ID, []RecipeItemInput{{IngredientID: rice.ID, Quantity: 0.2}, {IngredientID: egg.ID, Quantity: 1}}); err != nil {
+		t.Fatalf("SetRecipe() error = %v", err)
+	}
+	service.SetRecipe(extraEgg.ID, []RecipeItemInput{{IngredientID: egg.ID, Quantity: 1}})
+	recipe, err := service.SetRecipe(kopi.ID, []RecipeItemInput{{IngredientID: milk.ID, VariantID: &latte.ID, Quantity: 150}})
+	if err != nil || len(recipe) != 1 || recipe[0].Ingredient.Name != "Susu" {
+		t.Fatalf("SetRecipe(kopi) = %+v, %v", recipe, err)
+	}
+
+	invalid := [][]RecipeItemInput{
+		{{IngredientID: rice.ID, Quantity: 0}},
+		{{IngredientID: 999, Quantity: 1}},
+		{{IngredientID: rice.ID, Quantity: 1}, {IngredientID: rice.ID, Quantity: 2}},
+		{{IngredientID: rice.ID, VariantID: &latte.ID, Quantity: 1}},
+	}
+	for _, lines := range invalid {
+		if _, err := service.SetRecipe(nasi.ID, lines); !errors.Is(err, ErrInvalidRecipe) {
+			t.Errorf("SetRecipe(%+v) error = %v, want ErrInvalidRecipe", lines, err)
+		}
+	}
+
+	// Nasi goreng x2 + add-on telur, kopi latte x2, kopi biasa (tanpa resep susu)
+	order := models.Order{CustomerID: 1, Status: OrderStatusPendingPayment, OrderNumber: "A-001"}
+	db.Create(&order)
+	plate := models.OrderItem{OrderID: order.ID, MenuID: nasi.ID, Quantity: 2, Price: nasi.Price}
+	db.Create(&plate)
+	db.Create(&models.OrderItem{OrderID: order.ID, MenuID: extraEgg.ID, Quantity: 1, Price: extraEgg.Price, ParentItemID: &plate.ID})
+	db.Create(&models.OrderItem{OrderID: order.ID, MenuID: kopi.ID, VariantID: &latte.ID, Quantity: 2, Price: latte.Price})
+	db.Create(&models.OrderItem{OrderID: order.ID, MenuID: kopi.ID, Quantity: 1, Price: kopi.Price})
+
+	// Belum lunas: belum ada yang dikurangi
+	if n, err := service.DepletePendingOrders(InventoryDepleteOnPaid); err != nil || n != 0 {
+		t.Fatalf("DepletePendingOrders(unpaid) = %d, %v", n, err)
+	}
+	db.Model(&order).Update("status", OrderStatusPaid)
+	if n, _ := service.DepletePendingOrders(InventoryDepleteOnCooked); n != 0 {
+		t.Errorf("DepletePendingOrders(cooked) depleted %d paid orders, want 0", n)
+	}
+	if n, err := service.DepletePendingOrders(InventoryDepleteOnPaid); err != nil || n != 1 {
+		t.Fatalf("DepletePendingOrders(paid) = %d, %v, want 1", n, err)
+	}
+	if again, _ := service.DepleteOrder(order.ID); again {
+		t.Errorf("DepleteOrder() depleted the same order twice")
+	}
+
+	stock := func(id uint) float64 {
+		var ingredient models.Ingredient
+		db.First(&ingredient, id)
+		return ingredient.Stock
+	}
+	if got := stock(rice.ID); got != 0.6 {
+		t.Errorf("rice stock = %v, want 0.6", got)
+	}
+	if got := stock(egg.ID); got != 7 {
+		t.Errorf("egg stock = %v, want 7", got)
+	}
+	if got := stock(milk.ID); got != 700 {
+		t.Errorf("milk stock = %v, want 700", got)
+	}
+	var sales int64
+	db.Model(&models.IngredientMovement{}).Where("order_id = ? AND reason = ?", order.ID, models.IngredientMovementSale).Count(&sales)
+	if sales != 3 {
+		t.Errorf("sale movements = %d, want 3", sales)
+	}
+
+	// Beras 0.6 -> 0.2: menipis, satu notifikasi saja walau berkurang lagi
+	if _, err := service.AdjustStock(rice.ID, StockAdjustmentInput{Change: -0.4, Note: "susut"}, nil); err != nil {
+		t.Fatalf("AdjustStock() error = %v", err)
+	}
+	service.AdjustStock(rice.ID, StockAdjustmentInput{Change: -0.1}, nil)
+	notifications := func(title string) int64 {
+		var count int64
+		db.Model(&models.Notification{}).Where("type = ? AND title = ?", "inventory", title).Count(&count)
+		return count
+	}
+	if got := notifications("Low Ingredient Stock"); got != 1 {
+		t.Errorf("low stock notifications = %d, want 1", got)
+	}
+
+	// Habis: menu yang memakai beras tidak tersedia, notifikasi menyebut menunya
+	service.AdjustStock(rice.ID, StockAdjustmentInput{Change: -0.1}, nil)
+	var out models.Notification
+	db.Where("title = ?", "Ingredient Out of Stock").First(&out)
+	if !strings.Contains(out.Message, "Nasi Goreng") {
+		t.Errorf("out of stock notification = %q, want it to list Nasi Goreng", out.Message)
+	}
+	availability, err := NewMenuAvailabilityService(db).Current()
+	if err != nil {
+		t.Fatalf("Current() error = %v", err)
+	}
+	if availability.IsAvailable(&nasi) || !availability.IsAvailable(&extraEgg) || !availability.IsAvailable(&kopi) {
+		t.Errorf("availability: nasi %v, telur %v, kopi %v, want false, true, true",
+			availability.IsAvailable(&nasi), availability.IsAvailable(&extraEgg), availability.IsAvailable(&kopi))
+	}
+
+	// Restock mengembalikan menu dan mengaktifkan lagi notifikasi berikutnya
+	restocked, err := service.AdjustStock(rice.ID, StockAdjustmentInput{Change: 5, Reason: "restock"}, nil)
+	if err != nil || restocked.Stock != 5 || restocked.LowStockNotifiedAt != nil {
+		t.Errorf("restock = %+v, %v", restocked, err)
+	}
+	availability, _ = NewMenuAvailabilityService(db).Current()
+	if !availability.IsAvailable(&nasi) {
+		t.Errorf("nasi still unavailable after restock")
+	}
+
+	adjustments := []StockAdjustmentInput{{Change: 0}, {Change: -1, Reason: "restock"}, {Change: 1, Reason: "sale"}}
+	for _, input := range adjustments {
+		if _, err := service.AdjustStock(rice.ID, input, nil); !errors.Is(err, ErrInvalidIngredient) {
+			t.Errorf("AdjustStock(%+v) error = %v, want ErrInvalidIngredient", input, err)
+		}
+	}
+	if _, err := service.CreateIngredient(IngredientInput{Name: "Beras", Unit: "kg"}, nil); !errors.Is(err, ErrInvalidIngredient) {
+		t.Errorf("CreateIngredient(duplicate) error = %v, want ErrInvalidIngredient", err)
+	}
+	if err := service.DeleteIngredient(rice.ID); !errors.Is(err, ErrIngredientInUse) {
+		t.Errorf("DeleteIngredient(in use) error = %v, want ErrIngredientInUse", err)
+	}
+
+	var oldOrder models.Order
+	db.First(&oldOrder, old.ID)
+	if oldOrder.InventoryDepletedAt != nil {
+		t.Errorf("order created before inventory was set up was depleted")
+	}
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Nama transport email yang didukung (dipilih lewat env MAIL_TRANSPORT)
+const (
+	MailTransportSMTP = "smtp"
+	MailTransportFile = "file"
+)
+
+// Default konfigurasi email
+const (
+	defaultMailFrom      = "Restaurant <no-reply@localhost>"
+	defaultMailOutboxDir = "storage/mail"
+	defaultSMTPPort      = "587"
+	smtpTimeout          = 15 * time.Second
+)
+
+// ErrInvalidMail dikembalikan untuk email tanpa penerima atau dengan alamat yang tidak valid
+var ErrInvalidMail = errors.New("invalid mail")
+
+// Mail adalah satu email yang akan dikirim
+type Mail struct {
+	To          []string
+	Subject     string
+	Text        string
+	HTML        string // Opsional
+	Attachments []MailAttachment
+}
+
+// MailAttachment adalah file lampiran email
+type MailAttachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Mailer adalah kontrak transport email (SMTP atau pengganti lokal)
+type Mailer interface {
+	// Name mengembalikan nama transport, misalnya "smtp" atau "file"
+	Name() string
+	// Send mengirim email
+	Send(msg Mail) error
+}
+
+var (
+	mailer     Mailer
+	mailerOnce sync.Once
+	mailerMu   sync.RWMutex
+)
+
+// GetMailer mengembalikan transport aktif sesuai env MAIL_TRANSPORT (default file)
+func GetMailer() Mailer {
+	mailerOnce.Do(func() {
+		name := strings.ToLower(strings.TrimSpace(os.Getenv("MAIL_TRANSPORT")))
+		transport := newMailer(name)
+
+		mailerMu.Lock()
+		if mailer == nil {
+			mailer = transport
+		}
+		mailerMu.Unlock()
+
+		log.Printf("Mail transport initialized: %s", transport.Name())
+	})
+
+	mailerMu.RLock()
+	defer mailerMu.RUnlock()
+	return mailer
+}
+
+// SetMailer mengganti transport aktif (dipakai untuk test)
+func SetMailer(m Mailer) {
+	mailerOnce.Do(func() {})
+
+	mailerMu.Lock()
+	defer mailerMu.Unlock()
+	mailer = m
+}
+
+func newMailer(name string) Mailer {
+	from := envString("MAIL_FROM", defaultMailFrom)
+	switch name {
+	case MailTransportSMTP:
+		return &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     envString("SMTP_PORT", defaultSMTPPort),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	case "", MailTransportFile:
+		return &FileMailer{Dir: envString("MAIL_OUTBOX_DIR", defaultMailOutboxDir), From: from}
+	default:
+		log.Printf("WARNING: unknown MAIL_TRANSPORT %q, falling back to file", name)
+		return &FileMailer{Dir: envString("MAIL_OUTBOX_DIR", defaultMailOutboxDir), From: from}
+	}
+}
+
+// SMTPMailer mengirim email lewat server SMTP. Port 465 memakai TLS langsung; port lain
+// memakai STARTTLS jika server mendukungnya (mis. mailpit di port 1025 tanpa TLS).
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string // Kosong = tanpa autentikasi
+	Password string
+	From     string
+}
+
+// Name mengembalikan nama transport
+func (m *SMTPMailer) Name() string {
+	return MailTransportSMTP
+}
+
+// Send mengirim email ke semua penerima dalam satu sesi SMTP
+func (m *SMTPMailer) Send(msg Mail) error {
+	if m.Host == "" {
+		return fmt.Errorf("SMTP_HOST is not set")
+	}
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return fmt.Errorf("%w: MAIL_FROM %q: %v", ErrInvalidMail, m.From, err)
+	}
+	data, recipients, err := buildMailMessage(m.From, msg)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(m.Host, m.Port)
+	var conn net.Conn
+	if m.Port == "465" {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: smtpTimeout}, "tcp", addr, &tls.Config{ServerName: m.Host})
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, smtpTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server %s: %w", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(2 * smtpTimeout))
+
+	client, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && m.Port != "465" {
+		if err := client.StartTLS(&tls.Config{ServerName: m.Host}); err != nil {
+			return fmt.Errorf("SMTP STARTTLS failed: %w", err)
+		}
+	}
+	if m.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.Username, m.Password, m.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM failed: %w", err)
+	}
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("SMTP RCPT TO %s failed: %w", rcpt, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA failed: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP server rejected mail: %w", err)
+	}
+	return client.Quit()
+}
+
+// FileMailer menyimpan setiap email sebagai file .eml di Dir, pengganti SMTP untuk
+// development. File bisa dibuka di mail client atau diimpor ke mailpit.
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+// Name mengembalikan nama transport
+func (m *FileMailer) Name() string {
+	return MailTransportFile
+}
+
+// Send menulis email ke Dir/<waktu>-<acak>.eml
+func (m *FileMailer) Send(msg Mail) error {
+	data, _, err := buildMailMessage(m.From, msg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create mail outbox: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405"), randomHex(4))
+	if err := os.WriteFile(filepath.Join(m.Dir, name), data, 0644); err != nil {
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+	return nil
+}
+
+// buildMailMessage menyusun email MIME: teks (dan HTML jika ada) serta lampiran base64.
+// Mengembalikan isi email dan alamat penerima yang sudah divalidasi.
+func buildMailMessage(from string, msg Mail) ([]byte, []string, error) {
+	if len(msg.To) == 0 {
+		return nil, nil, fmt.Errorf("%w: no recipients", ErrInvalidMail)
+	}
+	recipients := make([]string, 0, len(msg.To))
+	for _, to := range msg.To {
+		addr, err := mail.ParseAddress(to)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: recipient %q", ErrInvalidMail, to)
+		}
+		recipients = append(recipients, addr.Address)
+	}
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: sender %q", ErrInvalidMail, from)
+	}
+
+	var buf bytes.Buffer
+	writeHeader := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	writeHeader("From", sender.String())
+	writeHeader("To", strings.Join(recipients, ", "))
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	writeHeader("Date", time.Now().Format(time.RFC1123Z))
+	writeHeader("Message-ID", fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), randomHex(6), mailDomain(sender.Address)))
+	writeHeader("MIME-Version", "1.0")
+
+	mixed := multipart.NewWriter(&buf)
+	writeHeader("Content-Type", `multipart/mixed; boundary="`+mixed.Boundary()+`"`)
+	buf.WriteString("\r\n")
+
+	// Isi email: teks, atau teks + HTML sebagai multipart/alternative
+	if msg.HTML == "" {
+		if err := writeMailPart(mixed, "text/plain; charset=utf-8", "", []byte(msg.Text)); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		var body bytes.Buffer
+		alternative := multipart.NewWriter(&body)
+		if err := writeMailPart(alternative, "text/plain; charset=utf-8", "", []byte(msg.Text)); err != nil {
+			return nil, nil, err
+		}
+		if err := writeMailPart(alternative, "text/html; charset=utf-8", "", []byte(msg.HTML)); err != nil {
+			return nil, nil, err
+		}
+		alternative.Close()
+		part, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {`multipart/alternative; boundary="` + alternative.Boundary() + `"`},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		part.Write(body.Bytes())
+	}
+
+	for _, attachment := range msg.Attachments {
+		contentType := attachment.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		disposition := mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})
+		if err := writeMailPart(mixed, contentType, disposition, attachment.Data); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), recipients, nil
+}
+
+// writeMailPart menulis satu bagian MIME dengan encoding base64 (baris 76 karakter)
+func writeMailPart(w *multipart.Writer, contentType, disposition string, data []byte) error {
+	header := textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"base64"},
+	}
+	if disposition != "" {
+		header.Set("Content-Disposition", disposition)
+	}
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := fmt.Fprintf(part, "%s\r\n", encoded[:76]); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = fmt.Fprintf(part, "%s\r\n", encoded)
+	return err
+}
+
+func mailDomain(address string) string {
+	if at := strings.LastIndex(address, "@"); at >= 0 {
+		return address[at+1:]
+	}
+	return "localhost"
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recordingMailer menyimpan email yang dikirim (dipakai test lain di package ini)
+type recordingMailer struct {
+	sent []Mail
+}
+
+func (m *recordingMailer) Name() string { return "recording" }
+
+func (m *recordingMailer) Send(msg Mail) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestFileMailer_Send(t *testing.T) {
+	dir := t.TempDir()
+	mailer := &FileMailer{Dir: dir, From: "Warung <no-reply@warung.test>"}
+
+	tests := []struct {
+		name    string
+		msg     Mail
+		wantErr error
+	}{
+		{name: "no recipients", msg: Mail{Subject: "x"}, wantErr: ErrInvalidMail},
+		{name: "invalid recipient", msg: Mail{To: []string{"bukan email"}, Subject: "x"}, wantErr: ErrInvalidMail},
+		{
+			name: "text, html and attachment",
+			msg: Mail{
+				To:          []string{"tamu@warung.test"},
+				Subject:     "Struk RCP/20261018/000001",
+				Text:        "Terima kasih",
+				HTML:        "<p>Terima kasih</p>",
+				Attachments: []MailAttachment{{Filename: "struk.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.4")}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := mailer.Send(tt.msg); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Send() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("outbox has %d files, want 1", len(files))
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	parsed, err := mail.ReadMessage(f)
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	if got := parsed.Header.Get("To"); got != "tamu@warung.test" {
+		t.Errorf("To = %q", got)
+	}
+	if subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject")); subject != "Struk RCP/20261018/000001" {
+		t.Errorf("Subject = %q", subject)
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q, want multipart/mixed", mediaType)
+	}
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	var parts []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextPart() error = %v", err)
+		}
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts = append(parts, partType+" "+part.FileName())
+	}
+	if got := strings.Join(parts, ","); got != "multipart/alternative ,application/pdf struk.pdf" {
+		t.Errorf("parts = %q", got)
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"gorm.io/gorm"
+)
+
+// MediaMigrationOptions mengatur MigrateMenuImages
+type MediaMigrationOptions struct {
+	DryRun       bool // Hanya menghitung, tidak menyalin atau mengubah database
+	DeleteSource bool // Hapus objek di storage asal setelah menu diperbarui
+}
+
+// MediaMigrationResult adalah ringkasan MigrateMenuImages
+type MediaMigrationResult struct {
+	Menus   int      `json:"menus"`   // Menu yang ImageUrls-nya diperbarui
+	Moved   int      `json:"moved"`   // Gambar yang dipindahkan
+	Skipped int      `json:"skipped"` // Sudah di storage tujuan atau bukan milik storage asal
+	Failed  int      `json:"failed"`  // Gagal dipindahkan, URL lama dipertahankan
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// MigrateMenuImages menyalin semua gambar menu (semua rendisinya) dari storage from ke to
+// dan mengganti URL di Menu.ImageUrls. Aman dijalankan ulang: gambar yang sudah ada di
+// storage tujuan dilewati, dan gambar yang gagal tetap memakai URL lama.
+func MigrateMenuImages(db *gorm.DB, from, to ObjectStorage, opts MediaMigrationOptions) (*MediaMigrationResult, error) {
+	var menus []models.Menu
+	if err := db.Unscoped().Order("id ASC").Find(&menus).Error; err != nil {
+		return nil, fmt.Errorf("failed to load menus: %w", err)
+	}
+
+	result := &MediaMigrationResult{}
+	for i := range menus {
+		menu := &menus[i]
+		urls := menu.GetImageUrls()
+		changed := false
+		var moved []string
+
+		for j, imageURL := range urls {
+			if key, ok := to.KeyFromURL(imageURL); ok && to.URL(key) == imageURL {
+				result.Skipped++
+				continue
+			}
+			key, ok := from.KeyFromURL(imageURL)
+			if !ok {
+				result.Skipped++
+				continue
+			}
+			if opts.DryRun {
+				result.Moved++
+				continue
+			}
+
+			keys, err := copyMenuImage(from, to, key)
+			if err != nil {
+				result.Failed++
+				result.Errors = append(result.Errors, fmt.Sprintf("menu %d: %v", menu.ID, err))
+				continue
+			}
+			urls[j] = to.URL(key)
+			moved = append(moved, keys...)
+			changed = true
+			result.Moved++
+		}
+
+		if !changed {
+			continue
+		}
+		if err := menu.SetImageUrls(urls); err != nil {
+			return result, err
+		}
+		if err := db.Unscoped().Model(&models.Menu{}).Where("id = ?", menu.ID).
+			UpdateColumn("image_urls", menu.ImageUrls).Error; err != nil {
+			return result, fmt.Errorf("failed to update menu %d: %w", menu.ID, err)
+		}
+		result.Menus++
+
+		if opts.DeleteSource {
+			for _, key := range moved {
+				if err := from.Delete(key); err != nil {
+					log.Printf("Failed to delete migrated object %s: %v", key, err)
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// copyMenuImage menyalin semua rendisi satu gambar. Gambar lama tanpa rendisi hanya
+// punya satu file.
+func copyMenuImage(from, to ObjectStorage, key string) ([]string, error) {
+	keys := []string{key}
+	for _, rendition := range MenuImageRenditions {
+		if renditionKey := models.MenuImageRenditionURL(key, rendition.Name); renditionKey != key {
+			keys = append(keys, renditionKey)
+		}
+	}
+
+	for _, k := range keys {
+		data, err := from.Get(k)
+		if err != nil {
+			// Rendisi yang hilang tidak menggagalkan gambar selama file utamanya ada
+			if errors.Is(err, ErrObjectNotFound) && k != key {
+				continue
+			}
+			return nil, err
+		}
+		if err := to.Put(k, data, http.DetectContentType(data)); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
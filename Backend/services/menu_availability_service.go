@@ -0,0 +1,370 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"gorm.io/gorm"
+)
+
+// ErrMenuUnavailable dikembalikan jika menu dipesan di luar jadwal ketersediaannya
+var ErrMenuUnavailable = errors.New("menu is not available at this time")
+
+// ErrInvalidAvailabilitySchedule dikembalikan jika isian jadwal ketersediaan tidak valid
+var ErrInvalidAvailabilitySchedule = errors.New("invalid availability schedule")
+
+// AvailabilityScheduleInput adalah isian jadwal ketersediaan dari admin
+type AvailabilityScheduleInput struct {
+	MenuID     *uint   `json:"menu_id"`
+	CategoryID *uint   `json:"category_id"`
+	Name       string  `json:"name"`
+	Kind       string  `json:"kind"`       // available (default) atau unavailable
+	Days       string  `json:"days"`       // Mis. "1,2,3,4,5"; kosong = setiap hari
+	StartTime  string  `json:"start_time"` // HH:MM; kosong bersama end_time = sepanjang hari
+	EndTime    string  `json:"end_time"`
+	StartDate  *string `json:"start_date"` // 2006-01-02
+	EndDate    *string `json:"end_date"`
+}
+
+// MenuAvailabilityService mengevaluasi dan mengelola jadwal ketersediaan menu
+type MenuAvailabilityService struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+// NewMenuAvailabilityService membuat instance baru MenuAvailabilityService
+func NewMenuAvailabilityService(db *gorm.DB) *MenuAvailabilityService {
+	return &MenuAvailabilityService{db: db, now: time.Now}
+}
+
+// MenuAvailability adalah hasil evaluasi semua jadwal pada satu waktu
+type MenuAvailability struct {
+	at            time.Time // Dalam zona waktu restoran
+	menuRules     map[uint][]availabilityRule
+	categoryRules map[uint][]availabilityRule
+	outOfStock    map[uint]bool // Menu yang bahan resepnya habis, lihat OutOfStockMenuIDs
+}
+
+// availabilityRule adalah AvailabilitySchedule yang sudah di-parse
+type availabilityRule struct {
+	kind      string
+	days      [8]bool // Indeks 1-7 (ISO), semua false = setiap hari
+	anyDay    bool
+	allDay    bool
+	start     int // Menit sejak tengah malam
+	end       int
+	startDate string // Kosong = tanpa batas
+	endDate   string
+}
+
+// Current mengevaluasi jadwal untuk waktu sekarang
+func (s *MenuAvailabilityService) Current() (*MenuAvailability, error) {
+	return s.At(s.now())
+}
+
+// At memuat semua jadwal dan mengevaluasinya pada waktu at di zona waktu restoran
+func (s *MenuAvailabilityService) At(at time.Time) (*MenuAvailability, error) {
+	var schedules []models.AvailabilitySchedule
+	if err := s.db.Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to load availability schedules: %w", err)
+	}
+
+	outOfStock, err := OutOfStockMenuIDs(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := CurrentRestaurantProfile()
+	availability := &MenuAvailability{
+		at:            at.In(profile.Location()),
+		menuRules:     make(map[uint][]availabilityRule),
+		categoryRules: make(map[uint][]availabilityRule),
+		outOfStock:    outOfStock,
+	}
+	for _, schedule := range schedules {
+		rule := parseAvailabilityRule(schedule)
+		switch {
+		case schedule.MenuID != nil:
+			availability.menuRules[*schedule.MenuID] = append(availability.menuRules[*schedule.MenuID], rule)
+		case schedule.CategoryID != nil:
+			availability.categoryRules[*schedule.CategoryID] = append(availability.categoryRules[*schedule.CategoryID], rule)
+		}
+	}
+	return availability, nil
+}
+
+// IsAvailable memeriksa stok bahan, jadwal kategori lalu jadwal menu; semuanya harus mengizinkan
+func (a *MenuAvailability) IsAvailable(menu *models.Menu) bool {
+	if a.outOfStock[menu.ID] {
+		return false
+	}
+	return rulesAllow(a.categoryRules[menu.CategoryID], a.at) && rulesAllow(a.menuRules[menu.ID], a.at)
+}
+
+// Mark mengisi field Available di setiap menu
+func (a *MenuAvailability) Mark(menus []models.Menu) {
+	for i := range menus {
+		available := a.IsAvailable(&menus[i])
+		menus[i].Available = &available
+	}
+}
+
+// Filter mengembalikan hanya menu yang tersedia (untuk endpoint customer)
+func (a *MenuAvailability) Filter(menus []models.Menu) []models.Menu {
+	a.Mark(menus)
+	available := make([]models.Menu, 0, len(menus))
+	for _, menu := range menus {
+		if *menu.Available {
+			available = append(available, menu)
+		}
+	}
+	return available
+}
+
+// rulesAllow: aturan unavailable yang cocok selalu menolak. Jika ada aturan available,
+// salah satunya harus cocok. Tanpa aturan, menu selalu tersedia.
+func rulesAllow(rules []availabilityRule, at time.Time) bool {
+	hasWindow, inWindow := false, false
+	for _, rule := range rules {
+		if rule.kind == models.AvailabilityUnavailable {
+			if rule.matches(at) {
+				return false
+			}
+			continue
+		}
+		hasWindow = true
+		if rule.matches(at) {
+			inWindow = true
+		}
+	}
+	return !hasWindow || inWindow
+}
+
+func (r availabilityRule) matches(at time.Time) bool {
+	minute := at.Hour()*60 + at.Minute()
+	switch {
+	case r.allDay:
+		return r.matchesDay(at)
+	case r.start < r.end:
+		return minute >= r.start && minute < r.end && r.matchesDay(at)
+	case minute >= r.start:
+		return r.matchesDay(at)
+	case minute < r.end:
+		// Jendela lewat tengah malam (mis. 22:00-02:00) milik hari sebelumnya
+		return r.matchesDay(at.AddDate(0, 0, -1))
+	}
+	return false
+}
+
+func (r availabilityRule) matchesDay(day time.Time) bool {
+	if !r.anyDay {
+		weekday := int(day.Weekday())
+		if weekday == 0 {
+			weekday = 7
+		}
+		if !r.days[weekday] {
+			return false
+		}
+	}
+	date := day.Format("2006-01-02")
+	if r.startDate != "" && date < r.startDate {
+		return false
+	}
+	if r.endDate != "" && date > r.endDate {
+		return false
+	}
+	return true
+}
+
+func parseAvailabilityRule(schedule models.AvailabilitySchedule) availabilityRule {
+	rule := availabilityRule{kind: schedule.Kind, anyDay: true, allDay: schedule.StartTime == ""}
+	days, _ := parseAvailabilityDays(schedule.Days)
+	for _, day := range days {
+		rule.days[day] = true
+		rule.anyDay = false
+	}
+	if !rule.allDay {
+		rule.start, _ = parseClockMinutes(schedule.StartTime)
+		rule.end, _ = parseClockMinutes(schedule.EndTime)
+	}
+	if schedule.StartDate != nil {
+		rule.startDate = *schedule.StartDate
+	}
+	if schedule.EndDate != nil {
+		rule.endDate = *schedule.EndDate
+	}
+	return rule
+}
+
+// ListSchedules mengembalikan jadwal, bisa difilter per menu atau kategori
+func (s *MenuAvailabilityService) ListSchedules(menuID, categoryID uint) ([]models.AvailabilitySchedule, error) {
+	query := s.db.Order("id ASC")
+	if menuID > 0 {
+		query = query.Where("menu_id = ?", menuID)
+	}
+	if categoryID > 0 {
+		query = query.Where("category_id = ?", categoryID)
+	}
+	var schedules []models.AvailabilitySchedule
+	err := query.Find(&schedules).Error
+	return schedules, err
+}
+
+// CreateSchedule membuat jadwal ketersediaan baru
+func (s *MenuAvailabilityService) CreateSchedule(input AvailabilityScheduleInput) (*models.AvailabilitySchedule, error) {
+	var schedule models.AvailabilitySchedule
+	if err := s.applyInput(&schedule, input); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(&schedule).Error; err != nil {
+		return nil, fmt.Errorf("failed to create availability schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+// UpdateSchedule mengganti isi jadwal ketersediaan
+func (s *MenuAvailabilityService) UpdateSchedule(scheduleID uint, input AvailabilityScheduleInput) (*models.AvailabilitySchedule, error) {
+	var schedule models.AvailabilitySchedule
+	if err := s.db.First(&schedule, scheduleID).Error; err != nil {
+		return nil, err
+	}
+	if err := s.applyInput(&schedule, input); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(&schedule).Error; err != nil {
+		return nil, fmt.Errorf("failed to update availability schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+// DeleteSchedule menghapus jadwal ketersediaan
+func (s *MenuAvailabilityService) DeleteSchedule(scheduleID uint) error {
+	result := s.db.Delete(&models.AvailabilitySchedule{}, scheduleID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *MenuAvailabilityService) applyInput(schedule *models.AvailabilitySchedule, input AvailabilityScheduleInput) error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrInvalidAvailabilitySchedule, fmt.Sprintf(format, args...))
+	}
+
+	if (input.MenuID == nil) == (input.CategoryID == nil) {
+		return invalid("exactly one of menu_id or category_id is required")
+	}
+	if input.MenuID != nil {
+		if err := s.db.First(&models.Menu{}, *input.MenuID).Error; err != nil {
+			return invalid("menu %d not found", *input.MenuID)
+		}
+	} else if err := s.db.First(&models.MenuCategory{}, *input.CategoryID).Error; err != nil {
+		return invalid("category %d not found", *input.CategoryID)
+	}
+
+	kind := strings.ToLower(strings.TrimSpace(input.Kind))
+	if kind == "" {
+		kind = models.AvailabilityAvailable
+	}
+	if kind != models.AvailabilityAvailable && kind != models.AvailabilityUnavailable {
+		return invalid("kind must be available or unavailable")
+	}
+
+	days, err := parseAvailabilityDays(input.Days)
+	if err != nil {
+		return invalid("%v", err)
+	}
+	dayStrings := make([]string, len(days))
+	for i, day := range days {
+		dayStrings[i] = strconv.Itoa(day)
+	}
+
+	if (input.StartTime == "") != (input.EndTime == "") {
+		return invalid("start_time and end_time must be set together")
+	}
+	if input.StartTime != "" {
+		start, err := parseClockMinutes(input.StartTime)
+		if err != nil {
+			return invalid("start_time must be HH:MM")
+		}
+		end, err := parseClockMinutes(input.EndTime)
+		if err != nil {
+			return invalid("end_time must be HH:MM")
+		}
+		if start == end {
+			return invalid("start_time and end_time must differ, leave both empty for the whole day")
+		}
+	}
+
+	startDate, endDate := emptyToNil(input.StartDate), emptyToNil(input.EndDate)
+	for _, date := range []*string{startDate, endDate} {
+		if date == nil {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", *date); err != nil {
+			return invalid("dates must be YYYY-MM-DD")
+		}
+	}
+	if startDate != nil && endDate != nil && *endDate < *startDate {
+		return invalid("end_date is before start_date")
+	}
+
+	schedule.MenuID = input.MenuID
+	schedule.CategoryID = input.CategoryID
+	schedule.Name = strings.TrimSpace(input.Name)
+	schedule.Kind = kind
+	schedule.Days = strings.Join(dayStrings, ",")
+	schedule.StartTime = input.StartTime
+	schedule.EndTime = input.EndTime
+	schedule.StartDate = startDate
+	schedule.EndDate = endDate
+	return nil
+}
+
+// parseAvailabilityDays membaca daftar hari ISO ("1,2,3") menjadi slice terurut tanpa duplikat
+func parseAvailabilityDays(value string) ([]int, error) {
+	seen := make(map[int]bool)
+	var days []int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		day, err := strconv.Atoi(part)
+		if err != nil || day < 1 || day > 7 {
+			return nil, fmt.Errorf("days must be numbers 1 (Monday) to 7 (Sunday)")
+		}
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+	sort.Ints(days)
+	return days, nil
+}
+
+// parseClockMinutes membaca jam HH:MM menjadi menit sejak tengah malam
+func parseClockMinutes(value string) (int, error) {
+	if !clockPattern.MatchString(value) {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	hour, _ := strconv.Atoi(value[:2])
+	minute, _ := strconv.Atoi(value[3:])
+	return hour*60 + minute, nil
+}
+
+func emptyToNil(value *string) *string {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	return &trimmed
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+func TestMenuAvailability(t *testing.T) {
+	db := newPaymentTestDB(t)
+	if err := db.AutoMigrate(&models.MenuCategory{}, &models.Menu{}, &models.AvailabilitySchedule{}, &models.Ingredient{}, &models.RecipeItem{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	InvalidateRestaurantProfile()
+	t.Cleanup(InvalidateRestaurantProfile)
+
+	breakfast := models.MenuCategory{Name: "Sarapan"}
+	mains := models.MenuCategory{Name: "Makanan"}
+	db.Create(&breakfast)
+	db.Create(&mains)
+	bubur := models.Menu{CategoryID: breakfast.ID, Name: "Bubur Ayam", Price: utils.Rupiah(15000)}
+	nasi := models.Menu{CategoryID: mains.ID, Name: "Nasi Goreng", Price: utils.Rupiah(25000)}
+	iga := models.Menu{CategoryID: mains.ID, Name: "Iga Bakar", Price: utils.Rupiah(60000)}
+	for _, menu := range []*models.Menu{&bubur, &nasi, &iga} {
+		db.Create(menu)
+	}
+
+	service := NewMenuAvailabilityService(db)
+	holiday := "2026-12-25"
+	inputs := []AvailabilityScheduleInput{
+		// Sarapan setiap hari 06:00-10:30
+		{CategoryID: &breakfast.ID, Name: "Sarapan", StartTime: "06:00", EndTime: "10:30"},
+		// Iga bakar hanya Sabtu-Minggu, termasuk larut malam sampai 01:00
+		{MenuID: &iga.ID, Name: "Weekend", Days: "6,7", StartTime: "17:00", EndTime: "01:00"},
+		// Dapur utama tutup saat Natal
+		{CategoryID: &mains.ID, Name: "Natal", Kind: models.AvailabilityUnavailable, StartDate: &holiday, EndDate: &holiday},
+	}
+	for _, input := range inputs {
+		if _, err := service.CreateSchedule(input); err != nil {
+			t.Fatalf("CreateSchedule(%s) error = %v", input.Name, err)
+		}
+	}
+
+	invalid := []AvailabilityScheduleInput{
+		{Name: "no target"},
+		{MenuID: &nasi.ID, CategoryID: &mains.ID, Name: "both targets"},
+		{MenuID: &nasi.ID, Days: "0,8"},
+		{MenuID: &nasi.ID, StartTime: "10:00"},
+		{MenuID: &nasi.ID, StartTime: "10:00", EndTime: "10:00"},
+		{MenuID: &nasi.ID, Kind: "sometimes"},
+	}
+	for _, input := range invalid {
+		if _, err := service.CreateSchedule(input); !errors.Is(err, ErrInvalidAvailabilitySchedule) {
+			t.Errorf("CreateSchedule(%+v) error = %v, want ErrInvalidAvailabilitySchedule", input, err)
+		}
+	}
+
+	jakarta, _ := time.LoadLocation(DefaultRestaurantTimezone)
+	tests := []struct {
+		name string
+		at   time.Time
+		want map[string]bool
+	}{
+		{name: "friday breakfast", at: time.Date(2026, 10, 16, 7, 0, 0, 0, jakarta),
+			want: map[string]bool{"Bubur Ayam": true, "Nasi Goreng": true, "Iga Bakar": false}},
+		{name: "friday lunch", at: time.Date(2026, 10, 16, 12, 0, 0, 0, jakarta),
+			want: map[string]bool{"Bubur Ayam": false, "Nasi Goreng": true, "Iga Bakar": false}},
+		{name: "saturday dinner", at: time.Date(2026, 10, 17, 19, 0, 0, 0, jakarta),
+			want: map[string]bool{"Bubur Ayam": false, "Nasi Goreng": true, "Iga Bakar": true}},
+		{name: "sunday after midnight belongs to saturday", at: time.Date(2026, 10, 18, 0, 30, 0, 0, jakarta),
+			want: map[string]bool{"Iga Bakar": true}},
+		{name: "monday after midnight belongs to sunday", at: time.Date(2026, 10, 19, 0, 30, 0, 0, jakarta),
+			want: map[string]bool{"Iga Bakar": true}},
+		{name: "tuesday after midnight", at: time.Date(2026, 10, 20, 0, 30, 0, 0, jakarta),
+			want: map[string]bool{"Iga Bakar": false}},
+		{name: "christmas breakfast", at: time.Date(2026, 12, 25, 8, 0, 0, 0, jakarta),
+			want: map[string]bool{"Bubur Ayam": true, "Nasi Goreng": false}},
+		// 23:30 UTC adalah 06:30 WIB keesokan harinya
+		{name: "evaluated in restaurant timezone", at: time.Date(2026, 10, 15, 23, 30, 0, 0, time.UTC),
+			want: map[string]bool{"Bubur Ayam": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			availability, err := service.At(tt.at)
+			if err != nil {
+				t.Fatalf("At() error = %v", err)
+			}
+			for _, menu := range []models.Menu{bubur, nasi, iga} {
+				want, ok := tt.want[menu.Name]
+				if !ok {
+					continue
+				}
+				if got := availability.IsAvailable(&menu); got != want {
+					t.Errorf("IsAvailable(%s) = %v, want %v", menu.Name, got, want)
+				}
+			}
+		})
+	}
+
+	availability, _ := service.At(time.Date(2026, 10, 16, 12, 0, 0, 0, jakarta))
+	if menus := availability.Filter([]models.Menu{bubur, nasi, iga}); len(menus) != 1 || menus[0].Name != "Nasi Goreng" {
+		t.Errorf("Filter() = %+v, want only Nasi Goreng", menus)
+	}
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // Decoder GIF
+	"image/jpeg"
+	_ "image/png" // Decoder PNG
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // Decoder WebP
+)
+
+// MaxMenuImageBytes adalah ukuran maksimum satu file gambar menu yang diupload
+const MaxMenuImageBytes = 5 << 20
+
+// maxMenuImagePixels membatasi dimensi gambar agar file kecil berdimensi raksasa tidak menghabiskan memori
+const maxMenuImagePixels = 40_000_000
+
+// menuImageKeyPrefix adalah folder gambar menu di storage
+const menuImageKeyPrefix = "menu_images/"
+
+// IsMenuImageKey melaporkan apakah key berada di folder gambar menu, satu-satunya folder
+// yang boleh diakses publik lewat /media
+func IsMenuImageKey(key string) bool {
+	return strings.HasPrefix(key, menuImageKeyPrefix) && len(key) > len(menuImageKeyPrefix)
+}
+
+// menuImageQuality adalah kualitas JPEG semua rendisi
+const menuImageQuality = 82
+
+// ErrImageTooLarge dikembalikan jika file atau dimensi gambar melebihi batas
+var ErrImageTooLarge = errors.New("image is too large")
+
+// ErrUnsupportedImage dikembalikan jika isi file bukan JPEG, PNG, GIF atau WebP
+var ErrUnsupportedImage = errors.New("unsupported image type")
+
+// menuImageTypes adalah tipe konten (hasil sniffing, bukan nama file) yang diterima
+var menuImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// MenuImageRenditions adalah ukuran sisi terpanjang setiap rendisi. Gambar tidak pernah diperbesar.
+var MenuImageRenditions = []struct {
+	Name    string
+	MaxSide int
+}{
+	{models.MenuImageThumbnail, 200},
+	{models.MenuImageMedium, 600},
+	{models.MenuImageLarge, 1200},
+}
+
+// ProcessedMenuImage adalah hasil ProcessMenuImage: ID acak dan isi JPEG setiap rendisi
+type ProcessedMenuImage struct {
+	ID         string
+	Renditions map[string][]byte
+}
+
+// ProcessMenuImage memvalidasi upload dari isinya, membuang metadata (EXIF dll.) dengan
+// meng-encode ulang, dan membuat rendisi thumbnail, medium dan large dalam format JPEG.
+// Orientasi EXIF diterapkan lebih dulu agar foto dari ponsel tidak miring.
+func ProcessMenuImage(r io.Reader) (*ProcessedMenuImage, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxMenuImageBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if len(data) > MaxMenuImageBytes {
+		return nil, fmt.Errorf("%w: maximum is %d MB", ErrImageTooLarge, MaxMenuImageBytes>>20)
+	}
+	contentType := http.DetectContentType(data)
+	if !menuImageTypes[contentType] {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedImage, contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	if config.Width*config.Height > maxMenuImagePixels {
+		return nil, fmt.Errorf("%w: %dx%d pixels", ErrImageTooLarge, config.Width, config.Height)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+
+	// JPEG tidak punya transparansi, jadi latar transparan dijadikan putih
+	bounds := src.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), src, bounds.Min, draw.Over)
+
+	var oriented image.Image = flat
+	if contentType == "image/jpeg" {
+		oriented = applyOrientation(flat, jpegOrientation(data))
+	}
+
+	processed := &ProcessedMenuImage{ID: randomHex(16), Renditions: make(map[string][]byte)}
+	for _, rendition := range MenuImageRenditions {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, fitImage(oriented, rendition.MaxSide), &jpeg.Options{Quality: menuImageQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode %s rendition: %w", rendition.Name, err)
+		}
+		processed.Renditions[rendition.Name] = buf.Bytes()
+	}
+	return processed, nil
+}
+
+// Store menyimpan semua rendisi ke storage dan mengembalikan URL rendisi large, yang
+// disimpan di Menu.ImageUrls. Jika gagal, rendisi yang sudah tersimpan dihapus lagi.
+func (p *ProcessedMenuImage) Store(store ObjectStorage) (string, error) {
+	var written []string
+	for _, rendition := range MenuImageRenditions {
+		key := menuImageKeyPrefix + models.MenuImageFilename(p.ID, rendition.Name)
+		if err := store.Put(key, p.Renditions[rendition.Name], "image/jpeg"); err != nil {
+			for _, w := range written {
+				store.Delete(w)
+			}
+			return "", fmt.Errorf("failed to store image: %w", err)
+		}
+		written = append(written, key)
+	}
+	return store.URL(menuImageKeyPrefix + models.MenuImageFilename(p.ID, models.MenuImageLarge)), nil
+}
+
+// DeleteMenuImage menghapus semua rendisi gambar menu dari URL-nya. URL yang belum
+// dipindahkan dari disk lokal tetap dikenali walaupun storage aktif sudah S3.
+func DeleteMenuImage(imageURL string) {
+	stores := []ObjectStorage{GetStorage()}
+	if stores[0].Name() != StorageBackendLocal {
+		stores = append(stores, NewStorage(StorageBackendLocal))
+	}
+	for _, store := range stores {
+		key, ok := store.KeyFromURL(imageURL)
+		if !ok {
+			continue
+		}
+		if !IsMenuImageKey(key) {
+			return
+		}
+		for _, rendition := range MenuImageRenditions {
+			renditionKey := models.MenuImageRenditionURL(key, rendition.Name)
+			if err := store.Delete(renditionKey); err != nil {
+				log.Printf("Failed to delete menu image %s: %v", renditionKey, err)
+			}
+		}
+		return
+	}
+}
+
+// fitImage mengecilkan gambar agar sisi terpanjangnya maxSide
+func fitImage(src image.Image, maxSide int) image.Image {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if w <= maxSide && h <= maxSide {
+		return src
+	}
+	if w >= h {
+		h = max(1, h*maxSide/w)
+		w = maxSide
+	} else {
+		w = max(1, w*maxSide/h)
+		h = maxSide
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+	return dst
+}
+
+// jpegOrientation membaca tag Orientation (0x0112) dari segmen EXIF JPEG; 1 jika tidak ada
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			return 1
+		}
+		marker := data[pos+1]
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		// SOS: data gambar dimulai, tidak ada EXIF lagi
+		if marker == 0xDA || length < 2 || pos+2+length > len(data) {
+			return 1
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		pos += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation mencari tag Orientation di IFD0 header TIFF
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if o := int(order.Uint16(tiff[entry+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// applyOrientation memutar/mencerminkan gambar sesuai nilai Orientation EXIF (1-8)
+func applyOrientation(src *image.RGBA, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // Cermin horizontal
+				dx, dy = w-1-x, y
+			case 3: // Putar 180
+				dx, dy = w-1-x, h-1-y
+			case 4: // Cermin vertikal
+				dx, dy = x, h-1-y
+			case 5: // Transpose
+				dx, dy = y, x
+			case 6: // Putar 90 searah jarum jam
+				dx, dy = h-1-y, x
+			case 7: // Transverse
+				dx, dy = h-1-y, w-1-x
+			case 8: // Putar 90 berlawanan jarum jam
+				dx, dy = y, w-1-x
+			}
+			dst.SetRGBA(dx, dy, src.RGBAAt(x, y))
+		}
+	}
+	return dst
+}
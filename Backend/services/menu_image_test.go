@@ -0,0 +1,128 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yeremiapane/restaurant-app/models"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w/2; x++ {
+		img.Set(x, 0, color.NRGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+// encodeOrientedJPEG membuat JPEG dengan segmen EXIF berisi tag Orientation
+func encodeOrientedJPEG(t *testing.T, w, h, orientation int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatalf("jpeg.Encode() error = %v", err)
+	}
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1, 0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, byte(orientation), 0, 0, 0, 0, 0, 0}
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, byte((len(segment) + 2) >> 8), byte(len(segment) + 2)}
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), append(app1, segment...)...), data[2:]...)
+}
+
+func TestProcessMenuImage(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+		want    map[string][2]int
+	}{
+		{
+			name: "landscape png is resized",
+			data: encodePNG(t, 1600, 800),
+			want: map[string][2]int{models.MenuImageThumbnail: {200, 100}, models.MenuImageMedium: {600, 300}, models.MenuImageLarge: {1200, 600}},
+		},
+		{
+			name: "small image is not enlarged",
+			data: encodePNG(t, 300, 150),
+			want: map[string][2]int{models.MenuImageThumbnail: {200, 100}, models.MenuImageMedium: {300, 150}, models.MenuImageLarge: {300, 150}},
+		},
+		{
+			name: "exif orientation is applied",
+			data: encodeOrientedJPEG(t, 400, 200, 6),
+			want: map[string][2]int{models.MenuImageThumbnail: {100, 200}, models.MenuImageMedium: {200, 400}, models.MenuImageLarge: {200, 400}},
+		},
+		{name: "script renamed to jpg", data: []byte("<?php echo 'hi'; ?>"), wantErr: ErrUnsupportedImage},
+		{name: "oversized file", data: append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, MaxMenuImageBytes)...), wantErr: ErrImageTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processed, err := ProcessMenuImage(bytes.NewReader(tt.data))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ProcessMenuImage() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			for name, size := range tt.want {
+				img, format, err := image.Decode(bytes.NewReader(processed.Renditions[name]))
+				if err != nil || format != "jpeg" {
+					t.Fatalf("%s: decode = %s, %v, want jpeg", name, format, err)
+				}
+				if got := [2]int{img.Bounds().Dx(), img.Bounds().Dy()}; got != size {
+					t.Errorf("%s: size = %v, want %v", name, got, size)
+				}
+				if bytes.Contains(processed.Renditions[name], []byte("Exif")) {
+					t.Errorf("%s: EXIF was not stripped", name)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessedMenuImageStore(t *testing.T) {
+	processed, err := ProcessMenuImage(bytes.NewReader(encodePNG(t, 50, 50)))
+	if err != nil {
+		t.Fatalf("ProcessMenuImage() error = %v", err)
+	}
+	dir := t.TempDir()
+	store := &LocalStorage{Dir: dir}
+	largeURL, err := processed.Store(store)
+	if err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "menu_images"))
+	if len(entries) != len(MenuImageRenditions) {
+		t.Fatalf("stored %d files, want %d", len(entries), len(MenuImageRenditions))
+	}
+
+	img := models.NewMenuImage(largeURL)
+	if !strings.HasSuffix(img.Large, "/uploads/menu_images/"+processed.ID+"-large.jpg") ||
+		!strings.HasSuffix(img.Thumbnail, processed.ID+"-thumb.jpg") || !strings.HasSuffix(img.Medium, processed.ID+"-medium.jpg") {
+		t.Errorf("NewMenuImage() = %+v", img)
+	}
+	legacy := models.NewMenuImage("/uploads/menu_images/123-photo.png")
+	if legacy.Thumbnail != legacy.Large || legacy.Medium != legacy.Large {
+		t.Errorf("legacy NewMenuImage() = %+v, want original URL for every rendition", legacy)
+	}
+
+	// Menghapus gambar menghapus semua rendisinya
+	SetStorage(store)
+	t.Cleanup(func() { SetStorage(NewStorage(StorageBackendLocal)) })
+	DeleteMenuImage(largeURL)
+	if entries, _ := os.ReadDir(filepath.Join(dir, "menu_images")); len(entries) != 0 {
+		t.Errorf("%d files left after DeleteMenuImage, want 0", len(entries))
+	}
+}
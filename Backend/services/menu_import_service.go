@@ -0,0 +1,450 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+// Format file impor/ekspor menu
+const (
+	MenuFileCSV  = "csv"
+	MenuFileXLSX = "xlsx"
+)
+
+// Aksi per baris impor menu
+const (
+	MenuImportCreate    = "create"
+	MenuImportUpdate    = "update"
+	MenuImportUnchanged = "unchanged"
+	MenuImportError     = "error"
+)
+
+// MaxMenuImportRows membatasi jumlah baris satu file impor
+const MaxMenuImportRows = 2000
+
+// ErrInvalidMenuImport dikembalikan jika file impor tidak bisa dibaca atau header-nya salah
+var ErrInvalidMenuImport = errors.New("invalid menu import file")
+
+// menuImportColumns adalah urutan kolom file ekspor (dan template impor)
+var menuImportColumns = []string{"sku", "name", "category", "price", "stock", "description", "image_url"}
+
+// menuImportAliases memetakan judul kolom alternatif (mis. dari template berbahasa Indonesia)
+var menuImportAliases = map[string]string{
+	"nama":      "name",
+	"kategori":  "category",
+	"harga":     "price",
+	"stok":      "stock",
+	"deskripsi": "description",
+	"image":     "image_url",
+	"gambar":    "image_url",
+}
+
+// MenuImportRow adalah hasil validasi/impor satu baris
+type MenuImportRow struct {
+	Row      int      `json:"row"` // Nomor baris di file, header = 1
+	SKU      string   `json:"sku,omitempty"`
+	Name     string   `json:"name"`
+	Category string   `json:"category"`
+	Action   string   `json:"action"`
+	MenuID   uint     `json:"menu_id,omitempty"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// MenuImportReport adalah laporan impor. Impor bersifat semua-atau-tidak sama sekali:
+// jika ada satu baris error, tidak ada perubahan yang disimpan.
+type MenuImportReport struct {
+	DryRun            bool            `json:"dry_run"`
+	Applied           bool            `json:"applied"`
+	Created           int             `json:"created"`
+	Updated           int             `json:"updated"`
+	Unchanged         int             `json:"unchanged"`
+	Failed            int             `json:"failed"`
+	CategoriesCreated []string        `json:"categories_created"`
+	Rows              []MenuImportRow `json:"rows"`
+}
+
+// MenuImportService mengimpor dan mengekspor menu secara massal
+type MenuImportService struct {
+	db *gorm.DB
+}
+
+// NewMenuImportService membuat instance baru MenuImportService
+func NewMenuImportService(db *gorm.DB) *MenuImportService {
+	return &MenuImportService{db: db}
+}
+
+// ParseMenuFile membaca file CSV atau XLSX menjadi baris teks. Format dikenali dari isi
+// file (XLSX adalah arsip zip), bukan hanya dari ekstensinya.
+func ParseMenuFile(filename string, data []byte) ([][]string, error) {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		records, err := utils.ReadXLSX(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMenuImport, err)
+		}
+		return records, nil
+	}
+	if strings.EqualFold(filepath.Ext(filename), ".xlsx") {
+		return nil, fmt.Errorf("%w: file is not a valid xlsx workbook", ErrInvalidMenuImport)
+	}
+
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	// Excel dengan locale Indonesia menyimpan CSV dengan pemisah titik koma
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMenuImport, err)
+	}
+	return records, nil
+}
+
+// Import memvalidasi dan (jika bukan dryRun) menyimpan menu dari baris file. Menu dicocokkan
+// lewat SKU, atau lewat nama jika SKU kosong. Sel kosong mempertahankan nilai yang ada.
+// Kategori yang belum ada dibuat otomatis.
+func (s *MenuImportService) Import(records [][]string, dryRun bool) (*MenuImportReport, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidMenuImport)
+	}
+	columns, err := menuImportHeader(records[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(records)-1 > MaxMenuImportRows {
+		return nil, fmt.Errorf("%w: at most %d rows per file", ErrInvalidMenuImport, MaxMenuImportRows)
+	}
+
+	report := &MenuImportReport{DryRun: dryRun, CategoriesCreated: []string{}, Rows: []MenuImportRow{}}
+	var droppedImages []string
+
+	tx := s.db.Begin()
+	defer tx.Rollback()
+
+	categories, err := s.loadCategories(tx)
+	if err != nil {
+		return nil, err
+	}
+	seenSKU := make(map[string]int)
+	seenName := make(map[string]int)
+
+	for i, record := range records[1:] {
+		cell := func(column string) string {
+			if idx, ok := columns[column]; ok && idx < len(record) {
+				return strings.TrimSpace(record[idx])
+			}
+			return ""
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		row := MenuImportRow{Row: i + 2, SKU: cell("sku"), Name: cell("name"), Category: cell("category")}
+		fail := func(format string, args ...interface{}) {
+			row.Errors = append(row.Errors, fmt.Sprintf(format, args...))
+		}
+
+		if row.Name == "" && row.SKU == "" {
+			fail("name or sku is required")
+		}
+		if len(row.SKU) > 50 {
+			fail("sku must be at most 50 characters")
+		}
+		if row.SKU != "" {
+			if first, ok := seenSKU[strings.ToLower(row.SKU)]; ok {
+				fail("duplicate sku, already used in row %d", first)
+			}
+			seenSKU[strings.ToLower(row.SKU)] = row.Row
+		} else if row.Name != "" {
+			if first, ok := seenName[strings.ToLower(row.Name)]; ok {
+				fail("duplicate name, already used in row %d", first)
+			}
+			seenName[strings.ToLower(row.Name)] = row.Row
+		}
+
+		var price *utils.Money
+		if text := cell("price"); text != "" {
+			if value, err := utils.ParseMoney(text); err != nil || value < 0 {
+				fail("invalid price %q", text)
+			} else {
+				price = &value
+			}
+		}
+		var stock *int
+		if text := cell("stock"); text != "" {
+			if value, err := strconv.Atoi(text); err != nil || value < 0 {
+				fail("invalid stock %q", text)
+			} else {
+				stock = &value
+			}
+		}
+		imageURL := cell("image_url")
+		if imageURL != "" {
+			if parsed, err := url.Parse(imageURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				fail("image_url must be an http(s) URL")
+			}
+		}
+
+		menu, err := s.findMenu(tx, row.SKU, row.Name)
+		if err != nil {
+			return nil, err
+		}
+		isNew := menu == nil
+		if isNew {
+			menu = &models.Menu{}
+			if row.Name == "" {
+				fail("name is required for a new menu")
+			}
+			if row.Category == "" {
+				fail("category is required for a new menu")
+			}
+			if price == nil {
+				fail("price is required for a new menu")
+			}
+		} else {
+			// Menu lama yang cocok lewat nama dan belum punya SKU mendapat SKU dari file
+			row.MenuID = menu.ID
+			if row.SKU == "" && menu.SKU != nil {
+				row.SKU = *menu.SKU
+			}
+		}
+
+		if len(row.Errors) > 0 {
+			row.Action = MenuImportError
+			report.Failed++
+			report.Rows = append(report.Rows, row)
+			continue
+		}
+
+		before := menuImportSnapshot(menu)
+		if row.SKU != "" {
+			sku := row.SKU
+			menu.SKU = &sku
+		}
+		if row.Name != "" {
+			menu.Name = row.Name
+		}
+		if row.Category != "" {
+			category, created, err := s.category(tx, categories, row.Category)
+			if err != nil {
+				return nil, err
+			}
+			if created {
+				report.CategoriesCreated = append(report.CategoriesCreated, category.Name)
+			}
+			menu.CategoryID = category.ID
+		}
+		if price != nil {
+			menu.Price = *price
+		}
+		if stock != nil {
+			menu.Stock = *stock
+		}
+		if description := cell("description"); description != "" {
+			menu.Description = description
+		}
+		if imageURL != "" {
+			current := menu.GetImageUrls()
+			if len(current) == 0 || current[0] != imageURL {
+				droppedImages = append(droppedImages, current...)
+				if err := menu.SetImageUrls([]string{imageURL}); err != nil {
+					return nil, err
+				}
+			}
+		}
+		row.Name = menu.Name
+
+		switch {
+		case isNew:
+			if err := tx.Create(menu).Error; err != nil {
+				return nil, fmt.Errorf("row %d: failed to create menu: %w", row.Row, err)
+			}
+			row.Action = MenuImportCreate
+			row.MenuID = menu.ID
+			report.Created++
+		case menuImportSnapshot(menu) == before && !menu.DeletedAt.Valid:
+			row.Action = MenuImportUnchanged
+			report.Unchanged++
+		default:
+			// Menu yang pernah dihapus tetapi SKU-nya diimpor lagi diaktifkan kembali
+			menu.DeletedAt = gorm.DeletedAt{}
+			if err := tx.Unscoped().Save(menu).Error; err != nil {
+				return nil, fmt.Errorf("row %d: failed to update menu: %w", row.Row, err)
+			}
+			row.Action = MenuImportUpdate
+			report.Updated++
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	if dryRun || report.Failed > 0 {
+		return report, nil
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("failed to save menu import: %w", err)
+	}
+	report.Applied = true
+	for _, imageURL := range droppedImages {
+		DeleteMenuImage(imageURL)
+	}
+	return report, nil
+}
+
+// Export menulis semua menu aktif dalam format CSV atau XLSX dengan kolom yang sama
+// seperti file impor, sehingga hasilnya bisa diedit lalu diimpor kembali
+func (s *MenuImportService) Export(w io.Writer, format string) error {
+	var menus []models.Menu
+	if err := s.db.Preload("Category").Order("name ASC").Find(&menus).Error; err != nil {
+		return fmt.Errorf("failed to load menus: %w", err)
+	}
+
+	rows := [][]interface{}{make([]interface{}, len(menuImportColumns))}
+	for i, column := range menuImportColumns {
+		rows[0][i] = column
+	}
+	for _, menu := range menus {
+		sku := ""
+		if menu.SKU != nil {
+			sku = *menu.SKU
+		}
+		imageURL := ""
+		if urls := menu.GetImageUrls(); len(urls) > 0 {
+			imageURL = urls[0]
+		}
+		rows = append(rows, []interface{}{sku, menu.Name, menu.Category.Name, menu.Price, menu.Stock, menu.Description, imageURL})
+	}
+
+	if format == MenuFileXLSX {
+		return utils.WriteXLSX(w, "Menu", rows)
+	}
+	writer := csv.NewWriter(w)
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			if money, ok := value.(utils.Money); ok {
+				record[i] = money.Decimal()
+			} else {
+				record[i] = fmt.Sprint(value)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// menuImportHeader memetakan nama kolom ke indeksnya
+func menuImportHeader(header []string) (map[string]int, error) {
+	columns := make(map[string]int)
+	for i, title := range header {
+		name := strings.ToLower(strings.TrimSpace(title))
+		name = strings.ReplaceAll(strings.TrimPrefix(name, "\ufeff"), " ", "_")
+		if alias, ok := menuImportAliases[name]; ok {
+			name = alias
+		}
+		known := false
+		for _, column := range menuImportColumns {
+			if column == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			if name == "" {
+				continue
+			}
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidMenuImport, title)
+		}
+		if _, dup := columns[name]; dup {
+			return nil, fmt.Errorf("%w: duplicate column %q", ErrInvalidMenuImport, title)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		if _, ok := columns["sku"]; !ok {
+			return nil, fmt.Errorf("%w: a name or sku column is required", ErrInvalidMenuImport)
+		}
+	}
+	return columns, nil
+}
+
+func (s *MenuImportService) loadCategories(tx *gorm.DB) (map[string]*models.MenuCategory, error) {
+	var list []models.MenuCategory
+	if err := tx.Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+	categories := make(map[string]*models.MenuCategory, len(list))
+	for i := range list {
+		categories[strings.ToLower(list[i].Name)] = &list[i]
+	}
+	return categories, nil
+}
+
+// category mencari kategori berdasarkan nama (tanpa membedakan huruf besar), atau membuatnya
+func (s *MenuImportService) category(tx *gorm.DB, categories map[string]*models.MenuCategory, name string) (*models.MenuCategory, bool, error) {
+	if category, ok := categories[strings.ToLower(name)]; ok {
+		return category, false, nil
+	}
+	category := &models.MenuCategory{Name: name}
+	if err := tx.Create(category).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to create category %s: %w", name, err)
+	}
+	categories[strings.ToLower(name)] = category
+	return category, true, nil
+}
+
+// findMenu mencari menu lewat SKU (termasuk yang sudah dihapus, karena SKU tetap unik),
+// lalu lewat nama untuk menu yang belum punya SKU
+func (s *MenuImportService) findMenu(tx *gorm.DB, sku, name string) (*models.Menu, error) {
+	var menu models.Menu
+	if sku != "" {
+		err := tx.Unscoped().Where("sku = ?", sku).First(&menu).Error
+		if err == nil {
+			return &menu, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to find menu: %w", err)
+		}
+	}
+	if name == "" {
+		return nil, nil
+	}
+	query := tx.Where("LOWER(name) = ?", strings.ToLower(name))
+	if sku != "" {
+		query = query.Where("sku IS NULL")
+	}
+	err := query.First(&menu).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find menu: %w", err)
+	}
+	return &menu, nil
+}
+
+// menuImportSnapshot adalah nilai kolom yang bisa diubah lewat impor, untuk mendeteksi baris tanpa perubahan
+func menuImportSnapshot(menu *models.Menu) string {
+	sku := ""
+	if menu.SKU != nil {
+		sku = *menu.SKU
+	}
+	return strings.Join([]string{sku, menu.Name, strconv.FormatUint(uint64(menu.CategoryID), 10),
+		menu.Price.Decimal(), strconv.Itoa(menu.Stock), menu.Description, menu.ImageUrls}, "\x00")
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+func TestParseMenuFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		data     string
+		want     [][]string
+		wantErr  bool
+	}{
+		{"comma", "menu.csv", "name,price\nEs Teh,8000\n", [][]string{{"name", "price"}, {"Es Teh", "8000"}}, false},
+		{"semicolon with BOM", "menu.csv", "\xef\xbb\xbfnama;harga\n\"Teh; Manis\";8000\n", [][]string{{"nama", "harga"}, {"Teh; Manis", "8000"}}, false},
+		{"fake xlsx", "menu.xlsx", "name,price\n", nil, true},
+		{"broken quotes", "menu.csv", "name\n\"Es Teh\n", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMenuFile(tt.filename, []byte(tt.data))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidMenuImport) {
+					t.Errorf("ParseMenuFile() error = %v, want ErrInvalidMenuImport", err)
+				}
+				return
+			}
+			if err != nil || len(got) != len(tt.want) || got[1][0] != tt.want[1][0] || got[0][0] != tt.want[0][0] {
+				t.Errorf("ParseMenuFile() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMenuImport(t *testing.T) {
+	db := newPaymentTestDB(t)
+	if err := db.AutoMigrate(&models.MenuCategory{}, &models.Menu{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	drinks := models.MenuCategory{Name: "Minuman"}
+	db.Create(&drinks)
+	teh := models.Menu{CategoryID: drinks.ID, Name: "Es Teh", Price: utils.Rupiah(8000), Stock: 5, Description: "Segar"}
+	db.Create(&teh)
+
+	service := NewMenuImportService(db)
+	records := [][]string{
+		{"SKU", "Nama", "Kategori", "Harga", "Stok", "Deskripsi", "Gambar"},
+		{"", "es teh", "", "9000", "", "", ""},
+		{"NG-01", "Nasi Goreng", "Makanan", "25000.50", "10", "Pedas", "https://images.example.com/ng.jpg"},
+		{"", "", "", "", "", "", ""},
+	}
+
+	dry, err := service.Import(records, true)
+	if err != nil {
+		t.Fatalf("Import(dry run) error = %v", err)
+	}
+	if dry.Applied || dry.Created != 1 || dry.Updated != 1 || len(dry.Rows) != 2 {
+		t.Errorf("dry run report = %+v", dry)
+	}
+	var count int64
+	db.Model(&models.Menu{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("dry run wrote %d menus, want 1", count)
+	}
+
+	report, err := service.Import(records, false)
+	if err != nil || !report.Applied {
+		t.Fatalf("Import() = %+v, %v", report, err)
+	}
+	if len(report.CategoriesCreated) != 1 || report.CategoriesCreated[0] != "Makanan" {
+		t.Errorf("categories created = %v, want [Makanan]", report.CategoriesCreated)
+	}
+	var stored models.Menu
+	db.First(&stored, teh.ID)
+	if stored.Price != utils.Rupiah(9000) || stored.Stock != 5 || stored.Description != "Segar" || stored.Name != "es teh" {
+		t.Errorf("updated menu = %+v, want new price and kept stock/description", stored)
+	}
+	var nasi models.Menu
+	db.Preload("Category").Where("sku = ?", "NG-01").First(&nasi)
+	if nasi.Price != utils.Money(2500050) || nasi.Category.Name != "Makanan" || nasi.GetImageUrls()[0] != "https://images.example.com/ng.jpg" {
+		t.Errorf("created menu = %+v", nasi)
+	}
+
+	// Impor ulang file yang sama tidak mengubah apa pun
+	again, err := service.Import(records, false)
+	if err != nil || again.Unchanged != 2 || again.Created != 0 || again.Updated != 0 {
+		t.Errorf("second import = %+v, %v", again, err)
+	}
+
+	// Satu baris salah membatalkan seluruh impor
+	invalid := [][]string{
+		{"sku", "name", "category", "price", "stock"},
+		{"NG-01", "", "", "30000", ""},
+		{"", "Mie Ayam", "", "-1", "x"},
+		{"ng-01", "Nasi Goreng Spesial", "Makanan", "30000", ""},
+	}
+	failed, err := service.Import(invalid, false)
+	if err != nil {
+		t.Fatalf("Import(invalid) error = %v", err)
+	}
+	if failed.Applied || failed.Failed != 2 || len(failed.Rows[1].Errors) != 4 || failed.Rows[2].Row != 4 {
+		t.Errorf("invalid report = %+v", failed)
+	}
+	db.First(&nasi, nasi.ID)
+	if nasi.Price != utils.Money(2500050) {
+		t.Errorf("failed import changed price to %s", nasi.Price.Decimal())
+	}
+
+	// SKU menu yang sudah dihapus dipakai lagi: menu diaktifkan kembali
+	db.Delete(&nasi)
+	restored, err := service.Import([][]string{{"sku", "price"}, {"NG-01", "27000"}}, false)
+	if err != nil || restored.Updated != 1 || restored.Rows[0].MenuID != nasi.ID {
+		t.Errorf("restore import = %+v, %v", restored, err)
+	}
+
+	for _, header := range [][]string{{"name", "warna"}, {"price"}, {"name", "nama"}} {
+		if _, err := service.Import([][]string{header}, true); !errors.Is(err, ErrInvalidMenuImport) {
+			t.Errorf("Import(header %v) error = %v, want ErrInvalidMenuImport", header, err)
+		}
+	}
+}
+
+func TestMenuExportRoundTrip(t *testing.T) {
+	db := newPaymentTestDB(t)
+	if err := db.AutoMigrate(&models.MenuCategory{}, &models.Menu{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	drinks := models.MenuCategory{Name: "Minuman"}
+	db.Create(&drinks)
+	sku := "007"
+	db.Create(&models.Menu{CategoryID: drinks.ID, SKU: &sku, Name: "Kopi, Susu", Price: utils.Money(1250050), Stock: 3})
+
+	service := NewMenuImportService(db)
+	for _, format := range []string{MenuFileCSV, MenuFileXLSX} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := service.Export(&buf, format); err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+			records, err := ParseMenuFile("menus."+format, buf.Bytes())
+			if err != nil {
+				t.Fatalf("ParseMenuFile() error = %v", err)
+			}
+			if len(records) != 2 || records[1][0] != "007" || records[1][1] != "Kopi, Susu" || records[1][2] != "Minuman" {
+				t.Fatalf("exported records = %q", records)
+			}
+			report, err := service.Import(records, true)
+			if err != nil || report.Unchanged != 1 {
+				t.Errorf("re-import report = %+v, %v", report, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+// Urutan hasil pencarian menu
+const (
+	MenuSortNameAsc   = "name_asc"
+	MenuSortNameDesc  = "name_desc"
+	MenuSortPriceAsc  = "price_asc"
+	MenuSortPriceDesc = "price_desc"
+	MenuSortNewest    = "newest"
+)
+
+// Batas ukuran halaman pencarian menu
+const (
+	DefaultMenuSearchLimit = 20
+	MaxMenuSearchLimit     = 100
+)
+
+// menuSearchIndex adalah index FULLTEXT (MySQL) untuk kolom nama dan deskripsi
+const menuSearchIndex = "idx_menus_search"
+
+// minFullTextTerm adalah panjang kata minimum yang diindeks InnoDB (innodb_ft_min_token_size)
+const minFullTextTerm = 3
+
+var (
+	// ErrInvalidMenuSearch dikembalikan untuk parameter pencarian yang tidak valid
+	ErrInvalidMenuSearch = errors.New("invalid menu search")
+	// ErrInvalidDietaryTag dikembalikan untuk tag diet di luar models.DietaryTags
+	ErrInvalidDietaryTag = errors.New("invalid dietary tag")
+)
+
+// MenuSearchQuery adalah parameter pencarian menu. Semua filter bersifat AND.
+type MenuSearchQuery struct {
+	Query       string       // Kata kunci di nama dan deskripsi
+	CategoryIDs []uint       // Salah satu dari kategori ini
+	MinPrice    *utils.Money // Inklusif
+	MaxPrice    *utils.Money // Inklusif
+	InStock     bool         // Hanya menu (atau salah satu variant-nya) yang masih ada stok
+	Tags        []string     // Menu harus punya semua tag ini
+	Sort        string       // Salah satu MenuSort*, default name_asc
+	Cursor      string       // NextCursor dari halaman sebelumnya
+	Limit       int
+
+	// Available (opsional) menyaring menu yang tidak bisa dipesan saat ini, mis. di luar
+	// jadwal. Menu yang dilewati tidak mengurangi limit: baris berikutnya terus dibaca
+	// sampai halaman penuh atau data habis.
+	Available func(menu *models.Menu) bool
+}
+
+// MenuSearchResult adalah satu halaman hasil pencarian
+type MenuSearchResult struct {
+	Menus      []models.Menu
+	NextCursor string // Kosong jika sudah halaman terakhir
+}
+
+// menuCursor menyimpan posisi terakhir satu halaman: nilai kolom urut dan ID sebagai pemecah seri
+type menuCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v,omitempty"`
+	ID    uint   `json:"id"`
+}
+
+// MenuSearchService mencari menu dengan filter, urutan dan cursor pagination
+type MenuSearchService struct {
+	db *gorm.DB
+}
+
+// NewMenuSearchService membuat instance baru MenuSearchService
+func NewMenuSearchService(db *gorm.DB) *MenuSearchService {
+	return &MenuSearchService{db: db}
+}
+
+// EnsureMenuSearchIndex membuat index FULLTEXT untuk pencarian menu di MySQL. Database
+// lain memakai LIKE, sehingga tidak perlu index tambahan.
+func EnsureMenuSearchIndex(db *gorm.DB) error {
+	if db.Dialector.Name() != "mysql" || db.Migrator().HasIndex(&models.Menu{}, menuSearchIndex) {
+		return nil
+	}
+	return db.Exec("CREATE FULLTEXT INDEX " + menuSearchIndex + " ON menus (name, description)").Error
+}
+
+// Search mengembalikan satu halaman menu. Pagination memakai keyset (kolom urut + id),
+// jadi halaman berikutnya tetap konsisten walau ada menu baru ditambahkan.
+func (s *MenuSearchService) Search(q MenuSearchQuery) (*MenuSearchResult, error) {
+	if q.Sort == "" {
+		q.Sort = MenuSortNameAsc
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultMenuSearchLimit
+	}
+	if q.Limit > MaxMenuSearchLimit {
+		q.Limit = MaxMenuSearchLimit
+	}
+	if q.MinPrice != nil && q.MaxPrice != nil && *q.MinPrice > *q.MaxPrice {
+		return nil, fmt.Errorf("%w: min_price is greater than max_price", ErrInvalidMenuSearch)
+	}
+	tags, err := NormalizeDietaryTags(q.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.db.Model(&models.Menu{})
+	if terms := searchTerms(q.Query); len(terms) > 0 {
+		query = s.matchTerms(query, terms)
+	}
+	if len(q.CategoryIDs) > 0 {
+		query = query.Where("menus.category_id IN ?", q.CategoryIDs)
+	}
+	if q.MinPrice != nil {
+		query = query.Where("menus.price >= ?", *q.MinPrice)
+	}
+	if q.MaxPrice != nil {
+		query = query.Where("menus.price <= ?", *q.MaxPrice)
+	}
+	if q.InStock {
+		// Menu dengan variant dijual per variant, stok menu-nya sendiri tidak dipakai
+		query = query.Where(`(menus.stock > 0 AND NOT EXISTS (SELECT 1 FROM menu_variants v WHERE v.menu_id = menus.id AND v.deleted_at IS NULL))
+			OR EXISTS (SELECT 1 FROM menu_variants v WHERE v.menu_id = menus.id AND v.deleted_at IS NULL AND v.stock > 0)`)
+	}
+	if len(tags) > 0 {
+		query = query.Where(`menus.id IN (SELECT menu_id FROM menu_dietary_tags WHERE tag IN ? GROUP BY menu_id HAVING COUNT(*) = ?)`,
+			tags, len(tags))
+	}
+
+	column, desc, err := menuSortColumn(q.Sort)
+	if err != nil {
+		return nil, err
+	}
+	var cursor *menuCursor
+	if q.Cursor != "" {
+		if cursor, err = decodeMenuCursor(q.Cursor, q.Sort); err != nil {
+			return nil, err
+		}
+	}
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+	if column != "" {
+		query = query.Order("menus." + column + " " + direction)
+	}
+	query = query.Order("menus.id " + direction)
+	// Session baru agar query bisa dipakai ulang untuk setiap batch
+	query = query.Preload("Category").Preload("Variants", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order ASC, id ASC")
+	}).Preload("DietaryTags").Session(&gorm.Session{})
+
+	result := &MenuSearchResult{Menus: make([]models.Menu, 0, q.Limit)}
+	for {
+		batch := query
+		if cursor != nil {
+			batch = applyMenuCursor(batch, column, desc, cursor)
+		}
+		var menus []models.Menu
+		if err := batch.Limit(q.Limit + 1).Find(&menus).Error; err != nil {
+			return nil, fmt.Errorf("failed to search menus: %w", err)
+		}
+		more := len(menus) > q.Limit
+		if more {
+			menus = menus[:q.Limit]
+		}
+
+		for i := range menus {
+			if q.Available != nil && !q.Available(&menus[i]) {
+				continue
+			}
+			result.Menus = append(result.Menus, menus[i])
+			if len(result.Menus) == q.Limit {
+				if more || i < len(menus)-1 {
+					result.NextCursor = encodeMenuCursor(q.Sort, &menus[i])
+				}
+				return result, nil
+			}
+		}
+		if !more {
+			return result, nil
+		}
+		next := newMenuCursor(q.Sort, &menus[len(menus)-1])
+		cursor = &next
+	}
+}
+
+// matchTerms memfilter menu yang nama atau deskripsinya memuat semua kata kunci.
+// Di MySQL dipakai MATCH ... AGAINST dengan index FULLTEXT; kata yang lebih pendek dari
+// token minimum InnoDB tidak diindeks, jadi dicari dengan LIKE.
+func (s *MenuSearchService) matchTerms(query *gorm.DB, terms []string) *gorm.DB {
+	var fullText []string
+	for _, term := range terms {
+		if s.db.Dialector.Name() == "mysql" && len([]rune(term)) >= minFullTextTerm {
+			fullText = append(fullText, "+"+term+"*")
+			continue
+		}
+		pattern := "%" + term + "%"
+		query = query.Where("(LOWER(menus.name) LIKE ? OR LOWER(menus.description) LIKE ?)", pattern, pattern)
+	}
+	if len(fullText) > 0 {
+		query = query.Where("MATCH(menus.name, menus.description) AGAINST (? IN BOOLEAN MODE)", strings.Join(fullText, " "))
+	}
+	return query
+}
+
+// searchTerms memecah kata kunci menjadi kata huruf kecil. Karakter selain huruf dan angka
+// dibuang sehingga tidak bisa dipakai sebagai operator FULLTEXT atau wildcard LIKE.
+func searchTerms(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(fields) > 10 {
+		fields = fields[:10]
+	}
+	return fields
+}
+
+func menuSortColumn(sort string) (string, bool, error) {
+	switch sort {
+	case MenuSortNameAsc:
+		return "name", false, nil
+	case MenuSortNameDesc:
+		return "name", true, nil
+	case MenuSortPriceAsc:
+		return "price", false, nil
+	case MenuSortPriceDesc:
+		return "price", true, nil
+	case MenuSortNewest:
+		// ID bertambah sesuai waktu dibuat, jadi cukup urut berdasarkan id
+		return "", true, nil
+	}
+	return "", false, fmt.Errorf("%w: unknown sort %q", ErrInvalidMenuSearch, sort)
+}
+
+// applyMenuCursor melanjutkan setelah baris terakhir halaman sebelumnya
+func applyMenuCursor(query *gorm.DB, column string, desc bool, cursor *menuCursor) *gorm.DB {
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	if column == "" {
+		return query.Where("menus.id "+op+" ?", cursor.ID)
+	}
+	var value interface{} = cursor.Value
+	if column == "price" {
+		price, _ := utils.ParseMoney(cursor.Value)
+		value = price
+	}
+	return query.Where(fmt.Sprintf("(menus.%[1]s %[2]s ? OR (menus.%[1]s = ? AND menus.id %[2]s ?))", column, op),
+		value, value, cursor.ID)
+}
+
+// newMenuCursor membuat posisi setelah menu last pada urutan sort
+func newMenuCursor(sort string, last *models.Menu) menuCursor {
+	cursor := menuCursor{Sort: sort, ID: last.ID}
+	switch sort {
+	case MenuSortNameAsc, MenuSortNameDesc:
+		cursor.Value = last.Name
+	case MenuSortPriceAsc, MenuSortPriceDesc:
+		cursor.Value = last.Price.Decimal()
+	}
+	return cursor
+}
+
+func encodeMenuCursor(sort string, last *models.Menu) string {
+	data, _ := json.Marshal(newMenuCursor(sort, last))
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeMenuCursor menolak cursor rusak atau cursor dari urutan lain
+func decodeMenuCursor(raw, sort string) (*menuCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidMenuSearch)
+	}
+	var cursor menuCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidMenuSearch)
+	}
+	if cursor.Sort != sort {
+		return nil, fmt.Errorf("%w: cursor was created for sort %q", ErrInvalidMenuSearch, cursor.Sort)
+	}
+	if sort == MenuSortPriceAsc || sort == MenuSortPriceDesc {
+		if _, err := utils.ParseMoney(cursor.Value); err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidMenuSearch)
+		}
+	}
+	return &cursor, nil
+}
+
+// NormalizeDietaryTags merapikan dan memvalidasi daftar tag, tanpa duplikat
+func NormalizeDietaryTags(tags []string) ([]string, error) {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(tag)), "-", "_")
+		if tag == "" || seen[tag] {
+			continue
+		}
+		valid := false
+		for _, known := range models.DietaryTags {
+			if tag == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("%w: %q, use one of %s", ErrInvalidDietaryTag, tag, strings.Join(models.DietaryTags, ", "))
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized, nil
+}
+
+// MenuDietaryTags membuat baris tag untuk menu dari tag yang sudah dinormalisasi
+func MenuDietaryTags(menuID uint, tags []string) []models.MenuDietaryTag {
+	rows := make([]models.MenuDietaryTag, 0, len(tags))
+	for _, tag := range tags {
+		rows = append(rows, models.MenuDietaryTag{MenuID: menuID, Tag: tag})
+	}
+	return rows
+}
+
+// ReplaceMenuDietaryTags mengganti semua tag diet satu menu di dalam transaksi tx
+func ReplaceMenuDietaryTags(tx *gorm.DB, menu *models.Menu, tags []string) error {
+	if err := tx.Where("menu_id = ?", menu.ID).Delete(&models.MenuDietaryTag{}).Error; err != nil {
+		return fmt.Errorf("failed to clear dietary tags: %w", err)
+	}
+	menu.DietaryTags = MenuDietaryTags(menu.ID, tags)
+	if len(menu.DietaryTags) == 0 {
+		return nil
+	}
+	if err := tx.Create(&menu.DietaryTags).Error; err != nil {
+		return fmt.Errorf("failed to save dietary tags: %w", err)
+	}
+	return nil
+}
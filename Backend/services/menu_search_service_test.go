@@ -0,0 +1,189 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+func TestMenuSearch(t *testing.T) {
+	db := newPaymentTestDB(t)
+	if err := db.AutoMigrate(&models.MenuCategory{}, &models.Menu{}, &models.MenuVariant{}, &models.MenuDietaryTag{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	food := models.MenuCategory{Name: "Makanan"}
+	drinks := models.MenuCategory{Name: "Minuman"}
+	db.Create(&food)
+	db.Create(&drinks)
+
+	seed := []struct {
+		category uint
+		name     string
+		price    int64
+		stock    int
+		desc     string
+		tags     []string
+	}{
+		{food.ID, "Nasi Goreng", 25000, 10, "Nasi goreng kampung pedas", []string{"halal", "spicy"}},
+		{food.ID, "Gado-gado", 20000, 0, "Sayur dengan saus kacang", []string{"vegetarian", "halal"}},
+		{food.ID, "Mie Goreng", 22000, 4, "Mie telur", []string{"halal"}},
+		{drinks.ID, "Es Teh", 8000, 0, "Teh manis dingin", []string{"vegan", "halal"}},
+		{drinks.ID, "Kopi Susu", 18000, 0, "Kopi dengan susu segar", nil},
+		{drinks.ID, "Jus Alpukat", 18000, 2, "", []string{"vegetarian"}},
+	}
+	ids := make(map[string]uint)
+	for _, s := range seed {
+		menu := models.Menu{CategoryID: s.category, Name: s.name, Price: utils.Rupiah(s.price), Stock: s.stock, Description: s.desc}
+		tags, err := NormalizeDietaryTags(s.tags)
+		if err != nil {
+			t.Fatalf("NormalizeDietaryTags(%v) error = %v", s.tags, err)
+		}
+		menu.DietaryTags = MenuDietaryTags(0, tags)
+		db.Create(&menu)
+		ids[s.name] = menu.ID
+	}
+	// Es Teh dijual per variant: stok menu 0 tapi variant Large masih ada
+	db.Create(&models.MenuVariant{MenuID: ids["Es Teh"], Name: "Large", Price: utils.Rupiah(10000), Stock: 3})
+	// Mie Goreng punya variant yang habis, jadi stok menu-nya diabaikan
+	db.Create(&models.MenuVariant{MenuID: ids["Mie Goreng"], Name: "Jumbo", Price: utils.Rupiah(30000), Stock: 0})
+
+	service := NewMenuSearchService(db)
+	names := func(menus []models.Menu) []string {
+		var list []string
+		for _, menu := range menus {
+			list = append(list, menu.Name)
+		}
+		return list
+	}
+
+	// Setiap urutan dibaca halaman demi halaman sampai habis
+	orders := map[string][]string{
+		MenuSortNameAsc:   {"Es Teh", "Gado-gado", "Jus Alpukat", "Kopi Susu", "Mie Goreng", "Nasi Goreng"},
+		MenuSortNameDesc:  {"Nasi Goreng", "Mie Goreng", "Kopi Susu", "Jus Alpukat", "Gado-gado", "Es Teh"},
+		MenuSortPriceAsc:  {"Es Teh", "Kopi Susu", "Jus Alpukat", "Gado-gado", "Mie Goreng", "Nasi Goreng"},
+		MenuSortPriceDesc: {"Nasi Goreng", "Mie Goreng", "Gado-gado", "Jus Alpukat", "Kopi Susu", "Es Teh"},
+		MenuSortNewest:    {"Jus Alpukat", "Kopi Susu", "Es Teh", "Mie Goreng", "Gado-gado", "Nasi Goreng"},
+	}
+	for sort, want := range orders {
+		t.Run(sort, func(t *testing.T) {
+			var got []string
+			cursor := ""
+			for page := 0; page < 10; page++ {
+				result, err := service.Search(MenuSearchQuery{Sort: sort, Limit: 4, Cursor: cursor})
+				if err != nil {
+					t.Fatalf("Search() error = %v", err)
+				}
+				got = append(got, names(result.Menus)...)
+				if cursor = result.NextCursor; cursor == "" {
+					break
+				}
+			}
+			if len(got) != len(want) {
+				t.Fatalf("Search() pages = %v, want %v", got, want)
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("Search() pages = %v, want %v", got, want)
+				}
+			}
+		})
+	}
+
+	// Menu yang tidak tersedia dilewati tanpa membuat halaman kurang dari limit
+	t.Run("available only", func(t *testing.T) {
+		unavailable := map[uint]bool{ids["Es Teh"]: true, ids["Gado-gado"]: true, ids["Kopi Susu"]: true}
+		available := func(menu *models.Menu) bool { return !unavailable[menu.ID] }
+		var pages [][]string
+		cursor := ""
+		for page := 0; page < 10; page++ {
+			result, err := service.Search(MenuSearchQuery{Limit: 2, Cursor: cursor, Available: available})
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			pages = append(pages, names(result.Menus))
+			if cursor = result.NextCursor; cursor == "" {
+				break
+			}
+		}
+		want := [][]string{{"Jus Alpukat", "Mie Goreng"}, {"Nasi Goreng"}}
+		if len(pages) != len(want) {
+			t.Fatalf("Search() pages = %v, want %v", pages, want)
+		}
+		for i := range want {
+			if len(pages[i]) != len(want[i]) {
+				t.Fatalf("Search() pages = %v, want %v", pages, want)
+			}
+			for j := range want[i] {
+				if pages[i][j] != want[i][j] {
+					t.Fatalf("Search() pages = %v, want %v", pages, want)
+				}
+			}
+		}
+	})
+
+	min, max := utils.Rupiah(15000), utils.Rupiah(22000)
+	filters := []struct {
+		name  string
+		query MenuSearchQuery
+		want  []string
+	}{
+		{"full text", MenuSearchQuery{Query: "GORENG"}, []string{"Mie Goreng", "Nasi Goreng"}},
+		{"all terms", MenuSearchQuery{Query: "goreng pedas"}, []string{"Nasi Goreng"}},
+		{"description", MenuSearchQuery{Query: "kacang"}, []string{"Gado-gado"}},
+		{"wildcards are ignored", MenuSearchQuery{Query: "%_"}, orders[MenuSortNameAsc]},
+		{"category", MenuSearchQuery{CategoryIDs: []uint{drinks.ID}}, []string{"Es Teh", "Jus Alpukat", "Kopi Susu"}},
+		{"price range", MenuSearchQuery{MinPrice: &min, MaxPrice: &max}, []string{"Gado-gado", "Jus Alpukat", "Kopi Susu", "Mie Goreng"}},
+		{"in stock", MenuSearchQuery{InStock: true}, []string{"Es Teh", "Jus Alpukat", "Nasi Goreng"}},
+		{"all tags", MenuSearchQuery{Tags: []string{"Halal", "vegetarian"}}, []string{"Gado-gado"}},
+		{"combined", MenuSearchQuery{Query: "teh", Tags: []string{"vegan"}, InStock: true, Sort: MenuSortPriceDesc}, []string{"Es Teh"}},
+	}
+	for _, tt := range filters {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := service.Search(tt.query)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			got := names(result.Menus)
+			if len(got) != len(tt.want) || result.NextCursor != "" {
+				t.Fatalf("Search() = %v (next %q), want %v", got, result.NextCursor, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Search() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	first, _ := service.Search(MenuSearchQuery{Limit: 2})
+	if len(first.Menus[0].DietaryTags) != 2 || first.Menus[0].Variants[0].Name != "Large" {
+		t.Errorf("first menu = %+v, want tags and variants preloaded", first.Menus[0])
+	}
+	invalid := []MenuSearchQuery{
+		{Sort: "popular"},
+		{Cursor: "not-a-cursor"},
+		{Cursor: first.NextCursor, Sort: MenuSortPriceAsc},
+		{MinPrice: &max, MaxPrice: &min},
+	}
+	for _, query := range invalid {
+		if _, err := service.Search(query); !errors.Is(err, ErrInvalidMenuSearch) {
+			t.Errorf("Search(%+v) error = %v, want ErrInvalidMenuSearch", query, err)
+		}
+	}
+	if _, err := service.Search(MenuSearchQuery{Tags: []string{"keto"}}); !errors.Is(err, ErrInvalidDietaryTag) {
+		t.Errorf("Search(keto) error = %v, want ErrInvalidDietaryTag", err)
+	}
+
+	// Mengganti tag menghapus tag lama
+	var nasi models.Menu
+	db.First(&nasi, ids["Nasi Goreng"])
+	if err := ReplaceMenuDietaryTags(db, &nasi, []string{"halal"}); err != nil {
+		t.Fatalf("ReplaceMenuDietaryTags() error = %v", err)
+	}
+	spicy, _ := service.Search(MenuSearchQuery{Tags: []string{"spicy"}})
+	if len(spicy.Menus) != 0 {
+		t.Errorf("spicy menus after replace = %v, want none", names(spicy.Menus))
+	}
+}
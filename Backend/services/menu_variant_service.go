@@ -0,0 +1,205 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidMenuVariant dikembalikan jika isian variant menu tidak valid
+var ErrInvalidMenuVariant = errors.New("invalid menu variant")
+
+// ErrVariantRequired dikembalikan jika menu yang punya variant dipesan tanpa memilih variant
+var ErrVariantRequired = errors.New("menu variant is required")
+
+// ErrInsufficientVariantStock dikembalikan jika stok variant tidak cukup untuk dipesan
+var ErrInsufficientVariantStock = errors.New("insufficient variant stock")
+
+// MenuVariantInput adalah isian variant menu dari admin
+type MenuVariantInput struct {
+	Kind      string      `json:"kind"` // size, portion, temperature atau kosong
+	Name      string      `json:"name"`
+	SKU       *string     `json:"sku"`
+	Price     utils.Money `json:"price"`
+	Stock     int         `json:"stock"`
+	SortOrder int         `json:"sort_order"`
+}
+
+// MenuVariantService mengelola variant menu beserta stoknya
+type MenuVariantService struct {
+	db *gorm.DB
+}
+
+// NewMenuVariantService membuat instance baru MenuVariantService
+func NewMenuVariantService(db *gorm.DB) *MenuVariantService {
+	return &MenuVariantService{db: db}
+}
+
+// ListVariants mengembalikan variant satu menu sesuai urutan tampil
+func (s *MenuVariantService) ListVariants(menuID uint) ([]models.MenuVariant, error) {
+	var variants []models.MenuVariant
+	err := s.db.Where("menu_id = ?", menuID).Order("sort_order ASC, id ASC").Find(&variants).Error
+	return variants, err
+}
+
+// CreateVariant menambah variant pada menu
+func (s *MenuVariantService) CreateVariant(menuID uint, input MenuVariantInput) (*models.MenuVariant, error) {
+	if err := s.db.First(&models.Menu{}, menuID).Error; err != nil {
+		return nil, err
+	}
+	variant := models.MenuVariant{MenuID: menuID}
+	if err := s.applyInput(&variant, input); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(&variant).Error; err != nil {
+		return nil, fmt.Errorf("failed to create menu variant: %w", err)
+	}
+	return &variant, nil
+}
+
+// UpdateVariant mengganti isi variant
+func (s *MenuVariantService) UpdateVariant(variantID uint, input MenuVariantInput) (*models.MenuVariant, error) {
+	var variant models.MenuVariant
+	if err := s.db.First(&variant, variantID).Error; err != nil {
+		return nil, err
+	}
+	if err := s.applyInput(&variant, input); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(&variant).Error; err != nil {
+		return nil, fmt.Errorf("failed to update menu variant: %w", err)
+	}
+	return &variant, nil
+}
+
+// DeleteVariant menghapus variant (soft delete, order lama tetap menyimpan namanya)
+func (s *MenuVariantService) DeleteVariant(variantID uint) error {
+	result := s.db.Delete(&models.MenuVariant{}, variantID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ResolveVariant mencari variant yang dipilih untuk menu. Menu yang punya variant wajib
+// dipilih variant-nya; menu tanpa variant mengembalikan nil.
+func ResolveVariant(tx *gorm.DB, menu *models.Menu, variantID *uint) (*models.MenuVariant, error) {
+	if variantID == nil {
+		var count int64
+		if err := tx.Model(&models.MenuVariant{}).Where("menu_id = ?", menu.ID).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to load menu variants: %w", err)
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("%w: %s", ErrVariantRequired, menu.Name)
+		}
+		return nil, nil
+	}
+
+	var variant models.MenuVariant
+	if err := tx.Where("id = ? AND menu_id = ?", *variantID, menu.ID).First(&variant).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: variant %d does not belong to %s", ErrInvalidMenuVariant, *variantID, menu.Name)
+		}
+		return nil, fmt.Errorf("failed to load menu variant: %w", err)
+	}
+	return &variant, nil
+}
+
+// TakeVariantStock mengurangi stok variant secara atomik; gagal jika stok tidak cukup
+func TakeVariantStock(tx *gorm.DB, variant *models.MenuVariant, quantity int) error {
+	result := tx.Model(&models.MenuVariant{}).
+		Where("id = ? AND stock >= ?", variant.ID, quantity).
+		Update("stock", gorm.Expr("stock - ?", quantity))
+	if result.Error != nil {
+		return fmt.Errorf("failed to update variant stock: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", ErrInsufficientVariantStock, variant.Name)
+	}
+	variant.Stock -= quantity
+	return nil
+}
+
+// CancelOrder membatalkan order dan mengembalikan stok variant-nya. Stok hanya dikembalikan
+// saat order pertama kali dibatalkan. Payment yang gagal / expired tidak membatalkan order
+// (order tetap pending_payment agar bisa dibayar ulang), jadi stoknya tetap dipesan.
+func CancelOrder(tx *gorm.DB, order *models.Order) error {
+	if order.Status != OrderStatusCancelled {
+		if err := ReleaseOrderVariantStock(tx, order.ID); err != nil {
+			return err
+		}
+	}
+	order.Status = OrderStatusCancelled
+	if err := tx.Save(order).Error; err != nil {
+		return fmt.Errorf("failed to cancel order: %w", err)
+	}
+	return nil
+}
+
+// ReleaseOrderVariantStock mengembalikan stok variant yang diambil item order. Dipanggil
+// sekali, di transaksi yang sama dengan perubahan status order menjadi cancelled.
+func ReleaseOrderVariantStock(tx *gorm.DB, orderID uint) error {
+	var items []models.OrderItem
+	if err := tx.Where("order_id = ? AND variant_id IS NOT NULL", orderID).Find(&items).Error; err != nil {
+		return fmt.Errorf("failed to load order items: %w", err)
+	}
+	for _, item := range items {
+		if err := tx.Model(&models.MenuVariant{}).Where("id = ?", *item.VariantID).
+			Update("stock", gorm.Expr("stock + ?", item.Quantity)).Error; err != nil {
+			return fmt.Errorf("failed to restore variant stock: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *MenuVariantService) applyInput(variant *models.MenuVariant, input MenuVariantInput) error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrInvalidMenuVariant, fmt.Sprintf(format, args...))
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return invalid("name is required")
+	}
+	kind := strings.ToLower(strings.TrimSpace(input.Kind))
+	switch kind {
+	case "", models.VariantKindSize, models.VariantKindPortion, models.VariantKindTemperature:
+	default:
+		return invalid("kind must be size, portion or temperature")
+	}
+	if input.Price < 0 {
+		return invalid("price must not be negative")
+	}
+	if input.Stock < 0 {
+		return invalid("stock must not be negative")
+	}
+
+	var sku *string
+	if input.SKU != nil && strings.TrimSpace(*input.SKU) != "" {
+		trimmed := strings.TrimSpace(*input.SKU)
+		sku = &trimmed
+		// Unscoped: SKU variant yang sudah dihapus masih memegang unique index
+		var count int64
+		if err := s.db.Unscoped().Model(&models.MenuVariant{}).Where("sku = ? AND id <> ?", trimmed, variant.ID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check variant sku: %w", err)
+		}
+		if count > 0 {
+			return invalid("sku %s is already used", trimmed)
+		}
+	}
+
+	variant.Kind = kind
+	variant.Name = name
+	variant.SKU = sku
+	variant.Price = input.Price
+	variant.Stock = input.Stock
+	variant.SortOrder = input.SortOrder
+	return nil
+}
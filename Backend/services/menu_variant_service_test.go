@@ -0,0 +1,157 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+func TestMenuVariants(t *testing.T) {
+	db := newPaymentTestDB(t)
+	if err := db.AutoMigrate(&models.MenuCategory{}, &models.Menu{}, &models.MenuVariant{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	drinks := models.MenuCategory{Name: "Minuman"}
+	db.Create(&drinks)
+	teh := models.Menu{CategoryID: drinks.ID, Name: "Es Teh", Price: utils.Rupiah(8000)}
+	kopi := models.Menu{CategoryID: drinks.ID, Name: "Kopi Tubruk", Price: utils.Rupiah(10000)}
+	db.Create(&teh)
+	db.Create(&kopi)
+
+	service := NewMenuVariantService(db)
+	sku := " TEH-L "
+	large, err := service.CreateVariant(teh.ID, MenuVariantInput{Kind: "Size", Name: "Large", SKU: &sku, Price: utils.Rupiah(12000), Stock: 3})
+	if err != nil {
+		t.Fatalf("CreateVariant() error = %v", err)
+	}
+	if large.Kind != models.VariantKindSize || *large.SKU != "TEH-L" {
+		t.Errorf("variant = %+v, want normalized kind and sku", large)
+	}
+
+	invalid := []MenuVariantInput{
+		{Name: " "},
+		{Name: "Jumbo", Kind: "color"},
+		{Name: "Jumbo", Price: -1},
+		{Name: "Jumbo", Stock: -1},
+		{Name: "Jumbo", SKU: &sku},
+	}
+	for _, input := range invalid {
+		if _, err := service.CreateVariant(teh.ID, input); !errors.Is(err, ErrInvalidMenuVariant) {
+			t.Errorf("CreateVariant(%+v) error = %v, want ErrInvalidMenuVariant", input, err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		menu      *models.Menu
+		variantID *uint
+		quantity  int
+		wantErr   error
+		wantStock int
+	}{
+		{name: "menu without variants", menu: &kopi, quantity: 1},
+		{name: "variant required", menu: &teh, quantity: 1, wantErr: ErrVariantRequired},
+		{name: "variant of another menu", menu: &kopi, variantID: &large.ID, quantity: 1, wantErr: ErrInvalidMenuVariant},
+		{name: "take stock", menu: &teh, variantID: &large.ID, quantity: 2, wantStock: 1},
+		{name: "insufficient stock", menu: &teh, variantID: &large.ID, quantity: 2, wantErr: ErrInsufficientVariantStock, wantStock: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variant, err := ResolveVariant(db, tt.menu, tt.variantID)
+			if err == nil && variant != nil {
+				err = TakeVariantStock(db, variant, tt.quantity)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.variantID == nil || tt.wantErr == ErrInvalidMenuVariant {
+				return
+			}
+			var stored models.MenuVariant
+			db.First(&stored, *tt.variantID)
+			if stored.Stock != tt.wantStock {
+				t.Errorf("stock = %d, want %d", stored.Stock, tt.wantStock)
+			}
+		})
+	}
+
+	// Variant yang dihapus tidak bisa dipesan lagi dan SKU-nya tetap terpakai
+	if err := service.DeleteVariant(large.ID); err != nil {
+		t.Fatalf("DeleteVariant() error = %v", err)
+	}
+	if _, err := ResolveVariant(db, &teh, &large.ID); !errors.Is(err, ErrInvalidMenuVariant) {
+		t.Errorf("ResolveVariant(deleted) error = %v, want ErrInvalidMenuVariant", err)
+	}
+	if _, err := ResolveVariant(db, &teh, nil); err != nil {
+		t.Errorf("ResolveVariant(no variants left) error = %v, want nil", err)
+	}
+}
+
+func TestReleaseOrderVariantStock(t *testing.T) {
+	db := newPaymentTestDB(t)
+	if err := db.AutoMigrate(&models.Menu{}, &models.MenuVariant{}, &models.OrderItem{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	teh := models.Menu{Name: "Es Teh", Price: utils.Rupiah(8000)}
+	db.Create(&teh)
+	large := models.MenuVariant{MenuID: teh.ID, Kind: models.VariantKindSize, Name: "Large", Price: utils.Rupiah(12000), Stock: 5}
+	db.Create(&large)
+	if err := TakeVariantStock(db, &large, 2); err != nil {
+		t.Fatalf("TakeVariantStock() error = %v", err)
+	}
+
+	order := models.Order{CustomerID: 1, Status: OrderStatusPendingPayment, TotalAmount: utils.Rupiah(32000)}
+	db.Create(&order)
+	db.Create(&models.OrderItem{OrderID: order.ID, MenuID: teh.ID, VariantID: &large.ID, Quantity: 2, Price: large.Price})
+	db.Create(&models.OrderItem{OrderID: order.ID, MenuID: teh.ID, Quantity: 1, Price: teh.Price})
+
+	// Payment expired tidak membatalkan order, jadi stok variant tetap dipesan untuk pembayaran ulang
+	expiredAt := time.Now().Add(-time.Minute)
+	payment := models.Payment{OrderID: order.ID, Amount: order.TotalAmount, Status: PaymentStatusPending, PaymentMethod: "qris", ExpiredAt: &expiredAt}
+	db.Create(&payment)
+	if err := NewPaymentExpiryScheduler(db).expire(payment.ID); err != nil {
+		t.Fatalf("expire() error = %v", err)
+	}
+	var stored models.MenuVariant
+	db.First(&stored, large.ID)
+	if stored.Stock != 3 {
+		t.Errorf("after expired payment stock = %d, want 3", stored.Stock)
+	}
+
+	// Stok hanya dikembalikan saat order pertama kali dibatalkan
+	for i := 0; i < 2; i++ {
+		db.First(&order, order.ID)
+		if err := CancelOrder(db, &order); err != nil {
+			t.Fatalf("CancelOrder() error = %v", err)
+		}
+		db.First(&stored, large.ID)
+		if stored.Stock != 5 {
+			t.Errorf("after cancel #%d stock = %d, want 5", i+1, stored.Stock)
+		}
+	}
+
+	db.First(&order, order.ID)
+	if order.Status != OrderStatusCancelled {
+		t.Errorf("order status = %s, want %s", order.Status, OrderStatusCancelled)
+	}
+}
+
+func TestReceiptItemDisplayName(t *testing.T) {
+	tests := []struct {
+		item models.ReceiptItem
+		want string
+	}{
+		{models.ReceiptItem{MenuName: "Es Teh"}, "Es Teh"},
+		{models.ReceiptItem{MenuName: "Es Teh", VariantName: "Large"}, "Es Teh (Large)"},
+	}
+	for _, tt := range tests {
+		if got := tt.item.DisplayName(); got != tt.want {
+			t.Errorf("DisplayName() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+// ErrInvalidWebhookSignature dikembalikan jika signature notifikasi tidak cocok
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// Pastikan MidtransService memenuhi interface PaymentProvider
+var _ PaymentProvider = (*MidtransService)(nil)
+
+// Name mengembalikan nama provider
+func (ms *MidtransService) Name() string {
+	return PaymentProviderMidtrans
+}
+
+// CreateCharge membuat transaksi QRIS atau virtual account di Midtrans
+func (ms *MidtransService) CreateCharge(req ChargeRequest) (*ChargeResult, error) {
+	if !req.Amount.IsWholeRupiah() {
+		return nil, ErrFractionalRupiah
+	}
+	if req.Method == ChargeMethodBankTransfer {
+		return ms.createBankTransferCharge(req)
+	}
+
+	resp, err := ms.CreateTransactionWithCustomer(req.OrderRef, req.Amount, req.CustomerName, req.CustomerEmail, req.ExpiryMinutes)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ChargeResult{
+		TransactionID: resp.TransactionID,
+		OrderRef:      req.OrderRef,
+		QRString:      resp.QRCodeURL,
+	}
+
+	// Prioritaskan URL dari actions jika tersedia
+	for _, action := range resp.Actions {
+		if action.Name == "generate-qr-code" || action.Name == "display-qr-code" {
+			result.QRImageURL = action.URL
+			break
+		}
+	}
+	if result.QRImageURL == "" && result.QRString != "" {
+		result.QRImageURL = ms.GenerateQRImageURL(result.QRString)
+	}
+	if result.QRImageURL == "" && resp.TransactionID != "" {
+		result.QRImageURL = ms.GenerateQRImageURL(resp.TransactionID)
+	}
+
+	result.ExpiresAt = parseMidtransTime(resp.ExpiryTime)
+
+	return result, nil
+}
+
+// createBankTransferCharge membuat virtual account lewat Core API Midtrans.
+// Mandiri memakai payment_type echannel (biller code + bill key), bank lain payment_type bank_transfer.
+func (ms *MidtransService) createBankTransferCharge(req ChargeRequest) (*ChargeResult, error) {
+	bank, err := NormalizeVABank(req.Bank)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := map[string]interface{}{
+		"transaction_details": map[string]interface{}{
+			"order_id":     req.OrderRef,
+			"gross_amount": req.Amount.WholeRupiah(),
+		},
+		"customer_details": midtransCustomerDetails(req.CustomerName, req.CustomerEmail),
+	}
+	if bank == BankMandiri {
+		payload["payment_type"] = "echannel"
+		payload["echannel"] = map[string]interface{}{
+			"bill_info1": "Payment:",
+			"bill_info2": "Order " + req.OrderRef,
+		}
+	} else {
+		payload["payment_type"] = "bank_transfer"
+		payload["bank_transfer"] = map[string]interface{}{"bank": bank}
+	}
+	if req.ExpiryMinutes > 0 {
+		payload["custom_expiry"] = map[string]interface{}{
+			"expiry_duration": req.ExpiryMinutes,
+			"unit":            "minute",
+		}
+	}
+
+	body, err := ms.postAction("/v2/charge", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp MidtransResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("error unmarshaling response: %v", err)
+	}
+
+	result := &ChargeResult{
+		TransactionID: resp.TransactionID,
+		OrderRef:      req.OrderRef,
+		Bank:          bank,
+		ExpiresAt:     parseMidtransTime(resp.ExpiryTime),
+	}
+	switch {
+	case bank == BankMandiri:
+		result.VANumber = resp.BillKey
+		result.BillerCode = resp.BillerCode
+	case resp.PermataVANumber != "":
+		result.VANumber = resp.PermataVANumber
+	default:
+		for _, va := range resp.VANumbers {
+			if va.Bank == bank {
+				result.VANumber = va.VANumber
+				break
+			}
+		}
+	}
+	if result.VANumber == "" {
+		return nil, fmt.Errorf("Midtrans response has no virtual account number for bank %s", bank)
+	}
+
+	return result, nil
+}
+
+// midtransTimeLayout adalah format expiry_time / transaction_time dari Midtrans
+const midtransTimeLayout = "2006-01-02 15:04:05"
+
+// midtransLocation adalah zona waktu Midtrans: semua waktu dikirim dalam WIB tanpa offset.
+// Jika tzdata tidak tersedia di server, dipakai offset tetap UTC+7 (WIB tanpa DST).
+var midtransLocation = func() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Jakarta"); err == nil {
+		return loc
+	}
+	return time.FixedZone("WIB", 7*60*60)
+}()
+
+// parseMidtransTime mem-parsing waktu Midtrans (WIB, format "2006-01-02 15:04:05"), nil jika kosong atau tidak valid
+func parseMidtransTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.ParseInLocation(midtransTimeLayout, value, midtransLocation)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// CheckStatus mengambil status transaksi di Midtrans
+func (ms *MidtransService) CheckStatus(providerRef string) (string, error) {
+	return ms.CheckTransactionStatus(providerRef)
+}
+
+// GetTransaction mengambil detail transaksi dari status API Midtrans
+func (ms *MidtransService) GetTransaction(providerRef string) (*ProviderTransaction, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/v2/%s/status", ms.getBaseURL(), providerRef), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(ms.config.ServerKey+":")))
+
+	resp, err := ms.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %v", err)
+	}
+
+	var statusResp struct {
+		StatusCode        string `json:"status_code"`
+		StatusMessage     string `json:"status_message"`
+		OrderID           string `json:"order_id"`
+		TransactionID     string `json:"transaction_id"`
+		TransactionStatus string `json:"transaction_status"`
+		GrossAmount       string `json:"gross_amount"`
+		TransactionTime   string `json:"transaction_time"`
+	}
+	if err := json.Unmarshal(body, &statusResp); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("error unmarshaling response: %v", err)
+	}
+
+	// Midtrans mengembalikan status_code 404 (kadang dengan HTTP 200) untuk transaksi yang tidak dikenal
+	if resp.StatusCode == http.StatusNotFound || statusResp.StatusCode == "404" {
+		return nil, ErrTransactionNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Midtrans API error: %s", string(body))
+	}
+
+	amount, err := utils.ParseMoney(statusResp.GrossAmount)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gross_amount %q: %v", statusResp.GrossAmount, err)
+	}
+
+	trx := &ProviderTransaction{
+		OrderRef:          statusResp.OrderID,
+		TransactionID:     statusResp.TransactionID,
+		TransactionStatus: statusResp.TransactionStatus,
+		Status:            ms.mapTransactionStatus(statusResp.TransactionStatus),
+		Amount:            amount,
+	}
+	trx.TransactionTime = parseMidtransTime(statusResp.TransactionTime)
+	return trx, nil
+}
+
+// Cancel membatalkan transaksi yang masih pending di Midtrans
+func (ms *MidtransService) Cancel(providerRef string) error {
+	_, err := ms.postAction(fmt.Sprintf("/v2/%s/cancel", providerRef), nil)
+	return err
+}
+
+// Refund mengembalikan dana transaksi yang sudah settlement di Midtrans
+func (ms *MidtransService) Refund(providerRef string, amount utils.Money, reason string) error {
+	if !amount.IsWholeRupiah() {
+		return ErrFractionalRupiah
+	}
+	payload := map[string]interface{}{
+		"refund_key": fmt.Sprintf("%s-refund-%d", providerRef, time.Now().Unix()),
+		"amount":     amount.WholeRupiah(),
+		"reason":     reason,
+	}
+	_, err := ms.postAction(fmt.Sprintf("/v2/%s/refund", providerRef), payload)
+	return err
+}
+
+// VerifyWebhook memvalidasi signature notifikasi Midtrans dan mem-parsing isinya
+func (ms *MidtransService) VerifyWebhook(payload []byte) (*WebhookNotification, error) {
+	var request struct {
+		OrderID           string `json:"order_id"`
+		TransactionID     string `json:"transaction_id"`
+		TransactionStatus string `json:"transaction_status"`
+		StatusCode        string `json:"status_code"`
+		GrossAmount       string `json:"gross_amount"`
+		SignatureKey      string `json:"signature_key"`
+	}
+	if err := json.Unmarshal(payload, &request); err != nil {
+		return nil, fmt.Errorf("error parsing notification: %v", err)
+	}
+
+	if !ms.ValidateSignature(request.OrderID, request.StatusCode, request.GrossAmount, request.SignatureKey) {
+		return nil, ErrInvalidWebhookSignature
+	}
+
+	return &WebhookNotification{
+		OrderRef:          request.OrderID,
+		TransactionID:     request.TransactionID,
+		TransactionStatus: request.TransactionStatus,
+		Status:            ms.mapTransactionStatus(request.TransactionStatus),
+		StatusCode:        request.StatusCode,
+		GrossAmount:       request.GrossAmount,
+	}, nil
+}
+
+// postAction mengirim request POST ke endpoint Core API Midtrans (charge/cancel/refund)
+func (ms *MidtransService) postAction(path string, payload interface{}) ([]byte, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling request: %v", err)
+		}
+		reqBody = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequest("POST", ms.getBaseURL()+path, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(ms.config.ServerKey+":")))
+
+	resp, err := ms.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Midtrans API error: %s", string(body))
+	}
+
+	// Midtrans bisa mengembalikan HTTP 200 dengan status_code error di body
+	var result struct {
+		StatusCode    string `json:"status_code"`
+		StatusMessage string `json:"status_message"`
+	}
+	if err := json.Unmarshal(body, &result); err == nil && result.StatusCode != "" && !strings.HasPrefix(result.StatusCode, "2") {
+		return nil, fmt.Errorf("Midtrans API error %s: %s", result.StatusCode, result.StatusMessage)
+	}
+
+	return body, nil
+}
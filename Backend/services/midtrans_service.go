@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
 )
 
 // MidtransConfig holds Midtrans configuration
@@ -32,6 +33,7 @@ type MidtransConfig struct {
 type MidtransService struct {
 	config     *MidtransConfig
 	httpClient *http.Client
+	baseURL    string // override base URL API (dipakai untuk test)
 }
 
 var (
@@ -79,18 +81,7 @@ func GetMidtransService() *MidtransService {
 			merchantID = "G117629268"
 		}
 
-		// Atur nilai default untuk field lain jika kosong
-		if merchantName == "" {
-			merchantName = "Restaurant App"
-		}
-
-		if merchantEmail == "" {
-			merchantEmail = "restaurant@example.com"
-		}
-
-		if merchantPhone == "" {
-			merchantPhone = "08123456789"
-		}
+		// Nama, email dan telepon merchant yang kosong diambil dari profil restoran (lihat MerchantDetails)
 
 		if webhookURL == "" {
 			webhookURL = "https://example.com/callback"
@@ -126,13 +117,14 @@ func (ms *MidtransService) ValidateConfig() error {
 	if ms.config.MerchantID == "" {
 		return fmt.Errorf("MIDTRANS_MERCHANT_ID is not set")
 	}
-	if ms.config.MerchantName == "" {
+	name, email, phone := ms.MerchantDetails()
+	if name == "" {
 		return fmt.Errorf("MIDTRANS_MERCHANT_NAME is not set")
 	}
-	if ms.config.MerchantEmail == "" {
+	if email == "" {
 		return fmt.Errorf("MIDTRANS_MERCHANT_EMAIL is not set")
 	}
-	if ms.config.MerchantPhone == "" {
+	if phone == "" {
 		return fmt.Errorf("MIDTRANS_MERCHANT_PHONE is not set")
 	}
 	if ms.config.WebhookURL == "" {
@@ -141,8 +133,29 @@ func (ms *MidtransService) ValidateConfig() error {
 	return nil
 }
 
+// MerchantDetails mengembalikan nama, email dan telepon merchant. Nilai dari env
+// MIDTRANS_MERCHANT_* diutamakan; yang kosong diisi dari profil restoran saat ini,
+// sehingga perubahan profil langsung terpakai tanpa restart.
+func (ms *MidtransService) MerchantDetails() (name, email, phone string) {
+	name, email, phone = ms.config.MerchantName, ms.config.MerchantEmail, ms.config.MerchantPhone
+	if name != "" && email != "" && phone != "" {
+		return name, email, phone
+	}
+	profile := CurrentRestaurantProfile()
+	if name == "" {
+		name = profile.Name
+	}
+	if email == "" {
+		email = profile.Email
+	}
+	if phone == "" {
+		phone = profile.Phone
+	}
+	return name, email, phone
+}
+
 // CreateTransaction creates a new transaction in Midtrans using Order model
-func (ms *MidtransService) CreateTransaction(orderID string, amount float64, order models.Order) (*MidtransResponse, error) {
+func (ms *MidtransService) CreateTransaction(orderID string, amount utils.Money, order models.Order) (*MidtransResponse, error) {
 	baseURL := ms.getBaseURL()
 	url := fmt.Sprintf("%s/v2/charge", baseURL)
 
@@ -161,16 +174,13 @@ func (ms *MidtransService) CreateTransaction(orderID string, amount float64, ord
 		"payment_type": "qris",
 		"transaction_details": map[string]interface{}{
 			"order_id":     orderID,
-			"gross_amount": int64(amount),
-		},
-		"customer_details": map[string]interface{}{
-			"first_name": customerName,
-			"email":      customerEmail,
+			"gross_amount": amount.WholeRupiah(),
 		},
+		"customer_details": midtransCustomerDetails(customerName, customerEmail),
 		"item_details": []map[string]interface{}{
 			{
 				"id":       orderID,
-				"price":    int64(amount),
+				"price":    amount.WholeRupiah(),
 				"quantity": 1,
 				"name":     "Order Payment",
 			},
@@ -230,7 +240,8 @@ func (ms *MidtransService) CreateTransaction(orderID string, amount float64, ord
 }
 
 // CreateTransactionWithCustomer creates a new transaction in Midtrans with customer details
-func (ms *MidtransService) CreateTransactionWithCustomer(orderID string, amount float64, customerName string, customerEmail string) (*MidtransResponse, error) {
+// expiryMinutes > 0 mengatur custom_expiry QRIS, 0 memakai default Midtrans.
+func (ms *MidtransService) CreateTransactionWithCustomer(orderID string, amount utils.Money, customerName string, customerEmail string, expiryMinutes int) (*MidtransResponse, error) {
 	baseURL := ms.getBaseURL()
 	url := fmt.Sprintf("%s/v2/charge", baseURL)
 
@@ -245,22 +256,26 @@ func (ms *MidtransService) CreateTransactionWithCustomer(orderID string, amount
 		"payment_type": "qris",
 		"transaction_details": map[string]interface{}{
 			"order_id":     orderID,
-			"gross_amount": int64(amount),
-		},
-		"customer_details": map[string]interface{}{
-			"first_name": customerName,
-			"email":      customerEmail,
+			"gross_amount": amount.WholeRupiah(),
 		},
+		"customer_details": midtransCustomerDetails(customerName, customerEmail),
 		"item_details": []map[string]interface{}{
 			{
 				"id":       orderID,
-				"price":    int64(amount),
+				"price":    amount.WholeRupiah(),
 				"quantity": 1,
 				"name":     "Order Payment",
 			},
 		},
 	}
 
+	if expiryMinutes > 0 {
+		payload["custom_expiry"] = map[string]interface{}{
+			"expiry_duration": expiryMinutes,
+			"unit":            "minute",
+		}
+	}
+
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling request: %v", err)
@@ -378,8 +393,17 @@ func (ms *MidtransService) mapTransactionStatus(status string) string {
 		return "success"
 	case "pending", "authorize":
 		return "pending"
-	case "deny", "cancel", "expire", "failure":
+	case "deny", "failure":
 		return "failed"
+	case "expire":
+		return "expired"
+	case "cancel":
+		return "cancelled"
+	case "partial_refund":
+		// Refund sebagian: payment tetap success, nominalnya dicatat di refunded_amount
+		return "success"
+	case "refund":
+		return "refunded"
 	default:
 		return "unknown"
 	}
@@ -387,6 +411,9 @@ func (ms *MidtransService) mapTransactionStatus(status string) string {
 
 // getBaseURL returns the appropriate Midtrans API base URL
 func (ms *MidtransService) getBaseURL() string {
+	if ms.baseURL != "" {
+		return ms.baseURL
+	}
 	if ms.config.IsProduction {
 		return "https://api.midtrans.com"
 	}
@@ -408,6 +435,13 @@ type MidtransResponse struct {
 	TransactionTime string `json:"transaction_time"`
 	Message         string `json:"message"`
 	ExpiryTime      string `json:"expiry_time"` // Waktu kadaluarsa pembayaran
+	VANumbers       []struct {
+		Bank     string `json:"bank"`
+		VANumber string `json:"va_number"`
+	} `json:"va_numbers"` // Nomor virtual account (BCA, BNI, BRI, CIMB)
+	PermataVANumber string `json:"permata_va_number"` // Nomor virtual account Permata
+	BillKey         string `json:"bill_key"`          // Kode pembayaran Mandiri bill payment
+	BillerCode      string `json:"biller_code"`       // Kode perusahaan Mandiri bill payment
 	Actions         []struct {
 		Name   string `json:"name"`
 		Method string `json:"method"`
@@ -446,3 +480,12 @@ func NewMidtransService(config *MidtransConfig) *MidtransService {
 		},
 	}
 }
+
+// midtransCustomerDetails menyusun customer_details; email hanya dikirim jika customer mengisinya
+func midtransCustomerDetails(name, email string) map[string]interface{} {
+	details := map[string]interface{}{"first_name": name}
+	if email != "" {
+		details["email"] = email
+	}
+	return details
+}
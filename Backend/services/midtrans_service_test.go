@@ -1,9 +1,13 @@
 package services
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/utils"
 )
 
 func TestMidtransService_ValidateConfig(t *testing.T) {
@@ -120,6 +124,7 @@ func TestMidtransService_CheckTransactionStatus(t *testing.T) {
 					ServerKey: "test-server-key",
 				},
 				httpClient: server.Client(),
+				baseURL:    server.URL,
 			}
 
 			status, err := ms.CheckTransactionStatus(tt.orderID)
@@ -148,7 +153,7 @@ func TestMidtransService_ValidateSignature(t *testing.T) {
 			orderID:     "test-order-1",
 			statusCode:  "200",
 			grossAmount: "10000",
-			signature:   "valid-signature", // This should be replaced with actual calculated signature
+			signature:   "ac37ecc8939c14dc78e9805ae4a784270ec987ca451fb92abdf6984e43e09d24b537d1d54f9ee4170a9da2cdee10415a97ebc61b9fcae1cb32a74992e2bd1718",
 			serverKey:   "test-server-key",
 			wantValid:   true,
 		},
@@ -178,3 +183,127 @@ func TestMidtransService_ValidateSignature(t *testing.T) {
 		})
 	}
 }
+
+func TestMidtransService_CreateBankTransferCharge(t *testing.T) {
+	tests := []struct {
+		name            string
+		bank            string
+		mockResponse    string
+		wantPaymentType string
+		wantVANumber    string
+		wantBillerCode  string
+		wantErr         bool
+	}{
+		{
+			name:            "bca virtual account",
+			bank:            "BCA",
+			mockResponse:    `{"status_code":"201","transaction_id":"trx-1","va_numbers":[{"bank":"bca","va_number":"12345678901"}],"expiry_time":"2026-01-02 15:04:05"}`,
+			wantPaymentType: "bank_transfer",
+			wantVANumber:    "12345678901",
+		},
+		{
+			name:            "permata virtual account",
+			bank:            "permata",
+			mockResponse:    `{"status_code":"201","transaction_id":"trx-2","permata_va_number":"8562000111"}`,
+			wantPaymentType: "bank_transfer",
+			wantVANumber:    "8562000111",
+		},
+		{
+			name:            "mandiri bill payment",
+			bank:            "mandiri",
+			mockResponse:    `{"status_code":"201","transaction_id":"trx-3","bill_key":"990011","biller_code":"70012"}`,
+			wantPaymentType: "echannel",
+			wantVANumber:    "990011",
+			wantBillerCode:  "70012",
+		},
+		{
+			name:         "unsupported bank",
+			bank:         "xyz",
+			mockResponse: `{}`,
+			wantErr:      true,
+		},
+		{
+			name:            "charge rejected",
+			bank:            "bni",
+			mockResponse:    `{"status_code":"406","status_message":"duplicate order id"}`,
+			wantPaymentType: "bank_transfer",
+			wantErr:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPaymentType string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var payload struct {
+					PaymentType  string `json:"payment_type"`
+					CustomExpiry struct {
+						ExpiryDuration int `json:"expiry_duration"`
+					} `json:"custom_expiry"`
+				}
+				json.NewDecoder(r.Body).Decode(&payload)
+				gotPaymentType = payload.PaymentType
+				if payload.CustomExpiry.ExpiryDuration != 60 {
+					t.Errorf("custom_expiry duration = %d, want 60", payload.CustomExpiry.ExpiryDuration)
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(tt.mockResponse))
+			}))
+			defer server.Close()
+
+			ms := &MidtransService{
+				config:     &MidtransConfig{ServerKey: "test-server-key"},
+				httpClient: server.Client(),
+				baseURL:    server.URL,
+			}
+
+			charge, err := ms.CreateCharge(ChargeRequest{
+				OrderRef:      "ORDER-1-abc",
+				Amount:        utils.Rupiah(50000),
+				Method:        ChargeMethodBankTransfer,
+				Bank:          tt.bank,
+				ExpiryMinutes: 60,
+			})
+			if gotPaymentType != tt.wantPaymentType {
+				t.Errorf("payment_type = %q, want %q", gotPaymentType, tt.wantPaymentType)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateCharge() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if charge.VANumber != tt.wantVANumber || charge.BillerCode != tt.wantBillerCode {
+				t.Errorf("CreateCharge() VA = %s/%s, want %s/%s", charge.BillerCode, charge.VANumber, tt.wantBillerCode, tt.wantVANumber)
+			}
+		})
+	}
+}
+
+func TestParseMidtransTime(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string // RFC3339 dalam UTC, kosong berarti nil
+	}{
+		{name: "expiry time in WIB", value: "2026-01-02 15:04:05", want: "2026-01-02T08:04:05Z"},
+		{name: "crosses midnight UTC", value: "2026-10-18 03:30:00", want: "2026-10-17T20:30:00Z"},
+		{name: "empty", value: ""},
+		{name: "invalid", value: "2026-01-02T15:04:05Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseMidtransTime(tt.value)
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("parseMidtransTime(%q) = %v, want nil", tt.value, got)
+				}
+				return
+			}
+			if got == nil || got.UTC().Format(time.RFC3339) != tt.want {
+				t.Errorf("parseMidtransTime(%q) = %v, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
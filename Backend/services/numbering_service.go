@@ -0,0 +1,215 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Jenis nomor dokumen
+const (
+	NumberSequenceReceipt = "receipt"
+	NumberSequenceOrder   = "order"
+)
+
+// Aturan reset nomor urut
+const (
+	NumberResetDaily   = "daily"
+	NumberResetMonthly = "monthly"
+	NumberResetYearly  = "yearly"
+	NumberResetNever   = "never"
+)
+
+// Format default: struk pajak RCP/20261018/000001, nomor order harian 001
+const (
+	DefaultReceiptNumberFormat = "RCP/{date}/{seq:6}"
+	DefaultOrderNumberFormat   = "{seq:3}"
+)
+
+// ErrInvalidNumberFormat dikembalikan untuk format atau aturan reset yang tidak dikenal
+var ErrInvalidNumberFormat = errors.New("invalid number format")
+
+var numberTokenPattern = regexp.MustCompile(`\{([a-z]+)(?::(\d+))?\}`)
+
+// NumberScheme adalah format dan aturan reset untuk satu jenis nomor dokumen.
+// Token format: {seq} atau {seq:N} (nomor urut, dipad nol sampai N digit), {branch},
+// {date} (20060102), {yyyy}, {yy}, {mm}, {dd}.
+type NumberScheme struct {
+	Name     string
+	Format   string
+	Reset    string
+	Branch   string
+	Location *time.Location // Zona waktu pergantian hari, nil = waktu lokal server
+}
+
+// ReceiptNumberScheme membaca format nomor struk dari env RECEIPT_NUMBER_FORMAT dan
+// RECEIPT_NUMBER_RESET (default RCP/{date}/{seq:6}, reset harian)
+func ReceiptNumberScheme() NumberScheme {
+	return numberSchemeFromEnv(NumberSequenceReceipt, "RECEIPT_NUMBER", DefaultReceiptNumberFormat)
+}
+
+// OrderNumberScheme membaca format nomor order dari env ORDER_NUMBER_FORMAT dan
+// ORDER_NUMBER_RESET (default {seq:3}, reset harian)
+func OrderNumberScheme() NumberScheme {
+	return numberSchemeFromEnv(NumberSequenceOrder, "ORDER_NUMBER", DefaultOrderNumberFormat)
+}
+
+// numberSchemeFromEnv memakai cabang dari BRANCH_CODE dan zona waktu profil restoran;
+// konfigurasi yang tidak valid dicatat di log lalu diganti default agar order dan struk
+// tetap bisa dibuat
+func numberSchemeFromEnv(name, envPrefix, defaultFormat string) NumberScheme {
+	profile := CurrentRestaurantProfile()
+	scheme := NumberScheme{
+		Name:     name,
+		Format:   envString(envPrefix+"_FORMAT", defaultFormat),
+		Reset:    strings.ToLower(envString(envPrefix+"_RESET", NumberResetDaily)),
+		Branch:   strings.TrimSpace(os.Getenv("BRANCH_CODE")),
+		Location: profile.Location(),
+	}
+	if err := scheme.Validate(); err != nil {
+		log.Printf("Invalid %s number scheme, using default: %v", name, err)
+		scheme.Format = defaultFormat
+		scheme.Reset = NumberResetDaily
+	}
+	return scheme
+}
+
+// Validate memastikan format memuat tepat satu {seq} dan hanya token yang dikenal
+func (s NumberScheme) Validate() error {
+	switch s.Reset {
+	case NumberResetDaily, NumberResetMonthly, NumberResetYearly, NumberResetNever:
+	default:
+		return fmt.Errorf("%w: unknown reset rule %q", ErrInvalidNumberFormat, s.Reset)
+	}
+
+	seqCount := 0
+	for _, match := range numberTokenPattern.FindAllStringSubmatch(s.Format, -1) {
+		switch match[1] {
+		case "seq":
+			seqCount++
+			if match[2] != "" {
+				if width, _ := strconv.Atoi(match[2]); width < 1 || width > 12 {
+					return fmt.Errorf("%w: {seq} width must be 1-12", ErrInvalidNumberFormat)
+				}
+			}
+		case "branch", "date", "yyyy", "yy", "mm", "dd":
+			if match[2] != "" {
+				return fmt.Errorf("%w: {%s} does not take a width", ErrInvalidNumberFormat, match[1])
+			}
+		default:
+			return fmt.Errorf("%w: unknown token {%s}", ErrInvalidNumberFormat, match[1])
+		}
+	}
+	if seqCount != 1 {
+		return fmt.Errorf("%w: format %q must contain {seq} exactly once", ErrInvalidNumberFormat, s.Format)
+	}
+	return nil
+}
+
+// Period mengembalikan kunci periode reset untuk waktu at
+func (s NumberScheme) Period(at time.Time) string {
+	at = s.localTime(at)
+	switch s.Reset {
+	case NumberResetMonthly:
+		return at.Format("200601")
+	case NumberResetYearly:
+		return at.Format("2006")
+	case NumberResetNever:
+		return ""
+	default:
+		return at.Format("20060102")
+	}
+}
+
+// Render menyusun nomor dokumen dari nomor urut seq pada waktu at
+func (s NumberScheme) Render(seq int64, at time.Time) string {
+	at = s.localTime(at)
+	return numberTokenPattern.ReplaceAllStringFunc(s.Format, func(token string) string {
+		match := numberTokenPattern.FindStringSubmatch(token)
+		switch match[1] {
+		case "seq":
+			width, _ := strconv.Atoi(match[2])
+			return fmt.Sprintf("%0*d", width, seq)
+		case "branch":
+			return s.Branch
+		case "date":
+			return at.Format("20060102")
+		case "yyyy":
+			return at.Format("2006")
+		case "yy":
+			return at.Format("06")
+		case "mm":
+			return at.Format("01")
+		case "dd":
+			return at.Format("02")
+		}
+		return token
+	})
+}
+
+// EnsurePeriod membuat baris sequence untuk periode at jika belum ada. Panggil dengan
+// koneksi biasa (bukan tx) sebelum transaksi yang memanggil Next: di MySQL, UPDATE ke
+// baris yang belum ada mengambil gap lock, dan dua transaksi yang sama-sama menyisipkan
+// baris periode baru di gap itu saling menunggu (deadlock) pada nomor pertama tiap periode.
+func (s NumberScheme) EnsurePeriod(db *gorm.DB, at time.Time) error {
+	// INSERT IGNORE di MySQL, ON CONFLICT DO NOTHING di sqlite
+	err := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.NumberSequence{Name: s.Name, Branch: s.Branch, Period: s.Period(at)}).Error
+	if err != nil {
+		return fmt.Errorf("failed to create %s sequence: %w", s.Name, err)
+	}
+	return nil
+}
+
+// Next mengambil nomor urut berikutnya dan merender nomor dokumen. tx harus transaksi
+// yang juga menyimpan dokumennya: baris sequence terkunci sampai commit, dan jika
+// transaksi di-rollback nomornya ikut batal sehingga tidak ada nomor yang loncat.
+// Pemanggil sebaiknya menjalankan EnsurePeriod sebelum transaksi dimulai.
+func (s NumberScheme) Next(tx *gorm.DB, at time.Time) (string, error) {
+	period := s.Period(at)
+	sequence := func() *gorm.DB {
+		return tx.Model(&models.NumberSequence{}).Where("name = ? AND branch = ? AND period = ?", s.Name, s.Branch, period)
+	}
+	increment := func() (int64, error) {
+		result := sequence().Updates(map[string]interface{}{"last_value": gorm.Expr("last_value + 1"), "updated_at": time.Now()})
+		return result.RowsAffected, result.Error
+	}
+
+	updated, err := increment()
+	if err != nil {
+		return "", fmt.Errorf("failed to increment %s sequence: %w", s.Name, err)
+	}
+	if updated == 0 {
+		// Baris periode belum ada (EnsurePeriod tidak dipanggil atau periode baru saja
+		// berganti): buat di dalam tx. Di MySQL jalur ini bisa deadlock saat bersamaan;
+		// InnoDB membatalkan salah satu transaksi dan pemanggil dapat mengulangnya.
+		if err := s.EnsurePeriod(tx, at); err != nil {
+			return "", err
+		}
+		if _, err := increment(); err != nil {
+			return "", fmt.Errorf("failed to increment %s sequence: %w", s.Name, err)
+		}
+	}
+
+	var current models.NumberSequence
+	if err := sequence().First(&current).Error; err != nil {
+		return "", fmt.Errorf("failed to read %s sequence: %w", s.Name, err)
+	}
+	return s.Render(current.LastValue, at), nil
+}
+
+func (s NumberScheme) localTime(at time.Time) time.Time {
+	if s.Location != nil {
+		return at.In(s.Location)
+	}
+	return at
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"gorm.io/gorm"
+)
+
+func TestNumberScheme_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		scheme  NumberScheme
+		wantErr bool
+	}{
+		{name: "default receipt", scheme: NumberScheme{Format: DefaultReceiptNumberFormat, Reset: NumberResetDaily}},
+		{name: "branch and month", scheme: NumberScheme{Format: "INV/{branch}/{yyyy}{mm}/{seq:5}", Reset: NumberResetMonthly}},
+		{name: "plain seq", scheme: NumberScheme{Format: "{seq}", Reset: NumberResetNever}},
+		{name: "missing seq", scheme: NumberScheme{Format: "RCP/{date}", Reset: NumberResetDaily}, wantErr: true},
+		{name: "seq twice", scheme: NumberScheme{Format: "{seq}-{seq}", Reset: NumberResetDaily}, wantErr: true},
+		{name: "unknown token", scheme: NumberScheme{Format: "{shift}/{seq}", Reset: NumberResetDaily}, wantErr: true},
+		{name: "seq too wide", scheme: NumberScheme{Format: "{seq:20}", Reset: NumberResetDaily}, wantErr: true},
+		{name: "unknown reset", scheme: NumberScheme{Format: "{seq}", Reset: "weekly"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.scheme.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidNumberFormat) {
+				t.Errorf("Validate() error = %v, want ErrInvalidNumberFormat", err)
+			}
+		})
+	}
+}
+
+func TestNumberScheme_Next(t *testing.T) {
+	db := newPaymentTestDB(t)
+	if err := db.AutoMigrate(&models.NumberSequence{}); err != nil {
+		t.Fatalf("failed to migrate number sequences: %v", err)
+	}
+	next := func(scheme NumberScheme, at time.Time) string {
+		t.Helper()
+		var number string
+		err := db.Transaction(func(tx *gorm.DB) (err error) {
+			number, err = scheme.Next(tx, at)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		return number
+	}
+
+	day1 := time.Date(2026, 10, 18, 9, 0, 0, 0, time.Local)
+	day2 := day1.AddDate(0, 0, 1)
+	receipts := NumberScheme{Name: NumberSequenceReceipt, Format: "RCP/{branch}/{date}/{seq:4}", Reset: NumberResetDaily, Branch: "JKT"}
+	otherBranch := receipts
+	otherBranch.Branch = "BDG"
+	monthly := NumberScheme{Name: NumberSequenceOrder, Format: "{yy}{mm}-{seq}", Reset: NumberResetMonthly}
+
+	tests := []struct {
+		scheme NumberScheme
+		at     time.Time
+		want   string
+	}{
+		{receipts, day1, "RCP/JKT/20261018/0001"},
+		{receipts, day1, "RCP/JKT/20261018/0002"},
+		{otherBranch, day1, "RCP/BDG/20261018/0001"},
+		{receipts, day2, "RCP/JKT/20261019/0001"},
+		{monthly, day1, "2610-1"},
+		{monthly, day2, "2610-2"},
+		{monthly, day1.AddDate(0, 1, 0), "2611-1"},
+	}
+	for _, tt := range tests {
+		if got := next(tt.scheme, tt.at); got != tt.want {
+			t.Errorf("Next(%s %s) = %q, want %q", tt.scheme.Branch, tt.at.Format("2006-01-02"), got, tt.want)
+		}
+	}
+
+	// Transaksi yang gagal tidak memakai nomor
+	rollback := errors.New("rollback")
+	db.Transaction(func(tx *gorm.DB) error {
+		if _, err := receipts.Next(tx, day1); err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		return rollback
+	})
+	if got := next(receipts, day1); got != "RCP/JKT/20261018/0003" {
+		t.Errorf("Next() after rollback = %q, want RCP/JKT/20261018/0003", got)
+	}
+
+	// EnsurePeriod tidak mereset periode yang sudah berjalan
+	if err := receipts.EnsurePeriod(db, day1); err != nil {
+		t.Fatalf("EnsurePeriod() error = %v", err)
+	}
+	if got := next(receipts, day1); got != "RCP/JKT/20261018/0004" {
+		t.Errorf("Next() after EnsurePeriod = %q, want RCP/JKT/20261018/0004", got)
+	}
+
+	// Permintaan bersamaan mendapat nomor berurutan tanpa duplikat. Seperti pemanggil
+	// sebenarnya, baris periode dibuat di luar transaksi sebelum Next dipanggil.
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var numbers []string
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := otherBranch.EnsurePeriod(db, day2); err != nil {
+				t.Errorf("EnsurePeriod() error = %v", err)
+				return
+			}
+			var number string
+			err := db.Transaction(func(tx *gorm.DB) (err error) {
+				number, err = otherBranch.Next(tx, day2)
+				return err
+			})
+			if err != nil {
+				t.Errorf("Next() error = %v", err)
+				return
+			}
+			mu.Lock()
+			numbers = append(numbers, number)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	sort.Strings(numbers)
+	for i, number := range numbers {
+		if want := otherBranch.Render(int64(i+1), day2); number != want {
+			t.Fatalf("concurrent numbers = %v, want 1..20 without gaps", numbers)
+		}
+	}
+}
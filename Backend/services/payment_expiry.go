@@ -0,0 +1,316 @@
+package services
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/kds"
+	"github.com/yeremiapane/restaurant-app/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultQRISExpiryMinutes adalah masa berlaku QRIS jika QRIS_EXPIRY_MINUTES kosong
+const defaultQRISExpiryMinutes = 15
+
+// expiryResyncInterval adalah jarak sinkronisasi ulang jadwal dari database,
+// jaring pengaman untuk payment pending yang dibuat tanpa lewat SchedulePaymentExpiry
+const expiryResyncInterval = 10 * time.Minute
+
+// errExpirySkipped menandai payment yang sudah berubah status sebelum sempat di-expire
+var errExpirySkipped = errors.New("payment is no longer pending")
+
+// PaymentExpiryMinutes mengembalikan masa berlaku payment per metode:
+// QRIS dari env QRIS_EXPIRY_MINUTES (default 15), bank transfer dari BANK_TRANSFER_EXPIRY_MINUTES (default 1440)
+func PaymentExpiryMinutes(method string) int {
+	if method == ChargeMethodBankTransfer {
+		return BankTransferExpiryMinutes()
+	}
+	return envInt("QRIS_EXPIRY_MINUTES", defaultQRISExpiryMinutes)
+}
+
+// expiryEntry adalah satu deadline payment di dalam heap
+type expiryEntry struct {
+	paymentID uint
+	deadline  time.Time
+	index     int
+}
+
+// expiryHeap adalah min-heap deadline (paling awal di atas)
+type expiryHeap []*expiryEntry
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].deadline.Before(h[j].deadline) }
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiryHeap) Push(x interface{}) {
+	entry := x.(*expiryEntry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	entry.index = -1
+	return entry
+}
+
+// PaymentExpiryScheduler meng-expire payment pending tepat pada ExpiredAt-nya.
+// Deadline disimpan di timer heap yang dibangun ulang dari database saat startup,
+// sehingga tidak ada jadwal yang hilang ketika server restart.
+type PaymentExpiryScheduler struct {
+	db       *gorm.DB
+	provider PaymentProvider
+	now      func() time.Time // Jam scheduler, bisa diganti di test
+
+	mu      sync.Mutex
+	queue   expiryHeap
+	entries map[uint]*expiryEntry
+	wake    chan struct{}
+	stop    chan struct{}
+}
+
+var (
+	expirySchedulerMu sync.RWMutex
+	expiryScheduler   *PaymentExpiryScheduler
+)
+
+// NewPaymentExpiryScheduler membuat instance baru PaymentExpiryScheduler
+func NewPaymentExpiryScheduler(db *gorm.DB) *PaymentExpiryScheduler {
+	return &PaymentExpiryScheduler{
+		db:      db,
+		now:     time.Now,
+		entries: make(map[uint]*expiryEntry),
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+	}
+}
+
+// SchedulePaymentExpiry mendaftarkan deadline payment pending ke scheduler yang aktif.
+// Tidak melakukan apa pun jika scheduler belum dijalankan (mis. di test).
+func SchedulePaymentExpiry(payment *models.Payment) {
+	expirySchedulerMu.RLock()
+	scheduler := expiryScheduler
+	expirySchedulerMu.RUnlock()
+
+	if scheduler == nil || payment.Status != PaymentStatusPending || payment.ExpiredAt == nil {
+		return
+	}
+	scheduler.Schedule(payment.ID, *payment.ExpiredAt)
+}
+
+// Start memuat semua payment pending dari database lalu menjalankan loop scheduler,
+// dan mendaftarkan scheduler ini sebagai scheduler aktif untuk SchedulePaymentExpiry
+func (s *PaymentExpiryScheduler) Start() {
+	if err := s.Resync(); err != nil {
+		log.Printf("Error loading pending payments for expiry: %v", err)
+	}
+
+	expirySchedulerMu.Lock()
+	expiryScheduler = s
+	expirySchedulerMu.Unlock()
+
+	go s.run()
+	log.Printf("Payment expiry scheduler started with %d pending payments", s.Len())
+}
+
+// Stop menghentikan loop scheduler
+func (s *PaymentExpiryScheduler) Stop() {
+	expirySchedulerMu.Lock()
+	if expiryScheduler == s {
+		expiryScheduler = nil
+	}
+	expirySchedulerMu.Unlock()
+	close(s.stop)
+}
+
+// Resync mendaftarkan ulang deadline semua payment pending yang ada di database
+func (s *PaymentExpiryScheduler) Resync() error {
+	var payments []models.Payment
+	err := s.db.Select("id", "expired_at").
+		Where("status = ? AND expired_at IS NOT NULL", PaymentStatusPending).
+		Find(&payments).Error
+	if err != nil {
+		return err
+	}
+	for _, payment := range payments {
+		s.Schedule(payment.ID, *payment.ExpiredAt)
+	}
+	return nil
+}
+
+// Schedule mendaftarkan atau memperbarui deadline sebuah payment
+func (s *PaymentExpiryScheduler) Schedule(paymentID uint, deadline time.Time) {
+	s.mu.Lock()
+	if entry, ok := s.entries[paymentID]; ok {
+		entry.deadline = deadline
+		heap.Fix(&s.queue, entry.index)
+	} else {
+		entry := &expiryEntry{paymentID: paymentID, deadline: deadline}
+		heap.Push(&s.queue, entry)
+		s.entries[paymentID] = entry
+	}
+	s.mu.Unlock()
+
+	// Bangunkan loop agar timer disetel ulang jika deadline ini yang paling awal
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Len mengembalikan jumlah deadline yang sedang dijadwalkan
+func (s *PaymentExpiryScheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue)
+}
+
+func (s *PaymentExpiryScheduler) run() {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	resync := time.NewTicker(expiryResyncInterval)
+	defer resync.Stop()
+
+	for {
+		s.expireDue()
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(s.nextWait(s.now()))
+
+		select {
+		case <-timer.C:
+		case <-s.wake:
+		case <-resync.C:
+			if err := s.Resync(); err != nil {
+				log.Printf("Error resyncing payment expiry schedule: %v", err)
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// expireDue meng-expire semua payment yang deadline-nya sudah lewat menurut jam scheduler
+func (s *PaymentExpiryScheduler) expireDue() {
+	for _, paymentID := range s.popDue(s.now()) {
+		if err := s.expire(paymentID); err != nil {
+			log.Printf("Error expiring payment %d: %v", paymentID, err)
+		}
+	}
+}
+
+// popDue mengeluarkan semua payment yang deadline-nya sudah lewat
+func (s *PaymentExpiryScheduler) popDue(now time.Time) []uint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []uint
+	for len(s.queue) > 0 && !s.queue[0].deadline.After(now) {
+		entry := heap.Pop(&s.queue).(*expiryEntry)
+		delete(s.entries, entry.paymentID)
+		due = append(due, entry.paymentID)
+	}
+	return due
+}
+
+// nextWait menghitung durasi sampai deadline paling awal (maksimal sampai resync berikutnya)
+func (s *PaymentExpiryScheduler) nextWait(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.queue) == 0 {
+		return expiryResyncInterval
+	}
+	wait := s.queue[0].deadline.Sub(now)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// expire membatalkan transaksi di provider, menandai payment expired, melepas order
+// agar bisa dibayar ulang, lalu broadcast EventPaymentExpired
+func (s *PaymentExpiryScheduler) expire(paymentID uint) error {
+	var payment models.Payment
+	if err := s.db.First(&payment, paymentID).Error; err != nil {
+		return err
+	}
+	if payment.Status != PaymentStatusPending || payment.ExpiredAt == nil {
+		return nil
+	}
+	// Deadline diperpanjang setelah dijadwalkan: jadwalkan ulang
+	if payment.ExpiredAt.After(s.now()) {
+		s.Schedule(payment.ID, *payment.ExpiredAt)
+		return nil
+	}
+
+	// Batalkan di provider agar QR / VA tidak bisa dibayar lagi. Jika gagal, cek apakah
+	// pelanggan sempat membayar; payment sukses dibiarkan untuk webhook / rekonsiliasi.
+	if ref := payment.ProviderRef(); ref != "" {
+		provider := s.paymentProvider()
+		if err := provider.Cancel(ref); err != nil {
+			status, checkErr := provider.CheckStatus(ref)
+			if checkErr != nil {
+				return fmt.Errorf("failed to cancel provider transaction: %v (status check: %v)", err, checkErr)
+			}
+			if status == PaymentStatusSuccess {
+				log.Printf("Payment %d was paid at %s before expiry, waiting for notification", payment.ID, provider.Name())
+				return nil
+			}
+		}
+	}
+
+	var order models.Order
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, paymentID).Error; err != nil {
+			return err
+		}
+		if payment.Status != PaymentStatusPending {
+			return errExpirySkipped
+		}
+
+		payment.Status = PaymentStatusExpired
+		payment.UpdatedAt = s.now()
+		if err := tx.Save(&payment).Error; err != nil {
+			return fmt.Errorf("failed to update payment status: %w", err)
+		}
+
+		// Order tetap pending_payment supaya pelanggan bisa membayar ulang
+		return tx.First(&order, payment.OrderID).Error
+	})
+	if errors.Is(err, errExpirySkipped) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Payment %d expired at %s, order %d released", payment.ID, payment.ExpiredAt.Format(time.RFC3339), order.ID)
+	kds.BroadcastPaymentExpired(payment)
+	kds.BroadcastOrderUpdate(order)
+	return nil
+}
+
+func (s *PaymentExpiryScheduler) paymentProvider() PaymentProvider {
+	if s.provider != nil {
+		return s.provider
+	}
+	return GetPaymentProvider()
+}
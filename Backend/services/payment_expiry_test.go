@@ -0,0 +1,109 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+func TestPaymentExpiryScheduler_ExpiresAtDeadline(t *testing.T) {
+	db := newPaymentTestDB(t)
+	fp := NewFakePaymentProvider("")
+
+	order := models.Order{CustomerID: 1, Status: OrderStatusPendingPayment, TotalAmount: utils.Rupiah(30000)}
+	db.Create(&order)
+
+	// expiry_time dari Midtrans dalam WIB: 14:15 WIB = 07:15 UTC
+	deadline := parseMidtransTime("2026-10-18 14:15:00")
+	if want := time.Date(2026, 10, 18, 7, 15, 0, 0, time.UTC); deadline == nil || !deadline.Equal(want) {
+		t.Fatalf("provider deadline = %v, want %v", deadline, want)
+	}
+
+	newPending := func(orderRef string, expiredAt time.Time) *models.Payment {
+		if _, err := fp.CreateCharge(ChargeRequest{OrderRef: orderRef, Amount: utils.Rupiah(30000)}); err != nil {
+			t.Fatalf("CreateCharge() error = %v", err)
+		}
+		payment := &models.Payment{OrderID: order.ID, Amount: utils.Rupiah(30000), Status: PaymentStatusPending,
+			PaymentMethod: "qris", ProviderReference: strPtr(orderRef), ExpiredAt: &expiredAt}
+		if err := db.Create(payment).Error; err != nil {
+			t.Fatalf("failed to create payment: %v", err)
+		}
+		return payment
+	}
+
+	due := newPending("ORDER-1-due00001", *deadline)
+	later := newPending("ORDER-1-later001", deadline.Add(time.Hour))
+	// Dibayar di provider tetapi notifikasinya belum masuk: tidak boleh di-expire
+	paid := newPending("ORDER-1-paid0001", *deadline)
+	if _, err := fp.Simulate("ORDER-1-paid0001", FakeActionSettle); err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+
+	clock := deadline.Add(-time.Second)
+	scheduler := NewPaymentExpiryScheduler(db)
+	scheduler.provider = fp
+	scheduler.now = func() time.Time { return clock }
+	if err := scheduler.Resync(); err != nil {
+		t.Fatalf("Resync() error = %v", err)
+	}
+	if scheduler.Len() != 3 {
+		t.Fatalf("scheduler loaded %d payments, want 3", scheduler.Len())
+	}
+	if wait := scheduler.nextWait(clock); wait != time.Second {
+		t.Errorf("nextWait() = %s, want 1s until the provider expiry", wait)
+	}
+
+	statuses := func(want map[uint]string) {
+		t.Helper()
+		for id, status := range want {
+			var payment models.Payment
+			db.First(&payment, id)
+			if payment.Status != status {
+				t.Errorf("payment %d status = %s, want %s", id, payment.Status, status)
+			}
+		}
+	}
+
+	// Satu detik sebelum expiry_time belum ada yang di-expire
+	scheduler.expireDue()
+	statuses(map[uint]string{due.ID: PaymentStatusPending, later.ID: PaymentStatusPending, paid.ID: PaymentStatusPending})
+
+	// Tepat pada expiry_time
+	clock = *deadline
+	scheduler.expireDue()
+	statuses(map[uint]string{due.ID: PaymentStatusExpired, later.ID: PaymentStatusPending, paid.ID: PaymentStatusPending})
+	if scheduler.Len() != 1 {
+		t.Errorf("scheduler has %d payments left, want 1", scheduler.Len())
+	}
+	if status, _ := fp.CheckStatus("ORDER-1-due00001"); status != PaymentStatusCancelled {
+		t.Errorf("provider status of expired payment = %s, want %s", status, PaymentStatusCancelled)
+	}
+
+	db.First(&order, order.ID)
+	if order.Status != OrderStatusPendingPayment {
+		t.Errorf("order status = %s, want %s (released for another payment)", order.Status, OrderStatusPendingPayment)
+	}
+}
+
+func TestPaymentExpiryScheduler_Order(t *testing.T) {
+	scheduler := NewPaymentExpiryScheduler(nil)
+	now := time.Now()
+
+	scheduler.Schedule(1, now.Add(3*time.Second))
+	scheduler.Schedule(2, now.Add(time.Second))
+	scheduler.Schedule(3, now.Add(2*time.Second))
+	// Deadline payment 1 dimajukan sehingga menjadi yang paling awal
+	scheduler.Schedule(1, now.Add(500*time.Millisecond))
+
+	if wait := scheduler.nextWait(now); wait != 500*time.Millisecond {
+		t.Errorf("nextWait() = %s, want 500ms", wait)
+	}
+	if due := scheduler.popDue(now.Add(2 * time.Second)); len(due) != 3 || due[0] != 1 || due[1] != 2 || due[2] != 3 {
+		t.Errorf("popDue() = %v, want [1 2 3]", due)
+	}
+	if scheduler.Len() != 0 || scheduler.nextWait(now) != expiryResyncInterval {
+		t.Errorf("empty scheduler Len() = %d, nextWait() = %s", scheduler.Len(), scheduler.nextWait(now))
+	}
+}
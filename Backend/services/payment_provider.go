@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+// Nama provider pembayaran yang didukung (dipilih lewat env PAYMENT_PROVIDER)
+const (
+	PaymentProviderMidtrans = "midtrans"
+	PaymentProviderFake     = "fake"
+)
+
+// Metode pembayaran yang bisa dibuat lewat CreateCharge
+const (
+	ChargeMethodQRIS         = "qris"
+	ChargeMethodBankTransfer = "bank_transfer"
+)
+
+// PaymentProvider adalah kontrak untuk payment gateway (QRIS dan virtual account)
+type PaymentProvider interface {
+	// Name mengembalikan nama provider, misalnya "midtrans" atau "fake"
+	Name() string
+	// CreateCharge membuat transaksi baru di provider
+	CreateCharge(req ChargeRequest) (*ChargeResult, error)
+	// CheckStatus mengambil status transaksi dan memetakannya ke status internal
+	CheckStatus(providerRef string) (string, error)
+	// GetTransaction mengambil detail transaksi (status dan nominal) untuk rekonsiliasi
+	GetTransaction(providerRef string) (*ProviderTransaction, error)
+	// Cancel membatalkan transaksi yang belum dibayar
+	Cancel(providerRef string) error
+	// Refund mengembalikan dana transaksi yang sudah dibayar
+	Refund(providerRef string, amount utils.Money, reason string) error
+	// VerifyWebhook memvalidasi signature notifikasi lalu mem-parsing isinya
+	VerifyWebhook(payload []byte) (*WebhookNotification, error)
+}
+
+// ChargeRequest berisi data yang dibutuhkan untuk membuat transaksi
+type ChargeRequest struct {
+	OrderRef      string
+	Amount        utils.Money
+	CustomerName  string
+	CustomerEmail string
+	Method        string // ChargeMethodQRIS (default) atau ChargeMethodBankTransfer
+	Bank          string // Bank virtual account, wajib untuk bank transfer
+	ExpiryMinutes int    // Masa berlaku transaksi, 0 berarti default provider
+}
+
+// ChargeResult adalah hasil pembuatan transaksi di provider
+type ChargeResult struct {
+	TransactionID string
+	OrderRef      string
+	QRString      string
+	QRImageURL    string
+	Bank          string
+	VANumber      string
+	BillerCode    string
+	ExpiresAt     *time.Time
+}
+
+// ProviderTransaction adalah data transaksi menurut provider
+type ProviderTransaction struct {
+	OrderRef          string
+	TransactionID     string
+	TransactionStatus string
+	Status            string
+	Amount            utils.Money
+	TransactionTime   *time.Time
+}
+
+// TransactionLister diimplementasikan provider yang bisa menampilkan daftar transaksinya.
+// Dipakai rekonsiliasi untuk menemukan transaksi yang tidak tercatat di database lokal.
+type TransactionLister interface {
+	ListTransactions(since time.Time) ([]ProviderTransaction, error)
+}
+
+// ErrTransactionNotFound dikembalikan jika provider tidak mengenal transaksi
+var ErrTransactionNotFound = errors.New("transaction not found at provider")
+
+// ErrFractionalRupiah dikembalikan jika nominal transaksi provider mengandung sen.
+// Payment gateway IDR hanya menerima Rupiah utuh.
+var ErrFractionalRupiah = errors.New("payment gateway amounts must be whole rupiah")
+
+// WebhookNotification adalah isi notifikasi provider yang sudah divalidasi
+type WebhookNotification struct {
+	OrderRef          string
+	TransactionID     string
+	TransactionStatus string
+	Status            string
+	StatusCode        string
+	GrossAmount       string
+}
+
+var (
+	paymentProvider     PaymentProvider
+	paymentProviderOnce sync.Once
+	paymentProviderMu   sync.RWMutex
+)
+
+// GetPaymentProvider mengembalikan provider aktif sesuai env PAYMENT_PROVIDER (default midtrans)
+func GetPaymentProvider() PaymentProvider {
+	paymentProviderOnce.Do(func() {
+		name := strings.ToLower(strings.TrimSpace(os.Getenv("PAYMENT_PROVIDER")))
+		provider := newPaymentProvider(name)
+
+		paymentProviderMu.Lock()
+		if paymentProvider == nil {
+			paymentProvider = provider
+		}
+		paymentProviderMu.Unlock()
+
+		log.Printf("Payment provider initialized: %s", provider.Name())
+	})
+
+	paymentProviderMu.RLock()
+	defer paymentProviderMu.RUnlock()
+	return paymentProvider
+}
+
+// SetPaymentProvider mengganti provider aktif (dipakai untuk test)
+func SetPaymentProvider(provider PaymentProvider) {
+	paymentProviderOnce.Do(func() {})
+
+	paymentProviderMu.Lock()
+	defer paymentProviderMu.Unlock()
+	paymentProvider = provider
+}
+
+func newPaymentProvider(name string) PaymentProvider {
+	switch name {
+	case PaymentProviderFake:
+		return NewFakePaymentProvider(os.Getenv("FAKE_PAYMENT_SECRET"))
+	case "", PaymentProviderMidtrans:
+		return GetMidtransService()
+	default:
+		log.Printf("WARNING: unknown PAYMENT_PROVIDER %q, falling back to midtrans", name)
+		return GetMidtransService()
+	}
+}
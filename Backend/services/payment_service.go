@@ -1,14 +1,48 @@
 package services
 
 import (
+	"errors"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// Error yang dikembalikan saat memproses notifikasi provider
+var (
+	ErrPaymentNotFound         = errors.New("payment not found")
+	ErrPaymentAmountMismatch   = errors.New("payment amount does not match")
+	ErrInvalidStatusTransition = errors.New("invalid payment status transition")
+)
+
+// CanTransitionPaymentStatus memeriksa apakah status payment boleh berubah dari -> ke.
+// Status hanya boleh maju: pending bisa ke status final mana pun, success hanya bisa
+// ke refunded, dan status final lain tidak pernah berubah lagi.
+func CanTransitionPaymentStatus(from, to string) bool {
+	switch to {
+	case PaymentStatusPending, PaymentStatusSuccess, PaymentStatusFailed,
+		PaymentStatusExpired, PaymentStatusCancelled, PaymentStatusRefunded:
+	default:
+		return false
+	}
+
+	if from == to {
+		return true
+	}
+
+	switch from {
+	case "", PaymentStatusPending:
+		return to != PaymentStatusRefunded
+	case PaymentStatusSuccess:
+		return to == PaymentStatusRefunded
+	default:
+		return false
+	}
+}
+
 // Status pembayaran
 const (
 	PaymentStatusPending   = "pending"
@@ -16,6 +50,14 @@ const (
 	PaymentStatusFailed    = "failed"
 	PaymentStatusExpired   = "expired"
 	PaymentStatusCancelled = "cancelled"
+	PaymentStatusRefunded  = "refunded"
+)
+
+// Penjualan bersih payment sukses yang sudah direfund sebagian: refund mengurangi
+// nominal bill lebih dulu, kelebihannya mengurangi tip
+const (
+	netPaymentAmountSQL = "amount - CASE WHEN refunded_amount > amount THEN amount ELSE refunded_amount END"
+	netPaymentTipSQL    = "tip - CASE WHEN refunded_amount > amount THEN refunded_amount - amount ELSE 0 END"
 )
 
 // Status order
@@ -67,6 +109,120 @@ func (s *PaymentService) GetPaymentByOrderID(orderID uint) (*models.Payment, err
 	return &payment, nil
 }
 
+// GetPaymentByProviderRef mencari payment berdasarkan referensi provider.
+// Setiap ref dicocokkan ke kolom provider_reference; payment lama tanpa
+// provider_reference dicocokkan lewat reference_id (transaction ID provider).
+func (s *PaymentService) GetPaymentByProviderRef(refs ...string) (*models.Payment, error) {
+	return findPaymentByProviderRef(s.db, refs...)
+}
+
+func findPaymentByProviderRef(db *gorm.DB, refs ...string) (*models.Payment, error) {
+	// Session baru agar kondisi Where tidak menumpuk antar query
+	db = db.Session(&gorm.Session{})
+
+	for _, ref := range refs {
+		if ref == "" {
+			continue
+		}
+		var payment models.Payment
+		err := db.Where("provider_reference = ?", ref).First(&payment).Error
+		if err == nil {
+			return &payment, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	for _, ref := range refs {
+		if ref == "" {
+			continue
+		}
+		var payment models.Payment
+		err := db.Where("provider_reference IS NULL AND reference_id = ?", ref).First(&payment).Error
+		if err == nil {
+			return &payment, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	return nil, ErrPaymentNotFound
+}
+
+// ApplyProviderNotification menerapkan notifikasi provider yang sudah diverifikasi
+// ke payment yang sesuai, lalu menandai order sebagai paid jika pembayaran sukses
+func (s *PaymentService) ApplyProviderNotification(notification *WebhookNotification) (*models.Payment, error) {
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	payment, err := findPaymentByProviderRef(tx.Clauses(clause.Locking{Strength: "UPDATE"}),
+		notification.OrderRef, notification.TransactionID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Validasi nominal: provider mengirim gross_amount sebagai string (mis. "10000.00")
+	grossAmount, err := utils.ParseMoney(notification.GrossAmount)
+	if err != nil || grossAmount != payment.ChargedAmount() {
+		tx.Rollback()
+		return nil, ErrPaymentAmountMismatch
+	}
+
+	// Notifikasi berulang dengan status yang sama tidak mengubah apa pun
+	if payment.Status == notification.Status {
+		tx.Rollback()
+		return payment, nil
+	}
+	if !CanTransitionPaymentStatus(payment.Status, notification.Status) {
+		tx.Rollback()
+		return payment, fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, payment.Status, notification.Status)
+	}
+
+	now := time.Now()
+	payment.Status = notification.Status
+	payment.UpdatedAt = now
+	if notification.Status == PaymentStatusSuccess {
+		payment.PaymentTime = &now
+	}
+	// Refund penuh dari provider (mis. lewat dashboard) mengembalikan seluruh tagihan
+	if notification.Status == PaymentStatusRefunded {
+		payment.RefundedAmount = payment.ChargedAmount()
+	}
+	if payment.ReferenceID == "" && notification.TransactionID != "" {
+		payment.ReferenceID = notification.TransactionID
+	}
+
+	if err := tx.Save(payment).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to update payment status: %w", err)
+	}
+
+	if notification.Status == PaymentStatusSuccess {
+		// Bagian dari split tender: order baru paid jika semua bagian sudah lunas
+		if payment.TenderGroup != "" {
+			if _, err := settleOrderIfPaid(tx, payment.OrderID); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		} else if err := tx.Model(&models.Order{}).Where("id = ?", payment.OrderID).
+			Updates(map[string]interface{}{"status": OrderStatusPaid, "updated_at": now}).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to update order status: %w", err)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return payment, nil
+}
+
 // UpdatePaymentStatus mengupdate status pembayaran
 func (s *PaymentService) UpdatePaymentStatus(paymentID uint, status string) error {
 	// Begin transaction
@@ -111,114 +267,3 @@ func (s *PaymentService) UpdatePaymentStatus(paymentID uint, status string) erro
 
 	return nil
 }
-
-// PaymentTimeoutChecker adalah goroutine yang memeriksa payment yang sudah mendekati waktu expired
-func (s *PaymentService) PaymentTimeoutChecker() {
-	ticker := time.NewTicker(5 * time.Minute)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			s.CheckExpiredPayments()
-		}
-	}
-}
-
-// CheckExpiredPayments memeriksa pembayaran yang hampir kedaluwarsa dan mengupdate statusnya
-func (s *PaymentService) CheckExpiredPayments() {
-	payments := make([]*models.Payment, 0)
-
-	// Get semua payment dengan status pending
-	result := s.db.Where("status = ?", PaymentStatusPending).Find(&payments)
-	if result.Error != nil {
-		log.Printf("Error checking expired payments: %v", result.Error)
-		return
-	}
-
-	now := time.Now()
-
-	for _, payment := range payments {
-		// Jika waktu expired sudah lewat, update status menjadi expired
-		if payment.ExpiredAt != nil && !payment.ExpiredAt.IsZero() && now.After(*payment.ExpiredAt) {
-			// Update status payment di database
-			payment.Status = PaymentStatusExpired
-			result := s.db.Save(payment)
-			if result.Error != nil {
-				log.Printf("Error updating expired payment %d: %v", payment.ID, result.Error)
-				continue
-			}
-
-			// Update status order
-			order := &models.Order{}
-			err := s.db.First(order, payment.OrderID).Error
-			if err != nil {
-				log.Printf("Error finding order %d for expired payment: %v", payment.OrderID, err)
-				continue
-			}
-
-			order.Status = OrderStatusCancelled
-			err = s.db.Save(order).Error
-			if err != nil {
-				log.Printf("Error updating order status for expired payment: %v", err)
-				continue
-			}
-
-			log.Printf("Payment %d expired and order %d cancelled", payment.ID, payment.OrderID)
-		} else if payment.ExpiredAt != nil && !payment.ExpiredAt.IsZero() {
-			// Jika payment mendekati waktu expired (10 menit sebelum expired)
-			// Cek status di Midtrans untuk memastikan status terbaru
-			tenMinutesBeforeExpiry := (*payment.ExpiredAt).Add(-10 * time.Minute)
-			if now.After(tenMinutesBeforeExpiry) {
-				// Check status di Midtrans
-				midtransService := GetMidtransService()
-				status, err := midtransService.CheckTransactionStatus(fmt.Sprintf("%d", payment.OrderID))
-				if err != nil {
-					log.Printf("Error checking transaction status for payment %d: %v", payment.ID, err)
-					continue
-				}
-
-				// Jika status dari Midtrans berbeda dengan status di database, update
-				if status != payment.Status {
-					payment.Status = status
-					result := s.db.Save(payment)
-					if result.Error != nil {
-						log.Printf("Error updating payment status from Midtrans: %v", result.Error)
-						continue
-					}
-
-					// Update order status
-					order := &models.Order{}
-					err := s.db.First(order, payment.OrderID).Error
-					if err != nil {
-						log.Printf("Error finding order for payment status update: %v", err)
-						continue
-					}
-
-					// Update order status based on payment status
-					if status == PaymentStatusSuccess {
-						order.Status = OrderStatusPaid
-					} else if status == PaymentStatusExpired ||
-						status == PaymentStatusFailed ||
-						status == PaymentStatusCancelled {
-						order.Status = OrderStatusCancelled
-					}
-
-					err = s.db.Save(order).Error
-					if err != nil {
-						log.Printf("Error updating order status based on payment: %v", err)
-						continue
-					}
-
-					log.Printf("Updated payment %d status to %s from Midtrans", payment.ID, status)
-				}
-			}
-		}
-	}
-}
-
-// StartTimeoutChecker memulai goroutine untuk memeriksa transaksi yang expired
-func (s *PaymentService) StartTimeoutChecker() {
-	go s.PaymentTimeoutChecker()
-	log.Println("Payment timeout checker started")
-}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newPaymentTestDB membuat database sqlite in-memory. Tabel dibuat manual karena
+// tag enum MySQL di models.Payment tidak didukung AutoMigrate sqlite.
+func newPaymentTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	// Satu koneksi saja: setiap koneksi sqlite in-memory punya database sendiri
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+
+	statements := []string{
+		`CREATE TABLE orders (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			order_number VARCHAR(30),
+			customer_id INTEGER NOT NULL DEFAULT 0,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending_payment',
+			total_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+			chef_id INTEGER,
+			start_cooking_time DATETIME,
+			finish_cooking_time DATETIME,
+			inventory_depleted_at DATETIME,
+			table_id INTEGER,
+			created_at DATETIME,
+			updated_at DATETIME
+		)`,
+		`CREATE TABLE payments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			order_id INTEGER,
+			amount REAL,
+			tip REAL DEFAULT 0,
+			tip_recipient_id INTEGER,
+			status VARCHAR(20) DEFAULT 'pending',
+			payment_method VARCHAR(20) DEFAULT 'cash',
+			payment_type TEXT,
+			reference_id TEXT,
+			provider_reference VARCHAR(100),
+			qr_code TEXT,
+			qr_image_url TEXT,
+			payment_url TEXT,
+			bank VARCHAR(20),
+			va_number VARCHAR(50),
+			biller_code VARCHAR(20),
+			details TEXT,
+			cash_received REAL,
+			change REAL,
+			refunded_amount REAL NOT NULL DEFAULT 0,
+			payment_time DATETIME,
+			expired_at DATETIME,
+			verified_by INTEGER,
+			shift_id INTEGER,
+			tender_group VARCHAR(36),
+			created_at DATETIME,
+			updated_at DATETIME
+		)`,
+		`CREATE UNIQUE INDEX idx_payments_provider_reference ON payments(provider_reference)`,
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("failed to create schema: %v", err)
+		}
+	}
+	if err := db.AutoMigrate(&models.Customer{}); err != nil {
+		t.Fatalf("failed to migrate customers: %v", err)
+	}
+	return db
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestPaymentService_ApplyProviderNotification_MultipleAttempts(t *testing.T) {
+	db := newPaymentTestDB(t)
+
+	order := models.Order{CustomerID: 1, Status: OrderStatusPendingPayment, TotalAmount: utils.Rupiah(50000)}
+	if err := db.Create(&order).Error; err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	// Percobaan pertama sudah expired, percobaan kedua masih pending,
+	// dan ada payment lama yang belum punya provider_reference
+	first := models.Payment{OrderID: order.ID, Amount: utils.Rupiah(50000), Status: PaymentStatusExpired, PaymentMethod: "qris",
+		ReferenceID: "trx-first", ProviderReference: strPtr("ORDER-1-aaaa1111")}
+	second := models.Payment{OrderID: order.ID, Amount: utils.Rupiah(50000), Status: PaymentStatusPending, PaymentMethod: "qris",
+		ReferenceID: "trx-second", ProviderReference: strPtr("ORDER-1-bbbb2222")}
+	legacy := models.Payment{OrderID: order.ID, Amount: utils.Rupiah(50000), Status: PaymentStatusPending, PaymentMethod: "qris",
+		ReferenceID: "trx-legacy"}
+	for _, p := range []*models.Payment{&first, &second, &legacy} {
+		if err := db.Create(p).Error; err != nil {
+			t.Fatalf("failed to create payment: %v", err)
+		}
+	}
+
+	svc := NewPaymentService(db)
+
+	tests := []struct {
+		name          string
+		notification  WebhookNotification
+		wantPaymentID uint
+		wantErr       error
+	}{
+		{
+			name: "settlement for second attempt",
+			notification: WebhookNotification{OrderRef: "ORDER-1-bbbb2222", TransactionID: "trx-second",
+				Status: PaymentStatusSuccess, GrossAmount: "50000.00"},
+			wantPaymentID: second.ID,
+		},
+		{
+			name: "late expire for first attempt",
+			notification: WebhookNotification{OrderRef: "ORDER-1-aaaa1111", TransactionID: "trx-first",
+				Status: PaymentStatusExpired, GrossAmount: "50000.00"},
+			wantPaymentID: first.ID,
+		},
+		{
+			name: "legacy payment matched by transaction id",
+			notification: WebhookNotification{OrderRef: "ORDER-1-legacy99", TransactionID: "trx-legacy",
+				Status: PaymentStatusFailed, GrossAmount: "50000.00"},
+			wantPaymentID: legacy.ID,
+		},
+		{
+			name: "order id alone is not a provider reference",
+			notification: WebhookNotification{OrderRef: "1", Status: PaymentStatusSuccess,
+				GrossAmount: "50000.00"},
+			wantErr: ErrPaymentNotFound,
+		},
+		{
+			name: "amount mismatch",
+			notification: WebhookNotification{OrderRef: "ORDER-1-bbbb2222", Status: PaymentStatusSuccess,
+				GrossAmount: "10.00"},
+			wantErr: ErrPaymentAmountMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payment, err := svc.ApplyProviderNotification(&tt.notification)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ApplyProviderNotification() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyProviderNotification() error = %v", err)
+			}
+			if payment.ID != tt.wantPaymentID {
+				t.Errorf("ApplyProviderNotification() payment = %d, want %d", payment.ID, tt.wantPaymentID)
+			}
+		})
+	}
+
+	// Setiap percobaan hanya menerima status dari notifikasinya sendiri
+	want := map[uint]string{
+		first.ID:  PaymentStatusExpired,
+		second.ID: PaymentStatusSuccess,
+		legacy.ID: PaymentStatusFailed,
+	}
+	for id, status := range want {
+		var p models.Payment
+		db.First(&p, id)
+		if p.Status != status {
+			t.Errorf("payment %d status = %s, want %s", id, p.Status, status)
+		}
+	}
+
+	var reloaded models.Order
+	db.First(&reloaded, order.ID)
+	if reloaded.Status != OrderStatusPaid {
+		t.Errorf("order status = %s, want %s", reloaded.Status, OrderStatusPaid)
+	}
+}
+
+func TestPayment_ProviderReferenceIsUnique(t *testing.T) {
+	db := newPaymentTestDB(t)
+
+	p1 := models.Payment{OrderID: 1, Amount: utils.Rupiah(1000), PaymentMethod: "qris", ProviderReference: strPtr("ORDER-1-dup")}
+	if err := db.Create(&p1).Error; err != nil {
+		t.Fatalf("failed to create payment: %v", err)
+	}
+
+	p2 := models.Payment{OrderID: 1, Amount: utils.Rupiah(1000), PaymentMethod: "qris", ProviderReference: strPtr("ORDER-1-dup")}
+	if err := db.Create(&p2).Error; err == nil {
+		t.Errorf("expected unique index violation for duplicate provider_reference")
+	}
+
+	// Payment tunai tanpa provider reference boleh lebih dari satu
+	for i := 0; i < 2; i++ {
+		cash := models.Payment{OrderID: 1, Amount: utils.Rupiah(1000), PaymentMethod: "cash"}
+		if err := db.Create(&cash).Error; err != nil {
+			t.Fatalf("failed to create cash payment: %v", err)
+		}
+	}
+}
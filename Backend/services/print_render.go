@@ -0,0 +1,180 @@
+package services
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg" // decoder logo JPG
+	_ "image/png"  // decoder logo PNG
+	"log"
+	"os"
+	"strings"
+
+	"github.com/yeremiapane/restaurant-app/escpos"
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+// RenderReceiptESCPOS merender snapshot struk menjadi byte stream ESC/POS.
+// columns adalah lebar kertas dalam karakter (escpos.Columns80mm / escpos.Columns58mm).
+func RenderReceiptESCPOS(receipt *models.Receipt, columns int, branding ReceiptBranding, openDrawer bool) []byte {
+	p := escpos.NewBuilder(columns)
+
+	p.Align(escpos.AlignCenter)
+	if logo := loadPrintLogo(branding.LogoPath); logo != nil {
+		p.Image(logo, printDots(columns)/2).Feed(1)
+	}
+	p.Bold(true).Size(2, 2).Line(branding.Name).Size(1, 1).Bold(false)
+	for _, line := range escpos.Wrap(branding.Address, p.Columns()) {
+		p.Line(line)
+	}
+	if branding.Phone != "" {
+		p.Line(branding.Phone)
+	}
+	if branding.TaxID != "" {
+		p.Line("NPWP: " + branding.TaxID)
+	}
+
+	p.Align(escpos.AlignLeft).Separator('-')
+	p.Row("No", receipt.ReceiptNumber)
+	p.Row("Tanggal", branding.FormatTime(receipt.CreatedAt))
+	p.Row("Meja", receipt.TableNumber)
+	if receipt.CashierName != "" {
+		p.Row("Kasir", receipt.CashierName)
+	}
+	p.Separator('-')
+
+	for _, item := range receipt.ReceiptItems {
+		for _, line := range escpos.Wrap(item.DisplayName(), p.Columns()) {
+			p.Line(line)
+		}
+		p.Row(fmt.Sprintf("  %d x %s", item.Quantity, utils.FormatCurrencyIDR(item.UnitPrice)),
+			utils.FormatCurrencyIDR(item.UnitPrice.Mul(item.Quantity)))
+		for _, addon := range item.AddOnItems {
+			p.Row(fmt.Sprintf("  + %s x%d", addon.Name, addon.Quantity), utils.FormatCurrencyIDR(addon.Price.Mul(addon.Quantity)))
+		}
+		if item.Notes != "" {
+			for _, line := range escpos.Wrap("Catatan: "+item.Notes, p.Columns()-2) {
+				p.Line("  " + line)
+			}
+		}
+	}
+	p.Separator('-')
+
+	p.Row("Subtotal", utils.FormatCurrencyIDR(receipt.Subtotal))
+	p.Row("Service Charge", utils.FormatCurrencyIDR(receipt.ServiceCharge))
+	p.Row("Pajak", utils.FormatCurrencyIDR(receipt.Tax))
+	p.Row("Total", utils.FormatCurrencyIDR(receipt.Total))
+	if rounding := receipt.RoundedTotal - receipt.Total; rounding != 0 {
+		p.Row("Pembulatan", utils.FormatCurrencyIDR(rounding))
+	}
+	p.Bold(true).Row("TOTAL BAYAR", utils.FormatCurrencyIDR(receipt.RoundedTotal)).Bold(false)
+	if receipt.Tip > 0 {
+		p.Row("Tip", utils.FormatCurrencyIDR(receipt.Tip))
+	}
+	p.Separator('-')
+
+	if len(receipt.Tenders) == 0 {
+		p.Row(strings.ToUpper(receipt.PaymentMethod), utils.FormatCurrencyIDR(receipt.AmountPaid))
+		p.Row("Kembalian", utils.FormatCurrencyIDR(receipt.Change))
+	}
+	for _, tender := range receipt.Tenders {
+		p.Row(strings.ToUpper(tender.Method), utils.FormatCurrencyIDR(tender.Amount+tender.Tip))
+		if tender.Tendered > 0 {
+			p.Row("  Diterima", utils.FormatCurrencyIDR(tender.Tendered))
+			p.Row("  Kembalian", utils.FormatCurrencyIDR(tender.Change))
+		}
+	}
+
+	p.Feed(1).Align(escpos.AlignCenter)
+	p.QRCode(branding.VerificationURL(receipt), 5).Feed(1)
+	for _, line := range escpos.Wrap(branding.Footer, p.Columns()) {
+		p.Line(line)
+	}
+	p.Feed(3).Cut(true)
+	if openDrawer {
+		p.KickDrawer()
+	}
+	return p.Bytes()
+}
+
+// RenderKitchenTicket merender order menjadi tiket dapur ESC/POS: tanpa harga,
+// jumlah dan nama menu dicetak besar, add-on dan catatan di bawah item induknya
+func RenderKitchenTicket(order *models.Order, columns int) []byte {
+	p := escpos.NewBuilder(columns)
+
+	p.Align(escpos.AlignCenter).Bold(true).Size(2, 2)
+	if order.OrderNumber != "" {
+		p.Line("ORDER #" + order.OrderNumber)
+	} else {
+		p.Line(fmt.Sprintf("ORDER #%d", order.ID))
+	}
+	p.Size(1, 1).Bold(false)
+	if order.TableID > 0 && order.Table.TableNumber != "" {
+		p.Size(2, 1).Line("MEJA "+order.Table.TableNumber).Size(1, 1)
+	}
+	profile := CurrentRestaurantProfile()
+	p.Line(order.CreatedAt.In(profile.Location()).Format("02/01/2006 15:04"))
+	p.Align(escpos.AlignLeft).Separator('=')
+
+	addOns := make(map[uint][]models.OrderItem)
+	for _, item := range order.OrderItems {
+		if item.ParentItemID != nil {
+			addOns[*item.ParentItemID] = append(addOns[*item.ParentItemID], item)
+		}
+	}
+
+	// Dengan huruf lebar ganda satu baris hanya memuat setengah kolom
+	wide := p.Columns() / 2
+	for _, item := range order.OrderItems {
+		if item.ParentItemID != nil {
+			continue
+		}
+		p.Bold(true).Size(2, 2)
+		for _, line := range escpos.Wrap(fmt.Sprintf("%dx %s", item.Quantity, item.DisplayName()), wide) {
+			p.Line(line)
+		}
+		p.Size(1, 1).Bold(false)
+		for _, addon := range addOns[item.ID] {
+			p.Line(fmt.Sprintf("   + %dx %s", addon.Quantity, addon.DisplayName()))
+		}
+		if item.Notes != "" {
+			p.Bold(true)
+			for _, line := range escpos.Wrap("** "+item.Notes, p.Columns()-3) {
+				p.Line("   " + line)
+			}
+			p.Bold(false)
+		}
+		p.Separator('-')
+	}
+
+	p.Feed(3).Cut(false)
+	return p.Bytes()
+}
+
+// printDots mengembalikan lebar raster printer dalam titik untuk lebar kolom tertentu
+func printDots(columns int) int {
+	if columns <= escpos.Columns58mm {
+		return escpos.Dots58mm
+	}
+	return escpos.Dots80mm
+}
+
+// loadPrintLogo membaca logo restoran; logo yang tidak bisa dibaca dilewati
+func loadPrintLogo(path string) image.Image {
+	if path == "" {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		log.Printf("Error opening print logo %s: %v", path, err)
+		return nil
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		log.Printf("Error decoding print logo %s: %v", path, err)
+		return nil
+	}
+	return img
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/escpos"
+	"github.com/yeremiapane/restaurant-app/models"
+	"gorm.io/gorm"
+)
+
+// Pengaturan antrean cetak
+const (
+	defaultPrinterPort  = "9100"
+	maxPrintAttempts    = 5
+	printDialTimeout    = 5 * time.Second
+	printWriteTimeout   = 15 * time.Second
+	printPollInterval   = 5 * time.Second
+	printRetryBaseDelay = 5 * time.Second
+	printRetryMaxDelay  = time.Minute
+)
+
+// Error print job
+var (
+	ErrNoPrinterConfigured = errors.New("no printer configured")
+	ErrPrinterNotAllowed   = errors.New("printer is not configured on this server")
+	ErrPrintJobNotFailed   = errors.New("only failed print jobs can be retried")
+)
+
+// PrintService membuat print job ESC/POS untuk struk dan tiket dapur
+type PrintService struct {
+	db *gorm.DB
+}
+
+// NewPrintService membuat instance baru PrintService
+func NewPrintService(db *gorm.DB) *PrintService {
+	return &PrintService{db: db}
+}
+
+// PrintColumns mengembalikan lebar kertas printer dari env PRINTER_PAPER_WIDTH (80 atau 58, default 80)
+func PrintColumns() int {
+	if strings.TrimSpace(os.Getenv("PRINTER_PAPER_WIDTH")) == "58" {
+		return escpos.Columns58mm
+	}
+	return escpos.Columns80mm
+}
+
+// PrintReceipt mengantrekan struk ke printer kasir (printer pertama di RECEIPT_PRINTER jika printer kosong).
+// openDrawer membuka laci uang setelah struk terpotong.
+func (s *PrintService) PrintReceipt(receiptID uint, printer string, openDrawer bool, requestedBy *uint) (*models.PrintJob, error) {
+	addr, err := printerAddress(printer, "RECEIPT_PRINTER")
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := NewReceiptService(s.db).GetReceipt(receiptID)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := RenderReceiptESCPOS(receipt, PrintColumns(), CurrentReceiptBranding(), openDrawer)
+	return s.enqueue(models.PrintJobKindReceipt, receipt.ID, addr, payload, requestedBy)
+}
+
+// PrintKitchenTicket mengantrekan tiket dapur order ke printer dapur (printer pertama di KITCHEN_PRINTER jika printer kosong)
+func (s *PrintService) PrintKitchenTicket(orderID uint, printer string, requestedBy *uint) (*models.PrintJob, error) {
+	addr, err := printerAddress(printer, "KITCHEN_PRINTER")
+	if err != nil {
+		return nil, err
+	}
+	var order models.Order
+	if err := s.db.Preload("OrderItems", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("OrderItems.Menu").Preload("Table").
+		First(&order, orderID).Error; err != nil {
+		return nil, err
+	}
+
+	payload := RenderKitchenTicket(&order, PrintColumns())
+	return s.enqueue(models.PrintJobKindKitchenTicket, order.ID, addr, payload, requestedBy)
+}
+
+// ListJobs mengembalikan print job terbaru, opsional difilter per status
+func (s *PrintService) ListJobs(status string, limit int) ([]models.PrintJob, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	query := s.db.Order("id DESC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var jobs []models.PrintJob
+	err := query.Find(&jobs).Error
+	return jobs, err
+}
+
+// RetryJob mengantrekan ulang print job yang gagal
+func (s *PrintService) RetryJob(jobID uint) (*models.PrintJob, error) {
+	var job models.PrintJob
+	if err := s.db.First(&job, jobID).Error; err != nil {
+		return nil, err
+	}
+	if job.Status != models.PrintJobStatusFailed {
+		return nil, ErrPrintJobNotFailed
+	}
+
+	job.Status = models.PrintJobStatusPending
+	job.Attempts = 0
+	job.LastError = ""
+	job.NextAttempt = nil
+	if err := s.db.Save(&job).Error; err != nil {
+		return nil, err
+	}
+	wakePrintQueue()
+	return &job, nil
+}
+
+func (s *PrintService) enqueue(kind string, referenceID uint, printer string, payload []byte, requestedBy *uint) (*models.PrintJob, error) {
+	job := &models.PrintJob{
+		Kind:        kind,
+		ReferenceID: referenceID,
+		Printer:     printer,
+		Payload:     payload,
+		Status:      models.PrintJobStatusPending,
+		RequestedBy: requestedBy,
+	}
+	if err := s.db.Create(job).Error; err != nil {
+		return nil, fmt.Errorf("failed to queue print job: %w", err)
+	}
+	wakePrintQueue()
+	return job, nil
+}
+
+// printerAddress memilih printer dari daftar di env (dipisah koma, entri pertama
+// adalah default). Printer dari request hanya diterima jika ada di daftar itu,
+// sehingga server tidak membuka koneksi ke alamat sembarang.
+func printerAddress(printer, envKey string) (string, error) {
+	var allowed []string
+	for _, entry := range strings.Split(os.Getenv(envKey), ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			allowed = append(allowed, withPrinterPort(entry))
+		}
+	}
+	if len(allowed) == 0 {
+		return "", fmt.Errorf("%w: set %s", ErrNoPrinterConfigured, envKey)
+	}
+
+	printer = strings.TrimSpace(printer)
+	if printer == "" {
+		return allowed[0], nil
+	}
+	printer = withPrinterPort(printer)
+	for _, addr := range allowed {
+		if strings.EqualFold(addr, printer) {
+			return addr, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s is not listed in %s", ErrPrinterNotAllowed, printer, envKey)
+}
+
+// withPrinterPort menambahkan port 9100 jika alamat printer tanpa port
+func withPrinterPort(printer string) string {
+	if _, _, err := net.SplitHostPort(printer); err != nil {
+		return net.JoinHostPort(printer, defaultPrinterPort)
+	}
+	return printer
+}
+
+// PrintQueue mengirim print job ke printer jaringan (raw TCP, port 9100) satu per satu.
+// Job tersimpan di database sehingga tidak hilang saat server restart; job yang gagal
+// dikirim dicoba ulang dengan jeda yang makin lama sampai maxPrintAttempts.
+type PrintQueue struct {
+	db   *gorm.DB
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+var (
+	printQueueMu sync.RWMutex
+	printQueue   *PrintQueue
+)
+
+// NewPrintQueue membuat instance baru PrintQueue
+func NewPrintQueue(db *gorm.DB) *PrintQueue {
+	return &PrintQueue{
+		db:   db,
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Start mengembalikan job yang terhenti di tengah pengiriman ke antrean, lalu menjalankan worker
+func (q *PrintQueue) Start() {
+	if err := q.db.Model(&models.PrintJob{}).Where("status = ?", models.PrintJobStatusPrinting).
+		Update("status", models.PrintJobStatusPending).Error; err != nil {
+		log.Printf("Error requeueing interrupted print jobs: %v", err)
+	}
+
+	printQueueMu.Lock()
+	printQueue = q
+	printQueueMu.Unlock()
+
+	go q.run()
+	log.Println("Print queue started")
+}
+
+// Stop menghentikan worker dan menunggu job yang sedang dikirim selesai
+func (q *PrintQueue) Stop() {
+	printQueueMu.Lock()
+	if printQueue == q {
+		printQueue = nil
+	}
+	printQueueMu.Unlock()
+	close(q.stop)
+	<-q.done
+}
+
+// wakePrintQueue membangunkan worker yang aktif agar job baru langsung dikirim
+func wakePrintQueue() {
+	printQueueMu.RLock()
+	q := printQueue
+	printQueueMu.RUnlock()
+	if q == nil {
+		return
+	}
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *PrintQueue) run() {
+	defer close(q.done)
+	ticker := time.NewTicker(printPollInterval)
+	defer ticker.Stop()
+
+	for {
+		q.processDue()
+		select {
+		case <-q.wake:
+		case <-ticker.C:
+		case <-q.stop:
+			return
+		}
+	}
+}
+
+// processDue mengirim semua job pending yang sudah waktunya, urut sesuai antrean
+func (q *PrintQueue) processDue() {
+	for {
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+
+		var job models.PrintJob
+		err := q.db.Where("status = ? AND (next_attempt IS NULL OR next_attempt <= ?)", models.PrintJobStatusPending, time.Now()).
+			Order("id ASC").First(&job).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return
+		}
+		if err != nil {
+			log.Printf("Error loading print jobs: %v", err)
+			return
+		}
+		q.process(&job)
+	}
+}
+
+func (q *PrintQueue) process(job *models.PrintJob) {
+	job.Status = models.PrintJobStatusPrinting
+	job.Attempts++
+	if err := q.db.Save(job).Error; err != nil {
+		log.Printf("Error updating print job %d: %v", job.ID, err)
+		return
+	}
+
+	err := sendToPrinter(job.Printer, job.Payload)
+	now := time.Now()
+	switch {
+	case err == nil:
+		job.Status = models.PrintJobStatusPrinted
+		job.LastError = ""
+		job.NextAttempt = nil
+		job.PrintedAt = &now
+	case job.Attempts >= maxPrintAttempts:
+		job.Status = models.PrintJobStatusFailed
+		job.LastError = err.Error()
+		job.NextAttempt = nil
+		log.Printf("Print job %d to %s failed after %d attempts: %v", job.ID, job.Printer, job.Attempts, err)
+	default:
+		next := now.Add(printRetryDelay(job.Attempts))
+		job.Status = models.PrintJobStatusPending
+		job.LastError = err.Error()
+		job.NextAttempt = &next
+	}
+
+	if err := q.db.Save(job).Error; err != nil {
+		log.Printf("Error updating print job %d: %v", job.ID, err)
+	}
+}
+
+// printRetryDelay menghitung jeda retry: 5 detik, lalu dua kali lipat, maksimal 1 menit
+func printRetryDelay(attempts int) time.Duration {
+	delay := printRetryBaseDelay << uint(attempts-1)
+	if delay > printRetryMaxDelay || delay <= 0 {
+		return printRetryMaxDelay
+	}
+	return delay
+}
+
+// sendToPrinter mengirim byte stream ESC/POS ke printer lewat raw TCP
+func sendToPrinter(addr string, payload []byte) error {
+	conn, err := net.DialTimeout("tcp", addr, printDialTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to printer %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetWriteDeadline(time.Now().Add(printWriteTimeout)); err != nil {
+		return err
+	}
+	if _, err := conn.Write(payload); err != nil {
+		return fmt.Errorf("failed to send to printer %s: %w", addr, err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"gorm.io/gorm"
+)
+
+func newPrintTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db := newPaymentTestDB(t)
+	if err := db.AutoMigrate(&models.PrintJob{}); err != nil {
+		t.Fatalf("failed to migrate print jobs: %v", err)
+	}
+	return db
+}
+
+func TestPrintQueue_DeliversJob(t *testing.T) {
+	db := newPrintTestDB(t)
+
+	// Printer palsu: menerima satu koneksi dan mengembalikan semua byte yang dikirim
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(conn)
+		received <- data
+	}()
+
+	payload := RenderKitchenTicket(&models.Order{ID: 7}, 32)
+	job, err := NewPrintService(db).enqueue(models.PrintJobKindKitchenTicket, 7, ln.Addr().String(), payload, nil)
+	if err != nil {
+		t.Fatalf("enqueue() error = %v", err)
+	}
+
+	q := NewPrintQueue(db)
+	q.Start()
+	defer q.Stop()
+
+	select {
+	case data := <-received:
+		if !bytes.Equal(data, payload) {
+			t.Errorf("printer received %d bytes, want %d", len(data), len(payload))
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("printer did not receive the job")
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		var stored models.PrintJob
+		db.First(&stored, job.ID)
+		if stored.Status == models.PrintJobStatusPrinted {
+			if stored.Attempts != 1 || stored.PrintedAt == nil {
+				t.Errorf("printed job attempts = %d, printed_at = %v", stored.Attempts, stored.PrintedAt)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job status = %q, want %q", stored.Status, models.PrintJobStatusPrinted)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPrintQueue_RetriesUnreachablePrinter(t *testing.T) {
+	db := newPrintTestDB(t)
+
+	// Port yang baru saja ditutup: koneksi pasti ditolak
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	job, err := NewPrintService(db).enqueue(models.PrintJobKindReceipt, 1, addr, []byte("struk"), nil)
+	if err != nil {
+		t.Fatalf("enqueue() error = %v", err)
+	}
+	q := NewPrintQueue(db)
+
+	tests := []struct {
+		attempts   int
+		wantStatus string
+		wantDelay  time.Duration
+	}{
+		{attempts: 1, wantStatus: models.PrintJobStatusPending, wantDelay: 5 * time.Second},
+		{attempts: 2, wantStatus: models.PrintJobStatusPending, wantDelay: 10 * time.Second},
+		{attempts: 3, wantStatus: models.PrintJobStatusPending, wantDelay: 20 * time.Second},
+		{attempts: 4, wantStatus: models.PrintJobStatusPending, wantDelay: 40 * time.Second},
+		{attempts: 5, wantStatus: models.PrintJobStatusFailed},
+	}
+	for _, tt := range tests {
+		start := time.Now()
+		q.process(job)
+		if job.Attempts != tt.attempts || job.Status != tt.wantStatus || job.LastError == "" {
+			t.Fatalf("after attempt %d: attempts = %d, status = %q, last_error = %q", tt.attempts, job.Attempts, job.Status, job.LastError)
+		}
+		if tt.wantDelay == 0 {
+			if job.NextAttempt != nil {
+				t.Errorf("failed job next_attempt = %v, want nil", job.NextAttempt)
+			}
+			continue
+		}
+		if delay := job.NextAttempt.Sub(start); delay < tt.wantDelay || delay > tt.wantDelay+time.Second {
+			t.Errorf("attempt %d retry delay = %v, want %v", tt.attempts, delay, tt.wantDelay)
+		}
+	}
+
+	retried, err := NewPrintService(db).RetryJob(job.ID)
+	if err != nil {
+		t.Fatalf("RetryJob() error = %v", err)
+	}
+	if retried.Status != models.PrintJobStatusPending || retried.Attempts != 0 {
+		t.Errorf("RetryJob() status = %q, attempts = %d", retried.Status, retried.Attempts)
+	}
+	if _, err := NewPrintService(db).RetryJob(job.ID); err != ErrPrintJobNotFailed {
+		t.Errorf("RetryJob(pending) error = %v, want ErrPrintJobNotFailed", err)
+	}
+}
+
+func TestPrinterAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		printer string
+		want    string
+		wantErr error
+	}{
+		{name: "default printer", env: "192.168.1.50, 192.168.1.51:9101", want: "192.168.1.50:9100"},
+		{name: "listed printer", env: "192.168.1.50, 192.168.1.51:9101", printer: "192.168.1.51:9101", want: "192.168.1.51:9101"},
+		{name: "listed printer without port", env: "192.168.1.50,192.168.1.51", printer: "192.168.1.51", want: "192.168.1.51:9100"},
+		{name: "unlisted printer", env: "192.168.1.50", printer: "10.0.0.1:22", wantErr: ErrPrinterNotAllowed},
+		{name: "listed host on another port", env: "192.168.1.50", printer: "192.168.1.50:6379", wantErr: ErrPrinterNotAllowed},
+		{name: "no printer configured", printer: "192.168.1.50", wantErr: ErrNoPrinterConfigured},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RECEIPT_PRINTER", tt.env)
+			got, err := printerAddress(tt.printer, "RECEIPT_PRINTER")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("printerAddress() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("printerAddress() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
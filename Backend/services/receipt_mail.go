@@ -0,0 +1,87 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+// ErrNoRecipient dikembalikan jika struk dikirim tanpa alamat dan customer tidak mengisi email
+var ErrNoRecipient = errors.New("no email recipient")
+
+// EmailReceipt mengirim struk sebagai lampiran PDF (layout A5) beserta ringkasannya.
+// Jika to kosong, struk dikirim ke email yang diisi customer order tersebut.
+// Mengembalikan alamat tujuan.
+func (s *ReceiptService) EmailReceipt(receiptID uint, to string) (string, error) {
+	receipt, err := s.GetReceipt(receiptID)
+	if err != nil {
+		return "", err
+	}
+
+	to = strings.TrimSpace(to)
+	if to == "" {
+		var order models.Order
+		if err := s.db.Preload("Customer").First(&order, receipt.OrderID).Error; err != nil {
+			return "", fmt.Errorf("failed to load receipt order: %w", err)
+		}
+		to = order.GetCustomerEmail()
+	}
+	if to == "" {
+		return "", ErrNoRecipient
+	}
+
+	branding := CurrentReceiptBranding()
+	var pdf bytes.Buffer
+	if err := RenderReceiptPDF(&pdf, receipt, ReceiptLayoutA5, branding); err != nil {
+		return "", fmt.Errorf("failed to render receipt PDF: %w", err)
+	}
+
+	msg := receiptMail(receipt, branding)
+	msg.To = []string{to}
+	msg.Attachments = []MailAttachment{{
+		Filename:    strings.ReplaceAll(receipt.ReceiptNumber, "/", "-") + ".pdf",
+		ContentType: "application/pdf",
+		Data:        pdf.Bytes(),
+	}}
+	if err := GetMailer().Send(msg); err != nil {
+		return "", fmt.Errorf("failed to send receipt %s: %w", receipt.ReceiptNumber, err)
+	}
+	return to, nil
+}
+
+// receiptMail menyusun subjek dan isi email struk (teks dan HTML)
+func receiptMail(receipt *models.Receipt, branding ReceiptBranding) Mail {
+	lines := [][2]string{
+		{"No. Struk", receipt.ReceiptNumber},
+		{"Tanggal", branding.FormatTime(receipt.CreatedAt)},
+		{"Meja", receipt.TableNumber},
+	}
+	for _, item := range receipt.ReceiptItems {
+		lines = append(lines, [2]string{fmt.Sprintf("%dx %s", item.Quantity, item.DisplayName()), utils.FormatCurrencyIDR(item.Subtotal)})
+	}
+	lines = append(lines, [2]string{"Total", utils.FormatCurrencyIDR(receipt.RoundedTotal)})
+	if receipt.Tip > 0 {
+		lines = append(lines, [2]string{"Tip", utils.FormatCurrencyIDR(receipt.Tip)})
+	}
+	lines = append(lines, [2]string{"Cek keaslian", branding.VerificationURL(receipt)})
+
+	var text, rows strings.Builder
+	fmt.Fprintf(&text, "%s\n\n", branding.Name)
+	for _, line := range lines {
+		fmt.Fprintf(&text, "%s: %s\n", line[0], line[1])
+		fmt.Fprintf(&rows, "<tr><td>%s</td><td align=\"right\">%s</td></tr>", html.EscapeString(line[0]), html.EscapeString(line[1]))
+	}
+	fmt.Fprintf(&text, "\n%s\n", branding.Footer)
+
+	return Mail{
+		Subject: fmt.Sprintf("Struk %s - %s", receipt.ReceiptNumber, branding.Name),
+		Text:    text.String(),
+		HTML: fmt.Sprintf("<h2>%s</h2><table cellpadding=\"4\">%s</table><p>%s</p>",
+			html.EscapeString(branding.Name), rows.String(), html.EscapeString(branding.Footer)),
+	}
+}
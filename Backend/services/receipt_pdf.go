@@ -0,0 +1,359 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+// Layout struk PDF
+const (
+	ReceiptLayout80mm = "80mm" // Kertas roll thermal 80mm
+	ReceiptLayout58mm = "58mm" // Kertas roll thermal 58mm
+	ReceiptLayoutA5   = "a5"   // Invoice A5
+)
+
+// ErrInvalidReceiptLayout dikembalikan untuk layout PDF yang tidak dikenal
+var ErrInvalidReceiptLayout = errors.New("invalid receipt layout, use 80mm, 58mm or a5")
+
+// ReceiptBranding adalah identitas restoran yang dicetak di kepala dan kaki struk,
+// disusun dari profil restoran (lihat ReceiptBrandingFromProfile)
+type ReceiptBranding struct {
+	Name      string
+	Address   string
+	Phone     string
+	TaxID     string // NPWP
+	LogoPath  string // File PNG/JPG, opsional
+	Footer    string
+	Location  *time.Location // Zona waktu tanggal di struk, nil = waktu lokal server
+	VerifyURL string         // Halaman verifikasi struk yang dicetak sebagai QR
+}
+
+// VerificationURL mengembalikan link verifikasi struk yang dicetak sebagai QR. Link memuat
+// nomor, total, waktu terbit dan tanda tangan struk (lihat SignReceipt).
+func (b ReceiptBranding) VerificationURL(receipt *models.Receipt) string {
+	signature := receipt.Signature
+	if signature == "" {
+		// Struk lama yang dibuat sebelum ada tanda tangan
+		signature = SignReceipt(receipt)
+	}
+	query := url.Values{}
+	query.Set("number", receipt.ReceiptNumber)
+	query.Set("total", strconv.FormatInt(receipt.RoundedTotal.Sen(), 10))
+	query.Set("ts", strconv.FormatInt(receipt.CreatedAt.Unix(), 10))
+	query.Set("sig", signature)
+	return b.VerifyURL + "?" + query.Encode()
+}
+
+// FormatTime memformat waktu di struk dalam zona waktu restoran
+func (b ReceiptBranding) FormatTime(t time.Time) string {
+	if b.Location != nil {
+		t = t.In(b.Location)
+	}
+	return t.Format("02/01/2006 15:04")
+}
+
+// receiptPDFLayout adalah ukuran kertas dan huruf per layout (dalam mm / pt)
+type receiptPDFLayout struct {
+	width      float64
+	margin     float64
+	fontSize   float64
+	lineHeight float64
+	qrSize     float64
+	roll       bool // Tinggi halaman mengikuti isi struk
+}
+
+var receiptPDFLayouts = map[string]receiptPDFLayout{
+	ReceiptLayout80mm: {width: 80, margin: 4, fontSize: 8, lineHeight: 4, qrSize: 28, roll: true},
+	ReceiptLayout58mm: {width: 58, margin: 2.5, fontSize: 7, lineHeight: 3.4, qrSize: 24, roll: true},
+	ReceiptLayoutA5:   {width: 148.5, margin: 12, fontSize: 10, lineHeight: 5.5, qrSize: 30},
+}
+
+// RenderReceiptPDF merender snapshot struk ke PDF
+func RenderReceiptPDF(w io.Writer, receipt *models.Receipt, layout string, branding ReceiptBranding) error {
+	spec, ok := receiptPDFLayouts[layout]
+	if !ok {
+		return ErrInvalidReceiptLayout
+	}
+
+	// URL verifikasi yang terlalu panjang untuk QR (mis. RECEIPT_VERIFY_URL panjang)
+	// tidak menggagalkan struk: PDF dicetak tanpa QR
+	qr, err := utils.EncodeQR(branding.VerificationURL(receipt))
+	if errors.Is(err, utils.ErrQRDataTooLong) {
+		log.Printf("Receipt %s rendered without QR code: %v", receipt.ReceiptNumber, err)
+		qr = nil
+	} else if err != nil {
+		return fmt.Errorf("failed to encode receipt QR code: %w", err)
+	}
+
+	var height float64
+	if spec.roll {
+		// Render sekali di halaman panjang untuk mengukur tinggi, lalu render ulang seukuran isi
+		end, err := newReceiptPDF(spec, 1000, receipt, branding, qr).render()
+		if err != nil {
+			return err
+		}
+		height = end + spec.margin
+	}
+
+	r := newReceiptPDF(spec, height, receipt, branding, qr)
+	if _, err := r.render(); err != nil {
+		return err
+	}
+	return r.pdf.Output(w)
+}
+
+type receiptPDF struct {
+	pdf      *fpdf.Fpdf
+	spec     receiptPDFLayout
+	receipt  *models.Receipt
+	branding ReceiptBranding
+	qr       *utils.QRCode
+	tr       func(string) string
+	content  float64 // Lebar area cetak
+}
+
+func newReceiptPDF(spec receiptPDFLayout, height float64, receipt *models.Receipt, branding ReceiptBranding, qr *utils.QRCode) *receiptPDF {
+	var pdf *fpdf.Fpdf
+	if spec.roll {
+		pdf = fpdf.NewCustom(&fpdf.InitType{OrientationStr: "P", UnitStr: "mm", Size: fpdf.SizeType{Wd: spec.width, Ht: height}})
+		pdf.SetAutoPageBreak(false, 0)
+	} else {
+		pdf = fpdf.New("P", "mm", "A5", "")
+		pdf.SetAutoPageBreak(true, spec.margin)
+	}
+	pdf.SetMargins(spec.margin, spec.margin, spec.margin)
+	pdf.SetTitle(receipt.ReceiptNumber, true)
+	pdf.AddPage()
+
+	return &receiptPDF{
+		pdf:      pdf,
+		spec:     spec,
+		receipt:  receipt,
+		branding: branding,
+		qr:       qr,
+		tr:       pdf.UnicodeTranslatorFromDescriptor(""),
+		content:  spec.width - 2*spec.margin,
+	}
+}
+
+// render menulis seluruh isi struk dan mengembalikan posisi Y terakhir
+func (r *receiptPDF) render() (float64, error) {
+	receipt := r.receipt
+	r.header()
+
+	r.separator()
+	r.row("No", receipt.ReceiptNumber, false)
+	r.row("Tanggal", r.branding.FormatTime(receipt.CreatedAt), false)
+	r.row("Meja", receipt.TableNumber, false)
+	if receipt.CashierName != "" {
+		r.row("Kasir", receipt.CashierName, false)
+	}
+	r.separator()
+
+	r.items()
+	r.separator()
+
+	// Rincian pajak
+	r.row("Subtotal", utils.FormatCurrencyIDR(receipt.Subtotal), false)
+	r.row("Service Charge", utils.FormatCurrencyIDR(receipt.ServiceCharge), false)
+	r.row("Pajak", utils.FormatCurrencyIDR(receipt.Tax), false)
+	r.row("Total", utils.FormatCurrencyIDR(receipt.Total), false)
+	if rounding := receipt.RoundedTotal - receipt.Total; rounding != 0 {
+		r.row("Pembulatan", utils.FormatCurrencyIDR(rounding), false)
+	}
+	r.row("TOTAL BAYAR", utils.FormatCurrencyIDR(receipt.RoundedTotal), true)
+	if receipt.Tip > 0 {
+		r.row("Tip", utils.FormatCurrencyIDR(receipt.Tip), false)
+	}
+	r.separator()
+
+	r.payments()
+	r.separator()
+
+	r.qrCode()
+	if r.branding.Footer != "" {
+		r.center(r.branding.Footer, "", 0)
+	}
+	r.center("Struk ini adalah bukti pembayaran yang sah", "I", -1)
+
+	if err := r.pdf.Error(); err != nil {
+		return 0, fmt.Errorf("failed to render receipt PDF: %w", err)
+	}
+	return r.pdf.GetY(), nil
+}
+
+func (r *receiptPDF) header() {
+	pdf := r.pdf
+	if r.branding.LogoPath != "" {
+		if _, err := os.Stat(r.branding.LogoPath); err == nil {
+			logoWidth := r.content / 2
+			if logoWidth > 30 {
+				logoWidth = 30
+			}
+			x := r.spec.margin + (r.content-logoWidth)/2
+			pdf.ImageOptions(r.branding.LogoPath, x, pdf.GetY(), logoWidth, 0, true, fpdf.ImageOptions{ReadDpi: true}, 0, "")
+			pdf.Ln(1)
+		}
+	}
+
+	r.center(r.branding.Name, "B", 3)
+	if r.branding.Address != "" {
+		r.center(r.branding.Address, "", 0)
+	}
+	if r.branding.Phone != "" {
+		r.center(r.branding.Phone, "", 0)
+	}
+	if r.branding.TaxID != "" {
+		r.center("NPWP: "+r.branding.TaxID, "", 0)
+	}
+}
+
+func (r *receiptPDF) items() {
+	pdf := r.pdf
+	line := r.spec.lineHeight
+
+	if !r.spec.roll {
+		// Invoice A5: tabel kolom item, qty, harga, jumlah
+		nameWidth, qtyWidth, priceWidth := r.content*0.46, r.content*0.1, r.content*0.22
+		pdf.SetFont("Arial", "B", r.spec.fontSize)
+		pdf.CellFormat(nameWidth, line, "Item", "B", 0, "L", false, 0, "")
+		pdf.CellFormat(qtyWidth, line, "Qty", "B", 0, "C", false, 0, "")
+		pdf.CellFormat(priceWidth, line, "Harga", "B", 0, "R", false, 0, "")
+		pdf.CellFormat(r.content-nameWidth-qtyWidth-priceWidth, line, "Jumlah", "B", 1, "R", false, 0, "")
+
+		tableRow := func(name string, qty int, price utils.Money) {
+			pdf.SetFont("Arial", "", r.spec.fontSize)
+			pdf.CellFormat(nameWidth, line, r.tr(name), "", 0, "L", false, 0, "")
+			pdf.CellFormat(qtyWidth, line, fmt.Sprintf("%d", qty), "", 0, "C", false, 0, "")
+			pdf.CellFormat(priceWidth, line, utils.FormatCurrencyIDR(price), "", 0, "R", false, 0, "")
+			pdf.CellFormat(r.content-nameWidth-qtyWidth-priceWidth, line, utils.FormatCurrencyIDR(price.Mul(qty)), "", 1, "R", false, 0, "")
+		}
+		for _, item := range r.receipt.ReceiptItems {
+			tableRow(item.DisplayName(), item.Quantity, item.UnitPrice)
+			for _, addon := range item.AddOnItems {
+				tableRow("  + "+addon.Name, addon.Quantity, addon.Price)
+			}
+			if item.Notes != "" {
+				r.note(item.Notes)
+			}
+		}
+		return
+	}
+
+	for _, item := range r.receipt.ReceiptItems {
+		pdf.SetFont("Arial", "", r.spec.fontSize)
+		pdf.MultiCell(r.content, line, r.tr(item.DisplayName()), "", "L", false)
+		r.row(fmt.Sprintf("  %d x %s", item.Quantity, utils.FormatCurrencyIDR(item.UnitPrice)),
+			utils.FormatCurrencyIDR(item.UnitPrice.Mul(item.Quantity)), false)
+		for _, addon := range item.AddOnItems {
+			r.row(fmt.Sprintf("  + %s x%d", addon.Name, addon.Quantity), utils.FormatCurrencyIDR(addon.Price.Mul(addon.Quantity)), false)
+		}
+		if item.Notes != "" {
+			r.note(item.Notes)
+		}
+	}
+}
+
+func (r *receiptPDF) payments() {
+	receipt := r.receipt
+	if len(receipt.Tenders) == 0 {
+		r.row(strings.ToUpper(receipt.PaymentMethod), utils.FormatCurrencyIDR(receipt.AmountPaid), false)
+		r.row("Kembalian", utils.FormatCurrencyIDR(receipt.Change), false)
+		return
+	}
+
+	for _, tender := range receipt.Tenders {
+		r.row(strings.ToUpper(tender.Method), utils.FormatCurrencyIDR(tender.Amount+tender.Tip), false)
+		if tender.Tendered > 0 {
+			r.row("  Diterima", utils.FormatCurrencyIDR(tender.Tendered), false)
+			r.row("  Kembalian", utils.FormatCurrencyIDR(tender.Change), false)
+		}
+		if tender.Reference != "" {
+			r.row("  Ref", tender.Reference, false)
+		}
+	}
+}
+
+// qrCode menggambar QR link verifikasi di tengah, satu kotak per modul gelap.
+// Tanpa QR (URL terlalu panjang) tidak ada yang digambar.
+func (r *receiptPDF) qrCode() {
+	if r.qr == nil {
+		return
+	}
+	pdf := r.pdf
+	module := r.spec.qrSize / float64(r.qr.Size)
+	x0 := r.spec.margin + (r.content-r.spec.qrSize)/2
+	// Invoice: pindah halaman jika QR dan footer tidak muat
+	if _, pageHeight := pdf.GetPageSize(); !r.spec.roll && pdf.GetY()+r.spec.qrSize+4*r.spec.lineHeight > pageHeight-r.spec.margin {
+		pdf.AddPage()
+	}
+	y0 := pdf.GetY() + 1
+
+	pdf.SetFillColor(0, 0, 0)
+	for y := 0; y < r.qr.Size; y++ {
+		for x := 0; x < r.qr.Size; x++ {
+			if r.qr.Dark(x, y) {
+				pdf.Rect(x0+float64(x)*module, y0+float64(y)*module, module, module, "F")
+			}
+		}
+	}
+	pdf.SetY(y0 + r.spec.qrSize + 1)
+	r.center("Scan untuk verifikasi struk", "", -1)
+}
+
+// center menulis teks rata tengah; sizeDelta mengubah ukuran huruf relatif terhadap layout
+func (r *receiptPDF) center(text, style string, sizeDelta float64) {
+	if text == "" {
+		return
+	}
+	r.pdf.SetFont("Arial", style, r.spec.fontSize+sizeDelta)
+	r.pdf.MultiCell(r.content, r.spec.lineHeight+sizeDelta/2, r.tr(text), "", "C", false)
+}
+
+// row menulis label di kiri dan nilai rata kanan pada satu baris
+func (r *receiptPDF) row(label, value string, bold bool) {
+	style := ""
+	if bold {
+		style = "B"
+	}
+	pdf := r.pdf
+	pdf.SetFont("Arial", style, r.spec.fontSize)
+	valueWidth := pdf.GetStringWidth(value) + 1
+	pdf.CellFormat(r.content-valueWidth, r.spec.lineHeight, r.tr(label), "", 0, "L", false, 0, "")
+	pdf.CellFormat(valueWidth, r.spec.lineHeight, r.tr(value), "", 1, "R", false, 0, "")
+}
+
+func (r *receiptPDF) note(text string) {
+	r.pdf.SetFont("Arial", "I", r.spec.fontSize-1)
+	r.pdf.MultiCell(r.content, r.spec.lineHeight, r.tr("  Catatan: "+text), "", "L", false)
+}
+
+// separator menggambar garis putus-putus selebar area cetak
+func (r *receiptPDF) separator() {
+	pdf := r.pdf
+	y := pdf.GetY() + 1
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.SetLineWidth(0.2)
+	pdf.SetDashPattern([]float64{1, 1}, 0)
+	pdf.Line(r.spec.margin, y, r.spec.margin+r.content, y)
+	pdf.SetDashPattern([]float64{}, 0)
+	pdf.SetY(y + 1)
+}
+
+func envString(key, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
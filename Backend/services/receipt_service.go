@@ -0,0 +1,281 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Tarif yang dicetak di struk
+const (
+	ReceiptServiceChargePercent = 5  // Service charge 5%
+	ReceiptTaxPercent           = 10 // Pajak 10%
+)
+
+// ErrPaymentNotSettled dikembalikan jika struk diminta untuk payment yang belum sukses
+var ErrPaymentNotSettled = errors.New("payment belum selesai")
+
+// receiptRounding adalah pembulatan total struk (ke atas per Rp 1.000)
+var receiptRounding = utils.Rupiah(1000)
+
+// ReceiptService membuat snapshot struk dari order yang sudah dibayar
+type ReceiptService struct {
+	db *gorm.DB
+}
+
+// NewReceiptService membuat instance baru ReceiptService
+func NewReceiptService(db *gorm.DB) *ReceiptService {
+	return &ReceiptService{db: db}
+}
+
+// GenerateReceipt membuat struk untuk bill payment tersebut tepat satu kali. Item, add-on,
+// pajak dan tender disalin ke tabel struk dalam satu transaksi; pemanggilan berikutnya
+// (termasuk untuk bagian tender lain dari bill yang sama) mengembalikan struk yang sudah ada.
+// cashierID dipakai sebagai nama kasir jika payment tidak diverifikasi kasir (mis. QRIS).
+func (s *ReceiptService) GenerateReceipt(paymentID, cashierID uint) (*models.Receipt, bool, error) {
+	var receipt models.Receipt
+	created := false
+
+	// Baris nomor struk periode ini dibuat di luar transaksi agar tidak deadlock di MySQL
+	if err := ReceiptNumberScheme().EnsurePeriod(s.db, time.Now()); err != nil {
+		return nil, false, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var payment models.Payment
+		if err := tx.First(&payment, paymentID).Error; err != nil {
+			return err
+		}
+
+		// Kunci order agar dua permintaan bersamaan tidak membuat dua struk
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, payment.OrderID).Error; err != nil {
+			return err
+		}
+		if payment.Status != PaymentStatusSuccess {
+			return ErrPaymentNotSettled
+		}
+
+		tenders, err := GetTenderPayments(tx, &payment)
+		if err != nil {
+			return err
+		}
+		tenderIDs := make([]uint, len(tenders))
+		for i, tender := range tenders {
+			tenderIDs[i] = tender.ID
+		}
+
+		err = tx.Where("payment_id IN ?", tenderIDs).First(&receipt).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := tx.Preload("OrderItems", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+			Preload("OrderItems.Menu").Preload("Table").
+			First(&order, order.ID).Error; err != nil {
+			return err
+		}
+
+		receipt = buildReceipt(&order, tenders)
+		receipt.CashierName = s.cashierName(tx, tenders, cashierID)
+		receipt.ReceiptNumber, err = ReceiptNumberScheme().Next(tx, receipt.CreatedAt)
+		if err != nil {
+			return err
+		}
+		receipt.Signature = SignReceipt(&receipt)
+		if err := tx.Create(&receipt).Error; err != nil {
+			return fmt.Errorf("failed to save receipt: %w", err)
+		}
+		created = true
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	loaded, err := s.GetReceipt(receipt.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	return loaded, created, nil
+}
+
+// GetReceipt memuat snapshot struk lengkap dengan item, add-on dan tender
+func (s *ReceiptService) GetReceipt(receiptID uint) (*models.Receipt, error) {
+	var receipt models.Receipt
+	err := s.db.Preload("ReceiptItems", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("ReceiptItems.AddOnItems", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Tenders", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Tenders.Denominations").
+		First(&receipt, receiptID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+// DedupeReceipts menghapus struk ganda untuk payment yang sama (sisa data sebelum struk dibuat
+// sekali per bill), agar unique index receipts.payment_id bisa dibuat oleh AutoMigrate.
+// Struk paling lama (ID terkecil) dipertahankan beserta nomornya; item, add-on dan tender
+// milik struk ganda ikut dihapus. Mengembalikan jumlah struk yang dihapus.
+func DedupeReceipts(db *gorm.DB) (int, error) {
+	if !db.Migrator().HasTable(&models.Receipt{}) {
+		return 0, nil
+	}
+
+	var duplicates []uint
+	err := db.Model(&models.Receipt{}).
+		Where("id NOT IN (?)", db.Model(&models.Receipt{}).Select("MIN(id)").Group("payment_id")).
+		Pluck("id", &duplicates).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to find duplicate receipts: %w", err)
+	}
+	if len(duplicates) == 0 {
+		return 0, nil
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		itemIDs := tx.Model(&models.ReceiptItem{}).Select("id").Where("receipt_id IN ?", duplicates)
+		if err := tx.Where("receipt_item_id IN (?)", itemIDs).Delete(&models.ReceiptAddOn{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("receipt_id IN ?", duplicates).Delete(&models.ReceiptItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("receipt_id IN ?", duplicates).Delete(&models.ReceiptTender{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", duplicates).Delete(&models.Receipt{}).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete duplicate receipts: %w", err)
+	}
+	return len(duplicates), nil
+}
+
+// buildReceipt menyalin order yang sudah dihargai dan tender bill menjadi snapshot struk.
+// Order item dengan ParentItemID menjadi add-on dari item induknya.
+func buildReceipt(order *models.Order, tenders []models.Payment) models.Receipt {
+	now := time.Now()
+	primary := tenders[0]
+
+	receipt := models.Receipt{
+		OrderID:          order.ID,
+		PaymentID:        primary.ID,
+		TableNumber:      "N/A",
+		PaymentMethod:    primary.PaymentMethod,
+		PaymentStatus:    primary.Status,
+		PaymentReference: primary.ReferenceID,
+		PaymentTime:      primary.PaymentTime,
+		CreatedAt:        now,
+	}
+	if order.TableID > 0 && order.Table.TableNumber != "" {
+		receipt.TableNumber = order.Table.TableNumber
+	}
+
+	// Item utama lebih dulu, lalu add-on digabungkan ke item induknya
+	itemIndex := make(map[uint]int)
+	for _, item := range order.OrderItems {
+		if item.ParentItemID != nil {
+			continue
+		}
+		itemIndex[item.ID] = len(receipt.ReceiptItems)
+		receipt.ReceiptItems = append(receipt.ReceiptItems, receiptItem(item))
+	}
+	for _, item := range order.OrderItems {
+		if item.ParentItemID == nil {
+			continue
+		}
+		idx, ok := itemIndex[*item.ParentItemID]
+		if !ok {
+			// Induknya tidak ada di order ini: cetak sebagai item biasa agar harganya tetap tercatat
+			receipt.ReceiptItems = append(receipt.ReceiptItems, receiptItem(item))
+			continue
+		}
+		parent := &receipt.ReceiptItems[idx]
+		parent.AddOnItems = append(parent.AddOnItems, models.ReceiptAddOn{
+			MenuID:   item.MenuID,
+			Name:     item.DisplayName(),
+			Quantity: item.Quantity,
+			Price:    item.Price,
+		})
+		parent.Subtotal += item.Price.Mul(item.Quantity)
+	}
+
+	for _, item := range receipt.ReceiptItems {
+		receipt.Subtotal += item.Subtotal
+	}
+	receipt.ServiceCharge = receipt.Subtotal.Percent(ReceiptServiceChargePercent)
+	receipt.Tax = receipt.Subtotal.Percent(ReceiptTaxPercent)
+	receipt.Total = receipt.Subtotal + receipt.ServiceCharge + receipt.Tax
+	receipt.RoundedTotal = receipt.Total.RoundUpTo(receiptRounding)
+
+	// Kembalian diambil dari tender tunai yang tercatat, payment lama tanpa data tender
+	// tetap memakai selisih terhadap total
+	var cashTendered bool
+	for _, tender := range tenders {
+		receipt.AmountPaid += tender.Amount
+		receipt.Tip += tender.Tip
+		receipt.Change += tender.Change
+		cashTendered = cashTendered || tender.CashReceived > 0
+		if tender.PaymentMethod != primary.PaymentMethod {
+			receipt.PaymentMethod = "split"
+		}
+		receipt.Tenders = append(receipt.Tenders, models.ReceiptTender{
+			PaymentID: tender.ID,
+			Method:    tender.PaymentMethod,
+			Amount:    tender.Amount,
+			Tip:       tender.Tip,
+			Tendered:  tender.CashReceived,
+			Change:    tender.Change,
+			Reference: tender.ReferenceID,
+			CreatedAt: now,
+		})
+	}
+	if !cashTendered {
+		receipt.Change = receipt.AmountPaid - receipt.RoundedTotal
+	}
+	if receipt.Change < 0 {
+		receipt.Change = 0
+	}
+	return receipt
+}
+
+// receiptItem menyalin satu order item beserta harga saat dipesan
+func receiptItem(item models.OrderItem) models.ReceiptItem {
+	return models.ReceiptItem{
+		MenuID:      item.MenuID,
+		MenuName:    item.Menu.Name,
+		VariantName: item.VariantName,
+		Quantity:    item.Quantity,
+		UnitPrice:   item.Price,
+		Subtotal:    item.Price.Mul(item.Quantity),
+		Notes:       item.Notes,
+	}
+}
+
+// cashierName mengambil nama kasir yang memverifikasi tender, atau user yang membuat struk
+func (s *ReceiptService) cashierName(tx *gorm.DB, tenders []models.Payment, cashierID uint) string {
+	for _, tender := range tenders {
+		if tender.VerifiedBy != nil {
+			cashierID = *tender.VerifiedBy
+			break
+		}
+	}
+	if cashierID == 0 {
+		return ""
+	}
+	var user models.User
+	if err := tx.Select("id", "name").First(&user, cashierID).Error; err != nil {
+		return ""
+	}
+	return user.Name
+}
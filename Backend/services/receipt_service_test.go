@@ -0,0 +1,207 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+func TestReceiptService_GenerateReceiptSnapshot(t *testing.T) {
+	db, cashier := newShiftTestDB(t)
+	if err := db.AutoMigrate(&models.Table{}, &models.Menu{}, &models.OrderItem{}, &models.CashDenomination{}, &models.NumberSequence{}); err != nil {
+		t.Fatalf("failed to migrate order tables: %v", err)
+	}
+	// CreateTable, bukan AutoMigrate: AutoMigrate ikut memigrasi models.Payment (tag enum MySQL)
+	if err := db.Migrator().CreateTable(&models.Receipt{}, &models.ReceiptItem{}, &models.ReceiptAddOn{}, &models.ReceiptTender{}); err != nil {
+		t.Fatalf("failed to create receipt tables: %v", err)
+	}
+
+	table := models.Table{TableNumber: "A3"}
+	db.Create(&table)
+	nasi := models.Menu{Name: "Nasi Goreng", Price: utils.Rupiah(25000)}
+	telur := models.Menu{Name: "Telur Ceplok", Price: utils.Rupiah(5000)}
+	teh := models.Menu{Name: "Es Teh", Price: utils.Rupiah(10000)}
+	db.Create(&nasi)
+	db.Create(&telur)
+	db.Create(&teh)
+
+	order := models.Order{CustomerID: 1, TableID: table.ID, Status: OrderStatusPaid, TotalAmount: utils.Rupiah(81000)}
+	db.Create(&order)
+	nasiItem := models.OrderItem{OrderID: order.ID, MenuID: nasi.ID, Quantity: 2, Price: utils.Rupiah(25000), Notes: "pedas"}
+	db.Create(&nasiItem)
+	db.Create(&models.OrderItem{OrderID: order.ID, MenuID: telur.ID, Quantity: 2, Price: utils.Rupiah(5000), ParentItemID: &nasiItem.ID})
+	db.Create(&models.OrderItem{OrderID: order.ID, MenuID: teh.ID, Quantity: 1, Price: utils.Rupiah(10000)})
+
+	paidAt := time.Now()
+	payment := models.Payment{OrderID: order.ID, Amount: utils.Rupiah(81000), Status: PaymentStatusSuccess, PaymentMethod: "cash",
+		CashReceived: utils.Rupiah(100000), Change: utils.Rupiah(19000), PaymentTime: &paidAt, VerifiedBy: &cashier.ID}
+	db.Create(&payment)
+
+	service := NewReceiptService(db)
+	receipt, created, err := service.GenerateReceipt(payment.ID, 0)
+	if err != nil || !created {
+		t.Fatalf("GenerateReceipt() = %v, created %v", err, created)
+	}
+
+	// Subtotal 50.000 + add-on 10.000 + 10.000, service 5%, pajak 10%, dibulatkan ke atas per 1.000
+	want := map[string][2]utils.Money{
+		"subtotal":       {receipt.Subtotal, utils.Rupiah(70000)},
+		"service charge": {receipt.ServiceCharge, utils.Rupiah(3500)},
+		"tax":            {receipt.Tax, utils.Rupiah(7000)},
+		"total":          {receipt.Total, utils.Rupiah(80500)},
+		"rounded total":  {receipt.RoundedTotal, utils.Rupiah(81000)},
+		"change":         {receipt.Change, utils.Rupiah(19000)},
+	}
+	for name, got := range want {
+		if got[0] != got[1] {
+			t.Errorf("receipt %s = %s, want %s", name, got[0], got[1])
+		}
+	}
+	if receipt.TableNumber != "A3" || receipt.CashierName != cashier.Name || receipt.PaymentMethod != "cash" {
+		t.Errorf("receipt info = table %q, cashier %q, method %q", receipt.TableNumber, receipt.CashierName, receipt.PaymentMethod)
+	}
+	if want := "RCP/" + ReceiptNumberScheme().localTime(receipt.CreatedAt).Format("20060102") + "/000001"; receipt.ReceiptNumber != want {
+		t.Errorf("receipt number = %q, want %q", receipt.ReceiptNumber, want)
+	}
+	if len(receipt.ReceiptItems) != 2 || len(receipt.ReceiptItems[0].AddOnItems) != 1 {
+		t.Fatalf("receipt items = %+v, want 2 items with the add-on under the first", receipt.ReceiptItems)
+	}
+	if item := receipt.ReceiptItems[0]; item.MenuName != "Nasi Goreng" || item.Subtotal != utils.Rupiah(60000) ||
+		item.AddOnItems[0].Name != "Telur Ceplok" {
+		t.Errorf("first receipt item = %+v", item)
+	}
+	if len(receipt.Tenders) != 1 || receipt.Tenders[0].Tendered != utils.Rupiah(100000) {
+		t.Errorf("receipt tenders = %+v", receipt.Tenders)
+	}
+
+	// Data menu berubah setelah struk dibuat: cetak ulang tetap dari snapshot
+	db.Model(&nasi).Update("name", "Nasi Goreng Spesial")
+	db.Model(&nasiItem).Update("price", utils.Rupiah(30000))
+
+	again, created, err := service.GenerateReceipt(payment.ID, 0)
+	if err != nil || created || again.ID != receipt.ID {
+		t.Fatalf("second GenerateReceipt() = id %d, created %v, %v, want id %d", again.ID, created, err, receipt.ID)
+	}
+	reprint, err := service.GetReceipt(receipt.ID)
+	if err != nil {
+		t.Fatalf("GetReceipt() error = %v", err)
+	}
+	if reprint.ReceiptItems[0].MenuName != "Nasi Goreng" || reprint.Subtotal != utils.Rupiah(70000) {
+		t.Errorf("reprint = %q, subtotal %s, want the original snapshot", reprint.ReceiptItems[0].MenuName, reprint.Subtotal)
+	}
+
+	var count int64
+	db.Model(&models.Receipt{}).Count(&count)
+	if count != 1 {
+		t.Errorf("receipts = %d, want 1", count)
+	}
+
+	pending := models.Payment{OrderID: order.ID, Amount: utils.Rupiah(1000), Status: PaymentStatusPending, PaymentMethod: "qris"}
+	db.Create(&pending)
+	if _, _, err := service.GenerateReceipt(pending.ID, 0); !errors.Is(err, ErrPaymentNotSettled) {
+		t.Errorf("GenerateReceipt(pending) error = %v, want %v", err, ErrPaymentNotSettled)
+	}
+}
+
+func TestDedupeReceipts(t *testing.T) {
+	db := newPaymentTestDB(t)
+	if err := db.Migrator().CreateTable(&models.Receipt{}, &models.ReceiptItem{}, &models.ReceiptAddOn{}, &models.ReceiptTender{}); err != nil {
+		t.Fatalf("failed to create receipt tables: %v", err)
+	}
+	// Database lama belum punya unique index payment_id
+	if err := db.Migrator().DropIndex(&models.Receipt{}, "PaymentID"); err != nil {
+		t.Fatalf("failed to drop payment_id index: %v", err)
+	}
+
+	newReceipt := func(paymentID uint, number string) models.Receipt {
+		receipt := models.Receipt{PaymentID: paymentID, ReceiptNumber: number, PaymentMethod: "cash", PaymentStatus: "success",
+			ReceiptItems: []models.ReceiptItem{{MenuID: 1, MenuName: "Nasi Goreng", Quantity: 1,
+				AddOnItems: []models.ReceiptAddOn{{MenuID: 2, Name: "Telur", Quantity: 1}}}},
+			Tenders: []models.ReceiptTender{{PaymentID: paymentID, Method: "cash"}}}
+		if err := db.Create(&receipt).Error; err != nil {
+			t.Fatalf("failed to create receipt: %v", err)
+		}
+		return receipt
+	}
+	oldest := newReceipt(1, "RCP-001")
+	newReceipt(1, "RCP-002")
+	newReceipt(1, "RCP-003")
+	single := newReceipt(2, "RCP-004")
+
+	removed, err := DedupeReceipts(db)
+	if err != nil || removed != 2 {
+		t.Fatalf("DedupeReceipts() = %d, %v, want 2 removed", removed, err)
+	}
+
+	var numbers []string
+	db.Model(&models.Receipt{}).Order("id").Pluck("receipt_number", &numbers)
+	if len(numbers) != 2 || numbers[0] != oldest.ReceiptNumber || numbers[1] != single.ReceiptNumber {
+		t.Errorf("remaining receipts = %v, want [%s %s]", numbers, oldest.ReceiptNumber, single.ReceiptNumber)
+	}
+	counts := map[string]interface{}{"items": &models.ReceiptItem{}, "add-ons": &models.ReceiptAddOn{}, "tenders": &models.ReceiptTender{}}
+	for name, model := range counts {
+		var count int64
+		db.Model(model).Count(&count)
+		if count != 2 {
+			t.Errorf("%s left = %d, want 2 (only for the kept receipts)", name, count)
+		}
+	}
+
+	if err := db.Migrator().CreateIndex(&models.Receipt{}, "PaymentID"); err != nil {
+		t.Errorf("unique payment_id index after dedupe: %v", err)
+	}
+	if removed, err := DedupeReceipts(db); err != nil || removed != 0 {
+		t.Errorf("second DedupeReceipts() = %d, %v, want nothing to remove", removed, err)
+	}
+}
+
+func TestRenderReceiptPDF(t *testing.T) {
+	receipt := models.Receipt{
+		ReceiptNumber: "RCP/20261018/000042",
+		Subtotal:      utils.Rupiah(70000),
+		ServiceCharge: utils.Rupiah(3500),
+		Tax:           utils.Rupiah(7000),
+		Total:         utils.Rupiah(80500),
+		RoundedTotal:  utils.Rupiah(81000),
+		TableNumber:   "A3",
+		PaymentMethod: "cash",
+		ReceiptItems: []models.ReceiptItem{{MenuName: "Nasi Goreng", Quantity: 2, UnitPrice: utils.Rupiah(25000), Subtotal: utils.Rupiah(60000),
+			Notes: "pedas", AddOnItems: []models.ReceiptAddOn{{Name: "Telur Ceplok", Quantity: 2, Price: utils.Rupiah(5000)}}}},
+		Tenders: []models.ReceiptTender{{Method: "cash", Amount: utils.Rupiah(81000), Tendered: utils.Rupiah(100000), Change: utils.Rupiah(19000)}},
+	}
+
+	tests := []struct {
+		layout    string
+		wantWidth string // Lebar halaman dalam point
+	}{
+		{layout: ReceiptLayout80mm, wantWidth: "/MediaBox [0 0 226.77 "},
+		{layout: ReceiptLayout58mm, wantWidth: "/MediaBox [0 0 164.41 "},
+		{layout: ReceiptLayoutA5, wantWidth: "/MediaBox [0 0 420.94 595.28]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := RenderReceiptPDF(&buf, &receipt, tt.layout, ReceiptBranding{Name: "Warung Makan"}); err != nil {
+				t.Fatalf("RenderReceiptPDF() error = %v", err)
+			}
+			if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF")) || !bytes.Contains(buf.Bytes(), []byte(tt.wantWidth)) {
+				t.Errorf("RenderReceiptPDF() page size does not match %q", tt.wantWidth)
+			}
+		})
+	}
+
+	// URL verifikasi di luar kapasitas QR tetap menghasilkan PDF, tanpa QR
+	longURL := ReceiptBranding{VerifyURL: "https://resto.example.com/" + strings.Repeat("v", 2400)}
+	if err := RenderReceiptPDF(&bytes.Buffer{}, &receipt, ReceiptLayout80mm, longURL); err != nil {
+		t.Errorf("RenderReceiptPDF(long verify URL) error = %v", err)
+	}
+
+	if err := RenderReceiptPDF(&bytes.Buffer{}, &receipt, "a4", ReceiptBranding{}); !errors.Is(err, ErrInvalidReceiptLayout) {
+		t.Errorf("RenderReceiptPDF(a4) error = %v, want %v", err, ErrInvalidReceiptLayout)
+	}
+}
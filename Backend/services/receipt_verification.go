@@ -0,0 +1,144 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+// Hasil verifikasi struk
+const (
+	ReceiptVerificationValid            = "valid"             // Asli dan tidak diubah
+	ReceiptVerificationRefunded         = "refunded"          // Asli, tetapi sebagian/seluruh pembayarannya sudah direfund
+	ReceiptVerificationInvalidSignature = "invalid_signature" // Tanda tangan tidak cocok: struk palsu atau isinya diubah
+	ReceiptVerificationNotFound         = "not_found"         // Tanda tangan cocok tetapi struk tidak ada di database
+	ReceiptVerificationModified         = "modified"          // Data struk di database tidak cocok dengan yang dicetak
+)
+
+// receiptSignatureVersion ikut ditandatangani agar format bisa diganti tanpa bentrok dengan struk lama
+const receiptSignatureVersion = "v1"
+
+// receiptSignatureBytes adalah panjang HMAC-SHA256 yang dipakai (128 bit) agar QR tetap kecil
+const receiptSignatureBytes = 16
+
+var (
+	receiptSigningKey     []byte
+	receiptSigningKeyOnce sync.Once
+)
+
+// ReceiptVerificationQuery adalah isi QR verifikasi yang dipindai
+type ReceiptVerificationQuery struct {
+	Number    string
+	Total     utils.Money
+	IssuedAt  int64 // Unix detik
+	Signature string
+}
+
+// ReceiptVerification adalah hasil verifikasi yang ditampilkan ke publik
+type ReceiptVerification struct {
+	Status         string      `json:"status"`
+	Genuine        bool        `json:"genuine"`
+	Refunded       bool        `json:"refunded"`
+	ReceiptNumber  string      `json:"receipt_number"`
+	IssuedAt       *time.Time  `json:"issued_at,omitempty"`
+	Total          utils.Money `json:"total"`
+	RefundedAmount utils.Money `json:"refunded_amount,omitempty"`
+	RestaurantName string      `json:"restaurant_name"`
+}
+
+// SignReceipt menghitung tanda tangan struk: HMAC-SHA256 atas nomor, total setelah
+// pembulatan dan waktu terbit, dengan kunci RECEIPT_SIGNING_KEY
+func SignReceipt(receipt *models.Receipt) string {
+	return receiptSignature(receipt.ReceiptNumber, receipt.RoundedTotal, receipt.CreatedAt.Unix())
+}
+
+func receiptSignature(number string, total utils.Money, issuedAt int64) string {
+	mac := hmac.New(sha256.New, getReceiptSigningKey())
+	fmt.Fprintf(mac, "%s|%s|%d|%d", receiptSignatureVersion, number, total.Sen(), issuedAt)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:receiptSignatureBytes])
+}
+
+// getReceiptSigningKey membaca RECEIPT_SIGNING_KEY. Jika kosong, kunci diturunkan dari
+// JWT secret agar struk tetap bisa diverifikasi setelah restart.
+func getReceiptSigningKey() []byte {
+	receiptSigningKeyOnce.Do(func() {
+		if key := os.Getenv("RECEIPT_SIGNING_KEY"); key != "" {
+			receiptSigningKey = []byte(key)
+			return
+		}
+		log.Printf("WARNING: RECEIPT_SIGNING_KEY is not set, deriving receipt signing key from JWT secret")
+		mac := hmac.New(sha256.New, utils.JWTSecret)
+		mac.Write([]byte("receipt-signing-key"))
+		receiptSigningKey = mac.Sum(nil)
+	})
+	return receiptSigningKey
+}
+
+// VerifyReceipt memeriksa QR struk: tanda tangan harus cocok, struknya harus ada dan sama
+// dengan yang tersimpan. Struk asli yang pembayarannya sudah direfund berstatus refunded.
+func (s *ReceiptService) VerifyReceipt(query ReceiptVerificationQuery) (*ReceiptVerification, error) {
+	result := &ReceiptVerification{
+		ReceiptNumber:  query.Number,
+		Total:          query.Total,
+		RestaurantName: CurrentRestaurantProfile().Name,
+	}
+
+	// Tanda tangan dicek lebih dulu agar endpoint publik tidak bisa dipakai menebak nomor struk
+	expected := receiptSignature(query.Number, query.Total, query.IssuedAt)
+	if !hmac.Equal([]byte(expected), []byte(query.Signature)) {
+		result.Status = ReceiptVerificationInvalidSignature
+		return result, nil
+	}
+
+	// Nomor struk bisa sama di cabang berbeda, waktu terbit yang membedakan
+	var candidates []models.Receipt
+	if err := s.db.Preload("Tenders").Where("receipt_number = ?", query.Number).Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("failed to load receipt: %w", err)
+	}
+	var receipt *models.Receipt
+	for i := range candidates {
+		if candidates[i].CreatedAt.Unix() == query.IssuedAt {
+			receipt = &candidates[i]
+			break
+		}
+	}
+	if receipt == nil {
+		result.Status = ReceiptVerificationNotFound
+		return result, nil
+	}
+
+	issuedAt := receipt.CreatedAt
+	result.IssuedAt = &issuedAt
+	// Struk lama belum punya tanda tangan tersimpan, cukup dibandingkan isinya
+	if receipt.RoundedTotal != query.Total || (receipt.Signature != "" && receipt.Signature != SignReceipt(receipt)) {
+		result.Status = ReceiptVerificationModified
+		return result, nil
+	}
+	result.Genuine = true
+
+	paymentIDs := []uint{receipt.PaymentID}
+	for _, tender := range receipt.Tenders {
+		paymentIDs = append(paymentIDs, tender.PaymentID)
+	}
+	var refunded []models.Payment
+	if err := s.db.Where("id IN ? AND (status = ? OR refunded_amount > 0)", paymentIDs, PaymentStatusRefunded).Find(&refunded).Error; err != nil {
+		return nil, fmt.Errorf("failed to load receipt payments: %w", err)
+	}
+	for _, payment := range refunded {
+		result.RefundedAmount += payment.RefundedTotal()
+	}
+	result.Refunded = len(refunded) > 0
+	result.Status = ReceiptVerificationValid
+	if result.Refunded {
+		result.Status = ReceiptVerificationRefunded
+	}
+	return result, nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+func TestReceiptService_VerifyReceipt(t *testing.T) {
+	db := newPaymentTestDB(t)
+	// CreateTable, bukan AutoMigrate: AutoMigrate ikut memigrasi models.Payment (tag enum MySQL)
+	if err := db.Migrator().CreateTable(&models.Receipt{}, &models.ReceiptTender{}); err != nil {
+		t.Fatalf("failed to create receipt tables: %v", err)
+	}
+
+	paid := models.Payment{OrderID: 1, Amount: utils.Rupiah(81000), Status: PaymentStatusSuccess, PaymentMethod: "cash"}
+	refunded := models.Payment{OrderID: 2, Amount: utils.Rupiah(40000), Tip: utils.Rupiah(2000), Status: PaymentStatusRefunded, PaymentMethod: "qris"}
+	other := models.Payment{OrderID: 3, Amount: utils.Rupiah(50000), Status: PaymentStatusSuccess, PaymentMethod: "cash"}
+	// Refund sebagian: status tetap success
+	partial := models.Payment{OrderID: 4, Amount: utils.Rupiah(60000), Status: PaymentStatusSuccess, PaymentMethod: "qris",
+		RefundedAmount: utils.Rupiah(15000)}
+	for _, payment := range []*models.Payment{&paid, &refunded, &other, &partial} {
+		db.Create(payment)
+	}
+
+	issuedAt := time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC)
+	newReceipt := func(number string, payment models.Payment, total utils.Money) models.Receipt {
+		receipt := models.Receipt{OrderID: payment.OrderID, PaymentID: payment.ID, ReceiptNumber: number, Total: total,
+			RoundedTotal: total, PaymentMethod: payment.PaymentMethod, AmountPaid: total, PaymentStatus: PaymentStatusSuccess,
+			CreatedAt: issuedAt, Tenders: []models.ReceiptTender{{PaymentID: payment.ID, Method: payment.PaymentMethod, Amount: total}}}
+		receipt.Signature = SignReceipt(&receipt)
+		if err := db.Create(&receipt).Error; err != nil {
+			t.Fatalf("failed to create receipt: %v", err)
+		}
+		return receipt
+	}
+	genuine := newReceipt("RCP/20261018/000001", paid, utils.Rupiah(81000))
+	refundedReceipt := newReceipt("RCP/20261018/000002", refunded, utils.Rupiah(40000))
+	tampered := newReceipt("RCP/20261018/000003", other, utils.Rupiah(50000))
+	partialReceipt := newReceipt("RCP/20261018/000004", partial, utils.Rupiah(60000))
+	// Total di database diubah setelah struk dicetak
+	db.Model(&models.Receipt{}).Where("id = ?", tampered.ID).Update("rounded_total", utils.Rupiah(5000))
+
+	// queryFromURL membaca QR seperti yang dilakukan endpoint verifikasi
+	queryFromURL := func(receipt models.Receipt) ReceiptVerificationQuery {
+		parsed, err := url.Parse(ReceiptBranding{VerifyURL: "https://resto.test/receipts/verify"}.VerificationURL(&receipt))
+		if err != nil {
+			t.Fatalf("invalid verification URL: %v", err)
+		}
+		values := parsed.Query()
+		total, _ := strconv.ParseInt(values.Get("total"), 10, 64)
+		ts, _ := strconv.ParseInt(values.Get("ts"), 10, 64)
+		return ReceiptVerificationQuery{Number: values.Get("number"), Total: utils.Money(total), IssuedAt: ts, Signature: values.Get("sig")}
+	}
+
+	forgedTotal := queryFromURL(genuine)
+	forgedTotal.Total = utils.Rupiah(810000)
+	// Ditandatangani dengan kunci yang benar, tetapi tidak pernah tersimpan
+	missing := ReceiptVerificationQuery{Number: "RCP/20261018/000009", Total: utils.Rupiah(10000), IssuedAt: issuedAt.Unix(),
+		Signature: receiptSignature("RCP/20261018/000009", utils.Rupiah(10000), issuedAt.Unix())}
+
+	tests := []struct {
+		name         string
+		query        ReceiptVerificationQuery
+		wantStatus   string
+		wantGenuine  bool
+		wantRefunded utils.Money
+	}{
+		{name: "genuine receipt", query: queryFromURL(genuine), wantStatus: ReceiptVerificationValid, wantGenuine: true},
+		{name: "refunded receipt", query: queryFromURL(refundedReceipt), wantStatus: ReceiptVerificationRefunded, wantGenuine: true, wantRefunded: utils.Rupiah(42000)},
+		{name: "partially refunded receipt", query: queryFromURL(partialReceipt), wantStatus: ReceiptVerificationRefunded, wantGenuine: true, wantRefunded: utils.Rupiah(15000)},
+		{name: "edited total", query: forgedTotal, wantStatus: ReceiptVerificationInvalidSignature},
+		{name: "signed but unknown", query: missing, wantStatus: ReceiptVerificationNotFound},
+		{name: "database row modified", query: queryFromURL(tampered), wantStatus: ReceiptVerificationModified},
+	}
+
+	service := NewReceiptService(db)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := service.VerifyReceipt(tt.query)
+			if err != nil {
+				t.Fatalf("VerifyReceipt() error = %v", err)
+			}
+			if result.Status != tt.wantStatus || result.Genuine != tt.wantGenuine || result.RefundedAmount != tt.wantRefunded {
+				t.Errorf("VerifyReceipt() = %+v, want status %s genuine %v refunded %s", result, tt.wantStatus, tt.wantGenuine, tt.wantRefunded)
+			}
+		})
+	}
+}
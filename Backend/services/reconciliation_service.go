@@ -0,0 +1,367 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"gorm.io/gorm"
+)
+
+// Sumber pemicu rekonsiliasi
+const (
+	ReconciliationTriggerScheduler = "scheduler"
+	ReconciliationTriggerManual    = "manual"
+)
+
+// Default konfigurasi rekonsiliasi (bisa diubah lewat env)
+const (
+	defaultReconcileIntervalMinutes = 15
+	defaultReconcileLookbackDays    = 3
+)
+
+// ErrReconciliationRunning dikembalikan jika masih ada rekonsiliasi yang berjalan
+var ErrReconciliationRunning = errors.New("reconciliation is already running")
+
+// reconcileMu mencegah dua rekonsiliasi berjalan bersamaan dalam satu proses
+var reconcileMu sync.Mutex
+
+// ReconciliationService membandingkan payment lokal dengan data di payment provider.
+// Payment yang dicek dipilih ulang dari database setiap run, sehingga tidak ada antrian
+// yang hilang saat server restart.
+type ReconciliationService struct {
+	db           *gorm.DB
+	provider     PaymentProvider
+	Interval     time.Duration
+	LookbackDays int
+}
+
+// NewReconciliationService membuat instance baru ReconciliationService.
+// Interval dan lookback dibaca dari env RECONCILE_INTERVAL_MINUTES dan RECONCILE_LOOKBACK_DAYS.
+func NewReconciliationService(db *gorm.DB) *ReconciliationService {
+	return &ReconciliationService{
+		db:           db,
+		Interval:     time.Duration(envInt("RECONCILE_INTERVAL_MINUTES", defaultReconcileIntervalMinutes)) * time.Minute,
+		LookbackDays: envInt("RECONCILE_LOOKBACK_DAYS", defaultReconcileLookbackDays),
+	}
+}
+
+// Start menandai run yang terputus oleh restart sebagai gagal, lalu menjalankan
+// rekonsiliasi secara berkala (termasuk satu kali saat startup)
+func (s *ReconciliationService) Start() {
+	now := time.Now()
+	s.db.Model(&models.ReconciliationRun{}).
+		Where("status = ?", models.ReconciliationStatusRunning).
+		Updates(map[string]interface{}{
+			"status":      models.ReconciliationStatusFailed,
+			"error":       "interrupted by server restart",
+			"finished_at": now,
+		})
+
+	go func() {
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := s.Run(ReconciliationTriggerScheduler, nil); err != nil && !errors.Is(err, ErrReconciliationRunning) {
+				log.Printf("Payment reconciliation failed: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+	log.Printf("Payment reconciliation started (interval %s, lookback %d days)", s.Interval, s.LookbackDays)
+}
+
+// Run menjalankan rekonsiliasi secara sinkron dan mengembalikan hasilnya
+func (s *ReconciliationService) Run(trigger string, triggeredBy *uint) (*models.ReconciliationRun, error) {
+	run, err := s.Begin(trigger, triggeredBy)
+	if err != nil {
+		return nil, err
+	}
+	err = s.Execute(run)
+	return run, err
+}
+
+// Begin mengambil lock rekonsiliasi dan mencatat run baru berstatus running.
+// Pemanggil wajib melanjutkan dengan Execute, yang akan melepas lock.
+func (s *ReconciliationService) Begin(trigger string, triggeredBy *uint) (*models.ReconciliationRun, error) {
+	if !reconcileMu.TryLock() {
+		return nil, ErrReconciliationRunning
+	}
+
+	lookback := s.LookbackDays
+	if lookback <= 0 {
+		lookback = defaultReconcileLookbackDays
+	}
+
+	run := &models.ReconciliationRun{
+		Provider:     s.paymentProvider().Name(),
+		Trigger:      trigger,
+		TriggeredBy:  triggeredBy,
+		Status:       models.ReconciliationStatusRunning,
+		LookbackDays: lookback,
+		StartedAt:    time.Now(),
+	}
+	if err := s.db.Create(run).Error; err != nil {
+		reconcileMu.Unlock()
+		return nil, fmt.Errorf("failed to create reconciliation run: %w", err)
+	}
+	return run, nil
+}
+
+// Execute menjalankan pengecekan untuk run yang dibuat oleh Begin
+func (s *ReconciliationService) Execute(run *models.ReconciliationRun) error {
+	defer reconcileMu.Unlock()
+
+	r := &reconciler{
+		db:       s.db,
+		provider: s.paymentProvider(),
+		run:      run,
+		since:    run.StartedAt.AddDate(0, 0, -run.LookbackDays),
+		seen:     make(map[string]bool),
+	}
+	runErr := r.reconcile()
+
+	now := time.Now()
+	run.Status = models.ReconciliationStatusCompleted
+	if runErr != nil {
+		run.Status = models.ReconciliationStatusFailed
+		run.Error = runErr.Error()
+	} else if r.lookupErrors > 0 {
+		run.Error = fmt.Sprintf("%d provider lookups failed, see server log", r.lookupErrors)
+	}
+	run.Discrepancies = len(r.items)
+	run.FinishedAt = &now
+
+	for i := range r.items {
+		r.items[i].RunID = run.ID
+	}
+	if len(r.items) > 0 {
+		if err := s.db.CreateInBatches(r.items, 100).Error; err != nil {
+			run.Status = models.ReconciliationStatusFailed
+			run.Error = fmt.Sprintf("failed to store discrepancies: %v", err)
+		}
+	}
+	if err := s.db.Save(run).Error; err != nil {
+		log.Printf("Error saving reconciliation run %d: %v", run.ID, err)
+	}
+
+	log.Printf("Reconciliation run %d %s: checked=%d auto_fixed=%d discrepancies=%d",
+		run.ID, run.Status, run.Checked, run.AutoFixed, run.Discrepancies)
+	return runErr
+}
+
+// GetRun mengembalikan run beserta daftar selisihnya (opsional difilter per jenis)
+func (s *ReconciliationService) GetRun(runID uint, discrepancyType string) (*models.ReconciliationRun, error) {
+	var run models.ReconciliationRun
+	err := s.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		if discrepancyType != "" {
+			db = db.Where("type = ?", discrepancyType)
+		}
+		return db.Order("id ASC")
+	}).First(&run, runID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// ListRuns mengembalikan daftar run terbaru beserta total data
+func (s *ReconciliationService) ListRuns(page, limit int) ([]models.ReconciliationRun, int64, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	var total int64
+	if err := s.db.Model(&models.ReconciliationRun{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var runs []models.ReconciliationRun
+	err := s.db.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&runs).Error
+	return runs, total, err
+}
+
+func (s *ReconciliationService) paymentProvider() PaymentProvider {
+	if s.provider != nil {
+		return s.provider
+	}
+	return GetPaymentProvider()
+}
+
+// reconciler menyimpan state satu kali eksekusi rekonsiliasi
+type reconciler struct {
+	db           *gorm.DB
+	provider     PaymentProvider
+	run          *models.ReconciliationRun
+	since        time.Time
+	seen         map[string]bool
+	items        []models.ReconciliationDiscrepancy
+	lookupErrors int
+}
+
+func (r *reconciler) reconcile() error {
+	// Payment non-cash yang belum final, ditambah payment sukses dalam periode lookback
+	var payments []models.Payment
+	err := r.db.Where("payment_method <> ?", "cash").
+		Where("status = ? OR (status = ? AND created_at >= ?)", PaymentStatusPending, PaymentStatusSuccess, r.since).
+		Order("id ASC").
+		Find(&payments).Error
+	if err != nil {
+		return fmt.Errorf("failed to load payments: %w", err)
+	}
+
+	for i := range payments {
+		r.checkPayment(&payments[i])
+	}
+
+	r.findMissingLocally()
+	return nil
+}
+
+// checkPayment membandingkan satu payment lokal dengan data provider
+func (r *reconciler) checkPayment(payment *models.Payment) {
+	ref := payment.ProviderRef()
+	if ref == "" {
+		return
+	}
+	r.run.Checked++
+	r.seen[ref] = true
+
+	trx, err := r.provider.GetTransaction(ref)
+	if errors.Is(err, ErrTransactionNotFound) {
+		r.add(payment, nil, models.DiscrepancyMissingAtProvider, false, "provider has no transaction for this reference")
+		return
+	}
+	if err != nil {
+		r.lookupErrors++
+		log.Printf("Reconciliation: error fetching transaction %s for payment %d: %v", ref, payment.ID, err)
+		return
+	}
+	r.seen[trx.OrderRef] = true
+	r.seen[trx.TransactionID] = true
+
+	// Selisih nominal tidak pernah diperbaiki otomatis
+	if trx.Amount != payment.ChargedAmount() {
+		r.add(payment, trx, models.DiscrepancyAmountMismatch, false, "amount differs, manual review required")
+		return
+	}
+	if trx.Status == payment.Status {
+		return
+	}
+
+	// Kasus aman: payment lokal masih pending dan provider sudah final
+	if payment.Status == PaymentStatusPending && CanTransitionPaymentStatus(payment.Status, trx.Status) {
+		_, err := NewPaymentService(r.db).ApplyProviderNotification(&WebhookNotification{
+			OrderRef:          ref,
+			TransactionID:     trx.TransactionID,
+			TransactionStatus: trx.TransactionStatus,
+			Status:            trx.Status,
+			GrossAmount:       trx.Amount.Decimal(),
+		})
+		if err == nil {
+			r.run.AutoFixed++
+			r.add(payment, trx, models.DiscrepancyStatusMismatch, true,
+				fmt.Sprintf("local status updated %s -> %s", payment.Status, trx.Status))
+			return
+		}
+		r.add(payment, trx, models.DiscrepancyStatusMismatch, false, fmt.Sprintf("auto-fix failed: %v", err))
+		return
+	}
+
+	r.add(payment, trx, models.DiscrepancyStatusMismatch, false, "status differs, manual review required")
+}
+
+// findMissingLocally mencari transaksi provider yang tidak punya payment lokal,
+// dari daftar transaksi provider (jika didukung) dan dari webhook yang gagal diproses
+func (r *reconciler) findMissingLocally() {
+	var candidates []ProviderTransaction
+
+	if lister, ok := r.provider.(TransactionLister); ok {
+		transactions, err := lister.ListTransactions(r.since)
+		if err != nil {
+			r.lookupErrors++
+			log.Printf("Reconciliation: error listing provider transactions: %v", err)
+		}
+		candidates = append(candidates, transactions...)
+	}
+
+	var events []models.WebhookEvent
+	r.db.Where("provider = ? AND process_status = ? AND process_error = ? AND created_at >= ?",
+		r.provider.Name(), models.WebhookStatusFailed, ErrPaymentNotFound.Error(), r.since).
+		Order("id ASC").
+		Find(&events)
+	for _, event := range events {
+		if r.seen[event.OrderRef] {
+			continue
+		}
+		trx, err := r.provider.GetTransaction(event.OrderRef)
+		if err != nil {
+			if !errors.Is(err, ErrTransactionNotFound) {
+				r.lookupErrors++
+				log.Printf("Reconciliation: error fetching transaction %s from webhook %d: %v", event.OrderRef, event.ID, err)
+			}
+			continue
+		}
+		candidates = append(candidates, *trx)
+	}
+
+	for i := range candidates {
+		trx := &candidates[i]
+		if r.seen[trx.OrderRef] || r.seen[trx.TransactionID] {
+			continue
+		}
+		r.seen[trx.OrderRef] = true
+		r.seen[trx.TransactionID] = true
+
+		// Hanya transaksi yang benar-benar memindahkan uang yang perlu dilaporkan
+		if trx.Status != PaymentStatusSuccess && trx.Status != PaymentStatusRefunded {
+			continue
+		}
+		if _, err := findPaymentByProviderRef(r.db, trx.OrderRef, trx.TransactionID); !errors.Is(err, ErrPaymentNotFound) {
+			continue
+		}
+		r.add(nil, trx, models.DiscrepancyMissingLocally, false, "provider transaction has no local payment")
+	}
+}
+
+func (r *reconciler) add(payment *models.Payment, trx *ProviderTransaction, discrepancyType string, autoFixed bool, note string) {
+	item := models.ReconciliationDiscrepancy{
+		Type:      discrepancyType,
+		AutoFixed: autoFixed,
+		Note:      note,
+	}
+	if payment != nil {
+		paymentID, orderID := payment.ID, payment.OrderID
+		item.PaymentID = &paymentID
+		item.OrderID = &orderID
+		item.ProviderRef = payment.ProviderRef()
+		item.LocalStatus = payment.Status
+		item.LocalAmount = payment.ChargedAmount()
+	}
+	if trx != nil {
+		if item.ProviderRef == "" {
+			item.ProviderRef = trx.OrderRef
+		}
+		item.ProviderStatus = trx.Status
+		item.ProviderAmount = trx.Amount
+	}
+	r.items = append(r.items, item)
+}
+
+// envInt membaca env bertipe integer positif, atau mengembalikan nilai default
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
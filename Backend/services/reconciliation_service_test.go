@@ -0,0 +1,144 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+func newReconciliationTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db := newWebhookTestDB(t)
+	if err := db.AutoMigrate(&models.ReconciliationRun{}, &models.ReconciliationDiscrepancy{}); err != nil {
+		t.Fatalf("failed to migrate reconciliation tables: %v", err)
+	}
+	return db
+}
+
+func TestReconciliationService_Run(t *testing.T) {
+	db := newReconciliationTestDB(t)
+	fp := NewFakePaymentProvider("reconcile-secret")
+
+	// Webhook settlement hilang: lokal masih pending, provider sudah settlement
+	settled, _ := createFakeQRISPayment(t, db, fp, "ORDER-1-settled1")
+	if _, err := fp.Simulate("ORDER-1-settled1", FakeActionSettle); err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+
+	// Nominal di provider berbeda dengan nominal lokal
+	createFakeQRISPayment(t, db, fp, "ORDER-2-amount01")
+	if err := fp.SetAmount("ORDER-2-amount01", utils.Rupiah(25000)); err != nil {
+		t.Fatalf("SetAmount() error = %v", err)
+	}
+	if _, err := fp.Simulate("ORDER-2-amount01", FakeActionSettle); err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+
+	// Refund dilakukan langsung di dashboard provider
+	refunded, _ := createFakeQRISPayment(t, db, fp, "ORDER-3-refund01")
+	db.Model(refunded).Update("status", PaymentStatusSuccess)
+	fp.Simulate("ORDER-3-refund01", FakeActionSettle)
+	if err := fp.Refund("ORDER-3-refund01", utils.Rupiah(30000), "customer request"); err != nil {
+		t.Fatalf("Refund() error = %v", err)
+	}
+
+	// Payment lokal yang tidak dikenal provider
+	db.Create(&models.Payment{OrderID: 99, Amount: utils.Rupiah(10000), Status: PaymentStatusPending, PaymentMethod: "qris",
+		ProviderReference: strPtr("ORDER-4-unknown1")})
+
+	// Transaksi dibayar di provider tetapi payment lokal tidak pernah tersimpan
+	if _, err := fp.CreateCharge(ChargeRequest{OrderRef: "ORDER-5-orphan01", Amount: utils.Rupiah(15000)}); err != nil {
+		t.Fatalf("CreateCharge() error = %v", err)
+	}
+	fp.Simulate("ORDER-5-orphan01", FakeActionSettle)
+
+	// Payment cash tidak ikut direkonsiliasi
+	db.Create(&models.Payment{OrderID: 100, Amount: utils.Rupiah(5000), Status: PaymentStatusPending, PaymentMethod: "cash"})
+
+	svc := &ReconciliationService{db: db, provider: fp, LookbackDays: 3}
+	run, err := svc.Run(ReconciliationTriggerManual, nil)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if run.Status != models.ReconciliationStatusCompleted || run.Checked != 4 || run.AutoFixed != 1 {
+		t.Errorf("Run() status = %s checked = %d auto_fixed = %d, want completed/4/1", run.Status, run.Checked, run.AutoFixed)
+	}
+
+	stored, err := svc.GetRun(run.ID, "")
+	if err != nil {
+		t.Fatalf("GetRun() error = %v", err)
+	}
+	byRef := make(map[string]models.ReconciliationDiscrepancy)
+	for _, item := range stored.Items {
+		byRef[item.ProviderRef] = item
+	}
+
+	tests := []struct {
+		ref           string
+		wantType      string
+		wantAutoFixed bool
+	}{
+		{"ORDER-1-settled1", models.DiscrepancyStatusMismatch, true},
+		{"ORDER-2-amount01", models.DiscrepancyAmountMismatch, false},
+		{"ORDER-3-refund01", models.DiscrepancyStatusMismatch, false},
+		{"ORDER-4-unknown1", models.DiscrepancyMissingAtProvider, false},
+		{"ORDER-5-orphan01", models.DiscrepancyMissingLocally, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			item, ok := byRef[tt.ref]
+			if !ok {
+				t.Fatalf("no discrepancy reported for %s", tt.ref)
+			}
+			if item.Type != tt.wantType || item.AutoFixed != tt.wantAutoFixed {
+				t.Errorf("discrepancy = %s (auto_fixed=%v), want %s (auto_fixed=%v)", item.Type, item.AutoFixed, tt.wantType, tt.wantAutoFixed)
+			}
+		})
+	}
+	if len(stored.Items) != len(tests) {
+		t.Errorf("discrepancies = %d, want %d", len(stored.Items), len(tests))
+	}
+
+	var reloaded models.Payment
+	db.First(&reloaded, settled.ID)
+	if reloaded.Status != PaymentStatusSuccess {
+		t.Errorf("auto-fixed payment status = %s, want %s", reloaded.Status, PaymentStatusSuccess)
+	}
+	db.First(&reloaded, refunded.ID)
+	if reloaded.Status != PaymentStatusSuccess {
+		t.Errorf("refunded payment should not be auto-fixed, got status %s", reloaded.Status)
+	}
+
+	// Run kedua hanya melaporkan selisih yang belum selesai
+	second, err := svc.Run(ReconciliationTriggerScheduler, nil)
+	if err != nil {
+		t.Fatalf("second Run() error = %v", err)
+	}
+	if second.AutoFixed != 0 || second.Discrepancies != len(tests)-1 {
+		t.Errorf("second Run() auto_fixed = %d discrepancies = %d, want 0/%d", second.AutoFixed, second.Discrepancies, len(tests)-1)
+	}
+}
+
+func TestReconciliationService_NoOverlap(t *testing.T) {
+	db := newReconciliationTestDB(t)
+	svc := &ReconciliationService{db: db, provider: NewFakePaymentProvider(""), LookbackDays: 1}
+
+	run, err := svc.Begin(ReconciliationTriggerManual, nil)
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if _, err := svc.Run(ReconciliationTriggerScheduler, nil); !errors.Is(err, ErrReconciliationRunning) {
+		t.Errorf("Run() during active run error = %v, want %v", err, ErrReconciliationRunning)
+	}
+	if err := svc.Execute(run); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if _, err := svc.Run(ReconciliationTriggerScheduler, nil); err != nil {
+		t.Errorf("Run() after previous run finished error = %v", err)
+	}
+}
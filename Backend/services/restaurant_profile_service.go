@@ -0,0 +1,266 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Nilai default profil restoran sebelum diatur admin
+const (
+	DefaultRestaurantTimezone  = "Asia/Jakarta"
+	DefaultRestaurantCurrency  = "IDR"
+	DefaultRestaurantFooter    = "Terima kasih atas kunjungan Anda!"
+	DefaultRestaurantPublicURL = "http://localhost:8080"
+)
+
+// restaurantProfileCacheTTL membatasi umur cache agar perubahan dari instance lain ikut terbaca
+const restaurantProfileCacheTTL = time.Minute
+
+// ErrInvalidRestaurantProfile dikembalikan jika isian profil restoran tidak valid
+var ErrInvalidRestaurantProfile = errors.New("invalid restaurant profile")
+
+// RestaurantProfileInput adalah isian profil yang bisa diubah admin
+type RestaurantProfileInput struct {
+	Name          string `json:"name"`
+	Address       string `json:"address"`
+	Phone         string `json:"phone"`
+	Email         string `json:"email"`
+	TaxID         string `json:"tax_id"`
+	FooterText    string `json:"footer_text"`
+	Timezone      string `json:"timezone"`
+	Currency      string `json:"currency"`
+	PublicBaseURL string `json:"public_base_url"`
+}
+
+// restaurantProfileCache menyimpan profil di memori agar struk, ekspor dan URL gambar tidak
+// membaca database setiap kali. Dikosongkan setiap kali profil diubah dan kedaluwarsa
+// setelah restaurantProfileCacheTTL.
+var restaurantProfileCache struct {
+	sync.RWMutex
+	db         *gorm.DB
+	profile    *models.RestaurantProfile
+	loadedAt   time.Time
+	generation uint64 // Naik setiap invalidasi agar hasil baca lama tidak masuk cache
+}
+
+// RestaurantProfileService membaca dan mengubah profil restoran
+type RestaurantProfileService struct {
+	db *gorm.DB
+}
+
+// NewRestaurantProfileService membuat instance baru RestaurantProfileService
+func NewRestaurantProfileService(db *gorm.DB) *RestaurantProfileService {
+	restaurantProfileCache.Lock()
+	restaurantProfileCache.db = db
+	restaurantProfileCache.Unlock()
+	return &RestaurantProfileService{db: db}
+}
+
+// Get mengembalikan profil restoran dari cache. Jika belum ada di database, profil dibuat
+// dari env RESTAURANT_* agar konfigurasi lama tetap terpakai.
+func (s *RestaurantProfileService) Get() (*models.RestaurantProfile, error) {
+	restaurantProfileCache.RLock()
+	cached, generation := restaurantProfileCache.profile, restaurantProfileCache.generation
+	if time.Since(restaurantProfileCache.loadedAt) > restaurantProfileCacheTTL {
+		cached = nil
+	}
+	restaurantProfileCache.RUnlock()
+	if cached != nil {
+		profile := *cached
+		return &profile, nil
+	}
+
+	var profile models.RestaurantProfile
+	err := s.db.First(&profile, models.RestaurantProfileID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Permintaan bersamaan boleh sama-sama membuat default, hanya satu yang tersimpan
+		defaults := defaultRestaurantProfile()
+		if err = s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&defaults).Error; err == nil {
+			err = s.db.First(&profile, models.RestaurantProfileID).Error
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load restaurant profile: %w", err)
+	}
+
+	restaurantProfileCache.Lock()
+	if restaurantProfileCache.generation == generation {
+		stored := profile
+		restaurantProfileCache.profile = &stored
+		restaurantProfileCache.loadedAt = time.Now()
+	}
+	restaurantProfileCache.Unlock()
+	return &profile, nil
+}
+
+// Update menyimpan profil restoran lalu mengosongkan cache
+func (s *RestaurantProfileService) Update(input RestaurantProfileInput, updatedBy *uint) (*models.RestaurantProfile, error) {
+	input = normalizeRestaurantProfileInput(input)
+	if err := validateRestaurantProfile(input); err != nil {
+		return nil, err
+	}
+
+	profile, err := s.Get()
+	if err != nil {
+		return nil, err
+	}
+	profile.Name = input.Name
+	profile.Address = input.Address
+	profile.Phone = input.Phone
+	profile.Email = input.Email
+	profile.TaxID = input.TaxID
+	profile.FooterText = input.FooterText
+	profile.Timezone = input.Timezone
+	profile.Currency = input.Currency
+	profile.PublicBaseURL = input.PublicBaseURL
+	profile.UpdatedBy = updatedBy
+	return s.save(profile)
+}
+
+// UpdateLogo mengganti file logo struk dan mengembalikan path logo sebelumnya
+func (s *RestaurantProfileService) UpdateLogo(path string, updatedBy *uint) (*models.RestaurantProfile, string, error) {
+	profile, err := s.Get()
+	if err != nil {
+		return nil, "", err
+	}
+	previous := profile.LogoPath
+	profile.LogoPath = path
+	profile.UpdatedBy = updatedBy
+	saved, err := s.save(profile)
+	return saved, previous, err
+}
+
+func (s *RestaurantProfileService) save(profile *models.RestaurantProfile) (*models.RestaurantProfile, error) {
+	if err := s.db.Save(profile).Error; err != nil {
+		return nil, fmt.Errorf("failed to save restaurant profile: %w", err)
+	}
+	InvalidateRestaurantProfile()
+	return profile, nil
+}
+
+// InvalidateRestaurantProfile mengosongkan cache profil; pembacaan berikutnya memuat ulang dari database
+func InvalidateRestaurantProfile() {
+	restaurantProfileCache.Lock()
+	restaurantProfileCache.profile = nil
+	restaurantProfileCache.generation++
+	restaurantProfileCache.Unlock()
+}
+
+// CurrentRestaurantProfile mengembalikan profil restoran untuk dipakai di luar request
+// (struk, ekspor, payment gateway). Jika database belum siap, dipakai profil default.
+func CurrentRestaurantProfile() models.RestaurantProfile {
+	restaurantProfileCache.RLock()
+	db := restaurantProfileCache.db
+	restaurantProfileCache.RUnlock()
+	if db == nil {
+		return defaultRestaurantProfile()
+	}
+
+	profile, err := (&RestaurantProfileService{db: db}).Get()
+	if err != nil {
+		log.Printf("Error loading restaurant profile, using defaults: %v", err)
+		return defaultRestaurantProfile()
+	}
+	return *profile
+}
+
+// ReceiptBrandingFromProfile menyusun kepala dan kaki struk dari profil restoran
+func ReceiptBrandingFromProfile(profile models.RestaurantProfile) ReceiptBranding {
+	return ReceiptBranding{
+		Name:      profile.Name,
+		Address:   profile.Address,
+		Phone:     profile.Phone,
+		TaxID:     profile.TaxID,
+		LogoPath:  profile.LogoPath,
+		Footer:    profile.FooterText,
+		Location:  profile.Location(),
+		VerifyURL: envString("RECEIPT_VERIFY_URL", profile.PublicURL("/receipts/verify")),
+	}
+}
+
+// CurrentReceiptBranding adalah ReceiptBrandingFromProfile untuk profil restoran saat ini
+func CurrentReceiptBranding() ReceiptBranding {
+	return ReceiptBrandingFromProfile(CurrentRestaurantProfile())
+}
+
+func defaultRestaurantProfile() models.RestaurantProfile {
+	return models.RestaurantProfile{
+		ID:            models.RestaurantProfileID,
+		Name:          envString("RESTAURANT_NAME", "Restaurant Name"),
+		Address:       os.Getenv("RESTAURANT_ADDRESS"),
+		Phone:         os.Getenv("RESTAURANT_PHONE"),
+		TaxID:         os.Getenv("RESTAURANT_TAX_ID"),
+		LogoPath:      os.Getenv("RESTAURANT_LOGO_PATH"),
+		FooterText:    DefaultRestaurantFooter,
+		Timezone:      DefaultRestaurantTimezone,
+		Currency:      DefaultRestaurantCurrency,
+		PublicBaseURL: envString("PUBLIC_BASE_URL", DefaultRestaurantPublicURL),
+	}
+}
+
+func normalizeRestaurantProfileInput(input RestaurantProfileInput) RestaurantProfileInput {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Address = strings.TrimSpace(input.Address)
+	input.Phone = strings.TrimSpace(input.Phone)
+	input.Email = strings.TrimSpace(input.Email)
+	input.TaxID = strings.TrimSpace(input.TaxID)
+	input.FooterText = strings.TrimSpace(input.FooterText)
+	input.Timezone = strings.TrimSpace(input.Timezone)
+	if input.Timezone == "" {
+		input.Timezone = DefaultRestaurantTimezone
+	}
+	input.Currency = strings.ToUpper(strings.TrimSpace(input.Currency))
+	if input.Currency == "" {
+		input.Currency = DefaultRestaurantCurrency
+	}
+	input.PublicBaseURL = strings.TrimRight(strings.TrimSpace(input.PublicBaseURL), "/")
+	return input
+}
+
+func validateRestaurantProfile(input RestaurantProfileInput) error {
+	if input.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRestaurantProfile)
+	}
+	limits := []struct {
+		field string
+		value string
+		max   int
+	}{
+		{"name", input.Name, 100},
+		{"address", input.Address, 255},
+		{"phone", input.Phone, 30},
+		{"email", input.Email, 100},
+		{"tax_id", input.TaxID, 30},
+		{"footer_text", input.FooterText, 255},
+		{"public_base_url", input.PublicBaseURL, 255},
+	}
+	for _, limit := range limits {
+		if len([]rune(limit.value)) > limit.max {
+			return fmt.Errorf("%w: %s must be at most %d characters", ErrInvalidRestaurantProfile, limit.field, limit.max)
+		}
+	}
+	if _, err := time.LoadLocation(input.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidRestaurantProfile, input.Timezone)
+	}
+	// Semua nominal disimpan dan ditagihkan dalam Rupiah (Midtrans hanya menerima IDR)
+	if input.Currency != DefaultRestaurantCurrency {
+		return fmt.Errorf("%w: currency %q is not supported, only IDR", ErrInvalidRestaurantProfile, input.Currency)
+	}
+	if input.PublicBaseURL != "" {
+		parsed, err := url.Parse(input.PublicBaseURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%w: public_base_url must be an absolute http(s) URL", ErrInvalidRestaurantProfile)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yeremiapane/restaurant-app/models"
+)
+
+func TestRestaurantProfileService(t *testing.T) {
+	db := newPaymentTestDB(t)
+	if err := db.AutoMigrate(&models.RestaurantProfile{}); err != nil {
+		t.Fatalf("failed to migrate restaurant profile: %v", err)
+	}
+	t.Setenv("RESTAURANT_NAME", "Warung Lama")
+	InvalidateRestaurantProfile()
+	t.Cleanup(InvalidateRestaurantProfile)
+
+	service := NewRestaurantProfileService(db)
+	profile, err := service.Get()
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if profile.Name != "Warung Lama" || profile.Timezone != DefaultRestaurantTimezone || profile.Currency != DefaultRestaurantCurrency {
+		t.Errorf("default profile = %+v, want values from env and defaults", profile)
+	}
+
+	invalid := []struct {
+		name  string
+		input RestaurantProfileInput
+	}{
+		{name: "missing name", input: RestaurantProfileInput{Name: "  "}},
+		{name: "unknown timezone", input: RestaurantProfileInput{Name: "Warung", Timezone: "Asia/Bandung"}},
+		{name: "unsupported currency", input: RestaurantProfileInput{Name: "Warung", Currency: "usd"}},
+		{name: "relative base url", input: RestaurantProfileInput{Name: "Warung", PublicBaseURL: "resto.example.com"}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.Update(tt.input, nil); !errors.Is(err, ErrInvalidRestaurantProfile) {
+				t.Errorf("Update() error = %v, want ErrInvalidRestaurantProfile", err)
+			}
+		})
+	}
+
+	// Cache terisi sebelum update, lalu harus langsung memakai nilai baru
+	if got := CurrentRestaurantProfile().Name; got != "Warung Lama" {
+		t.Fatalf("CurrentRestaurantProfile().Name = %q before update", got)
+	}
+	_, err = service.Update(RestaurantProfileInput{
+		Name:          "Warung Baru",
+		TaxID:         "01.234.567.8-901.000",
+		FooterText:    "Sampai jumpa lagi",
+		Timezone:      "Asia/Makassar",
+		PublicBaseURL: "https://resto.example.com/",
+	}, nil)
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	current := CurrentRestaurantProfile()
+	if current.Name != "Warung Baru" || current.Location().String() != "Asia/Makassar" {
+		t.Errorf("CurrentRestaurantProfile() = %q %q after update", current.Name, current.Timezone)
+	}
+	if got := current.PublicURL("/uploads/menu_images/a.jpg"); got != "https://resto.example.com/uploads/menu_images/a.jpg" {
+		t.Errorf("PublicURL() = %q", got)
+	}
+	branding := CurrentReceiptBranding()
+	receipt := &models.Receipt{ReceiptNumber: "RCP/20261018/000001"}
+	if got := branding.VerificationURL(receipt); !strings.HasPrefix(got, "https://resto.example.com/receipts/verify?number=RCP%2F20261018%2F000001&sig=") {
+		t.Errorf("VerificationURL() = %q", got)
+	}
+	if branding.Footer != "Sampai jumpa lagi" || branding.TaxID != "01.234.567.8-901.000" {
+		t.Errorf("branding = %+v", branding)
+	}
+}
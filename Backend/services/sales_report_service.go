@@ -0,0 +1,311 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+// reportSchedulerInterval adalah jeda pengecekan jadwal laporan yang jatuh tempo
+const reportSchedulerInterval = time.Minute
+
+// ErrInvalidReportSchedule dikembalikan jika isian jadwal laporan tidak valid
+var ErrInvalidReportSchedule = errors.New("invalid report schedule")
+
+// clockPattern mencocokkan jam HH:MM (00:00-23:59)
+var clockPattern = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d$`)
+
+// DailySalesReport adalah ringkasan penjualan satu hari (zona waktu restoran)
+type DailySalesReport struct {
+	Date        string                  `json:"date"` // 2006-01-02
+	OrderCount  int                     `json:"order_count"`
+	Sales       utils.Money             `json:"sales"`
+	Tips        utils.Money             `json:"tips"`
+	ByMethod    []DailySalesMethodTotal `json:"by_method"`
+	GeneratedAt time.Time               `json:"generated_at"`
+}
+
+// DailySalesMethodTotal adalah penjualan per metode pembayaran
+type DailySalesMethodTotal struct {
+	PaymentMethod string      `json:"payment_method"`
+	PaymentCount  int         `json:"payment_count"`
+	Sales         utils.Money `json:"sales"`
+	Tips          utils.Money `json:"tips"`
+}
+
+// ReportScheduleInput adalah isian jadwal laporan dari admin
+type ReportScheduleInput struct {
+	Name       string `json:"name"`
+	Recipients string `json:"recipients"` // Email dipisah koma
+	SendTime   string `json:"send_time"`  // HH:MM
+	Enabled    *bool  `json:"enabled"`
+}
+
+// SalesReportService menyusun laporan penjualan harian dan mengelola jadwal pengirimannya
+type SalesReportService struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+// NewSalesReportService membuat instance baru SalesReportService
+func NewSalesReportService(db *gorm.DB) *SalesReportService {
+	return &SalesReportService{db: db, now: time.Now}
+}
+
+// DailySales menghitung penjualan dari payment sukses pada tanggal date di zona waktu loc
+func (s *SalesReportService) DailySales(date time.Time, loc *time.Location) (*DailySalesReport, error) {
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+	end := start.AddDate(0, 0, 1)
+
+	var rows []struct {
+		PaymentMethod string
+		PaymentCount  int
+		Total         utils.Money
+		Tips          utils.Money
+	}
+	err := s.db.Model(&models.Payment{}).
+		Select("payment_method, COUNT(*) AS payment_count, COALESCE(SUM("+netPaymentAmountSQL+"), 0) AS total, COALESCE(SUM("+netPaymentTipSQL+"), 0) AS tips").
+		Where("status = ? AND payment_time >= ? AND payment_time < ?", PaymentStatusSuccess, start, end).
+		Group("payment_method").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum daily payments: %w", err)
+	}
+
+	var orderCount int64
+	err = s.db.Model(&models.Payment{}).
+		Where("status = ? AND payment_time >= ? AND payment_time < ?", PaymentStatusSuccess, start, end).
+		Distinct("order_id").Count(&orderCount).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count daily orders: %w", err)
+	}
+
+	report := &DailySalesReport{
+		Date:        start.Format("2006-01-02"),
+		OrderCount:  int(orderCount),
+		GeneratedAt: s.now(),
+	}
+	for _, row := range rows {
+		report.Sales += row.Total
+		report.Tips += row.Tips
+		report.ByMethod = append(report.ByMethod, DailySalesMethodTotal{
+			PaymentMethod: row.PaymentMethod,
+			PaymentCount:  row.PaymentCount,
+			Sales:         row.Total,
+			Tips:          row.Tips,
+		})
+	}
+	sort.Slice(report.ByMethod, func(i, j int) bool {
+		return report.ByMethod[i].PaymentMethod < report.ByMethod[j].PaymentMethod
+	})
+	return report, nil
+}
+
+// SendSchedule mengirim laporan penjualan kemarin ke penerima jadwal tersebut
+func (s *SalesReportService) SendSchedule(schedule *models.ReportSchedule) error {
+	profile := CurrentRestaurantProfile()
+	loc := profile.Location()
+
+	report, err := s.DailySales(s.now().In(loc).AddDate(0, 0, -1), loc)
+	if err != nil {
+		return err
+	}
+	msg, err := salesReportMail(report, profile.Name)
+	if err != nil {
+		return err
+	}
+	msg.To = schedule.RecipientList()
+	return GetMailer().Send(msg)
+}
+
+// Start menjalankan pengecekan jadwal laporan setiap menit di background
+func (s *SalesReportService) Start() {
+	go func() {
+		ticker := time.NewTicker(reportSchedulerInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			s.RunDue()
+		}
+	}()
+	log.Printf("Sales report scheduler started")
+}
+
+// RunDue mengirim setiap jadwal aktif yang jam kirimnya sudah lewat dan belum terkirim hari ini
+func (s *SalesReportService) RunDue() {
+	profile := CurrentRestaurantProfile()
+	now := s.now().In(profile.Location())
+	today, clock := now.Format("2006-01-02"), now.Format("15:04")
+
+	var schedules []models.ReportSchedule
+	if err := s.db.Where("enabled = ? AND send_time <= ?", true, clock).Find(&schedules).Error; err != nil {
+		log.Printf("Error loading report schedules: %v", err)
+		return
+	}
+	for i := range schedules {
+		schedule := &schedules[i]
+		if schedule.LastSentOn == today {
+			continue
+		}
+		claimed, err := s.claim(schedule.ID, today)
+		if err != nil {
+			log.Printf("Error claiming report schedule %d: %v", schedule.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		// Kegagalan tidak diulang setiap menit; admin bisa mengirim ulang secara manual
+		sendErr := s.SendSchedule(schedule)
+		lastError := ""
+		if sendErr != nil {
+			lastError = sendErr.Error()
+			log.Printf("Error sending report schedule %d: %v", schedule.ID, sendErr)
+		}
+		s.db.Model(&models.ReportSchedule{}).Where("id = ?", schedule.ID).Update("last_error", lastError)
+	}
+}
+
+// claim menandai jadwal terkirim hari ini secara atomik agar instance lain tidak ikut mengirim
+func (s *SalesReportService) claim(scheduleID uint, today string) (bool, error) {
+	result := s.db.Model(&models.ReportSchedule{}).
+		Where("id = ? AND (last_sent_on IS NULL OR last_sent_on <> ?)", scheduleID, today).
+		Update("last_sent_on", today)
+	return result.RowsAffected == 1, result.Error
+}
+
+// ListSchedules mengembalikan semua jadwal laporan
+func (s *SalesReportService) ListSchedules() ([]models.ReportSchedule, error) {
+	var schedules []models.ReportSchedule
+	err := s.db.Order("id ASC").Find(&schedules).Error
+	return schedules, err
+}
+
+// CreateSchedule membuat jadwal laporan baru
+func (s *SalesReportService) CreateSchedule(input ReportScheduleInput, createdBy *uint) (*models.ReportSchedule, error) {
+	schedule := &models.ReportSchedule{CreatedBy: createdBy, Enabled: true}
+	if err := applyReportScheduleInput(schedule, input); err != nil {
+		return nil, err
+	}
+	// Default kolom enabled di database adalah true, nilai false disimpan setelah insert
+	enabled := schedule.Enabled
+	if err := s.db.Create(schedule).Error; err != nil {
+		return nil, fmt.Errorf("failed to create report schedule: %w", err)
+	}
+	if !enabled {
+		if err := s.db.Model(schedule).Update("enabled", false).Error; err != nil {
+			return nil, fmt.Errorf("failed to create report schedule: %w", err)
+		}
+	}
+	return schedule, nil
+}
+
+// UpdateSchedule mengubah jadwal laporan
+func (s *SalesReportService) UpdateSchedule(scheduleID uint, input ReportScheduleInput) (*models.ReportSchedule, error) {
+	var schedule models.ReportSchedule
+	if err := s.db.First(&schedule, scheduleID).Error; err != nil {
+		return nil, err
+	}
+	if err := applyReportScheduleInput(&schedule, input); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(&schedule).Error; err != nil {
+		return nil, fmt.Errorf("failed to update report schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+// DeleteSchedule menghapus jadwal laporan
+func (s *SalesReportService) DeleteSchedule(scheduleID uint) error {
+	result := s.db.Delete(&models.ReportSchedule{}, scheduleID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func applyReportScheduleInput(schedule *models.ReportSchedule, input ReportScheduleInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len([]rune(name)) > 100 {
+		return fmt.Errorf("%w: name is required (max 100 characters)", ErrInvalidReportSchedule)
+	}
+	if !clockPattern.MatchString(input.SendTime) {
+		return fmt.Errorf("%w: send_time must be HH:MM", ErrInvalidReportSchedule)
+	}
+	var recipients []string
+	for _, email := range strings.Split(input.Recipients, ",") {
+		email = strings.TrimSpace(email)
+		if email == "" {
+			continue
+		}
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			return fmt.Errorf("%w: invalid recipient %q", ErrInvalidReportSchedule, email)
+		}
+		recipients = append(recipients, email)
+	}
+	if len(recipients) == 0 {
+		return fmt.Errorf("%w: at least one recipient is required", ErrInvalidReportSchedule)
+	}
+
+	schedule.Name = name
+	schedule.Recipients = strings.Join(recipients, ",")
+	schedule.SendTime = input.SendTime
+	if input.Enabled != nil {
+		schedule.Enabled = *input.Enabled
+	}
+	return nil
+}
+
+// salesReportMail menyusun email laporan dengan rincian CSV per metode pembayaran
+func salesReportMail(report *DailySalesReport, restaurantName string) (Mail, error) {
+	var text, rows strings.Builder
+	fmt.Fprintf(&text, "Laporan penjualan %s - %s\n\n", restaurantName, report.Date)
+	fmt.Fprintf(&text, "Jumlah order: %d\nPenjualan: %s\nTip: %s\n\n", report.OrderCount,
+		utils.FormatCurrencyIDR(report.Sales), utils.FormatCurrencyIDR(report.Tips))
+
+	var attachment bytes.Buffer
+	writer := csv.NewWriter(&attachment)
+	writer.Write([]string{"Metode", "Jumlah Transaksi", "Penjualan", "Tip"})
+	for _, method := range report.ByMethod {
+		fmt.Fprintf(&text, "- %s: %d transaksi, %s\n", method.PaymentMethod, method.PaymentCount, utils.FormatCurrencyIDR(method.Sales))
+		fmt.Fprintf(&rows, "<tr><td>%s</td><td align=\"right\">%d</td><td align=\"right\">%s</td><td align=\"right\">%s</td></tr>",
+			html.EscapeString(method.PaymentMethod), method.PaymentCount,
+			utils.FormatCurrencyIDR(method.Sales), utils.FormatCurrencyIDR(method.Tips))
+		writer.Write([]string{method.PaymentMethod, fmt.Sprint(method.PaymentCount), method.Sales.Decimal(), method.Tips.Decimal()})
+	}
+	writer.Write([]string{"Total", fmt.Sprint(report.OrderCount), report.Sales.Decimal(), report.Tips.Decimal()})
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return Mail{}, fmt.Errorf("failed to write report CSV: %w", err)
+	}
+
+	return Mail{
+		Subject: fmt.Sprintf("Laporan Penjualan %s - %s", report.Date, restaurantName),
+		Text:    text.String(),
+		HTML: fmt.Sprintf("<h2>Laporan Penjualan %s</h2><p>%s</p><p>Jumlah order: %d<br>Penjualan: %s<br>Tip: %s</p>"+
+			"<table cellpadding=\"4\"><tr><th>Metode</th><th>Transaksi</th><th>Penjualan</th><th>Tip</th></tr>%s</table>",
+			html.EscapeString(report.Date), html.EscapeString(restaurantName), report.OrderCount,
+			utils.FormatCurrencyIDR(report.Sales), utils.FormatCurrencyIDR(report.Tips), rows.String()),
+		Attachments: []MailAttachment{{
+			Filename:    "penjualan-" + report.Date + ".csv",
+			ContentType: "text/csv",
+			Data:        attachment.Bytes(),
+		}},
+	}, nil
+}
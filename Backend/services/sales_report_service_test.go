@@ -0,0 +1,90 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+func TestSalesReportService_RunDue(t *testing.T) {
+	db := newPaymentTestDB(t)
+	if err := db.AutoMigrate(&models.RestaurantProfile{}, &models.ReportSchedule{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	InvalidateRestaurantProfile()
+	t.Cleanup(InvalidateRestaurantProfile)
+	NewRestaurantProfileService(db)
+
+	mailer := &recordingMailer{}
+	SetMailer(mailer)
+	t.Cleanup(func() { SetMailer(nil) })
+
+	jakarta, _ := time.LoadLocation(DefaultRestaurantTimezone)
+	yesterday := time.Date(2026, 10, 17, 21, 0, 0, 0, jakarta)
+	today := time.Date(2026, 10, 18, 0, 30, 0, 0, jakarta)
+	payments := []models.Payment{
+		{OrderID: 1, Amount: utils.Rupiah(50000), Tip: utils.Rupiah(5000), Status: PaymentStatusSuccess, PaymentMethod: "cash", PaymentTime: &yesterday},
+		{OrderID: 1, Amount: utils.Rupiah(20000), Status: PaymentStatusSuccess, PaymentMethod: "qris", PaymentTime: &yesterday},
+		{OrderID: 2, Amount: utils.Rupiah(30000), Status: PaymentStatusSuccess, PaymentMethod: "cash", PaymentTime: &yesterday},
+		{OrderID: 3, Amount: utils.Rupiah(99000), Status: PaymentStatusPending, PaymentMethod: "qris", PaymentTime: &yesterday},
+		{OrderID: 4, Amount: utils.Rupiah(10000), Status: PaymentStatusSuccess, PaymentMethod: "cash", PaymentTime: &today},
+	}
+	for i := range payments {
+		if err := db.Create(&payments[i]).Error; err != nil {
+			t.Fatalf("failed to create payment: %v", err)
+		}
+	}
+
+	service := NewSalesReportService(db)
+	due, err := service.CreateSchedule(ReportScheduleInput{Name: "Owner", Recipients: "owner@warung.test, manager@warung.test", SendTime: "07:00"}, nil)
+	if err != nil {
+		t.Fatalf("CreateSchedule() error = %v", err)
+	}
+	disabled := false
+	if _, err := service.CreateSchedule(ReportScheduleInput{Name: "Off", Recipients: "off@warung.test", SendTime: "07:00", Enabled: &disabled}, nil); err != nil {
+		t.Fatalf("CreateSchedule() error = %v", err)
+	}
+	if _, err := service.CreateSchedule(ReportScheduleInput{Name: "Bad", Recipients: "owner@warung.test", SendTime: "7:00"}, nil); err == nil {
+		t.Errorf("CreateSchedule() with invalid send_time succeeded")
+	}
+
+	steps := []struct {
+		name     string
+		now      time.Time
+		wantSent int
+	}{
+		{name: "before send time", now: time.Date(2026, 10, 18, 6, 59, 0, 0, jakarta), wantSent: 0},
+		{name: "at send time", now: time.Date(2026, 10, 18, 7, 0, 0, 0, jakarta), wantSent: 1},
+		{name: "already sent today", now: time.Date(2026, 10, 18, 9, 0, 0, 0, jakarta), wantSent: 1},
+	}
+	for _, step := range steps {
+		service.now = func() time.Time { return step.now }
+		service.RunDue()
+		if len(mailer.sent) != step.wantSent {
+			t.Fatalf("%s: sent %d mails, want %d", step.name, len(mailer.sent), step.wantSent)
+		}
+	}
+
+	msg := mailer.sent[0]
+	if strings.Join(msg.To, ",") != "owner@warung.test,manager@warung.test" {
+		t.Errorf("To = %v", msg.To)
+	}
+	if !strings.Contains(msg.Subject, "2026-10-17") {
+		t.Errorf("Subject = %q, want yesterday's date", msg.Subject)
+	}
+	if !strings.Contains(msg.Text, "Jumlah order: 2") || !strings.Contains(msg.Text, utils.FormatCurrencyIDR(utils.Rupiah(100000))) {
+		t.Errorf("Text = %q, want 2 orders and Rp 100.000", msg.Text)
+	}
+	if len(msg.Attachments) != 1 || !strings.Contains(string(msg.Attachments[0].Data), "cash,2,80000.00,5000.00") {
+		t.Errorf("CSV attachment = %q", msg.Attachments)
+	}
+
+	var stored models.ReportSchedule
+	db.First(&stored, due.ID)
+	if stored.LastSentOn != "2026-10-18" || stored.LastError != "" {
+		t.Errorf("schedule after send = %+v", stored)
+	}
+}
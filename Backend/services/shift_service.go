@@ -0,0 +1,426 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Error operasi shift kasir
+var (
+	ErrShiftAlreadyOpen    = errors.New("cashier already has an open shift")
+	ErrNoOpenShift         = errors.New("no open shift for this cashier")
+	ErrShiftClosed         = errors.New("shift is already closed")
+	ErrShiftNotOwned       = errors.New("shift belongs to another cashier")
+	ErrCashCountRequired   = errors.New("counted cash is required to close a shift")
+	ErrInvalidCashMovement = errors.New("invalid cash movement")
+)
+
+// ShiftService menangani shift kasir, pergerakan kas dan Z report
+type ShiftService struct {
+	db *gorm.DB
+}
+
+// NewShiftService membuat instance baru ShiftService
+func NewShiftService(db *gorm.DB) *ShiftService {
+	return &ShiftService{db: db}
+}
+
+// ShiftCloseRequest berisi hasil hitungan kasir saat menutup shift.
+// Counts berisi jumlah per metode pembayaran; "cash" wajib diisi.
+type ShiftCloseRequest struct {
+	ClosedBy    uint
+	AsAdmin     bool // admin boleh menutup shift kasir lain
+	Counts      map[string]utils.Money
+	ClosingNote string
+}
+
+// ZReport adalah ringkasan akhir shift: penjualan, pergerakan kas dan selisih hitungan
+type ZReport struct {
+	Shift           models.CashierShift        `json:"shift"`
+	CashierName     string                     `json:"cashier_name"`
+	Lines           []models.CashierShiftCount `json:"lines"`
+	Movements       []models.CashMovement      `json:"movements"`
+	PaidIn          utils.Money                `json:"paid_in"`
+	PaidOut         utils.Money                `json:"paid_out"`
+	TotalSales      utils.Money                `json:"total_sales"`
+	TotalTips       utils.Money                `json:"total_tips"`
+	TotalVariance   utils.Money                `json:"total_variance"`
+	VarianceFlagged bool                       `json:"variance_flagged"`
+	Tolerance       utils.Money                `json:"tolerance"`
+}
+
+// OpenShift membuka shift baru dengan modal awal. Satu kasir hanya boleh punya satu shift terbuka.
+func (s *ShiftService) OpenShift(cashierID uint, openingFloat utils.Money, note string) (*models.CashierShift, error) {
+	if openingFloat < 0 {
+		return nil, errors.New("opening float cannot be negative")
+	}
+
+	var shift *models.CashierShift
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Kunci baris user agar dua request open bersamaan tidak membuat dua shift
+		var cashier models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&cashier, cashierID).Error; err != nil {
+			return fmt.Errorf("cashier not found: %w", err)
+		}
+
+		var open int64
+		tx.Model(&models.CashierShift{}).Where("cashier_id = ? AND status = ?", cashierID, models.ShiftStatusOpen).Count(&open)
+		if open > 0 {
+			return ErrShiftAlreadyOpen
+		}
+
+		shift = &models.CashierShift{
+			CashierID:    cashierID,
+			Status:       models.ShiftStatusOpen,
+			OpeningFloat: openingFloat,
+			OpeningNote:  note,
+			OpenedAt:     time.Now(),
+		}
+		return tx.Create(shift).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Shift %d opened by cashier %d with float %s", shift.ID, cashierID, openingFloat)
+	return shift, nil
+}
+
+// GetOpenShift mengembalikan shift yang sedang terbuka milik kasir
+func (s *ShiftService) GetOpenShift(cashierID uint) (*models.CashierShift, error) {
+	var shift models.CashierShift
+	err := s.db.Where("cashier_id = ? AND status = ?", cashierID, models.ShiftStatusOpen).First(&shift).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoOpenShift
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// RecordPayment menyimpan payment dan menautkannya ke shift terbuka milik kasir.
+// Jika requireShift true (pembayaran tunai), payment ditolak bila kasir tidak punya shift terbuka.
+func (s *ShiftService) RecordPayment(cashierID uint, payment *models.Payment, requireShift bool) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return recordShiftPayment(tx, cashierID, payment, requireShift)
+	})
+}
+
+// recordShiftPayment adalah bagian RecordPayment yang berjalan di dalam transaksi pemanggil
+func recordShiftPayment(tx *gorm.DB, cashierID uint, payment *models.Payment, requireShift bool) error {
+	// Lock shift agar payment tidak masuk ke shift yang sedang ditutup
+	var shift models.CashierShift
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("cashier_id = ? AND status = ?", cashierID, models.ShiftStatusOpen).
+		First(&shift).Error
+	switch {
+	case err == nil:
+		payment.ShiftID = &shift.ID
+		payment.VerifiedBy = &cashierID
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if requireShift {
+			return ErrNoOpenShift
+		}
+	default:
+		return err
+	}
+
+	return tx.Create(payment).Error
+}
+
+// AddCashMovement mencatat paid-in / paid-out pada shift yang masih terbuka
+func (s *ShiftService) AddCashMovement(shiftID, userID uint, asAdmin bool, movementType string, amount utils.Money, reason string) (*models.CashMovement, error) {
+	if movementType != models.CashMovementPaidIn && movementType != models.CashMovementPaidOut {
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidCashMovement, movementType)
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be greater than 0", ErrInvalidCashMovement)
+	}
+	if reason == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidCashMovement)
+	}
+
+	var movement *models.CashMovement
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		shift, err := lockShift(tx, shiftID, userID, asAdmin)
+		if err != nil {
+			return err
+		}
+
+		movement = &models.CashMovement{
+			ShiftID:   shift.ID,
+			Type:      movementType,
+			Amount:    amount,
+			Reason:    reason,
+			CreatedBy: userID,
+		}
+		return tx.Create(movement).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return movement, nil
+}
+
+// CloseShift menutup shift dengan hitungan kasir, menyimpan baris Z report per metode
+// dan menandai selisih yang melebihi toleransi (env CASH_VARIANCE_TOLERANCE)
+func (s *ShiftService) CloseShift(shiftID uint, req ShiftCloseRequest) (*ZReport, error) {
+	counted, ok := req.Counts["cash"]
+	if !ok {
+		return nil, ErrCashCountRequired
+	}
+	if counted < 0 {
+		return nil, errors.New("counted cash cannot be negative")
+	}
+
+	var report *ZReport
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		shift, err := lockShift(tx, shiftID, req.ClosedBy, req.AsAdmin)
+		if err != nil {
+			return err
+		}
+
+		report, err = buildZReport(tx, shift, req.Counts)
+		if err != nil {
+			return err
+		}
+
+		for i := range report.Lines {
+			report.Lines[i].ShiftID = shift.ID
+		}
+		if err := tx.Create(&report.Lines).Error; err != nil {
+			return fmt.Errorf("failed to store shift counts: %w", err)
+		}
+
+		now := time.Now()
+		cashLine := report.Lines[0]
+		shift.Status = models.ShiftStatusClosed
+		shift.ClosedAt = &now
+		shift.ClosingNote = req.ClosingNote
+		shift.ExpectedCash = cashLine.Expected
+		shift.CountedCash = cashLine.Counted
+		shift.Variance = report.TotalVariance
+		shift.VarianceFlagged = report.VarianceFlagged
+		if err := tx.Save(shift).Error; err != nil {
+			return fmt.Errorf("failed to close shift: %w", err)
+		}
+
+		if report.VarianceFlagged {
+			notification := models.Notification{
+				Title:   "Cash Variance",
+				Message: fmt.Sprintf("Shift #%d closed with variance %s (cash expected %s, counted %s)", shift.ID, report.TotalVariance, cashLine.Expected, counted),
+				Type:    "shift",
+				Status:  "unread",
+			}
+			if err := tx.Create(&notification).Error; err != nil {
+				return fmt.Errorf("failed to create variance notification: %w", err)
+			}
+		}
+
+		report.Shift = *shift
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Shift %d closed: expected cash %s, variance %s, flagged %v",
+		report.Shift.ID, report.Shift.ExpectedCash, report.TotalVariance, report.VarianceFlagged)
+	return report, nil
+}
+
+// GetZReport mengembalikan Z report shift. Shift yang masih terbuka dihitung
+// secara live (tanpa hitungan kasir), shift tertutup memakai baris yang tersimpan.
+func (s *ShiftService) GetZReport(shiftID uint) (*ZReport, error) {
+	var shift models.CashierShift
+	if err := s.db.Preload("Counts", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).First(&shift, shiftID).Error; err != nil {
+		return nil, err
+	}
+
+	if shift.Status == models.ShiftStatusOpen {
+		return buildZReport(s.db, &shift, nil)
+	}
+
+	report := &ZReport{
+		Shift:           shift,
+		Lines:           shift.Counts,
+		TotalVariance:   shift.Variance,
+		VarianceFlagged: shift.VarianceFlagged,
+		Tolerance:       cashVarianceTolerance(),
+	}
+	if err := fillMovements(s.db, report); err != nil {
+		return nil, err
+	}
+	for _, line := range report.Lines {
+		report.TotalSales += line.Sales
+		report.TotalTips += line.Tips
+	}
+	report.CashierName = cashierName(s.db, shift.CashierID)
+	report.Shift.Counts = nil
+	return report, nil
+}
+
+// ListShifts mengembalikan daftar shift (terbaru lebih dulu), bisa difilter per kasir/status/flag
+func (s *ShiftService) ListShifts(cashierID uint, status string, flaggedOnly bool, page, limit int) ([]models.CashierShift, int64, error) {
+	query := s.db.Model(&models.CashierShift{})
+	if cashierID != 0 {
+		query = query.Where("cashier_id = ?", cashierID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if flaggedOnly {
+		query = query.Where("variance_flagged = ?", true)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	var shifts []models.CashierShift
+	err := query.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&shifts).Error
+	return shifts, total, err
+}
+
+// lockShift mengambil shift terbuka dengan lock dan memastikan pemiliknya benar
+func lockShift(tx *gorm.DB, shiftID, userID uint, asAdmin bool) (*models.CashierShift, error) {
+	var shift models.CashierShift
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&shift, shiftID).Error; err != nil {
+		return nil, err
+	}
+	if shift.Status != models.ShiftStatusOpen {
+		return nil, ErrShiftClosed
+	}
+	if shift.CashierID != userID && !asAdmin {
+		return nil, ErrShiftNotOwned
+	}
+	return &shift, nil
+}
+
+// buildZReport menghitung nilai expected per metode dari payment sukses di shift.
+// Baris cash selalu ada di urutan pertama: modal awal + penjualan tunai + paid-in - paid-out.
+func buildZReport(db *gorm.DB, shift *models.CashierShift, counts map[string]utils.Money) (*ZReport, error) {
+	report := &ZReport{
+		Shift:       *shift,
+		CashierName: cashierName(db, shift.CashierID),
+		Tolerance:   cashVarianceTolerance(),
+	}
+	if err := fillMovements(db, report); err != nil {
+		return nil, err
+	}
+
+	var sales []struct {
+		PaymentMethod string
+		PaymentCount  int
+		Total         utils.Money
+		Tips          utils.Money
+	}
+	err := db.Model(&models.Payment{}).
+		Select("payment_method, COUNT(*) AS payment_count, COALESCE(SUM("+netPaymentAmountSQL+"), 0) AS total, COALESCE(SUM("+netPaymentTipSQL+"), 0) AS tips").
+		Where("shift_id = ? AND status = ?", shift.ID, PaymentStatusSuccess).
+		Group("payment_method").
+		Scan(&sales).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum shift payments: %w", err)
+	}
+
+	lines := map[string]*models.CashierShiftCount{
+		"cash": {PaymentMethod: "cash"},
+	}
+	for _, row := range sales {
+		lines[row.PaymentMethod] = &models.CashierShiftCount{
+			PaymentMethod: row.PaymentMethod,
+			PaymentCount:  row.PaymentCount,
+			Sales:         row.Total,
+			Tips:          row.Tips,
+		}
+	}
+	// Metode yang dihitung kasir tetapi tidak punya penjualan tetap dilaporkan
+	for method := range counts {
+		if _, ok := lines[method]; !ok {
+			lines[method] = &models.CashierShiftCount{PaymentMethod: method}
+		}
+	}
+
+	methods := make([]string, 0, len(lines))
+	for method := range lines {
+		if method != "cash" {
+			methods = append(methods, method)
+		}
+	}
+	sort.Strings(methods)
+	methods = append([]string{"cash"}, methods...)
+
+	for _, method := range methods {
+		line := lines[method]
+		// Tip ikut masuk laci / rekening sehingga dihitung dalam expected
+		line.Expected = line.Sales + line.Tips
+		if method == "cash" {
+			line.Expected = shift.OpeningFloat + line.Sales + line.Tips + report.PaidIn - report.PaidOut
+		}
+		if value, ok := counts[method]; ok {
+			counted := value
+			line.Counted = &counted
+			line.Variance = counted - line.Expected
+			if line.Variance.Abs() > report.Tolerance {
+				report.VarianceFlagged = true
+			}
+		}
+		report.TotalSales += line.Sales
+		report.TotalTips += line.Tips
+		report.TotalVariance += line.Variance
+		report.Lines = append(report.Lines, *line)
+	}
+	return report, nil
+}
+
+func fillMovements(db *gorm.DB, report *ZReport) error {
+	if err := db.Where("shift_id = ?", report.Shift.ID).Order("id ASC").Find(&report.Movements).Error; err != nil {
+		return fmt.Errorf("failed to load cash movements: %w", err)
+	}
+	for _, movement := range report.Movements {
+		if movement.Type == models.CashMovementPaidIn {
+			report.PaidIn += movement.Amount
+		} else {
+			report.PaidOut += movement.Amount
+		}
+	}
+	return nil
+}
+
+func cashierName(db *gorm.DB, cashierID uint) string {
+	var cashier models.User
+	if err := db.Select("id", "name").First(&cashier, cashierID).Error; err != nil {
+		return ""
+	}
+	return cashier.Name
+}
+
+// cashVarianceTolerance membaca toleransi selisih kas dari env (default 0: setiap selisih ditandai)
+func cashVarianceTolerance() utils.Money {
+	value, err := utils.ParseMoney(os.Getenv("CASH_VARIANCE_TOLERANCE"))
+	if err != nil || value < 0 {
+		return 0
+	}
+	return value
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+func newShiftTestDB(t *testing.T) (*gorm.DB, models.User) {
+	t.Helper()
+
+	db := newPaymentTestDB(t)
+	if err := db.AutoMigrate(&models.User{}, &models.Notification{}, &models.CashierShift{},
+		&models.CashMovement{}, &models.CashierShiftCount{}); err != nil {
+		t.Fatalf("failed to migrate shift tables: %v", err)
+	}
+
+	cashier := models.User{Name: "Kasir Satu", Email: "kasir1@example.com", Password: "x", Role: "staff"}
+	if err := db.Create(&cashier).Error; err != nil {
+		t.Fatalf("failed to create cashier: %v", err)
+	}
+	return db, cashier
+}
+
+func TestShiftService_CloseShiftZReport(t *testing.T) {
+	tests := []struct {
+		name        string
+		counts      map[string]utils.Money
+		tolerance   string
+		wantFlagged bool
+		wantCash    utils.Money // selisih kas
+	}{
+		{name: "exact count", counts: map[string]utils.Money{"cash": utils.Rupiah(185000), "qris": utils.Rupiah(40000)}},
+		{name: "short cash", counts: map[string]utils.Money{"cash": utils.Rupiah(180000)}, wantFlagged: true, wantCash: utils.Rupiah(-5000)},
+		{name: "short within tolerance", counts: map[string]utils.Money{"cash": utils.Rupiah(184500)}, tolerance: "1000", wantCash: utils.Rupiah(-500)},
+		{name: "qris slip mismatch", counts: map[string]utils.Money{"cash": utils.Rupiah(185000), "qris": utils.Rupiah(30000)}, wantFlagged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CASH_VARIANCE_TOLERANCE", tt.tolerance)
+			db, cashier := newShiftTestDB(t)
+			svc := NewShiftService(db)
+
+			shift, err := svc.OpenShift(cashier.ID, utils.Rupiah(100000), "")
+			if err != nil {
+				t.Fatalf("OpenShift() error = %v", err)
+			}
+
+			// Penjualan: tunai 50.000 + 45.000, QRIS 50.000 yang direfund 10.000, satu QRIS pending tidak dihitung
+			payments := []models.Payment{
+				{OrderID: 1, Amount: utils.Rupiah(50000), Status: PaymentStatusSuccess, PaymentMethod: "cash"},
+				{OrderID: 2, Amount: utils.Rupiah(45000), Status: PaymentStatusSuccess, PaymentMethod: "cash"},
+				{OrderID: 3, Amount: utils.Rupiah(50000), RefundedAmount: utils.Rupiah(10000), Status: PaymentStatusSuccess, PaymentMethod: "qris"},
+				{OrderID: 4, Amount: utils.Rupiah(10000), Status: PaymentStatusPending, PaymentMethod: "qris"},
+			}
+			for i := range payments {
+				if err := svc.RecordPayment(cashier.ID, &payments[i], payments[i].PaymentMethod == "cash"); err != nil {
+					t.Fatalf("RecordPayment() error = %v", err)
+				}
+			}
+			if payments[0].ShiftID == nil || *payments[0].ShiftID != shift.ID || payments[0].VerifiedBy == nil {
+				t.Fatalf("cash payment not linked to shift/cashier: %+v", payments[0])
+			}
+
+			if _, err := svc.AddCashMovement(shift.ID, cashier.ID, false, models.CashMovementPaidIn, utils.Rupiah(10000), "tambah kembalian"); err != nil {
+				t.Fatalf("AddCashMovement() error = %v", err)
+			}
+			if _, err := svc.AddCashMovement(shift.ID, cashier.ID, false, models.CashMovementPaidOut, utils.Rupiah(20000), "beli es batu"); err != nil {
+				t.Fatalf("AddCashMovement() error = %v", err)
+			}
+
+			report, err := svc.CloseShift(shift.ID, ShiftCloseRequest{ClosedBy: cashier.ID, Counts: tt.counts})
+			if err != nil {
+				t.Fatalf("CloseShift() error = %v", err)
+			}
+
+			// 100.000 + 95.000 + 10.000 - 20.000
+			cash := report.Lines[0]
+			if cash.PaymentMethod != "cash" || cash.Expected != utils.Rupiah(185000) || cash.PaymentCount != 2 {
+				t.Errorf("cash line = %+v, want expected 185000 from 2 payments", cash)
+			}
+			if cash.Variance != tt.wantCash {
+				t.Errorf("cash variance = %s, want %s", cash.Variance, tt.wantCash)
+			}
+			if report.VarianceFlagged != tt.wantFlagged {
+				t.Errorf("variance flagged = %v, want %v", report.VarianceFlagged, tt.wantFlagged)
+			}
+			if report.TotalSales != utils.Rupiah(135000) {
+				t.Errorf("total sales = %s, want Rp 135.000", report.TotalSales)
+			}
+
+			var notifications int64
+			db.Model(&models.Notification{}).Where("type = ?", "shift").Count(&notifications)
+			if (notifications > 0) != tt.wantFlagged {
+				t.Errorf("variance notifications = %d, flagged = %v", notifications, tt.wantFlagged)
+			}
+
+			stored, err := svc.GetZReport(shift.ID)
+			if err != nil {
+				t.Fatalf("GetZReport() error = %v", err)
+			}
+			if stored.Shift.Status != models.ShiftStatusClosed || len(stored.Lines) != len(report.Lines) || stored.PaidOut != utils.Rupiah(20000) {
+				t.Errorf("stored Z report = %+v, want closed shift with %d lines", stored, len(report.Lines))
+			}
+		})
+	}
+}
+
+func TestShiftService_Rules(t *testing.T) {
+	db, cashier := newShiftTestDB(t)
+	svc := NewShiftService(db)
+
+	cash := models.Payment{OrderID: 1, Amount: utils.Rupiah(10000), Status: PaymentStatusSuccess, PaymentMethod: "cash"}
+	if err := svc.RecordPayment(cashier.ID, &cash, true); !errors.Is(err, ErrNoOpenShift) {
+		t.Errorf("RecordPayment() without shift error = %v, want %v", err, ErrNoOpenShift)
+	}
+	qris := models.Payment{OrderID: 2, Amount: utils.Rupiah(10000), Status: PaymentStatusPending, PaymentMethod: "qris"}
+	if err := svc.RecordPayment(cashier.ID, &qris, false); err != nil || qris.ShiftID != nil {
+		t.Errorf("RecordPayment() qris without shift = %v (shift %v), want stored without shift", err, qris.ShiftID)
+	}
+
+	shift, err := svc.OpenShift(cashier.ID, utils.Rupiah(50000), "")
+	if err != nil {
+		t.Fatalf("OpenShift() error = %v", err)
+	}
+	if _, err := svc.OpenShift(cashier.ID, utils.Rupiah(50000), ""); !errors.Is(err, ErrShiftAlreadyOpen) {
+		t.Errorf("second OpenShift() error = %v, want %v", err, ErrShiftAlreadyOpen)
+	}
+	if _, err := svc.AddCashMovement(shift.ID, cashier.ID+1, false, models.CashMovementPaidOut, utils.Rupiah(1000), "x"); !errors.Is(err, ErrShiftNotOwned) {
+		t.Errorf("AddCashMovement() by other user error = %v, want %v", err, ErrShiftNotOwned)
+	}
+	if _, err := svc.CloseShift(shift.ID, ShiftCloseRequest{ClosedBy: cashier.ID, Counts: map[string]utils.Money{"qris": 0}}); !errors.Is(err, ErrCashCountRequired) {
+		t.Errorf("CloseShift() without cash count error = %v, want %v", err, ErrCashCountRequired)
+	}
+	if _, err := svc.CloseShift(shift.ID, ShiftCloseRequest{ClosedBy: 999, AsAdmin: true, Counts: map[string]utils.Money{"cash": utils.Rupiah(50000)}}); err != nil {
+		t.Fatalf("admin CloseShift() error = %v", err)
+	}
+	if _, err := svc.AddCashMovement(shift.ID, cashier.ID, false, models.CashMovementPaidIn, utils.Rupiah(1000), "x"); !errors.Is(err, ErrShiftClosed) {
+		t.Errorf("AddCashMovement() on closed shift error = %v, want %v", err, ErrShiftClosed)
+	}
+}
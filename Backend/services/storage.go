@@ -0,0 +1,496 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Nama backend storage yang didukung (dipilih lewat env STORAGE_BACKEND)
+const (
+	StorageBackendLocal = "local"
+	StorageBackendS3    = "s3"
+)
+
+// Default konfigurasi storage
+const (
+	defaultLocalStorageDir = "public/uploads"
+	defaultS3Region        = "us-east-1"
+	defaultSignedURLExpiry = time.Hour
+	maxSignedURLExpiry     = 7 * 24 * time.Hour // Batas presigned URL SigV4
+	s3Timeout              = 30 * time.Second
+)
+
+// ErrObjectNotFound dikembalikan jika objek tidak ada di storage
+var ErrObjectNotFound = errors.New("object not found")
+
+// ErrInvalidObjectKey dikembalikan untuk key kosong, absolut atau berisi ".."
+var ErrInvalidObjectKey = errors.New("invalid object key")
+
+// ObjectStorage adalah kontrak penyimpanan file upload (disk lokal atau S3-compatible).
+// Key adalah path relatif, misalnya "menu_images/abc-large.jpg".
+type ObjectStorage interface {
+	// Name mengembalikan nama backend, misalnya "local" atau "s3"
+	Name() string
+	// Put menyimpan (atau menimpa) objek
+	Put(key string, data []byte, contentType string) error
+	// Get membaca objek; ErrObjectNotFound jika tidak ada
+	Get(key string) ([]byte, error)
+	// Delete menghapus objek; objek yang tidak ada tidak dianggap error
+	Delete(key string) error
+	// URL mengembalikan URL permanen objek, yang disimpan di database
+	URL(key string) string
+	// KeyFromURL kebalikan URL; false jika URL bukan milik storage ini
+	KeyFromURL(rawURL string) (string, bool)
+	// SignedURL mengembalikan URL sementara untuk membaca objek
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
+
+var (
+	storage     ObjectStorage
+	storageOnce sync.Once
+	storageMu   sync.RWMutex
+)
+
+// GetStorage mengembalikan storage aktif sesuai env STORAGE_BACKEND (default local)
+func GetStorage() ObjectStorage {
+	storageOnce.Do(func() {
+		backend := NewStorage(os.Getenv("STORAGE_BACKEND"))
+
+		storageMu.Lock()
+		if storage == nil {
+			storage = backend
+		}
+		storageMu.Unlock()
+
+		log.Printf("Object storage initialized: %s", backend.Name())
+	})
+
+	storageMu.RLock()
+	defer storageMu.RUnlock()
+	return storage
+}
+
+// SetStorage mengganti storage aktif (dipakai untuk test)
+func SetStorage(s ObjectStorage) {
+	storageOnce.Do(func() {})
+
+	storageMu.Lock()
+	defer storageMu.Unlock()
+	storage = s
+}
+
+// NewStorage membuat backend storage dari namanya dengan konfigurasi dari env.
+// Nama yang tidak dikenal memakai disk lokal.
+func NewStorage(name string) ObjectStorage {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case StorageBackendS3:
+		return &S3Storage{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    envString("S3_REGION", defaultS3Region),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") != "false",
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+		}
+	case "", StorageBackendLocal:
+	default:
+		log.Printf("WARNING: unknown STORAGE_BACKEND %q, using local storage", name)
+	}
+	return &LocalStorage{Dir: envString("LOCAL_STORAGE_DIR", defaultLocalStorageDir)}
+}
+
+// SignedURLExpiry adalah masa berlaku URL sementara (env STORAGE_URL_EXPIRY, default 1 jam)
+func SignedURLExpiry() time.Duration {
+	expiry, err := time.ParseDuration(os.Getenv("STORAGE_URL_EXPIRY"))
+	if err != nil || expiry <= 0 {
+		return defaultSignedURLExpiry
+	}
+	return min(expiry, maxSignedURLExpiry)
+}
+
+// CleanObjectKey menormalkan key dan menolak key yang keluar dari root storage
+func CleanObjectKey(key string) (string, error) {
+	cleaned := path.Clean("/" + strings.TrimSpace(key))[1:]
+	if cleaned == "" || cleaned != strings.TrimPrefix(strings.TrimSpace(key), "/") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidObjectKey, key)
+	}
+	return cleaned, nil
+}
+
+// keyAfter mengambil key setelah penanda path (mis. "/uploads/") pada URL
+func keyAfter(rawURL, marker string) (string, bool) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	idx := strings.Index(parsed.Path, marker)
+	if idx < 0 {
+		return "", false
+	}
+	key, err := CleanObjectKey(parsed.Path[idx+len(marker):])
+	return key, err == nil
+}
+
+// LocalStorage menyimpan objek di disk dan disajikan lewat /uploads
+type LocalStorage struct {
+	Dir string
+}
+
+func (s *LocalStorage) Name() string {
+	return StorageBackendLocal
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	cleaned, err := CleanObjectKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(cleaned)), nil
+}
+
+func (s *LocalStorage) Put(key string, data []byte, contentType string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return os.WriteFile(p, data, 0644)
+}
+
+func (s *LocalStorage) Get(key string) ([]byte, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	return data, err
+}
+
+func (s *LocalStorage) Delete(key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// URL memakai base URL publik dari profil restoran
+func (s *LocalStorage) URL(key string) string {
+	profile := CurrentRestaurantProfile()
+	return profile.PublicURL("/uploads/" + key)
+}
+
+// KeyFromURL hanya melihat path setelah /uploads/, sehingga URL lama tetap dikenali
+// walaupun base URL di profil sudah berubah
+func (s *LocalStorage) KeyFromURL(rawURL string) (string, bool) {
+	return keyAfter(rawURL, "/uploads/")
+}
+
+// SignedURL sama dengan URL karena file lokal disajikan publik
+func (s *LocalStorage) SignedURL(key string, ttl time.Duration) (string, error) {
+	return s.URL(key), nil
+}
+
+// S3Storage menyimpan objek di bucket S3-compatible (AWS S3, MinIO, R2, dll.) dengan
+// request bertanda tangan AWS Signature Version 4
+type S3Storage struct {
+	Endpoint  string // Mis. https://s3.ap-southeast-1.amazonaws.com atau http://minio:9000
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool   // endpoint/bucket/key (MinIO) alih-alih bucket.endpoint/key
+	PublicURL string // Opsional: URL publik bucket/CDN. Kosong = dibaca lewat presigned URL di /media/
+
+	Client *http.Client
+	now    func() time.Time
+}
+
+func (s *S3Storage) Name() string {
+	return StorageBackendS3
+}
+
+func (s *S3Storage) Put(key string, data []byte, contentType string) error {
+	resp, err := s.do(http.MethodPut, key, data, map[string]string{"Content-Type": contentType})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return s3Error(resp, "put", key)
+	}
+	return nil
+}
+
+func (s *S3Storage) Get(key string) ([]byte, error) {
+	resp, err := s.do(http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, s3Error(resp, "get", key)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (s *S3Storage) Delete(key string) error {
+	resp, err := s.do(http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return s3Error(resp, "delete", key)
+	}
+	return nil
+}
+
+// URL memakai PublicURL jika diatur; selain itu URL /media/ di aplikasi ini yang
+// mengarahkan ke presigned URL, sehingga URL di database tidak pernah kedaluwarsa
+func (s *S3Storage) URL(key string) string {
+	if s.PublicURL != "" {
+		return strings.TrimRight(s.PublicURL, "/") + "/" + s3EscapePath(key)
+	}
+	profile := CurrentRestaurantProfile()
+	return profile.PublicURL("/media/" + s3EscapePath(key))
+}
+
+func (s *S3Storage) KeyFromURL(rawURL string) (string, bool) {
+	if s.PublicURL != "" {
+		prefix := strings.TrimRight(s.PublicURL, "/") + "/"
+		if strings.HasPrefix(rawURL, prefix) {
+			key, err := url.PathUnescape(strings.TrimPrefix(rawURL, prefix))
+			if err != nil {
+				return "", false
+			}
+			key, err = CleanObjectKey(key)
+			return key, err == nil
+		}
+	}
+	return keyAfter(rawURL, "/media/")
+}
+
+// SignedURL membuat presigned GET URL (SigV4 query string)
+func (s *S3Storage) SignedURL(key string, ttl time.Duration) (string, error) {
+	cleaned, err := CleanObjectKey(key)
+	if err != nil {
+		return "", err
+	}
+	if ttl <= 0 || ttl > maxSignedURLExpiry {
+		ttl = maxSignedURLExpiry
+	}
+	target := s.objectURL(cleaned)
+	now := s.clock().UTC()
+	query := url.Values{
+		"X-Amz-Algorithm":     {"AWS4-HMAC-SHA256"},
+		"X-Amz-Credential":    {s.AccessKey + "/" + s.scope(now)},
+		"X-Amz-Date":          {now.Format("20060102T150405Z")},
+		"X-Amz-Expires":       {fmt.Sprintf("%d", int(ttl.Seconds()))},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	headers := map[string]string{"host": target.Host}
+	signature := s.signature(http.MethodGet, target.EscapedPath(), query, headers, "UNSIGNED-PAYLOAD", now)
+	query.Set("X-Amz-Signature", signature)
+	target.RawQuery = s3CanonicalQuery(query)
+	return target.String(), nil
+}
+
+// do mengirim request bertanda tangan SigV4 (header Authorization)
+func (s *S3Storage) do(method, key string, body []byte, extra map[string]string) (*http.Response, error) {
+	cleaned, err := CleanObjectKey(key)
+	if err != nil {
+		return nil, err
+	}
+	target := s.objectURL(cleaned)
+	req, err := http.NewRequest(method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 request: %w", err)
+	}
+
+	now := s.clock().UTC()
+	payloadHash := sha256Hex(body)
+	headers := map[string]string{
+		"host":                 target.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           now.Format("20060102T150405Z"),
+	}
+	for name, value := range extra {
+		if value != "" {
+			headers[strings.ToLower(name)] = value
+		}
+	}
+	signature := s.signature(method, target.EscapedPath(), url.Values{}, headers, payloadHash, now)
+
+	signedHeaders := s3SignedHeaders(headers)
+	for name, value := range headers {
+		if name != "host" {
+			req.Header.Set(name, value)
+		}
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, s.scope(now), signedHeaders, signature))
+	req.ContentLength = int64(len(body))
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: s3Timeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 %s %s failed: %w", strings.ToLower(method), cleaned, err)
+	}
+	return resp, nil
+}
+
+func (s *S3Storage) objectURL(key string) *url.URL {
+	endpoint, err := url.Parse(strings.TrimRight(s.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		endpoint = &url.URL{Scheme: "https", Host: "s3." + s.Region + ".amazonaws.com"}
+	}
+	target := *endpoint
+	escaped := "/" + s3EscapePath(key)
+	if s.PathStyle {
+		escaped = "/" + s.Bucket + escaped
+	} else {
+		target.Host = s.Bucket + "." + endpoint.Host
+	}
+	target.Path, _ = url.PathUnescape(escaped)
+	target.RawPath = escaped
+	return &target
+}
+
+func (s *S3Storage) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+func (s *S3Storage) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.Region + "/s3/aws4_request"
+}
+
+// signature menghitung tanda tangan SigV4 untuk satu request
+func (s *S3Storage) signature(method, escapedPath string, query url.Values, headers map[string]string, payloadHash string, now time.Time) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		escapedPath,
+		s3CanonicalQuery(query),
+		canonicalHeaders.String(),
+		s3SignedHeaders(headers),
+		payloadHash,
+	}, "\n")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.Format("20060102T150405Z"),
+		s.scope(now),
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func s3SignedHeaders(headers map[string]string) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ";")
+}
+
+// s3CanonicalQuery mengurutkan query dan meng-encode sesuai aturan SigV4 (spasi = %20)
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, s3Escape(key, true)+"="+s3Escape(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3EscapePath meng-encode key objek per segmen, "/" dipertahankan
+func s3EscapePath(key string) string {
+	return s3Escape(key, false)
+}
+
+// s3Escape meng-encode semua karakter kecuali A-Z a-z 0-9 - _ . ~ (dan "/" jika encodeSlash false)
+func s3Escape(value string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func s3Error(resp *http.Response, op, key string) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 %s %s failed with status %d: %s", op, key, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
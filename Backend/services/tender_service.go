@@ -0,0 +1,364 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Error validasi tender
+var (
+	ErrInvalidTender    = errors.New("invalid tender")
+	ErrUnderTender      = errors.New("cash tendered is less than the cash amount")
+	ErrTenderMismatch   = errors.New("tender amounts do not match the amount due")
+	ErrOrderAlreadyPaid = errors.New("order is already fully paid")
+	ErrTenderPending    = errors.New("order has a pending payment, wait for it or cancel it first")
+)
+
+// CashDenominationValues adalah pecahan Rupiah (uang kertas dan logam) yang diterima kasir
+var CashDenominationValues = []utils.Money{
+	utils.Rupiah(100000), utils.Rupiah(50000), utils.Rupiah(20000), utils.Rupiah(10000), utils.Rupiah(5000),
+	utils.Rupiah(2000), utils.Rupiah(1000), utils.Rupiah(500), utils.Rupiah(200), utils.Rupiah(100),
+}
+
+// TenderRequest adalah permintaan pelunasan satu bill dengan satu atau beberapa metode
+type TenderRequest struct {
+	OrderID        uint
+	CashierID      uint
+	Tenders        []TenderLine
+	TipRecipientID *uint // Staf penerima tip, nil berarti tip masuk pool
+}
+
+// TenderLine adalah satu bagian pembayaran. Untuk tunai, Tendered adalah uang yang diserahkan
+// pelanggan (boleh dikosongkan jika Denominations diisi) dan Amount adalah bagian bill yang dibayar tunai.
+// Tip dibayar di atas Amount dan tidak dihitung sebagai pelunasan bill.
+type TenderLine struct {
+	Method        string
+	Amount        utils.Money
+	Tip           utils.Money
+	Tendered      utils.Money
+	Denominations []DenominationCount
+}
+
+// DenominationCount adalah jumlah lembar/keping untuk satu pecahan
+type DenominationCount struct {
+	Value    utils.Money `json:"value"`
+	Quantity int         `json:"quantity"`
+}
+
+// TenderResult adalah hasil tender: payment yang dibuat, kembalian dan status order
+type TenderResult struct {
+	TenderGroup string           `json:"tender_group"`
+	AmountDue   utils.Money      `json:"amount_due"`
+	Change      utils.Money      `json:"change"`
+	OrderPaid   bool             `json:"order_paid"`
+	Payments    []models.Payment `json:"payments"`
+}
+
+// TenderService memproses pembayaran tunai dengan kembalian dan kombinasi tunai + QRIS
+type TenderService struct {
+	db       *gorm.DB
+	provider PaymentProvider
+}
+
+// NewTenderService membuat instance baru TenderService
+func NewTenderService(db *gorm.DB) *TenderService {
+	return &TenderService{db: db}
+}
+
+// Tender memvalidasi dan mencatat semua bagian pembayaran untuk satu order.
+// Bagian tunai langsung sukses (wajib ada shift terbuka), bagian QRIS dibuat pending di provider.
+// Order ditandai paid setelah semua bagian sukses.
+func (s *TenderService) Tender(req TenderRequest) (*TenderResult, error) {
+	cash, qris, err := validateTenderLines(req.Tenders)
+	if err != nil {
+		return nil, err
+	}
+
+	var order models.Order
+	if err := s.db.Preload("Customer").First(&order, req.OrderID).Error; err != nil {
+		return nil, fmt.Errorf("order not found: %w", err)
+	}
+	due, err := amountDue(s.db, &order)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTenderTotal(req.Tenders, due); err != nil {
+		return nil, err
+	}
+
+	result := &TenderResult{TenderGroup: uuid.New().String(), AmountDue: due}
+	now := time.Now()
+
+	var payments []*models.Payment
+	if cash != nil {
+		payments = append(payments, &models.Payment{
+			OrderID:        order.ID,
+			Amount:         cash.Amount,
+			Tip:            cash.Tip,
+			TipRecipientID: req.TipRecipientID,
+			Status:         PaymentStatusSuccess,
+			PaymentMethod:  "cash",
+			ReferenceID:    "CSH-" + uuid.New().String(),
+			CashReceived:   cash.Tendered,
+			Change:         cash.Tendered - cash.Amount - cash.Tip,
+			PaymentTime:    &now,
+			TenderGroup:    result.TenderGroup,
+		})
+		result.Change = cash.Tendered - cash.Amount - cash.Tip
+	}
+
+	// Charge QRIS dibuat sebelum transaksi database karena memanggil API eksternal
+	var charge *ChargeResult
+	provider := s.paymentProvider()
+	if qris != nil {
+		orderRef := fmt.Sprintf("ORDER-%d-%s", order.ID, result.TenderGroup[:8])
+		charge, err = provider.CreateCharge(ChargeRequest{
+			OrderRef:      orderRef,
+			Amount:        qris.Amount + qris.Tip,
+			CustomerName:  order.GetCustomerName(),
+			CustomerEmail: order.GetCustomerEmail(),
+			ExpiryMinutes: PaymentExpiryMinutes(ChargeMethodQRIS),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create qris charge: %w", err)
+		}
+
+		payment := &models.Payment{
+			OrderID:           order.ID,
+			Amount:            qris.Amount,
+			Tip:               qris.Tip,
+			TipRecipientID:    req.TipRecipientID,
+			Status:            PaymentStatusPending,
+			PaymentMethod:     "qris",
+			PaymentType:       provider.Name(),
+			ReferenceID:       charge.TransactionID,
+			ProviderReference: &charge.OrderRef,
+			QRCode:            charge.QRString,
+			QRImageURL:        charge.QRImageURL,
+			ExpiredAt:         charge.ExpiresAt,
+			TenderGroup:       result.TenderGroup,
+		}
+		if payment.ExpiredAt == nil {
+			expiredAt := now.Add(time.Duration(PaymentExpiryMinutes(ChargeMethodQRIS)) * time.Minute)
+			payment.ExpiredAt = &expiredAt
+		}
+		payments = append(payments, payment)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock order lalu hitung ulang sisa tagihan agar dua tender bersamaan tidak dobel
+		var locked models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, order.ID).Error; err != nil {
+			return err
+		}
+		current, err := amountDue(tx, &locked)
+		if err != nil {
+			return err
+		}
+		if current != due {
+			return fmt.Errorf("%w: amount due changed to %s", ErrTenderMismatch, current)
+		}
+
+		for _, payment := range payments {
+			if err := recordShiftPayment(tx, req.CashierID, payment, payment.PaymentMethod == "cash"); err != nil {
+				return err
+			}
+		}
+		if cash != nil && len(cash.Denominations) > 0 {
+			rows := make([]models.CashDenomination, 0, len(cash.Denominations))
+			for _, d := range cash.Denominations {
+				rows = append(rows, models.CashDenomination{PaymentID: payments[0].ID, Value: d.Value, Quantity: d.Quantity})
+			}
+			if err := tx.Create(&rows).Error; err != nil {
+				return fmt.Errorf("failed to store denominations: %w", err)
+			}
+			payments[0].Denominations = rows
+		}
+
+		result.OrderPaid, err = settleOrderIfPaid(tx, order.ID)
+		return err
+	})
+	if err != nil {
+		// Charge QRIS yang tidak jadi tercatat dibatalkan agar tidak bisa dibayar
+		if charge != nil {
+			if cancelErr := provider.Cancel(charge.OrderRef); cancelErr != nil {
+				log.Printf("Error cancelling orphan charge %s: %v", charge.OrderRef, cancelErr)
+			}
+		}
+		return nil, err
+	}
+
+	for _, payment := range payments {
+		SchedulePaymentExpiry(payment)
+		result.Payments = append(result.Payments, *payment)
+	}
+	log.Printf("Order %d tendered: due %s, change %s, paid %v", order.ID, due, result.Change, result.OrderPaid)
+	return result, nil
+}
+
+// GetTenderPayments mengembalikan semua payment sukses dalam tender group yang sama dengan payment
+func GetTenderPayments(db *gorm.DB, payment *models.Payment) ([]models.Payment, error) {
+	if payment.TenderGroup == "" {
+		return []models.Payment{*payment}, nil
+	}
+
+	var payments []models.Payment
+	err := db.Preload("Denominations").
+		Where("tender_group = ? AND status = ?", payment.TenderGroup, PaymentStatusSuccess).
+		Order("id ASC").
+		Find(&payments).Error
+	return payments, err
+}
+
+func (s *TenderService) paymentProvider() PaymentProvider {
+	if s.provider != nil {
+		return s.provider
+	}
+	return GetPaymentProvider()
+}
+
+// validateTenderLines memeriksa setiap bagian tender dan mengembalikan bagian tunai dan QRIS
+func validateTenderLines(lines []TenderLine) (cash, qris *TenderLine, err error) {
+	if len(lines) == 0 {
+		return nil, nil, fmt.Errorf("%w: at least one tender is required", ErrInvalidTender)
+	}
+
+	for i := range lines {
+		line := &lines[i]
+		if line.Amount <= 0 {
+			return nil, nil, fmt.Errorf("%w: %s amount must be positive", ErrInvalidTender, line.Method)
+		}
+		if err := ValidateTip(line.Tip); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidTender, err)
+		}
+
+		switch line.Method {
+		case "cash":
+			if cash != nil {
+				return nil, nil, fmt.Errorf("%w: only one cash tender is allowed", ErrInvalidTender)
+			}
+			if err := validateCashLine(line); err != nil {
+				return nil, nil, err
+			}
+			cash = line
+		case "qris":
+			if qris != nil {
+				return nil, nil, fmt.Errorf("%w: only one qris tender is allowed", ErrInvalidTender)
+			}
+			if line.Tendered != 0 || len(line.Denominations) > 0 {
+				return nil, nil, fmt.Errorf("%w: tendered and denominations only apply to cash", ErrInvalidTender)
+			}
+			qris = line
+		default:
+			return nil, nil, fmt.Errorf("%w: unsupported method %q", ErrInvalidTender, line.Method)
+		}
+	}
+	return cash, qris, nil
+}
+
+// validateCashLine mengisi Tendered dari rincian pecahan bila perlu dan menolak uang yang kurang
+func validateCashLine(line *TenderLine) error {
+	if len(line.Denominations) > 0 {
+		var sum utils.Money
+		for _, d := range line.Denominations {
+			if d.Quantity <= 0 || !isCashDenomination(d.Value) {
+				return fmt.Errorf("%w: invalid denomination %s x %d", ErrInvalidTender, d.Value, d.Quantity)
+			}
+			sum += d.Value.Mul(d.Quantity)
+		}
+		if line.Tendered == 0 {
+			line.Tendered = sum
+		} else if line.Tendered != sum {
+			return fmt.Errorf("%w: denominations add up to %s, tendered %s", ErrInvalidTender, sum, line.Tendered)
+		}
+	}
+	if line.Tendered == 0 {
+		return fmt.Errorf("%w: cash tendered is required", ErrInvalidTender)
+	}
+	if line.Tendered < line.Amount+line.Tip {
+		return fmt.Errorf("%w: tendered %s, cash amount %s", ErrUnderTender, line.Tendered, line.Amount+line.Tip)
+	}
+	return nil
+}
+
+func isCashDenomination(value utils.Money) bool {
+	for _, v := range CashDenominationValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTenderTotal memastikan jumlah semua bagian sama dengan sisa tagihan
+func checkTenderTotal(lines []TenderLine, due utils.Money) error {
+	if due <= 0 {
+		return ErrOrderAlreadyPaid
+	}
+	var total utils.Money
+	for _, line := range lines {
+		total += line.Amount
+	}
+	if total != due {
+		return fmt.Errorf("%w: tenders %s, amount due %s", ErrTenderMismatch, total, due)
+	}
+	return nil
+}
+
+// amountDue menghitung sisa tagihan order: total order dikurangi payment yang sudah sukses.
+// Order dengan payment pending tidak boleh di-tender ulang.
+func amountDue(db *gorm.DB, order *models.Order) (utils.Money, error) {
+	var pending int64
+	if err := db.Model(&models.Payment{}).Where("order_id = ? AND status = ?", order.ID, PaymentStatusPending).
+		Count(&pending).Error; err != nil {
+		return 0, err
+	}
+	if pending > 0 {
+		return 0, ErrTenderPending
+	}
+
+	paid, err := paidAmount(db, order.ID)
+	if err != nil {
+		return 0, err
+	}
+	return order.TotalAmount - paid, nil
+}
+
+func paidAmount(db *gorm.DB, orderID uint) (utils.Money, error) {
+	var paid utils.Money
+	err := db.Model(&models.Payment{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("order_id = ? AND status = ?", orderID, PaymentStatusSuccess).
+		Scan(&paid).Error
+	return paid, err
+}
+
+// settleOrderIfPaid menandai order paid jika total payment sukses sudah menutup total order
+func settleOrderIfPaid(tx *gorm.DB, orderID uint) (bool, error) {
+	var order models.Order
+	if err := tx.First(&order, orderID).Error; err != nil {
+		return false, err
+	}
+	paid, err := paidAmount(tx, orderID)
+	if err != nil {
+		return false, err
+	}
+	if paid < order.TotalAmount {
+		return false, nil
+	}
+	if order.Status == OrderStatusPendingPayment {
+		if err := tx.Model(&models.Order{}).Where("id = ?", orderID).
+			Updates(map[string]interface{}{"status": OrderStatusPaid, "updated_at": time.Now()}).Error; err != nil {
+			return false, fmt.Errorf("failed to update order status: %w", err)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+func newTenderTestDB(t *testing.T) (*gorm.DB, models.User) {
+	t.Helper()
+
+	db, cashier := newShiftTestDB(t)
+	if err := db.AutoMigrate(&models.CashDenomination{}); err != nil {
+		t.Fatalf("failed to migrate denominations: %v", err)
+	}
+	if _, err := NewShiftService(db).OpenShift(cashier.ID, utils.Rupiah(100000), ""); err != nil {
+		t.Fatalf("OpenShift() error = %v", err)
+	}
+	return db, cashier
+}
+
+func TestTenderService_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		tenders []TenderLine
+		wantErr error
+	}{
+		{name: "under tender", tenders: []TenderLine{{Method: "cash", Amount: utils.Rupiah(30000), Tendered: utils.Rupiah(20000)}}, wantErr: ErrUnderTender},
+		{name: "missing tendered", tenders: []TenderLine{{Method: "cash", Amount: utils.Rupiah(30000)}}, wantErr: ErrInvalidTender},
+		{name: "denominations do not add up", tenders: []TenderLine{{Method: "cash", Amount: utils.Rupiah(30000), Tendered: utils.Rupiah(50000),
+			Denominations: []DenominationCount{{Value: utils.Rupiah(20000), Quantity: 2}}}}, wantErr: ErrInvalidTender},
+		{name: "unknown denomination", tenders: []TenderLine{{Method: "cash", Amount: utils.Rupiah(30000),
+			Denominations: []DenominationCount{{Value: utils.Rupiah(30000), Quantity: 1}}}}, wantErr: ErrInvalidTender},
+		{name: "two cash tenders", tenders: []TenderLine{{Method: "cash", Amount: utils.Rupiah(15000), Tendered: utils.Rupiah(15000)},
+			{Method: "cash", Amount: utils.Rupiah(15000), Tendered: utils.Rupiah(15000)}}, wantErr: ErrInvalidTender},
+		{name: "tenders below amount due", tenders: []TenderLine{{Method: "cash", Amount: utils.Rupiah(20000), Tendered: utils.Rupiah(50000)}}, wantErr: ErrTenderMismatch},
+		{name: "tendered on qris", tenders: []TenderLine{{Method: "qris", Amount: utils.Rupiah(30000), Tendered: utils.Rupiah(30000)}}, wantErr: ErrInvalidTender},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, cashier := newTenderTestDB(t)
+			order := models.Order{CustomerID: 1, Status: OrderStatusPendingPayment, TotalAmount: utils.Rupiah(30000)}
+			db.Create(&order)
+
+			svc := &TenderService{db: db, provider: NewFakePaymentProvider("")}
+			_, err := svc.Tender(TenderRequest{OrderID: order.ID, CashierID: cashier.ID, Tenders: tt.tenders})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Tender() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var payments int64
+			db.Model(&models.Payment{}).Count(&payments)
+			if payments != 0 {
+				t.Errorf("rejected tender stored %d payments", payments)
+			}
+		})
+	}
+}
+
+func TestTenderService_CashWithDenominations(t *testing.T) {
+	db, cashier := newTenderTestDB(t)
+	order := models.Order{CustomerID: 1, Status: OrderStatusPendingPayment, TotalAmount: utils.Rupiah(37500)}
+	db.Create(&order)
+
+	svc := &TenderService{db: db, provider: NewFakePaymentProvider("")}
+	result, err := svc.Tender(TenderRequest{OrderID: order.ID, CashierID: cashier.ID, Tenders: []TenderLine{{
+		Method: "cash", Amount: utils.Rupiah(37500),
+		Denominations: []DenominationCount{{Value: utils.Rupiah(20000), Quantity: 2}, {Value: utils.Rupiah(1000), Quantity: 1}},
+	}}})
+	if err != nil {
+		t.Fatalf("Tender() error = %v", err)
+	}
+
+	if result.Change != utils.Rupiah(3500) || !result.OrderPaid || len(result.Payments) != 1 {
+		t.Fatalf("Tender() = %+v, want change 3500 and order paid", result)
+	}
+	payment := result.Payments[0]
+	if payment.CashReceived != utils.Rupiah(41000) || payment.Change != utils.Rupiah(3500) || payment.ShiftID == nil || len(payment.Denominations) != 2 {
+		t.Errorf("cash payment = %+v, want tendered 41000, change 3500, linked shift and 2 denominations", payment)
+	}
+
+	db.First(&order, order.ID)
+	if order.Status != OrderStatusPaid {
+		t.Errorf("order status = %s, want %s", order.Status, OrderStatusPaid)
+	}
+	if _, err := svc.Tender(TenderRequest{OrderID: order.ID, CashierID: cashier.ID,
+		Tenders: []TenderLine{{Method: "cash", Amount: utils.Rupiah(1000), Tendered: utils.Rupiah(1000)}}}); !errors.Is(err, ErrOrderAlreadyPaid) {
+		t.Errorf("second Tender() error = %v, want %v", err, ErrOrderAlreadyPaid)
+	}
+}
+
+func TestTenderService_SplitCashAndQRIS(t *testing.T) {
+	db, cashier := newTenderTestDB(t)
+	order := models.Order{CustomerID: 1, Status: OrderStatusPendingPayment, TotalAmount: utils.Rupiah(30000)}
+	db.Create(&order)
+
+	fp := NewFakePaymentProvider("")
+	svc := &TenderService{db: db, provider: fp}
+	result, err := svc.Tender(TenderRequest{OrderID: order.ID, CashierID: cashier.ID, Tenders: []TenderLine{
+		{Method: "cash", Amount: utils.Rupiah(20000), Tendered: utils.Rupiah(20000)},
+		{Method: "qris", Amount: utils.Rupiah(10000)},
+	}})
+	if err != nil {
+		t.Fatalf("Tender() error = %v", err)
+	}
+	if result.OrderPaid || len(result.Payments) != 2 {
+		t.Fatalf("Tender() = %+v, want 2 payments and order not yet paid", result)
+	}
+	qris := result.Payments[1]
+	if qris.Status != PaymentStatusPending || qris.TenderGroup != result.TenderGroup || qris.ProviderReference == nil {
+		t.Fatalf("qris tender = %+v, want pending payment in the same tender group", qris)
+	}
+
+	// Tender lain ditolak selama bagian QRIS masih pending
+	if _, err := svc.Tender(TenderRequest{OrderID: order.ID, CashierID: cashier.ID,
+		Tenders: []TenderLine{{Method: "cash", Amount: utils.Rupiah(10000), Tendered: utils.Rupiah(10000)}}}); !errors.Is(err, ErrTenderPending) {
+		t.Errorf("Tender() while qris pending error = %v, want %v", err, ErrTenderPending)
+	}
+
+	payload, err := fp.Simulate(*qris.ProviderReference, FakeActionSettle)
+	if err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+	notification, err := fp.VerifyWebhook(payload)
+	if err != nil {
+		t.Fatalf("VerifyWebhook() error = %v", err)
+	}
+	if _, err := NewPaymentService(db).ApplyProviderNotification(notification); err != nil {
+		t.Fatalf("ApplyProviderNotification() error = %v", err)
+	}
+
+	db.First(&order, order.ID)
+	if order.Status != OrderStatusPaid {
+		t.Errorf("order status after qris settlement = %s, want %s", order.Status, OrderStatusPaid)
+	}
+
+	tenders, err := GetTenderPayments(db, &result.Payments[0])
+	if err != nil || len(tenders) != 2 {
+		t.Errorf("GetTenderPayments() = %d payments (%v), want 2", len(tenders), err)
+	}
+}
+
+func TestTenderService_TipOnTop(t *testing.T) {
+	db, cashier := newTenderTestDB(t)
+	order := models.Order{CustomerID: 1, Status: OrderStatusPendingPayment, TotalAmount: utils.Rupiah(45000)}
+	db.Create(&order)
+
+	fp := NewFakePaymentProvider("")
+	svc := &TenderService{db: db, provider: fp}
+	if _, err := svc.Tender(TenderRequest{OrderID: order.ID, CashierID: cashier.ID,
+		Tenders: []TenderLine{{Method: "cash", Amount: utils.Rupiah(45000), Tip: utils.Rupiah(5000), Tendered: utils.Rupiah(48000)}}}); !errors.Is(err, ErrUnderTender) {
+		t.Fatalf("Tender() not covering tip error = %v, want %v", err, ErrUnderTender)
+	}
+
+	result, err := svc.Tender(TenderRequest{OrderID: order.ID, CashierID: cashier.ID, Tenders: []TenderLine{
+		{Method: "cash", Amount: utils.Rupiah(25000), Tip: utils.Rupiah(2000), Tendered: utils.Rupiah(30000)},
+		{Method: "qris", Amount: utils.Rupiah(20000), Tip: utils.Rupiah(3000)},
+	}})
+	if err != nil {
+		t.Fatalf("Tender() error = %v", err)
+	}
+	if result.Change != utils.Rupiah(3000) {
+		t.Errorf("change = %s, want Rp 3.000 (tip is not returned)", result.Change)
+	}
+
+	// Tip QRIS ikut ditagihkan sehingga notifikasi provider sebesar bill + tip diterima
+	qris := result.Payments[1]
+	payload, _ := fp.Simulate(*qris.ProviderReference, FakeActionSettle)
+	notification, err := fp.VerifyWebhook(payload)
+	if err != nil {
+		t.Fatalf("VerifyWebhook() error = %v", err)
+	}
+	if notification.GrossAmount != "23000.00" {
+		t.Errorf("provider gross amount = %s, want 23000.00", notification.GrossAmount)
+	}
+	if _, err := NewPaymentService(db).ApplyProviderNotification(notification); err != nil {
+		t.Fatalf("ApplyProviderNotification() error = %v", err)
+	}
+
+	db.First(&order, order.ID)
+	if order.Status != OrderStatusPaid {
+		t.Errorf("order status = %s, want %s (tips do not count towards the bill)", order.Status, OrderStatusPaid)
+	}
+}
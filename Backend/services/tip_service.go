@@ -0,0 +1,230 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+// Mode pembagian tip yang tidak ditujukan ke staf tertentu
+const (
+	TipModePooled = "pooled" // dibagi rata ke semua staf yang punya shift di periode laporan
+	TipModeShift  = "shift"  // menjadi milik kasir pada shift tempat tip diterima
+)
+
+// ErrInvalidTip dikembalikan jika nominal tip tidak valid
+var ErrInvalidTip = errors.New("invalid tip")
+
+// defaultTipPercentages dipakai jika TIP_SUGGESTED_PERCENTAGES kosong
+var defaultTipPercentages = []float64{5, 10, 15}
+
+// TipSuggestion adalah pilihan tip yang ditampilkan ke pelanggan
+type TipSuggestion struct {
+	Percent float64     `json:"percent"`
+	Amount  utils.Money `json:"amount"`
+}
+
+// StaffTipShare adalah bagian tip satu staf dalam laporan distribusi
+type StaffTipShare struct {
+	UserID     uint        `json:"user_id"`
+	Name       string      `json:"name"`
+	Attributed utils.Money `json:"attributed"`
+	PoolShare  utils.Money `json:"pool_share"`
+	Total      utils.Money `json:"total"`
+}
+
+// ShiftTipSummary adalah total tip yang diterima dalam satu shift kasir
+type ShiftTipSummary struct {
+	ShiftID     uint        `json:"shift_id"`
+	CashierID   uint        `json:"cashier_id"`
+	CashierName string      `json:"cashier_name"`
+	Tips        utils.Money `json:"tips"`
+	TipCount    int         `json:"tip_count"`
+}
+
+// TipReport adalah laporan distribusi tip untuk satu periode
+type TipReport struct {
+	From       time.Time         `json:"from"`
+	To         time.Time         `json:"to"`
+	Mode       string            `json:"mode"`
+	TotalTips  utils.Money       `json:"total_tips"`
+	Attributed utils.Money       `json:"attributed"`
+	Pooled     utils.Money       `json:"pooled"`
+	Staff      []StaffTipShare   `json:"staff"`
+	Shifts     []ShiftTipSummary `json:"shifts"`
+}
+
+// TipService menangani saran tip dan laporan distribusi tip
+type TipService struct {
+	db *gorm.DB
+}
+
+// NewTipService membuat instance baru TipService
+func NewTipService(db *gorm.DB) *TipService {
+	return &TipService{db: db}
+}
+
+// ValidateTip memastikan tip tidak negatif
+func ValidateTip(tip utils.Money) error {
+	if tip < 0 {
+		return fmt.Errorf("%w: tip cannot be negative", ErrInvalidTip)
+	}
+	return nil
+}
+
+// TipSuggestedPercentages membaca persentase saran tip dari env TIP_SUGGESTED_PERCENTAGES (contoh "5,10,15")
+func TipSuggestedPercentages() []float64 {
+	raw := strings.TrimSpace(os.Getenv("TIP_SUGGESTED_PERCENTAGES"))
+	if raw == "" {
+		return defaultTipPercentages
+	}
+
+	var percentages []float64
+	for _, part := range strings.Split(raw, ",") {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || value <= 0 || value > 100 {
+			continue
+		}
+		percentages = append(percentages, value)
+	}
+	if len(percentages) == 0 {
+		return defaultTipPercentages
+	}
+	return percentages
+}
+
+// SuggestTips menghitung nominal tip dari total bill, dibulatkan ke rupiah penuh
+func SuggestTips(amount utils.Money) []TipSuggestion {
+	percentages := TipSuggestedPercentages()
+	suggestions := make([]TipSuggestion, 0, len(percentages))
+	for _, percent := range percentages {
+		suggestions = append(suggestions, TipSuggestion{
+			Percent: percent,
+			Amount:  amount.Percent(percent).RoundTo(utils.Rupiah(1)),
+		})
+	}
+	return suggestions
+}
+
+// TipDistributionMode membaca mode pembagian tip dari env TIP_DISTRIBUTION (default pooled)
+func TipDistributionMode() string {
+	if strings.ToLower(strings.TrimSpace(os.Getenv("TIP_DISTRIBUTION"))) == TipModeShift {
+		return TipModeShift
+	}
+	return TipModePooled
+}
+
+// DistributionReport menyusun laporan tip dari payment sukses dalam periode [from, to).
+// Tip dengan penerima langsung menjadi milik staf tersebut. Tip lain dibagi sesuai mode:
+// pooled dibagi rata ke staf yang membuka shift di periode ini, shift menjadi milik kasir shift.
+// Tip tanpa shift dan tanpa penerima selalu masuk pool.
+func (s *TipService) DistributionReport(from, to time.Time, mode string) (*TipReport, error) {
+	if mode == "" {
+		mode = TipDistributionMode()
+	}
+	if mode != TipModePooled && mode != TipModeShift {
+		return nil, fmt.Errorf("unknown tip distribution mode %q", mode)
+	}
+
+	var payments []models.Payment
+	err := s.db.Select("id", "tip", "tip_recipient_id", "shift_id").
+		Where("status = ? AND tip > 0 AND created_at >= ? AND created_at < ?", PaymentStatusSuccess, from, to).
+		Order("id ASC").
+		Find(&payments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tips: %w", err)
+	}
+
+	var shifts []models.CashierShift
+	err = s.db.Where("(opened_at >= ? AND opened_at < ?) OR id IN (?)", from, to,
+		s.db.Model(&models.Payment{}).Select("shift_id").
+			Where("status = ? AND tip > 0 AND created_at >= ? AND created_at < ? AND shift_id IS NOT NULL", PaymentStatusSuccess, from, to)).
+		Order("id ASC").
+		Find(&shifts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load shifts: %w", err)
+	}
+
+	report := &TipReport{From: from, To: to, Mode: mode}
+	staff := map[uint]*StaffTipShare{}
+	share := func(userID uint) *StaffTipShare {
+		if staff[userID] == nil {
+			staff[userID] = &StaffTipShare{UserID: userID}
+		}
+		return staff[userID]
+	}
+
+	// Anggota pool adalah staf yang membuka shift di periode laporan
+	shiftSummaries := map[uint]*ShiftTipSummary{}
+	var poolMembers []uint
+	for _, shift := range shifts {
+		shiftSummaries[shift.ID] = &ShiftTipSummary{ShiftID: shift.ID, CashierID: shift.CashierID}
+		if !shift.OpenedAt.Before(from) && shift.OpenedAt.Before(to) {
+			poolMembers = appendUnique(poolMembers, shift.CashierID)
+			share(shift.CashierID)
+		}
+	}
+
+	for _, payment := range payments {
+		report.TotalTips += payment.Tip
+
+		var summary *ShiftTipSummary
+		if payment.ShiftID != nil {
+			summary = shiftSummaries[*payment.ShiftID]
+		}
+		if summary != nil {
+			summary.Tips += payment.Tip
+			summary.TipCount++
+		}
+
+		switch {
+		case payment.TipRecipientID != nil:
+			share(*payment.TipRecipientID).Attributed += payment.Tip
+			report.Attributed += payment.Tip
+		case mode == TipModeShift && summary != nil:
+			share(summary.CashierID).Attributed += payment.Tip
+			report.Attributed += payment.Tip
+		default:
+			report.Pooled += payment.Tip
+		}
+	}
+
+	// Pool dibagi rata, sisa sen dibagikan mulai dari anggota pertama agar total tetap sama
+	if report.Pooled > 0 && len(poolMembers) > 0 {
+		for i, amount := range report.Pooled.Split(len(poolMembers)) {
+			share(poolMembers[i]).PoolShare += amount
+		}
+	}
+
+	for _, entry := range staff {
+		entry.Name = cashierName(s.db, entry.UserID)
+		entry.Total = entry.Attributed + entry.PoolShare
+		report.Staff = append(report.Staff, *entry)
+	}
+	sort.Slice(report.Staff, func(i, j int) bool { return report.Staff[i].UserID < report.Staff[j].UserID })
+
+	for _, shift := range shifts {
+		summary := shiftSummaries[shift.ID]
+		summary.CashierName = cashierName(s.db, shift.CashierID)
+		report.Shifts = append(report.Shifts, *summary)
+	}
+
+	return report, nil
+}
+
+func appendUnique(ids []uint, id uint) []uint {
+	for _, existing := range ids {
+		if existing == id {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+)
+
+func TestTipService_DistributionReport(t *testing.T) {
+	tests := []struct {
+		name           string
+		mode           string
+		wantPooled     utils.Money
+		wantAttributed utils.Money
+		wantTotals     map[string]utils.Money // total tip per nama staf
+	}{
+		// Tip tanpa penerima (7.000 + 3.001) dibagi rata; 2.000 langsung ke Kasir Dua
+		{name: "pooled", mode: TipModePooled, wantPooled: utils.Rupiah(10001), wantAttributed: utils.Rupiah(2000),
+			wantTotals: map[string]utils.Money{"Kasir Satu": utils.Money(500050), "Kasir Dua": utils.Money(700050)}},
+		// Tip shift menjadi milik kasir shift masing-masing
+		{name: "by shift", mode: TipModeShift, wantAttributed: utils.Rupiah(12001),
+			wantTotals: map[string]utils.Money{"Kasir Satu": utils.Rupiah(7000), "Kasir Dua": utils.Rupiah(5001)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, first := newShiftTestDB(t)
+			second := models.User{Name: "Kasir Dua", Email: "kasir2@example.com", Password: "x", Role: "staff"}
+			db.Create(&second)
+
+			shifts := NewShiftService(db)
+			for _, cashier := range []uint{first.ID, second.ID} {
+				if _, err := shifts.OpenShift(cashier, 0, ""); err != nil {
+					t.Fatalf("OpenShift() error = %v", err)
+				}
+			}
+
+			payments := []struct {
+				cashier   uint
+				payment   models.Payment
+				recipient *uint
+			}{
+				{cashier: first.ID, payment: models.Payment{OrderID: 1, Amount: utils.Rupiah(70000), Tip: utils.Rupiah(7000), Status: PaymentStatusSuccess, PaymentMethod: "cash"}},
+				{cashier: second.ID, payment: models.Payment{OrderID: 2, Amount: utils.Rupiah(30000), Tip: utils.Rupiah(3001), Status: PaymentStatusSuccess, PaymentMethod: "qris"}},
+				{cashier: first.ID, payment: models.Payment{OrderID: 3, Amount: utils.Rupiah(20000), Tip: utils.Rupiah(2000), Status: PaymentStatusSuccess, PaymentMethod: "cash"}, recipient: &second.ID},
+				{cashier: first.ID, payment: models.Payment{OrderID: 4, Amount: utils.Rupiah(10000), Tip: utils.Rupiah(9999), Status: PaymentStatusPending, PaymentMethod: "qris"}},
+			}
+			for i := range payments {
+				payments[i].payment.TipRecipientID = payments[i].recipient
+				if err := shifts.RecordPayment(payments[i].cashier, &payments[i].payment, false); err != nil {
+					t.Fatalf("RecordPayment() error = %v", err)
+				}
+			}
+
+			from := time.Now().Add(-time.Hour)
+			report, err := NewTipService(db).DistributionReport(from, from.Add(2*time.Hour), tt.mode)
+			if err != nil {
+				t.Fatalf("DistributionReport() error = %v", err)
+			}
+
+			if report.TotalTips != utils.Rupiah(12001) || report.Pooled != tt.wantPooled || report.Attributed != tt.wantAttributed {
+				t.Errorf("report totals = %s/%s/%s, want Rp 12.001/%s/%s",
+					report.TotalTips, report.Pooled, report.Attributed, tt.wantPooled, tt.wantAttributed)
+			}
+			if len(report.Staff) != len(tt.wantTotals) {
+				t.Fatalf("report has %d staff, want %d", len(report.Staff), len(tt.wantTotals))
+			}
+			for _, share := range report.Staff {
+				if share.Total != tt.wantTotals[share.Name] {
+					t.Errorf("%s total = %s, want %s", share.Name, share.Total, tt.wantTotals[share.Name])
+				}
+			}
+			if len(report.Shifts) != 2 || report.Shifts[0].Tips != utils.Rupiah(9000) || report.Shifts[1].Tips != utils.Rupiah(3001) {
+				t.Errorf("shift summaries = %+v, want 9000 and 3001", report.Shifts)
+			}
+		})
+	}
+}
+
+func TestSuggestTips(t *testing.T) {
+	t.Setenv("TIP_SUGGESTED_PERCENTAGES", "10, abc, 12.5")
+
+	suggestions := SuggestTips(utils.Rupiah(45500))
+	if len(suggestions) != 2 || suggestions[0].Amount != utils.Rupiah(4550) || suggestions[1].Amount != utils.Rupiah(5688) {
+		t.Errorf("SuggestTips() = %+v, want 10%% = 4550 and 12.5%% = 5688", suggestions)
+	}
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+// WebhookInboxService menyimpan notifikasi payment provider lalu memprosesnya secara idempotent
+type WebhookInboxService struct {
+	db *gorm.DB
+}
+
+// NewWebhookInboxService membuat instance baru WebhookInboxService
+func NewWebhookInboxService(db *gorm.DB) *WebhookInboxService {
+	return &WebhookInboxService{db: db}
+}
+
+// WebhookEventFilter adalah filter untuk daftar webhook di inbox
+type WebhookEventFilter struct {
+	Provider      string
+	ProcessStatus string
+	OrderRef      string
+	Page          int
+	Limit         int
+}
+
+// webhookEnvelope berisi field umum notifikasi (format Midtrans), dibaca tanpa verifikasi
+type webhookEnvelope struct {
+	OrderID           string `json:"order_id"`
+	TransactionID     string `json:"transaction_id"`
+	TransactionStatus string `json:"transaction_status"`
+	StatusCode        string `json:"status_code"`
+}
+
+// Receive menyimpan payload mentah beserta hasil verifikasi signature, lalu langsung memprosesnya.
+// Event selalu tersimpan, termasuk yang signature-nya tidak valid.
+func (s *WebhookInboxService) Receive(provider PaymentProvider, payload []byte) (*models.WebhookEvent, *models.Payment, error) {
+	event := &models.WebhookEvent{
+		Provider:      provider.Name(),
+		Payload:       string(payload),
+		ProcessStatus: models.WebhookStatusReceived,
+	}
+
+	var envelope webhookEnvelope
+	if err := json.Unmarshal(payload, &envelope); err == nil {
+		event.OrderRef = envelope.OrderID
+		event.TransactionID = envelope.TransactionID
+		event.TransactionStatus = envelope.TransactionStatus
+	}
+
+	notification, verifyErr := provider.VerifyWebhook(payload)
+	if verifyErr == nil {
+		event.SignatureValid = true
+		key := webhookEventKey(provider.Name(), notification)
+		event.EventKey = &key
+	}
+
+	// Unique index event_key memastikan notifikasi yang sama (termasuk yang datang
+	// bersamaan) hanya diterima dan diproses sekali
+	if err := s.db.Create(event).Error; err != nil {
+		if event.EventKey != nil && utils.IsDuplicateKeyError(err) {
+			return s.receiveDuplicate(event, notification)
+		}
+		return nil, nil, fmt.Errorf("failed to store webhook event: %w", err)
+	}
+
+	if verifyErr != nil {
+		status := models.WebhookStatusFailed
+		if errors.Is(verifyErr, ErrInvalidWebhookSignature) {
+			status = models.WebhookStatusInvalidSignature
+		}
+		s.finish(event, status, verifyErr.Error())
+		return event, nil, verifyErr
+	}
+
+	payment, err := s.process(event, notification)
+	return event, payment, err
+}
+
+// Reprocess memverifikasi ulang payload tersimpan dan memprosesnya kembali
+func (s *WebhookInboxService) Reprocess(eventID uint, provider PaymentProvider) (*models.WebhookEvent, *models.Payment, error) {
+	var event models.WebhookEvent
+	if err := s.db.First(&event, eventID).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if event.Provider != provider.Name() {
+		return &event, nil, fmt.Errorf("event was received from provider %s, active provider is %s", event.Provider, provider.Name())
+	}
+
+	notification, err := provider.VerifyWebhook([]byte(event.Payload))
+	if err != nil {
+		status := models.WebhookStatusFailed
+		if errors.Is(err, ErrInvalidWebhookSignature) {
+			status = models.WebhookStatusInvalidSignature
+		}
+		s.finish(&event, status, err.Error())
+		return &event, nil, err
+	}
+
+	event.SignatureValid = true
+	// Event yang dulu gagal diverifikasi belum punya key; jika key-nya sudah dimiliki event
+	// lain, notifikasi ini sudah pernah diterima
+	if event.EventKey == nil {
+		key := webhookEventKey(provider.Name(), notification)
+		err := s.db.Model(&event).Updates(map[string]interface{}{"event_key": key, "signature_valid": true}).Error
+		if utils.IsDuplicateKeyError(err) {
+			s.finish(&event, models.WebhookStatusDuplicate, "")
+			return &event, nil, nil
+		}
+		if err != nil {
+			return &event, nil, fmt.Errorf("failed to update webhook event: %w", err)
+		}
+		event.EventKey = &key
+	}
+
+	payment, err := s.process(&event, notification)
+	return &event, payment, err
+}
+
+// receiveDuplicate menyimpan notifikasi yang key-nya sudah dimiliki event lain, tanpa key.
+// Jika event asli gagal diproses (mis. payment belum tercatat), retry dari provider
+// memproses event asli tersebut sekali lagi.
+func (s *WebhookInboxService) receiveDuplicate(event *models.WebhookEvent, notification *WebhookNotification) (*models.WebhookEvent, *models.Payment, error) {
+	key := *event.EventKey
+	now := time.Now()
+	event.EventKey = nil
+	event.ProcessStatus = models.WebhookStatusDuplicate
+	event.Attempts = 1
+	event.ProcessedAt = &now
+	if err := s.db.Create(event).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to store webhook event: %w", err)
+	}
+
+	var original models.WebhookEvent
+	if err := s.db.Where("event_key = ?", key).First(&original).Error; err != nil {
+		return event, nil, fmt.Errorf("failed to load original webhook event: %w", err)
+	}
+	if original.ProcessStatus != models.WebhookStatusFailed {
+		return event, nil, nil
+	}
+	payment, err := s.process(&original, notification)
+	return event, payment, err
+}
+
+// DedupeWebhookEventKeys mengosongkan event_key ganda dari sebelum key dibuat unik, agar
+// unique index bisa dibuat oleh AutoMigrate. Per key dipertahankan event yang sudah
+// diproses (atau yang paling lama); sisanya menjadi duplikat tanpa key.
+func DedupeWebhookEventKeys(db *gorm.DB) (int, error) {
+	if !db.Migrator().HasTable(&models.WebhookEvent{}) {
+		return 0, nil
+	}
+	if err := db.Model(&models.WebhookEvent{}).Where("event_key = ?", "").UpdateColumn("event_key", nil).Error; err != nil {
+		return 0, fmt.Errorf("failed to clear empty webhook event keys: %w", err)
+	}
+
+	var events []models.WebhookEvent
+	err := db.Select("id", "event_key", "process_status").
+		Where("event_key IN (?)", db.Model(&models.WebhookEvent{}).Select("event_key").
+			Where("event_key IS NOT NULL").Group("event_key").Having("COUNT(*) > 1")).
+		Order("id ASC").
+		Find(&events).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to find duplicate webhook events: %w", err)
+	}
+
+	keep := make(map[string]*models.WebhookEvent)
+	for i := range events {
+		event := &events[i]
+		kept, ok := keep[*event.EventKey]
+		if !ok || (kept.ProcessStatus != models.WebhookStatusProcessed && event.ProcessStatus == models.WebhookStatusProcessed) {
+			keep[*event.EventKey] = event
+		}
+	}
+	var duplicates []uint
+	for _, event := range events {
+		if keep[*event.EventKey].ID != event.ID {
+			duplicates = append(duplicates, event.ID)
+		}
+	}
+	if len(duplicates) == 0 {
+		return 0, nil
+	}
+	if err := db.Model(&models.WebhookEvent{}).Where("id IN ?", duplicates).UpdateColumn("event_key", nil).Error; err != nil {
+		return 0, fmt.Errorf("failed to clear duplicate webhook event keys: %w", err)
+	}
+	return len(duplicates), nil
+}
+
+// List mengembalikan daftar webhook di inbox (terbaru lebih dulu) beserta total data
+func (s *WebhookInboxService) List(filter WebhookEventFilter) ([]models.WebhookEvent, int64, error) {
+	query := s.db.Model(&models.WebhookEvent{})
+	if filter.Provider != "" {
+		query = query.Where("provider = ?", filter.Provider)
+	}
+	if filter.ProcessStatus != "" {
+		query = query.Where("process_status = ?", filter.ProcessStatus)
+	}
+	if filter.OrderRef != "" {
+		query = query.Where("order_ref = ?", filter.OrderRef)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+
+	var events []models.WebhookEvent
+	err := query.Order("id DESC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&events).Error
+	return events, total, err
+}
+
+// process menerapkan notifikasi ke payment dan mencatat hasilnya di event
+func (s *WebhookInboxService) process(event *models.WebhookEvent, notification *WebhookNotification) (*models.Payment, error) {
+	payment, err := NewPaymentService(s.db).ApplyProviderNotification(notification)
+	if payment != nil {
+		event.PaymentID = &payment.ID
+	}
+
+	switch {
+	case err == nil:
+		s.finish(event, models.WebhookStatusProcessed, "")
+		return payment, nil
+	case errors.Is(err, ErrInvalidStatusTransition):
+		// Notifikasi terlambat / tidak berurutan: simpan tetapi jangan turunkan status
+		s.finish(event, models.WebhookStatusIgnored, err.Error())
+		return payment, nil
+	default:
+		s.finish(event, models.WebhookStatusFailed, err.Error())
+		return payment, err
+	}
+}
+
+// finish menyimpan hasil pemrosesan event
+func (s *WebhookInboxService) finish(event *models.WebhookEvent, status, processError string) {
+	now := time.Now()
+	event.ProcessStatus = status
+	event.ProcessError = processError
+	event.Attempts++
+	event.ProcessedAt = &now
+
+	if err := s.db.Save(event).Error; err != nil {
+		log.Printf("Error saving webhook event %d: %v", event.ID, err)
+	}
+}
+
+// webhookEventKey membentuk kunci idempotensi: satu transaksi dengan status yang sama
+// dianggap event yang sama walaupun dikirim berkali-kali oleh provider
+func webhookEventKey(provider string, notification *WebhookNotification) string {
+	ref := notification.TransactionID
+	if ref == "" {
+		ref = notification.OrderRef
+	}
+	return fmt.Sprintf("%s:%s:%s", provider, ref, notification.TransactionStatus)
+}
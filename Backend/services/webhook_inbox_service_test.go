@@ -0,0 +1,280 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yeremiapane/restaurant-app/models"
+	"github.com/yeremiapane/restaurant-app/utils"
+	"gorm.io/gorm"
+)
+
+func newWebhookTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db := newPaymentTestDB(t)
+	if err := db.AutoMigrate(&models.WebhookEvent{}); err != nil {
+		t.Fatalf("failed to migrate webhook events: %v", err)
+	}
+	return db
+}
+
+// createFakeQRISPayment membuat order + payment pending yang terhubung ke transaksi fake provider
+func createFakeQRISPayment(t *testing.T, db *gorm.DB, fp *FakePaymentProvider, orderRef string) (*models.Payment, *ChargeResult) {
+	t.Helper()
+
+	order := models.Order{CustomerID: 1, Status: OrderStatusPendingPayment, TotalAmount: utils.Rupiah(30000)}
+	if err := db.Create(&order).Error; err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+
+	charge, err := fp.CreateCharge(ChargeRequest{OrderRef: orderRef, Amount: utils.Rupiah(30000)})
+	if err != nil {
+		t.Fatalf("CreateCharge() error = %v", err)
+	}
+
+	payment := models.Payment{OrderID: order.ID, Amount: utils.Rupiah(30000), Status: PaymentStatusPending, PaymentMethod: "qris",
+		ReferenceID: charge.TransactionID, ProviderReference: strPtr(orderRef)}
+	if err := db.Create(&payment).Error; err != nil {
+		t.Fatalf("failed to create payment: %v", err)
+	}
+	return &payment, charge
+}
+
+func TestWebhookInbox_DuplicateAndOutOfOrder(t *testing.T) {
+	db := newWebhookTestDB(t)
+	fp := NewFakePaymentProvider("inbox-secret")
+	inbox := NewWebhookInboxService(db)
+
+	payment, charge := createFakeQRISPayment(t, db, fp, "ORDER-1-inbox001")
+
+	settle, err := fp.Simulate(charge.TransactionID, FakeActionSettle)
+	if err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+
+	// Notifikasi pending yang datang terlambat, ditandatangani dengan benar
+	latePending, err := fp.buildWebhook(&fakeTransaction{
+		TransactionID:     charge.TransactionID,
+		OrderRef:          charge.OrderRef,
+		Amount:            utils.Rupiah(30000),
+		TransactionStatus: "pending",
+	})
+	if err != nil {
+		t.Fatalf("buildWebhook() error = %v", err)
+	}
+
+	tampered := strings.Replace(string(settle), "30000.00", "1.00", 1)
+
+	tests := []struct {
+		name       string
+		payload    []byte
+		wantStatus string
+		wantErr    error
+	}{
+		{name: "first settlement", payload: settle, wantStatus: models.WebhookStatusProcessed},
+		{name: "repeated settlement", payload: settle, wantStatus: models.WebhookStatusDuplicate},
+		{name: "late pending", payload: latePending, wantStatus: models.WebhookStatusIgnored},
+		{name: "tampered payload", payload: []byte(tampered), wantStatus: models.WebhookStatusInvalidSignature,
+			wantErr: ErrInvalidWebhookSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, _, err := inbox.Receive(fp, tt.payload)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Receive() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Receive() error = %v", err)
+			}
+			if event.ProcessStatus != tt.wantStatus {
+				t.Errorf("Receive() process status = %s, want %s (%s)", event.ProcessStatus, tt.wantStatus, event.ProcessError)
+			}
+			if event.Payload != string(tt.payload) {
+				t.Errorf("Receive() payload was not stored raw")
+			}
+		})
+	}
+
+	var reloaded models.Payment
+	db.First(&reloaded, payment.ID)
+	if reloaded.Status != PaymentStatusSuccess {
+		t.Errorf("payment status = %s, want %s", reloaded.Status, PaymentStatusSuccess)
+	}
+
+	var stored int64
+	db.Model(&models.WebhookEvent{}).Count(&stored)
+	if stored != int64(len(tests)) {
+		t.Errorf("stored events = %d, want %d", stored, len(tests))
+	}
+}
+
+func TestWebhookInbox_Reprocess(t *testing.T) {
+	db := newWebhookTestDB(t)
+	fp := NewFakePaymentProvider("inbox-secret")
+	inbox := NewWebhookInboxService(db)
+
+	// Transaksi ada di provider tetapi payment lokal belum tercatat
+	charge, err := fp.CreateCharge(ChargeRequest{OrderRef: "ORDER-2-late0001", Amount: utils.Rupiah(30000)})
+	if err != nil {
+		t.Fatalf("CreateCharge() error = %v", err)
+	}
+	payload, err := fp.Simulate(charge.TransactionID, FakeActionSettle)
+	if err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+
+	event, _, err := inbox.Receive(fp, payload)
+	if !errors.Is(err, ErrPaymentNotFound) {
+		t.Fatalf("Receive() error = %v, want %v", err, ErrPaymentNotFound)
+	}
+	if event.ProcessStatus != models.WebhookStatusFailed {
+		t.Fatalf("Receive() process status = %s, want %s", event.ProcessStatus, models.WebhookStatusFailed)
+	}
+
+	order := models.Order{CustomerID: 1, Status: OrderStatusPendingPayment, TotalAmount: utils.Rupiah(30000)}
+	db.Create(&order)
+	payment := models.Payment{OrderID: order.ID, Amount: utils.Rupiah(30000), Status: PaymentStatusPending, PaymentMethod: "qris",
+		ProviderReference: strPtr("ORDER-2-late0001")}
+	db.Create(&payment)
+
+	event, reprocessed, err := inbox.Reprocess(event.ID, fp)
+	if err != nil {
+		t.Fatalf("Reprocess() error = %v", err)
+	}
+	if event.ProcessStatus != models.WebhookStatusProcessed || event.Attempts != 2 {
+		t.Errorf("Reprocess() status = %s attempts = %d, want processed after 2 attempts", event.ProcessStatus, event.Attempts)
+	}
+	if reprocessed == nil || reprocessed.Status != PaymentStatusSuccess {
+		t.Errorf("Reprocess() payment = %+v, want success", reprocessed)
+	}
+
+	// Reprocess kedua tidak boleh menerapkan event yang sama dua kali
+	event, _, err = inbox.Reprocess(event.ID, fp)
+	if err != nil {
+		t.Fatalf("Reprocess() error = %v", err)
+	}
+	if event.ProcessStatus != models.WebhookStatusProcessed {
+		t.Errorf("second Reprocess() status = %s, want %s", event.ProcessStatus, models.WebhookStatusProcessed)
+	}
+}
+
+func TestWebhookInbox_RetryAfterFailure(t *testing.T) {
+	db := newWebhookTestDB(t)
+	fp := NewFakePaymentProvider("inbox-secret")
+	inbox := NewWebhookInboxService(db)
+
+	charge, err := fp.CreateCharge(ChargeRequest{OrderRef: "ORDER-4-retry001", Amount: utils.Rupiah(30000)})
+	if err != nil {
+		t.Fatalf("CreateCharge() error = %v", err)
+	}
+	payload, err := fp.Simulate(charge.TransactionID, FakeActionSettle)
+	if err != nil {
+		t.Fatalf("Simulate() error = %v", err)
+	}
+
+	// Notifikasi pertama datang sebelum payment lokal tercatat
+	original, _, err := inbox.Receive(fp, payload)
+	if !errors.Is(err, ErrPaymentNotFound) {
+		t.Fatalf("Receive() error = %v, want %v", err, ErrPaymentNotFound)
+	}
+
+	order := models.Order{CustomerID: 1, Status: OrderStatusPendingPayment, TotalAmount: utils.Rupiah(30000)}
+	db.Create(&order)
+	payment := models.Payment{OrderID: order.ID, Amount: utils.Rupiah(30000), Status: PaymentStatusPending, PaymentMethod: "qris",
+		ProviderReference: strPtr("ORDER-4-retry001")}
+	db.Create(&payment)
+
+	// Retry provider dengan payload yang sama memproses event asli, bukan membuat key kedua
+	retry, processed, err := inbox.Receive(fp, payload)
+	if err != nil {
+		t.Fatalf("Receive(retry) error = %v", err)
+	}
+	if retry.ProcessStatus != models.WebhookStatusDuplicate || retry.EventKey != nil {
+		t.Errorf("retry event = %s with key %v, want duplicate without key", retry.ProcessStatus, retry.EventKey)
+	}
+	if processed == nil || processed.Status != PaymentStatusSuccess {
+		t.Errorf("Receive(retry) payment = %+v, want success", processed)
+	}
+	db.First(original, original.ID)
+	if original.ProcessStatus != models.WebhookStatusProcessed || original.Attempts != 2 {
+		t.Errorf("original event = %s after %d attempts, want processed after 2", original.ProcessStatus, original.Attempts)
+	}
+
+	// Key yang sama tidak bisa disimpan dua kali
+	duplicate := models.WebhookEvent{Provider: fp.Name(), EventKey: original.EventKey, Payload: string(payload)}
+	if err := db.Create(&duplicate).Error; !utils.IsDuplicateKeyError(err) {
+		t.Errorf("second event with the same key error = %v, want duplicate key error", err)
+	}
+}
+
+func TestDedupeWebhookEventKeys(t *testing.T) {
+	db := newPaymentTestDB(t)
+	// Skema lama: event_key hanya index biasa
+	if err := db.Exec(`CREATE TABLE webhook_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		provider VARCHAR(30) NOT NULL,
+		event_key VARCHAR(191),
+		process_status VARCHAR(20) NOT NULL DEFAULT 'received'
+	)`).Error; err != nil {
+		t.Fatalf("failed to create legacy table: %v", err)
+	}
+	rows := []struct {
+		key    string
+		status string
+	}{
+		{"fake:trx-1:settlement", models.WebhookStatusFailed},
+		{"fake:trx-1:settlement", models.WebhookStatusProcessed},
+		{"fake:trx-1:settlement", models.WebhookStatusDuplicate},
+		{"fake:trx-2:settlement", models.WebhookStatusProcessed},
+		{"", models.WebhookStatusInvalidSignature},
+		{"", models.WebhookStatusInvalidSignature},
+	}
+	for _, row := range rows {
+		db.Exec("INSERT INTO webhook_events (provider, event_key, process_status) VALUES ('fake', ?, ?)", row.key, row.status)
+	}
+
+	cleared, err := DedupeWebhookEventKeys(db)
+	if err != nil || cleared != 2 {
+		t.Fatalf("DedupeWebhookEventKeys() = %d, %v, want 2 cleared", cleared, err)
+	}
+
+	var kept []uint
+	db.Table("webhook_events").Where("event_key IS NOT NULL").Order("id").Pluck("id", &kept)
+	if len(kept) != 2 || kept[0] != 2 || kept[1] != 4 {
+		t.Errorf("events keeping their key = %v, want [2 4] (the processed ones)", kept)
+	}
+	if err := db.Exec("CREATE UNIQUE INDEX idx_webhook_events_event_key ON webhook_events(event_key)").Error; err != nil {
+		t.Errorf("unique event_key index after dedupe: %v", err)
+	}
+}
+
+func TestCanTransitionPaymentStatus(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{PaymentStatusPending, PaymentStatusSuccess, true},
+		{PaymentStatusPending, PaymentStatusExpired, true},
+		{PaymentStatusPending, PaymentStatusRefunded, false},
+		{PaymentStatusSuccess, PaymentStatusPending, false},
+		{PaymentStatusSuccess, PaymentStatusFailed, false},
+		{PaymentStatusSuccess, PaymentStatusRefunded, true},
+		{PaymentStatusExpired, PaymentStatusSuccess, false},
+		{PaymentStatusFailed, PaymentStatusPending, false},
+		{PaymentStatusRefunded, PaymentStatusSuccess, false},
+		{PaymentStatusSuccess, PaymentStatusSuccess, true},
+		{PaymentStatusPending, "unknown", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			if got := CanTransitionPaymentStatus(tt.from, tt.to); got != tt.want {
+				t.Errorf("CanTransitionPaymentStatus(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
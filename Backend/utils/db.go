@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"errors"
+	"strings"
 	"sync"
 
 	"gorm.io/gorm"
@@ -25,3 +27,16 @@ func GetDB() *gorm.DB {
 	defer mu.RUnlock()
 	return db
 }
+
+// IsDuplicateKeyError reports whether err is a unique index violation
+// (MySQL error 1062 or a sqlite UNIQUE constraint)
+func IsDuplicateKeyError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "Error 1062") || strings.Contains(msg, "UNIQUE constraint failed")
+}
@@ -0,0 +1,317 @@
+package utils
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Money adalah nominal Rupiah dalam satuan sen (1 Rupiah = 100 sen).
+// Semua perhitungan uang memakai integer agar total selalu cocok tanpa galat float.
+// Di database disimpan sebagai DECIMAL(12,2) dan di JSON sebagai angka Rupiah (mis. 15000.5).
+type Money int64
+
+// SenPerRupiah adalah jumlah sen dalam satu Rupiah
+const SenPerRupiah = 100
+
+// ErrInvalidMoney dikembalikan jika teks nominal tidak valid atau lebih dari 2 angka desimal
+var ErrInvalidMoney = errors.New("invalid money amount")
+
+// Rupiah membuat Money dari nominal Rupiah utuh
+func Rupiah(rupiah int64) Money {
+	return Money(rupiah * SenPerRupiah)
+}
+
+// MoneyFromFloat mengonversi float Rupiah (mis. dari API eksternal) ke Money,
+// dibulatkan ke sen terdekat (setengah menjauhi nol)
+func MoneyFromFloat(rupiah float64) Money {
+	return Money(math.Round(rupiah * SenPerRupiah))
+}
+
+// ParseMoney mem-parsing nominal Rupiah desimal ("15000", "15000.5", "-2.25") secara eksak.
+// Lebih dari 2 angka desimal ditolak.
+func ParseMoney(text string) (Money, error) {
+	return parseMoney(text, false)
+}
+
+// parseMoney mem-parsing teks desimal; jika lenient, digit setelah sen dibulatkan (dipakai untuk hasil SUM/AVG database)
+func parseMoney(text string, lenient bool) (Money, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, ErrInvalidMoney
+	}
+
+	negative := false
+	switch text[0] {
+	case '-':
+		negative = true
+		text = text[1:]
+	case '+':
+		text = text[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(text, ".")
+	if whole == "" && fraction == "" {
+		return 0, ErrInvalidMoney
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	if !isDigits(whole) || !isDigits(fraction) {
+		// Format eksponen (mis. 1.5e+06 dari float) hanya diterima dalam mode lenient
+		if lenient {
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return 0, ErrInvalidMoney
+			}
+			if negative {
+				value = -value
+			}
+			return MoneyFromFloat(value), nil
+		}
+		return 0, ErrInvalidMoney
+	}
+
+	roundUp := false
+	if len(fraction) > 2 {
+		extra := fraction[2:]
+		if !lenient && strings.Trim(extra, "0") != "" {
+			return 0, fmt.Errorf("%w: %q has more than 2 decimal places", ErrInvalidMoney, text)
+		}
+		roundUp = extra[0] >= '5'
+		fraction = fraction[:2]
+	}
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+
+	rupiah, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || rupiah > math.MaxInt64/SenPerRupiah-1 {
+		return 0, fmt.Errorf("%w: %q is out of range", ErrInvalidMoney, text)
+	}
+	sen, _ := strconv.ParseInt(fraction, 10, 64)
+
+	value := rupiah*SenPerRupiah + sen
+	if roundUp {
+		value++
+	}
+	if negative {
+		value = -value
+	}
+	return Money(value), nil
+}
+
+func isDigits(text string) bool {
+	for _, r := range text {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Float64 mengembalikan nominal dalam Rupiah sebagai float (hanya untuk tampilan/grafik, bukan perhitungan)
+func (m Money) Float64() float64 {
+	return float64(m) / SenPerRupiah
+}
+
+// Sen mengembalikan nominal dalam satuan sen
+func (m Money) Sen() int64 {
+	return int64(m)
+}
+
+// IsWholeRupiah bernilai true jika nominal tidak punya pecahan sen
+func (m Money) IsWholeRupiah() bool {
+	return m%SenPerRupiah == 0
+}
+
+// WholeRupiah mengembalikan nominal Rupiah utuh (dibulatkan setengah menjauhi nol),
+// dipakai untuk payment gateway yang tidak menerima sen
+func (m Money) WholeRupiah() int64 {
+	return int64(m.RoundTo(Rupiah(1))) / SenPerRupiah
+}
+
+// Mul mengalikan nominal dengan jumlah (mis. harga satuan x kuantitas)
+func (m Money) Mul(quantity int) Money {
+	return m * Money(quantity)
+}
+
+// Percent menghitung persentase nominal (mis. pajak 10, service 5, tip 12.5),
+// dibulatkan ke sen terdekat setengah menjauhi nol
+func (m Money) Percent(percent float64) Money {
+	basisPoints := int64(math.Round(percent * 100))
+	return Money(divRound(int64(m)*basisPoints, 100*100))
+}
+
+// Div membagi nominal dengan n (mis. rata-rata per order), dibulatkan ke sen terdekat
+func (m Money) Div(n int64) Money {
+	if n <= 0 {
+		return 0
+	}
+	return Money(divRound(int64(m), n))
+}
+
+// Split membagi nominal ke n bagian yang jumlahnya tepat sama dengan nominal awal.
+// Sisa sen dibagikan satu per satu mulai dari bagian pertama.
+func (m Money) Split(n int) []Money {
+	if n <= 0 {
+		return nil
+	}
+	parts := make([]Money, n)
+	base := m / Money(n)
+	remainder := m % Money(n)
+	step := Money(1)
+	if remainder < 0 {
+		remainder, step = -remainder, -1
+	}
+	for i := range parts {
+		parts[i] = base
+		if Money(i) < remainder {
+			parts[i] += step
+		}
+	}
+	return parts
+}
+
+// RoundTo membulatkan ke kelipatan unit terdekat (setengah menjauhi nol)
+func (m Money) RoundTo(unit Money) Money {
+	if unit <= 0 {
+		return m
+	}
+	return Money(divRound(int64(m), int64(unit))) * unit
+}
+
+// RoundUpTo membulatkan ke atas ke kelipatan unit (mis. pembulatan total struk ke Rp 1.000)
+func (m Money) RoundUpTo(unit Money) Money {
+	if unit <= 0 {
+		return m
+	}
+	q := m / unit
+	if m%unit > 0 {
+		q++
+	}
+	return q * unit
+}
+
+// Abs mengembalikan nilai absolut
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// Decimal mengembalikan nominal Rupiah dengan tepat 2 angka desimal ("15000.50"),
+// format yang dipakai Midtrans gross_amount, kolom DECIMAL dan export CSV
+func (m Money) Decimal() string {
+	sign := ""
+	value := int64(m)
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, value/SenPerRupiah, value%SenPerRupiah)
+}
+
+// String memformat nominal sebagai Rupiah (mis. "Rp 15.000,50")
+func (m Money) String() string {
+	return FormatCurrencyIDR(m)
+}
+
+// MarshalJSON menulis nominal sebagai angka Rupiah tanpa nol di belakang (15000, 15000.5)
+func (m Money) MarshalJSON() ([]byte, error) {
+	text := m.Decimal()
+	text = strings.TrimSuffix(strings.TrimRight(text, "0"), ".")
+	if text == "" || text == "-" {
+		text = "0"
+	}
+	return []byte(text), nil
+}
+
+// UnmarshalJSON menerima angka atau string Rupiah dengan maksimal 2 angka desimal
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "null" || text == "" {
+		*m = 0
+		return nil
+	}
+	value, err := parseMoney(text, false)
+	if err != nil {
+		return err
+	}
+	*m = value
+	return nil
+}
+
+// Scan membaca nilai kolom DECIMAL / REAL / INTEGER (dalam Rupiah) dari database
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+	case int64:
+		*m = Rupiah(v)
+	case float64:
+		*m = MoneyFromFloat(v)
+	case []byte:
+		value, err := parseMoney(string(v), true)
+		if err != nil {
+			return err
+		}
+		*m = value
+	case string:
+		value, err := parseMoney(v, true)
+		if err != nil {
+			return err
+		}
+		*m = value
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+	return nil
+}
+
+// Value menulis nominal sebagai teks desimal agar kolom DECIMAL menyimpan nilai eksak
+func (m Money) Value() (driver.Value, error) {
+	return m.Decimal(), nil
+}
+
+// GormDataType menentukan tipe kolom default untuk field Money
+func (Money) GormDataType() string {
+	return "decimal(12,2)"
+}
+
+// GormDBDataType menentukan tipe kolom per database
+func (Money) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	return "decimal(12,2)"
+}
+
+// SumMoney menjumlahkan beberapa nominal
+func SumMoney(values ...Money) Money {
+	var total Money
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
+
+// divRound membagi a/b dengan pembulatan setengah menjauhi nol (b > 0)
+func divRound(a, b int64) int64 {
+	q, r := a/b, a%b
+	if r < 0 {
+		r = -r
+	}
+	if 2*r >= b {
+		if a < 0 {
+			q--
+		} else {
+			q++
+		}
+	}
+	return q
+}
@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    Money
+		wantErr bool
+	}{
+		{text: "15000", want: 1500000},
+		{text: "15000.5", want: 1500050},
+		{text: "15000.50", want: 1500050},
+		{text: "0.1", want: 10},
+		{text: "-2.25", want: -225},
+		{text: ".75", want: 75},
+		{text: "100.000", want: 10000},
+		{text: "0.105", wantErr: true},
+		{text: "1e3", wantErr: true},
+		{text: "12,5", wantErr: true},
+		{text: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParseMoney(tt.text)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidMoney) {
+					t.Fatalf("ParseMoney(%q) error = %v, want %v", tt.text, err, ErrInvalidMoney)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseMoney(%q) = %d, %v, want %d", tt.text, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	// 0.1 + 0.2 tetap tepat 0.3, tidak seperti float64
+	if got := SumMoney(MoneyFromFloat(0.1), MoneyFromFloat(0.2)); got != MoneyFromFloat(0.3) {
+		t.Errorf("0.1 + 0.2 = %s, want 0.30", got.Decimal())
+	}
+
+	tests := []struct {
+		name string
+		got  Money
+		want Money
+	}{
+		{name: "percent rounds half up", got: Money(4550050).Percent(10), want: 455005},
+		{name: "fractional percent", got: Rupiah(45500).Percent(12.5), want: 568750},
+		{name: "percent of negative", got: Money(-15).Percent(10), want: -2},
+		{name: "round to rupiah", got: Money(568750).RoundTo(Rupiah(1)), want: Rupiah(5688)},
+		{name: "round up to thousand", got: Rupiah(41001).RoundUpTo(Rupiah(1000)), want: Rupiah(42000)},
+		{name: "round up exact", got: Rupiah(42000).RoundUpTo(Rupiah(1000)), want: Rupiah(42000)},
+		{name: "div rounds", got: Rupiah(100).Div(3), want: 3333},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %d, want %d", tt.name, tt.got, tt.want)
+		}
+	}
+
+	parts := Rupiah(100).Split(3)
+	if len(parts) != 3 || parts[0] != 3334 || parts[1] != 3333 || SumMoney(parts...) != Rupiah(100) {
+		t.Errorf("Split(3) = %v, want [3334 3333 3333]", parts)
+	}
+}
+
+func TestMoney_Format(t *testing.T) {
+	tests := []struct {
+		amount      Money
+		wantIDR     string
+		wantDecimal string
+		wantJSON    string
+	}{
+		{amount: Rupiah(15000), wantIDR: "Rp 15.000", wantDecimal: "15000.00", wantJSON: "15000"},
+		{amount: 1500050, wantIDR: "Rp 15.000,50", wantDecimal: "15000.50", wantJSON: "15000.5"},
+		{amount: 5, wantIDR: "Rp 0,05", wantDecimal: "0.05", wantJSON: "0.05"},
+		{amount: Rupiah(-1234567), wantIDR: "-Rp 1.234.567", wantDecimal: "-1234567.00", wantJSON: "-1234567"},
+		{amount: 0, wantIDR: "Rp 0", wantDecimal: "0.00", wantJSON: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wantDecimal, func(t *testing.T) {
+			if got := FormatCurrencyIDR(tt.amount); got != tt.wantIDR {
+				t.Errorf("FormatCurrencyIDR() = %q, want %q", got, tt.wantIDR)
+			}
+			if got := tt.amount.Decimal(); got != tt.wantDecimal {
+				t.Errorf("Decimal() = %q, want %q", got, tt.wantDecimal)
+			}
+			data, _ := json.Marshal(tt.amount)
+			if string(data) != tt.wantJSON {
+				t.Errorf("MarshalJSON() = %s, want %s", data, tt.wantJSON)
+			}
+
+			var decoded Money
+			if err := json.Unmarshal(data, &decoded); err != nil || decoded != tt.amount {
+				t.Errorf("UnmarshalJSON(%s) = %d, %v, want %d", data, decoded, err, tt.amount)
+			}
+		})
+	}
+
+	var amount Money
+	if err := json.Unmarshal([]byte("10.005"), &amount); !errors.Is(err, ErrInvalidMoney) {
+		t.Errorf("UnmarshalJSON(10.005) error = %v, want %v", err, ErrInvalidMoney)
+	}
+}
+
+func TestMoney_Scan(t *testing.T) {
+	tests := []struct {
+		name string
+		src  interface{}
+		want Money
+	}{
+		{name: "decimal bytes", src: []byte("15000.50"), want: 1500050},
+		{name: "avg with more decimals", src: "3333.33333", want: 333333},
+		{name: "integer rupiah", src: int64(2500), want: Rupiah(2500)},
+		{name: "real", src: 0.1 + 0.2, want: 30},
+		{name: "null", src: nil, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Money
+			if err := got.Scan(tt.src); err != nil || got != tt.want {
+				t.Errorf("Scan(%v) = %d, %v, want %d", tt.src, got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,488 @@
+package utils
+
+import (
+	"errors"
+)
+
+// ErrQRDataTooLong dikembalikan jika data melebihi kapasitas QR versi 40 (2331 byte)
+var ErrQRDataTooLong = errors.New("data too long for QR code")
+
+// QRCode adalah simbol QR versi 1-40 (byte mode, error correction level M) untuk
+// link verifikasi struk tanpa dependency tambahan.
+type QRCode struct {
+	Size    int
+	modules [][]bool
+}
+
+// qrVersion berisi total codeword dan susunan blok ECC level M per versi (ISO/IEC 18004 tabel 9).
+// Versi dengan dua ukuran blok dibagi rata: blok pendek di depan, blok panjang satu codeword lebih.
+type qrVersion struct {
+	totalCodewords int
+	eccPerBlock    int
+	blocks         int
+	alignment      []int
+}
+
+var qrVersions = []qrVersion{
+	{26, 10, 1, nil},
+	{44, 16, 1, []int{6, 18}},
+	{70, 26, 1, []int{6, 22}},
+	{100, 18, 2, []int{6, 26}},
+	{134, 24, 2, []int{6, 30}},
+	{172, 16, 4, []int{6, 34}},
+	{196, 18, 4, []int{6, 22, 38}},
+	{242, 22, 4, []int{6, 24, 42}},
+	{292, 22, 5, []int{6, 26, 46}},
+	{346, 26, 5, []int{6, 28, 50}},
+	{404, 30, 5, []int{6, 30, 54}},
+	{466, 22, 8, []int{6, 32, 58}},
+	{532, 22, 9, []int{6, 34, 62}},
+	{581, 24, 9, []int{6, 26, 46, 66}},
+	{655, 24, 10, []int{6, 26, 48, 70}},
+	{733, 28, 10, []int{6, 26, 50, 74}},
+	{815, 28, 11, []int{6, 30, 54, 78}},
+	{901, 26, 13, []int{6, 30, 56, 82}},
+	{991, 26, 14, []int{6, 30, 58, 86}},
+	{1085, 26, 16, []int{6, 34, 62, 90}},
+	{1156, 26, 17, []int{6, 28, 50, 72, 94}},
+	{1258, 28, 17, []int{6, 26, 50, 74, 98}},
+	{1364, 28, 18, []int{6, 30, 54, 78, 102}},
+	{1474, 28, 20, []int{6, 28, 54, 80, 106}},
+	{1588, 28, 21, []int{6, 32, 58, 84, 110}},
+	{1706, 28, 23, []int{6, 30, 58, 86, 114}},
+	{1828, 28, 25, []int{6, 34, 62, 90, 118}},
+	{1921, 28, 26, []int{6, 26, 50, 74, 98, 122}},
+	{2051, 28, 28, []int{6, 30, 54, 78, 102, 126}},
+	{2185, 28, 29, []int{6, 26, 52, 78, 104, 130}},
+	{2323, 28, 31, []int{6, 30, 56, 82, 108, 134}},
+	{2465, 28, 33, []int{6, 34, 60, 86, 112, 138}},
+	{2611, 28, 35, []int{6, 30, 58, 86, 114, 142}},
+	{2761, 28, 37, []int{6, 34, 62, 90, 118, 146}},
+	{2876, 28, 38, []int{6, 30, 54, 78, 102, 126, 150}},
+	{3034, 28, 40, []int{6, 24, 50, 76, 102, 128, 154}},
+	{3196, 28, 43, []int{6, 28, 54, 80, 106, 132, 158}},
+	{3362, 28, 45, []int{6, 32, 58, 84, 110, 136, 162}},
+	{3532, 28, 47, []int{6, 26, 54, 82, 110, 138, 166}},
+	{3706, 28, 49, []int{6, 30, 58, 86, 114, 142, 170}},
+}
+
+// Dark melaporkan apakah modul pada kolom x, baris y berwarna gelap
+func (q *QRCode) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= q.Size || y >= q.Size {
+		return false
+	}
+	return q.modules[y][x]
+}
+
+// EncodeQR membuat QR code untuk data dengan versi terkecil yang muat
+func EncodeQR(data string) (*QRCode, error) {
+	return encodeQR(data, -1)
+}
+
+// encodeQR memakai mask tertentu, atau mask dengan penalti terkecil jika mask < 0
+func encodeQR(data string, mask int) (*QRCode, error) {
+	for i, v := range qrVersions {
+		version := i + 1
+		dataCodewords := v.totalCodewords - v.eccPerBlock*v.blocks
+		countBits := 8
+		if version >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) > dataCodewords*8 {
+			continue
+		}
+		codewords := qrDataCodewords([]byte(data), countBits, dataCodewords)
+		return newQRCode(version, qrAddECC(codewords, v), mask), nil
+	}
+	return nil, ErrQRDataTooLong
+}
+
+// qrDataCodewords menyusun bit stream byte mode lengkap dengan terminator dan padding
+func qrDataCodewords(data []byte, countBits, capacity int) []byte {
+	var bits qrBitBuffer
+	bits.append(0x4, 4) // mode indicator byte
+	bits.append(len(data), countBits)
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	terminator := capacity*8 - len(bits)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-len(bits)%8)%8)
+
+	result := make([]byte, len(bits)/8, capacity)
+	for i, bit := range bits {
+		if bit {
+			result[i/8] |= 1 << (7 - uint(i%8))
+		}
+	}
+	for pad := byte(0xEC); len(result) < capacity; pad ^= 0xEC ^ 0x11 {
+		result = append(result, pad)
+	}
+	return result
+}
+
+// qrAddECC membagi data ke blok, menambahkan Reed-Solomon ECC, lalu meng-interleave
+func qrAddECC(data []byte, v qrVersion) []byte {
+	shortBlocks := v.blocks - v.totalCodewords%v.blocks
+	shortBlockLen := v.totalCodewords / v.blocks
+	generator := qrReedSolomonGenerator(v.eccPerBlock)
+
+	blocks := make([][]byte, v.blocks)
+	offset := 0
+	for i := range blocks {
+		dataLen := shortBlockLen - v.eccPerBlock
+		if i >= shortBlocks {
+			dataLen++
+		}
+		block := append([]byte(nil), data[offset:offset+dataLen]...)
+		offset += dataLen
+		ecc := qrReedSolomonRemainder(block, generator)
+		if i < shortBlocks {
+			block = append(block, 0) // placeholder agar semua blok sama panjang
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	result := make([]byte, 0, v.totalCodewords)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortBlockLen-v.eccPerBlock || j >= shortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+func newQRCode(version int, codewords []byte, mask int) *QRCode {
+	size := version*4 + 17
+	q := &qrBuilder{QRCode: QRCode{Size: size}}
+	q.modules = make([][]bool, size)
+	q.function = make([][]bool, size)
+	for i := range q.modules {
+		q.modules[i] = make([]bool, size)
+		q.function[i] = make([]bool, size)
+	}
+
+	q.drawFunctionPatterns(version)
+	q.drawCodewords(codewords)
+
+	if mask < 0 {
+		// Pilih mask dengan penalti terkecil
+		bestPenalty := -1
+		for m := 0; m < 8; m++ {
+			q.applyMask(m)
+			q.drawFormatBits(m)
+			if penalty := q.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+				mask, bestPenalty = m, penalty
+			}
+			q.applyMask(m) // XOR dua kali mengembalikan modul semula
+		}
+	}
+	q.applyMask(mask)
+	q.drawFormatBits(mask)
+	return &q.QRCode
+}
+
+type qrBuilder struct {
+	QRCode
+	function [][]bool
+}
+
+func (q *qrBuilder) set(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.function[y][x] = true
+}
+
+func (q *qrBuilder) drawFunctionPatterns(version int) {
+	size := q.Size
+	for i := 0; i < size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+
+	for _, c := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x < 0 || y < 0 || x >= size || y >= size {
+					continue
+				}
+				dist := qrMax(qrAbs(dx), qrAbs(dy))
+				q.set(x, y, dist != 2 && dist != 4)
+			}
+		}
+	}
+
+	positions := qrVersions[version-1].alignment
+	last := len(positions) - 1
+	for i, cy := range positions {
+		for j, cx := range positions {
+			// Lewati posisi yang bertabrakan dengan finder pattern
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.set(cx+dx, cy+dy, qrMax(qrAbs(dx), qrAbs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// Reservasi area format (nilai asli ditulis setelah mask dipilih)
+	q.drawFormatBits(0)
+
+	if version >= 7 {
+		bits := qrVersionBits(version)
+		for i := 0; i < 18; i++ {
+			dark := (bits>>uint(i))&1 != 0
+			a, b := size-11+i%3, i/3
+			q.set(a, b, dark)
+			q.set(b, a, dark)
+		}
+	}
+}
+
+// qrVersionBits menghitung 18 bit version info (hanya untuk versi 7 ke atas)
+func qrVersionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	return version<<12 | rem
+}
+
+// qrFormatBits menghitung 15 bit format info untuk level M dan mask
+func qrFormatBits(mask int) int {
+	data := 0<<3 | mask // level M = 00
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+func (q *qrBuilder) drawFormatBits(mask int) {
+	bits := qrFormatBits(mask)
+	bit := func(i int) bool { return (bits>>uint(i))&1 != 0 }
+	size := q.Size
+
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		q.set(size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, size-15+i, bit(i))
+	}
+	q.set(8, size-8, true) // dark module
+}
+
+// drawCodewords menempatkan bit data secara zig-zag dua kolom dari kanan bawah
+func (q *qrBuilder) drawCodewords(codewords []byte) {
+	size := q.Size
+	i := 0
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if upward {
+					y = size - 1 - vert
+				}
+				if q.function[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+				q.modules[y][x] = (codewords[i>>3]>>(7-uint(i&7)))&1 != 0
+				i++
+			}
+		}
+	}
+}
+
+func (q *qrBuilder) applyMask(mask int) {
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			if q.function[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty menghitung skor penalti mask (aturan 1-4 spesifikasi QR)
+func (q *qrBuilder) penalty() int {
+	size := q.Size
+	penalty := 0
+	at := func(x, y int, vertical bool) bool {
+		if vertical {
+			return q.modules[x][y]
+		}
+		return q.modules[y][x]
+	}
+
+	finderLike := []bool{true, false, true, true, true, false, true}
+	for _, vertical := range []bool{false, true} {
+		for y := 0; y < size; y++ {
+			// Aturan 1: deretan 5 modul atau lebih dengan warna sama
+			run := 1
+			for x := 1; x <= size; x++ {
+				if x < size && at(x, y, vertical) == at(x-1, y, vertical) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += 3 + run - 5
+				}
+				run = 1
+			}
+
+			// Aturan 3: pola mirip finder 1:1:3:1:1 dengan 4 modul terang di salah satu sisi
+			for x := 0; x+7 <= size; x++ {
+				match := true
+				for k, dark := range finderLike {
+					if at(x+k, y, vertical) != dark {
+						match = false
+						break
+					}
+				}
+				if match && (qrLightRun(at, x-4, y, vertical, size) || qrLightRun(at, x+7, y, vertical, size)) {
+					penalty += 40
+				}
+			}
+		}
+	}
+
+	// Aturan 2: blok 2x2 dengan warna sama
+	dark := 0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x+1 < size && y+1 < size {
+				c := q.modules[y][x]
+				if c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+					penalty += 3
+				}
+			}
+		}
+	}
+
+	// Aturan 4: proporsi modul gelap jauh dari 50%
+	total := size * size
+	deviation := qrAbs(dark*100/total - 50)
+	penalty += deviation / 5 * 10
+	return penalty
+}
+
+// qrLightRun memeriksa 4 modul terang mulai dari x (di luar simbol dianggap terang)
+func qrLightRun(at func(x, y int, vertical bool) bool, x, y int, vertical bool, size int) bool {
+	for k := x; k < x+4; k++ {
+		if k >= 0 && k < size && at(k, y, vertical) {
+			return false
+		}
+	}
+	return true
+}
+
+// qrReedSolomonGenerator menghitung polinom generator derajat n (koefisien tertinggi diabaikan)
+func qrReedSolomonGenerator(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = qrGFMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = qrGFMultiply(root, 0x02)
+	}
+	return result
+}
+
+func qrReedSolomonRemainder(data, generator []byte) []byte {
+	result := make([]byte, len(generator))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coef := range generator {
+			result[i] ^= qrGFMultiply(coef, factor)
+		}
+	}
+	return result
+}
+
+// qrGFMultiply mengalikan dua elemen GF(2^8) dengan polinom 0x11D
+func qrGFMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>uint(i))&1) * int(x)
+	}
+	return byte(z)
+}
+
+type qrBitBuffer []bool
+
+func (b *qrBitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, (value>>uint(i))&1 != 0)
+	}
+}
+
+func qrAbs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func qrMax(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
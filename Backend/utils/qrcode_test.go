@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestQRReedSolomon(t *testing.T) {
+	// Contoh "HELLO WORLD" versi 1-M dari spesifikasi
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	got := qrReedSolomonRemainder(data, qrReedSolomonGenerator(len(want)))
+	if string(got) != string(want) {
+		t.Errorf("ECC = %v, want %v", got, want)
+	}
+}
+
+func TestQRFormatAndVersionBits(t *testing.T) {
+	tests := []struct {
+		name string
+		got  int
+		want int
+	}{
+		{name: "format M mask 0", got: qrFormatBits(0), want: 0b101010000010010},
+		{name: "format M mask 5", got: qrFormatBits(5), want: 0b100000011001110},
+		{name: "version 7", got: qrVersionBits(7), want: 0b000111110010010100},
+		{name: "version 10", got: qrVersionBits(10), want: 0b001010010011010011},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %015b, want %015b", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestEncodeQR(t *testing.T) {
+	tests := []struct {
+		length   int
+		wantSize int
+	}{
+		{length: 14, wantSize: 21},    // versi 1 muat 14 byte
+		{length: 15, wantSize: 25},    // versi 2
+		{length: 122, wantSize: 45},   // versi 7, dengan version info
+		{length: 123, wantSize: 49},   // versi 8
+		{length: 213, wantSize: 57},   // versi 10
+		{length: 214, wantSize: 61},   // versi 11, dua ukuran blok
+		{length: 2331, wantSize: 177}, // versi 40, kapasitas maksimal
+	}
+
+	for _, tt := range tests {
+		qr, err := EncodeQR(strings.Repeat("a", tt.length))
+		if err != nil {
+			t.Fatalf("EncodeQR(%d bytes) error = %v", tt.length, err)
+		}
+		if qr.Size != tt.wantSize {
+			t.Errorf("EncodeQR(%d bytes) size = %d, want %d", tt.length, qr.Size, tt.wantSize)
+		}
+		// Finder pattern di tiga sudut dan dark module
+		for _, c := range [][2]int{{0, 0}, {qr.Size - 7, 0}, {0, qr.Size - 7}} {
+			if !qr.Dark(c[0], c[1]) || qr.Dark(c[0]+1, c[1]+1) || !qr.Dark(c[0]+3, c[1]+3) {
+				t.Errorf("size %d: missing finder pattern at %v", qr.Size, c)
+			}
+		}
+		if !qr.Dark(8, qr.Size-8) {
+			t.Errorf("size %d: missing dark module", qr.Size)
+		}
+	}
+
+	if _, err := EncodeQR(strings.Repeat("a", 2332)); !errors.Is(err, ErrQRDataTooLong) {
+		t.Errorf("EncodeQR(2332 bytes) error = %v, want %v", err, ErrQRDataTooLong)
+	}
+}
+
+// qrGoldenReceiptURL dihasilkan dengan encoder referensi rsc.io/qr/coding
+// (versi 6, level M, mask 2) untuk URL di TestEncodeQR_Golden
+var qrGoldenReceiptURL = []string{
+	"#######..#.####.##.#.#.####....#..#######",
+	"#.....#..#....#..#..##...###....#.#.....#",
+	"#.###.#.#..###.#.##.###.##.#..#...#.###.#",
+	"#.###.#.##.###.#...#.#..#.#####.#.#.###.#",
+	"#.###.#.#.##.##..##.####.#..#..##.#.###.#",
+	"#.....#.#..###..##...#...###.##.#.#.....#",
+	"#######.#.#.#.#.#.#.#.#.#.#.#.#.#.#######",
+	"........#.#.#.#....#.#......#.###........",
+	"#.#####....###...#....##.###.#.##.#####..",
+	"#......####..##.#.####.#.#....###.#.#.#.#",
+	"#..#.######....####.#......###..##.....#.",
+	"###.##...#..#...#..#.#....#.#.###...##..#",
+	"###.####....#..#....#.####...#.#.#.#.###.",
+	"##..#......#..####...#....##.####.####.#.",
+	".#.##.##..#.#.##..#.#.###..#..#.##.###...",
+	".##..#.#..##...#.....#.#...#..##..#..#.##",
+	"#....##..#....##.#..#.##.#.####.##.#..##.",
+	"#.##.....###......##..###.#...##..####.##",
+	".#.#####.######.#.#.#.#.#..#.....#..#.#..",
+	"..##.#...########..##...#.#.#....#..#..##",
+	"..###.#.#.##...#..#.###.#..#.#.......###.",
+	"....#...##.##....#..#..#..#.#.##..####.##",
+	".#.##.##.##.##.##...###...###...#..#.....",
+	"...##....######.#...##....##...#.#####...",
+	"..#..###.#...#...###..#.###..#...#.#..#.#",
+	"..#.##..#..#..##..####.#.##.#..#..#.##.##",
+	".####.###..#.#....#..##..###....##..#....",
+	"###.#..########...#####.#.###..##...##.##",
+	"##....######.#.#.###..#..#####.##....###.",
+	"######.#.######.#.#.###.#......#.####...#",
+	"#...#.#.##.#.....#.#.......#.....#######.",
+	"#...#..####..##.#.#####.#...#..#.#####...",
+	"#.#..###...#########..#..#.#.##.#####.#..",
+	"........####.##..#.###.######..##...#...#",
+	"#######..#.#.#...#..##..####.#.##.#.#.#..",
+	"#.....#.####.#.####..##.##....#.#...#...#",
+	"#.###.#.##.#.#.##..###....##.##.#######.#",
+	"#.###.#.##.###....##.###.#..#..#.#.#.#...",
+	"#.###.#.#####.#####..##.#..#.##..#####...",
+	"#.....#..##...##.#.#.###.......##...#..#.",
+	"#######.#...#####......#######.#.#.####..",
+}
+
+func TestEncodeQR_Golden(t *testing.T) {
+	url := "https://resto.example.com/receipts/verify?number=RCP%2F20261018%2F000001&sig=9f2c4e7a1b3d5f60"
+	qr, err := EncodeQR(url)
+	if err != nil {
+		t.Fatalf("EncodeQR() error = %v", err)
+	}
+	if qr.Size != len(qrGoldenReceiptURL) {
+		t.Fatalf("EncodeQR() size = %d, want %d", qr.Size, len(qrGoldenReceiptURL))
+	}
+	for y, row := range qrGoldenReceiptURL {
+		for x, module := range row {
+			if qr.Dark(x, y) != (module == '#') {
+				t.Fatalf("EncodeQR() module (%d, %d) differs from the reference encoder", x, y)
+			}
+		}
+	}
+}
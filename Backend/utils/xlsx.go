@@ -0,0 +1,277 @@
+package utils
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidXLSX dikembalikan jika file bukan workbook XLSX yang bisa dibaca
+var ErrInvalidXLSX = errors.New("invalid xlsx file")
+
+// maxXLSXPartSize membatasi ukuran satu file XML di dalam workbook setelah didekompresi
+const maxXLSXPartSize = 64 << 20
+
+// ReadXLSX membaca sheet pertama workbook XLSX sebagai baris teks. Cukup untuk impor
+// tabel sederhana tanpa dependency tambahan: rumus dibaca dari nilai tersimpannya,
+// dan angka dikembalikan seperti yang tersimpan di file (mis. "15000" atau "12.5").
+func ReadXLSX(r io.ReaderAt, size int64) ([][]string, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidXLSX, err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := xlsxFirstSheet(files)
+	if err != nil {
+		return nil, err
+	}
+	var shared []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		var sst struct {
+			Items []xlsxText `xml:"si"`
+		}
+		if err := xlsxDecode(f, &sst); err != nil {
+			return nil, err
+		}
+		for _, item := range sst.Items {
+			shared = append(shared, item.String())
+		}
+	}
+
+	f, ok := files[sheetPath]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidXLSX, sheetPath)
+	}
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string    `xml:"r,attr"`
+				Type   string    `xml:"t,attr"`
+				Value  string    `xml:"v"`
+				Inline *xlsxText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xlsxDecode(f, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		var values []string
+		for _, cell := range row.Cells {
+			// Sel kosong tidak ditulis, posisi kolom diambil dari referensi sel (mis. "C7")
+			col := len(values)
+			if cell.Ref != "" {
+				if parsed, ok := xlsxColumn(cell.Ref); ok {
+					col = parsed
+				}
+			}
+			for len(values) < col {
+				values = append(values, "")
+			}
+
+			var value string
+			switch cell.Type {
+			case "s":
+				idx, err := strconv.Atoi(strings.TrimSpace(cell.Value))
+				if err != nil || idx < 0 || idx >= len(shared) {
+					return nil, fmt.Errorf("%w: bad shared string in %s", ErrInvalidXLSX, cell.Ref)
+				}
+				value = shared[idx]
+			case "inlineStr":
+				if cell.Inline != nil {
+					value = cell.Inline.String()
+				}
+			case "b":
+				value = map[string]string{"1": "TRUE", "0": "FALSE"}[cell.Value]
+			default:
+				value = cell.Value
+			}
+			values = append(values, value)
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+// WriteXLSX menulis satu sheet. Nilai string ditulis sebagai teks (nol di depan SKU
+// tetap utuh); int dan Money ditulis sebagai angka.
+func WriteXLSX(w io.Writer, sheetName string, rows [][]interface{}) error {
+	archive := zip.NewWriter(w)
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="` + xlsxEscape(sheetName) + `" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`},
+	}
+	for _, part := range parts {
+		f, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+
+	f, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	var sheet strings.Builder
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(&sheet, `<row r="%d">`, i+1)
+		for j, value := range row {
+			ref := xlsxColumnName(j) + strconv.Itoa(i+1)
+			switch v := value.(type) {
+			case int:
+				fmt.Fprintf(&sheet, `<c r="%s"><v>%d</v></c>`, ref, v)
+			case int64:
+				fmt.Fprintf(&sheet, `<c r="%s"><v>%d</v></c>`, ref, v)
+			case Money:
+				fmt.Fprintf(&sheet, `<c r="%s"><v>%s</v></c>`, ref, strings.TrimSuffix(strings.TrimRight(v.Decimal(), "0"), "."))
+			default:
+				text := fmt.Sprint(v)
+				if text == "" {
+					continue
+				}
+				fmt.Fprintf(&sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xlsxEscape(text))
+			}
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+	if _, err := io.WriteString(f, sheet.String()); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// xlsxText adalah isi teks sel/shared string, bisa polos (<t>) atau rich text (<r><t>)
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+// xlsxFirstSheet mencari path sheet pertama lewat workbook.xml dan relasinya
+func xlsxFirstSheet(files map[string]*zip.File) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+	workbook, ok := files["xl/workbook.xml"]
+	if !ok {
+		return "", fmt.Errorf("%w: missing workbook", ErrInvalidXLSX)
+	}
+	var book struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xlsxDecode(workbook, &book); err != nil {
+		return "", err
+	}
+	rels, ok := files["xl/_rels/workbook.xml.rels"]
+	if len(book.Sheets) == 0 || !ok {
+		return fallback, nil
+	}
+	var relationships struct {
+		Items []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := xlsxDecode(rels, &relationships); err != nil {
+		return "", err
+	}
+	for _, rel := range relationships.Items {
+		if rel.ID != book.Sheets[0].ID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return fallback, nil
+}
+
+func xlsxDecode(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidXLSX, err)
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(io.LimitReader(rc, maxXLSXPartSize)).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidXLSX, f.Name, err)
+	}
+	return nil
+}
+
+// xlsxColumn mengubah referensi sel ("C7") menjadi indeks kolom berbasis 0
+func xlsxColumn(ref string) (int, bool) {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		n++
+	}
+	return col - 1, n > 0 && n <= 3
+}
+
+// xlsxColumnName mengubah indeks kolom berbasis 0 menjadi nama kolom ("A", "AA")
+func xlsxColumnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
+
+func xlsxEscape(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
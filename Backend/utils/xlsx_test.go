@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestXLSXRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	rows := [][]interface{}{
+		{"sku", "name", "price", "stock"},
+		{"007", "Es Teh <Manis> & Dingin", Rupiah(8000), 12},
+		{"", "Nasi Goreng", Money(1550050), int64(0)},
+	}
+	if err := WriteXLSX(&buf, "Menu", rows); err != nil {
+		t.Fatalf("WriteXLSX() error = %v", err)
+	}
+
+	got, err := ReadXLSX(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ReadXLSX() error = %v", err)
+	}
+	want := [][]string{
+		{"sku", "name", "price", "stock"},
+		{"007", "Es Teh <Manis> & Dingin", "8000", "12"},
+		{"", "Nasi Goreng", "15500.5", "0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadXLSX() = %q, want %q", got, want)
+	}
+}
+
+func TestReadXLSXSharedStrings(t *testing.T) {
+	// Workbook seperti yang disimpan Excel: shared strings, sheet lewat relasi, sel kosong dilewati
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Daftar" sheetId="1" r:id="rId7"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId7" Target="worksheets/daftar.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<si><t>nama</t></si><si><r><t>Kopi </t></r><r><t>Susu</t></r></si></sst>`,
+		"xl/worksheets/daftar.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="inlineStr"><is><t>harga</t></is></c></row>` +
+			`<row r="2"><c r="A2" t="s"><v>1</v></c><c r="C2"><v>18000</v></c></row>` +
+			`</sheetData></worksheet>`,
+	}
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range parts {
+		f, _ := archive.Create(name)
+		f.Write([]byte(content))
+	}
+	archive.Close()
+
+	got, err := ReadXLSX(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ReadXLSX() error = %v", err)
+	}
+	want := [][]string{{"nama", "", "harga"}, {"Kopi Susu", "", "18000"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadXLSX() = %q, want %q", got, want)
+	}
+
+	if _, err := ReadXLSX(bytes.NewReader([]byte("nama,harga")), 10); !errors.Is(err, ErrInvalidXLSX) {
+		t.Errorf("ReadXLSX(csv) error = %v, want ErrInvalidXLSX", err)
+	}
+}